@@ -213,3 +213,32 @@ func IsErrTooShortTTL(err error) bool {
 		strings.Contains(errMsg, ") is shorter than token's existing TTL (") &&
 		strings.Contains(errMsg, ")")
 }
+
+// ErrBranchProtected is returned if an operation is blocked by a branch's
+// protection rules (see pfs.BranchProtection), even though the caller has the
+// access to the branch's repo that the operation would otherwise require.
+type ErrBranchProtected struct {
+	Subject string // subject trying to perform blocked operation (may be empty)
+	Repo    string
+	Branch  string
+	Rule    string // the violated rule, e.g. "no_delete"
+	Reason  string
+}
+
+const errBranchProtectedMsg = "is protected by the rule"
+
+func (e *ErrBranchProtected) Error() string {
+	var msg string
+	if e.Subject != "" {
+		msg += e.Subject + " cannot perform this operation: "
+	}
+	return msg + fmt.Sprintf("branch %s@%s %s %q; %s", e.Repo, e.Branch, errBranchProtectedMsg, e.Rule, e.Reason)
+}
+
+// IsErrBranchProtected returns true if 'err' is an ErrBranchProtected
+func IsErrBranchProtected(err error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(err.Error(), errBranchProtectedMsg)
+}
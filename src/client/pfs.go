@@ -153,6 +153,104 @@ func (c APIClient) DeleteRepo(repoName string, force bool) error {
 	return grpcutil.ScrubGRPC(err)
 }
 
+// SetRepoQuota sets the maximum size and number of files of each commit in a
+// repo. A limit of 0 means no limit. Only cluster admins may set quotas.
+func (c APIClient) SetRepoQuota(repoName string, sizeBytes uint64, fileCount uint64) error {
+	_, err := c.PfsAPIClient.SetRepoQuota(
+		c.Ctx(),
+		&pfs.SetRepoQuotaRequest{
+			Repo: NewRepo(repoName),
+			Quota: &pfs.RepoQuota{
+				SizeBytes: sizeBytes,
+				FileCount: fileCount,
+			},
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// SetRetentionPolicy sets the retention policy of a branch, or the default
+// retention policy of a repo's branches if branchName is empty. A nil policy
+// removes the existing one.
+func (c APIClient) SetRetentionPolicy(repoName string, branchName string, policy *pfs.RetentionPolicy) error {
+	_, err := c.PfsAPIClient.SetRetentionPolicy(
+		c.Ctx(),
+		&pfs.SetRetentionPolicyRequest{
+			Repo:   NewRepo(repoName),
+			Branch: branchName,
+			Policy: policy,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// PruneCommits deletes the commits in a repo (or in all repos, if repoName is
+// empty) that aren't kept by any retention policy, and returns them. If dryRun
+// is set, the commits that would be deleted are returned, but not deleted.
+func (c APIClient) PruneCommits(repoName string, dryRun bool) ([]*pfs.Commit, error) {
+	request := &pfs.PruneCommitsRequest{DryRun: dryRun}
+	if repoName != "" {
+		request.Repo = NewRepo(repoName)
+	}
+	response, err := c.PfsAPIClient.PruneCommits(c.Ctx(), request)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return response.Commits, nil
+}
+
+// SetBranchProtection sets the protection rules of a branch. A nil protection
+// removes the branch's existing rules.
+func (c APIClient) SetBranchProtection(repoName string, branch string, protection *pfs.BranchProtection) error {
+	_, err := c.PfsAPIClient.SetBranchProtection(
+		c.Ctx(),
+		&pfs.SetBranchProtectionRequest{
+			Branch:     NewBranch(repoName, branch),
+			Protection: protection,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// SetRepoMetadata merges metadata into the metadata of a repo. Keys whose
+// value is empty are removed.
+func (c APIClient) SetRepoMetadata(repoName string, metadata map[string]string) error {
+	_, err := c.PfsAPIClient.SetMetadata(
+		c.Ctx(),
+		&pfs.SetMetadataRequest{
+			Repo:     NewRepo(repoName),
+			Metadata: metadata,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// SetBranchMetadata merges metadata into the metadata of a branch. Keys whose
+// value is empty are removed.
+func (c APIClient) SetBranchMetadata(repoName string, branch string, metadata map[string]string) error {
+	_, err := c.PfsAPIClient.SetMetadata(
+		c.Ctx(),
+		&pfs.SetMetadataRequest{
+			Branch:   NewBranch(repoName, branch),
+			Metadata: metadata,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// SetCommitMetadata merges metadata into the metadata of a commit, which may
+// be open or finished. Keys whose value is empty are removed.
+func (c APIClient) SetCommitMetadata(repoName string, commitID string, metadata map[string]string) error {
+	_, err := c.PfsAPIClient.SetMetadata(
+		c.Ctx(),
+		&pfs.SetMetadataRequest{
+			Commit:   NewCommit(repoName, commitID),
+			Metadata: metadata,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
 // StartCommit begins the process of committing data to a Repo. Once started
 // you can write to the Commit with PutFile and when all the data has been
 // written you must finish the Commit with FinishCommit. NOTE, data is not
@@ -301,11 +399,18 @@ func (c APIClient) ListCommit(repoName string, to string, from string, number ui
 // `reverse` lists the commits from oldest to newest, rather than newest to oldest
 // all commits that match the aforementioned criteria are passed to f.
 func (c APIClient) ListCommitF(repoName string, to string, from string, number uint64, reverse bool, f func(*pfs.CommitInfo) error) error {
+	return c.ListCommitByMetadataF(repoName, to, from, number, reverse, nil, f)
+}
+
+// ListCommitByMetadataF is like ListCommitF, but only calls f with the
+// commits whose metadata contains every key/value pair in metadata.
+func (c APIClient) ListCommitByMetadataF(repoName string, to string, from string, number uint64, reverse bool, metadata map[string]string, f func(*pfs.CommitInfo) error) error {
 	req := &pfs.ListCommitRequest{
 		// repoName may be "", but the repo object must exist
-		Repo:    NewRepo(repoName),
-		Number:  number,
-		Reverse: reverse,
+		Repo:     NewRepo(repoName),
+		Number:   number,
+		Reverse:  reverse,
+		Metadata: metadata,
 	}
 	if from != "" {
 		req.From = NewCommit(repoName, from)
@@ -420,6 +525,26 @@ func (c APIClient) DeleteBranch(repoName string, branch string, force bool) erro
 	return grpcutil.ScrubGRPC(err)
 }
 
+// MergeBranch merges the changes made on branch 'source' since its common
+// ancestor with branch 'target' into 'target', by adding a merge commit to
+// 'target'. Paths that were changed differently on both branches are
+// resolved by the first of 'resolutions' whose glob matches them, or by
+// 'strategy'. If any conflict is resolved with MergeStrategy_FAIL, no commit
+// is created, and the response only lists the conflicts.
+func (c APIClient) MergeBranch(repoName string, source string, target string, strategy pfs.MergeStrategy, resolutions []*pfs.MergeResolution, description string) (*pfs.MergeBranchResponse, error) {
+	response, err := c.PfsAPIClient.MergeBranch(
+		c.Ctx(),
+		&pfs.MergeBranchRequest{
+			Source:      NewBranch(repoName, source),
+			Target:      NewBranch(repoName, target),
+			Strategy:    strategy,
+			Resolutions: resolutions,
+			Description: description,
+		},
+	)
+	return response, grpcutil.ScrubGRPC(err)
+}
+
 // DeleteCommit deletes a commit.
 func (c APIClient) DeleteCommit(repoName string, commitID string) error {
 	_, err := c.PfsAPIClient.DeleteCommit(
@@ -546,12 +671,19 @@ func (c *commitInfoIterator) Close() {
 // SubscribeCommit is like ListCommit but it keeps listening for commits as
 // they come in.
 func (c APIClient) SubscribeCommit(repo, branch string, prov *pfs.CommitProvenance, from string, state pfs.CommitState) (CommitInfoIterator, error) {
+	return c.SubscribeCommitByMetadata(repo, branch, prov, from, state, nil)
+}
+
+// SubscribeCommitByMetadata is like SubscribeCommit, but only returns the
+// commits whose metadata contains every key/value pair in metadata.
+func (c APIClient) SubscribeCommitByMetadata(repo, branch string, prov *pfs.CommitProvenance, from string, state pfs.CommitState, metadata map[string]string) (CommitInfoIterator, error) {
 	ctx, cancel := context.WithCancel(c.Ctx())
 	req := &pfs.SubscribeCommitRequest{
-		Repo:   NewRepo(repo),
-		Branch: branch,
-		Prov:   prov,
-		State:  state,
+		Repo:     NewRepo(repo),
+		Branch:   branch,
+		Prov:     prov,
+		State:    state,
+		Metadata: metadata,
 	}
 	if from != "" {
 		req.From = NewCommit(repo, from)
@@ -1202,6 +1334,22 @@ func (c APIClient) CopyFile(srcRepo, srcCommit, srcPath, dstRepo, dstCommit, dst
 	return nil
 }
 
+// SyncFile mirrors the objects under the object store prefix 'url' (e.g.
+// s3://bucket/prefix) into 'path' in 'branch', in a commit that only
+// contains the objects that were added, changed or deleted since the last
+// sync. No commit is created if nothing changed.
+func (c APIClient) SyncFile(repoName, branch, path, url string) (*pfs.SyncFileResponse, error) {
+	response, err := c.PfsAPIClient.SyncFile(c.Ctx(),
+		&pfs.SyncFileRequest{
+			File: NewFile(repoName, branch, path),
+			Url:  url,
+		})
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return response, nil
+}
+
 // GetFile returns the contents of a file at a specific Commit.
 // offset specifies a number of bytes that should be skipped in the beginning of the file.
 // size limits the total amount of data returned, note you will get fewer bytes
@@ -1492,7 +1640,27 @@ type putFileWriteCloser struct {
 // prevent the completion of fsck. Errors that do prevent completion will be
 // returned from the function.
 func (c APIClient) Fsck(fix bool, cb func(*pfs.FsckResponse) error) error {
-	fsckClient, err := c.PfsAPIClient.Fsck(c.Ctx(), &pfs.FsckRequest{Fix: fix})
+	return c.fsck(&pfs.FsckRequest{Fix: fix}, cb)
+}
+
+// DeepFsck is like Fsck, but also checks that the data referenced by every
+// finished commit exists in object storage. If verifyContent is set, the
+// content of each object is also re-hashed. 'parallelism' limits the number
+// of commits checked concurrently (0 uses the server's default). Responses
+// with Checkpoint set may be passed back as 'resumeFrom' to continue an
+// interrupted check.
+func (c APIClient) DeepFsck(fix bool, verifyContent bool, parallelism int64, resumeFrom string, cb func(*pfs.FsckResponse) error) error {
+	return c.fsck(&pfs.FsckRequest{
+		Fix:           fix,
+		Deep:          true,
+		VerifyContent: verifyContent,
+		Parallelism:   parallelism,
+		ResumeFrom:    resumeFrom,
+	}, cb)
+}
+
+func (c APIClient) fsck(request *pfs.FsckRequest, cb func(*pfs.FsckResponse) error) error {
+	fsckClient, err := c.PfsAPIClient.Fsck(c.Ctx(), request)
 	if err != nil {
 		return grpcutil.ScrubGRPC(err)
 	}
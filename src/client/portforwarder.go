@@ -189,6 +189,11 @@ func (f *PortForwarder) RunForS3Gateway(localPort uint16) (uint16, error) {
 	return f.Run("pachd", localPort, 600)
 }
 
+// RunForWebDAV creates a port forwarder for the WebDAV server.
+func (f *PortForwarder) RunForWebDAV(localPort uint16) (uint16, error) {
+	return f.Run("pachd", localPort, 601)
+}
+
 // Close shuts down port forwarding.
 func (f *PortForwarder) Close() {
 	defer f.logger.Close()
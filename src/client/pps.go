@@ -63,8 +63,26 @@ const (
 	// OutputCommitIDEnv is an env var that is added to the environment of user
 	// pipelined code and indicates the id of the output commit.
 	OutputCommitIDEnv = "PACH_OUTPUT_COMMIT_ID"
+	// OutputMetadataEnv is an env var that is added to the environment of user
+	// pipeline code and holds the path of a file that the code may write a JSON
+	// object of string key/value pairs to. These pairs are added to the
+	// metadata of the output commit once the datum is processed.
+	OutputMetadataEnv = "PACH_OUTPUT_METADATA"
 	// PeerPortEnv is the env var that sets a custom peer port
 	PeerPortEnv = "PEER_PORT"
+	// PPSGitSecretPrefix is the prefix of the path where the secrets of git
+	// inputs are mounted in workers. The secret of a git input named `XXX` is
+	// mounted at `/pach-git/XXX/`.
+	PPSGitSecretPrefix = "/pach-git"
+	// GitUsernameKey is the key, in a git input's secret, of the username
+	// used to clone the repo.
+	GitUsernameKey = "username"
+	// GitPasswordKey is the key, in a git input's secret, of the password or
+	// access token used to clone the repo.
+	GitPasswordKey = "password"
+	// GitWebhookSecretKey is the key, in a git input's secret, of the shared
+	// secret used to verify push webhooks.
+	GitWebhookSecretKey = "webhook-secret"
 )
 
 // NewJob creates a pps.Job.
@@ -403,6 +421,22 @@ func (c APIClient) RestartDatum(jobID string, datumFilter []string) error {
 	return grpcutil.ScrubGRPC(err)
 }
 
+// RestartQuarantinedDatums releases datums that were quarantined by a job (in
+// a pipeline with QuarantineFailedDatums set), so that they're reprocessed by
+// the pipeline's next job. datumFilter is matched as in RestartDatum, and if
+// it's empty, all of the datums that the job quarantined are released.
+func (c APIClient) RestartQuarantinedDatums(jobID string, datumFilter []string) error {
+	_, err := c.PpsAPIClient.RestartDatum(
+		c.Ctx(),
+		&pps.RestartDatumRequest{
+			Job:         NewJob(jobID),
+			DataFilters: datumFilter,
+			Quarantined: true,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
 // ListDatum returns info about datums in a Job
 func (c APIClient) ListDatum(jobID string, pageSize, page int64) (*pps.ListDatumResponse, error) {
 	return c.listDatum(NewJob(jobID), nil, pageSize, page)
@@ -681,6 +715,27 @@ func (c APIClient) ListPipeline() ([]*pps.PipelineInfo, error) {
 	return pipelineInfos.PipelineInfo, nil
 }
 
+// InspectDAG returns the DAG of repos and pipelines, derived from pipeline
+// inputs and branch provenance.
+// `overlay` adds the state of each pipeline, and the state and datum counts of
+// its most recent job, to the DAG.
+// `upstream` and `downstream`, if set, limit the DAG to the named repo or
+// pipeline and the repos and pipelines upstream or downstream of it.
+func (c APIClient) InspectDAG(overlay bool, upstream, downstream string) (*pps.DAGInfo, error) {
+	dagInfo, err := c.PpsAPIClient.InspectDAG(
+		c.Ctx(),
+		&pps.InspectDAGRequest{
+			Overlay:    overlay,
+			Upstream:   upstream,
+			Downstream: downstream,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return dagInfo, nil
+}
+
 // ListPipelineHistory returns historical information about pipelines.
 // `pipeline` specifies which pipeline to return history about, if it's equal
 // to "" then ListPipelineHistory returns historical information about all
@@ -347,12 +347,33 @@ func (c *pfsBuilderClient) InspectBranch(ctx context.Context, req *pfs.InspectBr
 func (c *pfsBuilderClient) ListBranch(ctx context.Context, req *pfs.ListBranchRequest, opts ...grpc.CallOption) (*pfs.BranchInfos, error) {
 	return nil, unsupportedError("ListBranch")
 }
+func (c *pfsBuilderClient) MergeBranch(ctx context.Context, req *pfs.MergeBranchRequest, opts ...grpc.CallOption) (*pfs.MergeBranchResponse, error) {
+	return nil, unsupportedError("MergeBranch")
+}
+func (c *pfsBuilderClient) SetRepoQuota(ctx context.Context, req *pfs.SetRepoQuotaRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("SetRepoQuota")
+}
+func (c *pfsBuilderClient) SetRetentionPolicy(ctx context.Context, req *pfs.SetRetentionPolicyRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("SetRetentionPolicy")
+}
+func (c *pfsBuilderClient) PruneCommits(ctx context.Context, req *pfs.PruneCommitsRequest, opts ...grpc.CallOption) (*pfs.PruneCommitsResponse, error) {
+	return nil, unsupportedError("PruneCommits")
+}
+func (c *pfsBuilderClient) SetMetadata(ctx context.Context, req *pfs.SetMetadataRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("SetMetadata")
+}
+func (c *pfsBuilderClient) SetBranchProtection(ctx context.Context, req *pfs.SetBranchProtectionRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("SetBranchProtection")
+}
 func (c *pfsBuilderClient) PutFile(ctx context.Context, opts ...grpc.CallOption) (pfs.API_PutFileClient, error) {
 	return nil, unsupportedError("PutFile")
 }
 func (c *pfsBuilderClient) CopyFile(ctx context.Context, req *pfs.CopyFileRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("CopyFile")
 }
+func (c *pfsBuilderClient) SyncFile(ctx context.Context, req *pfs.SyncFileRequest, opts ...grpc.CallOption) (*pfs.SyncFileResponse, error) {
+	return nil, unsupportedError("SyncFile")
+}
 func (c *pfsBuilderClient) GetFile(ctx context.Context, req *pfs.GetFileRequest, opts ...grpc.CallOption) (pfs.API_GetFileClient, error) {
 	return nil, unsupportedError("GetFile")
 }
@@ -511,12 +532,18 @@ func (c *ppsBuilderClient) RestartDatum(ctx context.Context, req *pps.RestartDat
 func (c *ppsBuilderClient) CreatePipeline(ctx context.Context, req *pps.CreatePipelineRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("CreatePipeline")
 }
+func (c *ppsBuilderClient) PlanPipeline(ctx context.Context, req *pps.CreatePipelineRequest, opts ...grpc.CallOption) (*pps.PipelinePlan, error) {
+	return nil, unsupportedError("PlanPipeline")
+}
 func (c *ppsBuilderClient) InspectPipeline(ctx context.Context, req *pps.InspectPipelineRequest, opts ...grpc.CallOption) (*pps.PipelineInfo, error) {
 	return nil, unsupportedError("InspectPipeline")
 }
 func (c *ppsBuilderClient) ListPipeline(ctx context.Context, req *pps.ListPipelineRequest, opts ...grpc.CallOption) (*pps.PipelineInfos, error) {
 	return nil, unsupportedError("ListPipeline")
 }
+func (c *ppsBuilderClient) InspectDAG(ctx context.Context, req *pps.InspectDAGRequest, opts ...grpc.CallOption) (*pps.DAGInfo, error) {
+	return nil, unsupportedError("InspectDAG")
+}
 func (c *ppsBuilderClient) DeletePipeline(ctx context.Context, req *pps.DeletePipelineRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("DeletePipeline")
 }
@@ -172,6 +172,7 @@ func (a *apiServer) Extract(request *admin.ExtractRequest, extractServer admin.A
 				Repo: &pfs.CreateRepoRequest{
 					Repo:        ri.Repo,
 					Description: ri.Description,
+					Metadata:    ri.Metadata,
 				}},
 			}); err != nil {
 				return err
@@ -198,6 +199,7 @@ func (a *apiServer) Extract(request *admin.ExtractRequest, extractServer admin.A
 				Provenance: ci.Provenance,
 				Started:    ci.Started,
 				Finished:   ci.Finished,
+				Metadata:   ci.Metadata,
 			}}})
 		}); err != nil {
 			return err
@@ -217,6 +219,7 @@ func (a *apiServer) Extract(request *admin.ExtractRequest, extractServer admin.A
 					Head:       bi.Head,
 					Branch:     bi.Branch,
 					Provenance: bi.DirectProvenance,
+					Metadata:   bi.Metadata,
 				},
 			}}); err != nil {
 				return err
@@ -229,64 +229,56 @@ func NewAuthServer(
 		txnEnv:     txnEnv,
 		pachLogger: log.NewLogger("auth.API"),
 		adminCache: make(map[string]auth.ClusterRoles),
-		tokens: col.NewCollection(
-			env.GetEtcdClient(),
+		tokens: env.GetCollectionBackend().NewCollection(
 			path.Join(etcdPrefix, tokensPrefix),
 			nil,
 			&auth.TokenInfo{},
 			nil,
 			nil,
 		),
-		oneTimePasswords: col.NewCollection(
-			env.GetEtcdClient(),
+		oneTimePasswords: env.GetCollectionBackend().NewCollection(
 			path.Join(etcdPrefix, oneTimePasswordsPrefix),
 			nil,
 			&auth.OTPInfo{},
 			nil,
 			nil,
 		),
-		acls: col.NewCollection(
-			env.GetEtcdClient(),
+		acls: env.GetCollectionBackend().NewCollection(
 			path.Join(etcdPrefix, aclsPrefix),
 			nil,
 			&auth.ACL{},
 			nil,
 			nil,
 		),
-		admins: col.NewCollection(
-			env.GetEtcdClient(),
+		admins: env.GetCollectionBackend().NewCollection(
 			path.Join(etcdPrefix, adminsPrefix),
 			nil,
 			&types.BoolValue{}, // smallest value that etcd actually stores
 			nil,
 			nil,
 		),
-		fsAdmins: col.NewCollection(
-			env.GetEtcdClient(),
+		fsAdmins: env.GetCollectionBackend().NewCollection(
 			path.Join(etcdPrefix, fsAdminsPrefix),
 			nil,
 			&types.BoolValue{}, // smallest value that etcd actually stores
 			nil,
 			nil,
 		),
-		members: col.NewCollection(
-			env.GetEtcdClient(),
+		members: env.GetCollectionBackend().NewCollection(
 			path.Join(etcdPrefix, membersPrefix),
 			nil,
 			&auth.Groups{},
 			nil,
 			nil,
 		),
-		groups: col.NewCollection(
-			env.GetEtcdClient(),
+		groups: env.GetCollectionBackend().NewCollection(
 			path.Join(etcdPrefix, groupsPrefix),
 			nil,
 			&auth.Users{},
 			nil,
 			nil,
 		),
-		authConfig: col.NewCollection(
-			env.GetEtcdClient(),
+		authConfig: env.GetCollectionBackend().NewCollection(
 			path.Join(etcdPrefix, configKey),
 			nil,
 			&auth.AuthConfig{},
@@ -358,8 +350,8 @@ func (a *apiServer) retrieveOrGeneratePPSToken() {
 	b.MaxElapsedTime = 60 * time.Second
 	b.MaxInterval = 5 * time.Second
 	if err := backoff.Retry(func() error {
-		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
-			superUserTokenCol := col.NewCollection(a.env.GetEtcdClient(), ppsconsts.PPSTokenKey, nil, &types.StringValue{}, nil, nil).ReadWrite(stm)
+		if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
+			superUserTokenCol := a.env.GetCollectionBackend().NewCollection(ppsconsts.PPSTokenKey, nil, &types.StringValue{}, nil, nil).ReadWrite(stm)
 			// TODO(msteffen): Don't use an empty key, as it will not be erased by
 			// superUserTokenCol.DeleteAll()
 			err := superUserTokenCol.Get("", &tokenProto)
@@ -568,7 +560,7 @@ func (a *apiServer) Activate(ctx context.Context, req *auth.ActivateRequest) (re
 	// in the "partial" activation state. Users cannot authenticate, but auth
 	// checks are now enforced, which means no pipelines or repos can be created
 	// while ACLs are being added to every repo for the existing pipelines
-	if _, err = col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	if err = a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		return a.admins.ReadWrite(stm).Put(ppsUser, epsilon)
 	}); err != nil {
 		return nil, err
@@ -597,7 +589,7 @@ func (a *apiServer) Activate(ctx context.Context, req *auth.ActivateRequest) (re
 	// Generate a new Pachyderm token (as the caller is authenticating) and
 	// initialize admins (watchAdmins() above will see the write)
 	pachToken := uuid.NewWithoutDashes()
-	if _, err = col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	if err = a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		admins := a.admins.ReadWrite(stm)
 		tokens := a.tokens.ReadWrite(stm)
 		if err := admins.Delete(ppsUser); err != nil {
@@ -658,7 +650,7 @@ func (a *apiServer) Deactivate(ctx context.Context, req *auth.DeactivateRequest)
 		_, ppsUserIsAdmin = a.adminCache[ppsUser]
 	}()
 	if ppsUserIsAdmin {
-		_, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 			a.admins.ReadWrite(stm).DeleteAll() // watchAdmins() will see the write
 			return nil
 		})
@@ -683,7 +675,7 @@ func (a *apiServer) Deactivate(ctx context.Context, req *auth.DeactivateRequest)
 			AdminOp: "DeactivateAuth",
 		}
 	}
-	_, err = col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	err = a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		a.acls.ReadWrite(stm).DeleteAll()
 		a.tokens.ReadWrite(stm).DeleteAll()
 		a.admins.ReadWrite(stm).DeleteAll()   // watchAdmins() will see the write
@@ -891,7 +883,7 @@ func (a *apiServer) applyClusterRoleBindings(ctx context.Context, roleBindings m
 	a.adminMu.Unlock()
 
 	// Update "admins" list (watchAdmins() will update admins cache)
-	if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		admins := a.admins.ReadWrite(stm)
 		fsAdmins := a.fsAdmins.ReadWrite(stm)
 
@@ -1013,7 +1005,7 @@ func (a *apiServer) Authenticate(ctx context.Context, req *auth.AuthenticateRequ
 
 		// Generate a new Pachyderm token and write it
 		pachToken = uuid.NewWithoutDashes()
-		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 			tokens := a.tokens.ReadWrite(stm)
 			return tokens.PutTTL(hashToken(pachToken),
 				&auth.TokenInfo{
@@ -1051,7 +1043,7 @@ func (a *apiServer) Authenticate(ctx context.Context, req *auth.AuthenticateRequ
 
 		// Generate a new Pachyderm token and write it
 		pachToken = uuid.NewWithoutDashes()
-		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 			tokens := a.tokens.ReadWrite(stm)
 			return tokens.PutTTL(hashToken(pachToken),
 				&auth.TokenInfo{
@@ -1064,7 +1056,7 @@ func (a *apiServer) Authenticate(ctx context.Context, req *auth.AuthenticateRequ
 		}
 
 	case req.OneTimePassword != "":
-		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 			// read short-lived authentication code (and delete it if found)
 			otps := a.oneTimePasswords.ReadWrite(stm)
 			key := hashToken(req.OneTimePassword)
@@ -1152,7 +1144,7 @@ func (a *apiServer) Authenticate(ctx context.Context, req *auth.AuthenticateRequ
 
 		// Generate a new Pachyderm token and write it
 		pachToken = uuid.NewWithoutDashes()
-		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 			tokens := a.tokens.ReadWrite(stm)
 			return tokens.PutTTL(hashToken(pachToken),
 				&auth.TokenInfo{
@@ -1327,7 +1319,7 @@ func (a *apiServer) getOneTimePassword(ctx context.Context, username string, otp
 
 	// Generate and store new OTP
 	code = "otp/" + uuid.NewWithoutDashes()
-	if _, err = col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	if err = a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		return a.oneTimePasswords.ReadWrite(stm).PutTTL(hashToken(code),
 			otpInfo, otpTTL)
 	}); err != nil {
@@ -2156,7 +2148,7 @@ func (a *apiServer) ExtendAuthToken(ctx context.Context, req *auth.ExtendAuthTok
 
 	// The token must already exist. If a token has been revoked, it can't be
 	// extended
-	if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		tokens := a.tokens.ReadWrite(stm)
 
 		// Actually look up the request token in the relevant collections
@@ -2239,7 +2231,7 @@ func (a *apiServer) RevokeAuthTokenInTransaction(txnCtx *txnenv.TransactionConte
 // group membership information based on signed SAML assertions or JWT claims).
 // This does no auth checks, so the caller must do all relevant authorization.
 func (a *apiServer) setGroupsForUserInternal(ctx context.Context, subject string, groups []string) error {
-	_, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		members := a.members.ReadWrite(stm)
 
 		// Get groups to remove/add user from/to
@@ -2357,7 +2349,7 @@ func (a *apiServer) ModifyMembers(ctx context.Context, req *auth.ModifyMembersRe
 		return nil, err
 	}
 
-	if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		members := a.members.ReadWrite(stm)
 		var groupsProto auth.Groups
 		for _, username := range add {
@@ -2502,7 +2494,7 @@ func (a *apiServer) GetUsers(ctx context.Context, req *auth.GetUsersRequest) (re
 	// Filter by group
 	if req.Group != "" {
 		var membersProto auth.Users
-		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 			groups := a.groups.ReadWrite(stm)
 			if err := groups.Get(req.Group, &membersProto); err != nil {
 				return err
@@ -2781,7 +2773,7 @@ func (a *apiServer) SetConfiguration(ctx context.Context, req *auth.SetConfigura
 	}
 
 	// upsert new config
-	if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		var liveConfig auth.AuthConfig
 		return a.authConfig.ReadWrite(stm).Upsert(configKey, &liveConfig, func() error {
 			if liveConfig.LiveConfigVersion == 0 {
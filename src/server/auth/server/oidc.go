@@ -146,8 +146,7 @@ func (a *apiServer) NewOIDCSP(name, issuer, clientID, clientSecret, redirectURI
 		RedirectURI:         redirectURI,
 		Scopes:              scopes,
 		IgnoreEmailVerified: ignoreEmailVerified,
-		States: col.NewCollection(
-			a.env.GetEtcdClient(),
+		States: a.env.GetCollectionBackend().NewCollection(
 			path.Join(oidcAuthnPrefix),
 			nil,
 			&auth.SessionInfo{},
@@ -198,7 +197,7 @@ func (o *InternalOIDCProvider) GetOIDCLoginURL(ctx context.Context) (string, str
 		Scopes:       o.Scopes,
 	}
 
-	if _, err := col.NewSTM(ctx, o.a.env.GetEtcdClient(), func(stm col.STM) error {
+	if err := o.a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		return o.States.ReadWrite(stm).PutTTL(state, &auth.SessionInfo{
 			Nonce: nonce, // read & verified by /authorization-code/callback
 		}, threeMinutes)
@@ -317,7 +316,7 @@ func (a *apiServer) handleOIDCExchange(w http.ResponseWriter, req *http.Request)
 	// the caller a Pachyderm token.
 	nonce, email, conversionErr := a.handleOIDCExchangeInternal(
 		context.Background(), sp, code, state)
-	_, etcdErr := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	etcdErr := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		var si auth.SessionInfo
 		return sp.States.ReadWrite(stm).Update(state, &si, func() error {
 			// nonce can only be checked inside etcd txn, but if nonces don't match
@@ -2047,6 +2047,32 @@ func TestDeleteAll(t *testing.T) {
 	require.NoError(t, adminClient.DeleteAll())
 }
 
+// TestSetRepoQuota tests that you must be a cluster admin to set a repo's
+// quota, even if you own the repo
+func TestSetRepoQuota(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	deleteAll(t)
+	defer deleteAll(t)
+
+	alice := tu.UniqueString("alice")
+	aliceClient, adminClient := getPachClient(t, alice), getPachClient(t, admin)
+
+	// alice creates a repo, but can't set its quota
+	repo := tu.UniqueString(t.Name())
+	require.NoError(t, aliceClient.CreateRepo(repo))
+	err := aliceClient.SetRepoQuota(repo, 1024, 0)
+	require.YesError(t, err)
+	require.Matches(t, "not authorized", err.Error())
+
+	// admin sets the quota, and alice can see it
+	require.NoError(t, adminClient.SetRepoQuota(repo, 1024, 0))
+	repoInfo, err := aliceClient.InspectRepo(repo)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1024), repoInfo.Quota.SizeBytes)
+}
+
 // TestListDatum tests that you must have READER access to all of job's
 // input repos to call ListDatum on that job
 func TestListDatum(t *testing.T) {
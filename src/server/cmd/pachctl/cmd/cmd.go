@@ -533,6 +533,7 @@ This resets the cluster to its initial state.`,
 	var uiWebsocketPort uint16
 	var pfsPort uint16
 	var s3gatewayPort uint16
+	var webdavPort uint16
 	var namespace string
 	portForward := &cobra.Command{
 		Short: "Forward a port on the local machine to pachd. This command blocks.",
@@ -634,6 +635,16 @@ This resets the cluster to its initial state.`,
 				successCount++
 			}
 
+			fmt.Println("Forwarding the WebDAV port...")
+			port, err = fw.RunForWebDAV(webdavPort)
+			if err != nil {
+				fmt.Printf("port forwarding failed: %v\n", err)
+			} else {
+				fmt.Printf("listening on port %d\n", port)
+				context.PortForwarders["webdav"] = uint32(port)
+				successCount++
+			}
+
 			if successCount == 0 {
 				return errors.New("failed to start port forwarders")
 			}
@@ -677,6 +688,7 @@ This resets the cluster to its initial state.`,
 	portForward.Flags().Uint16VarP(&uiWebsocketPort, "proxy-port", "x", 30081, "The local port to bind Pachyderm's dash proxy service to.")
 	portForward.Flags().Uint16VarP(&pfsPort, "pfs-port", "f", 30652, "The local port to bind PFS over HTTP to.")
 	portForward.Flags().Uint16VarP(&s3gatewayPort, "s3gateway-port", "s", 30600, "The local port to bind the s3gateway to.")
+	portForward.Flags().Uint16Var(&webdavPort, "webdav-port", 30601, "The local port to bind the WebDAV server to.")
 	portForward.Flags().StringVar(&namespace, "namespace", "", "Kubernetes namespace Pachyderm is deployed in.")
 	subcommands = append(subcommands, cmdutil.CreateAlias(portForward, "port-forward"))
 
@@ -784,6 +796,12 @@ This resets the cluster to its initial state.`,
 	}
 	subcommands = append(subcommands, cmdutil.CreateAlias(getDocs, "get"))
 
+	mergeDocs := &cobra.Command{
+		Short: "Merge the changes of one Pachyderm resource into another.",
+		Long:  "Merge the changes of one Pachyderm resource into another.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(mergeDocs, "merge"))
+
 	globDocs := &cobra.Command{
 		Short: "Print a list of Pachyderm resources matching a glob pattern.",
 		Long:  "Print a list of Pachyderm resources matching a glob pattern.",
@@ -820,12 +838,24 @@ This resets the cluster to its initial state.`,
 	}
 	subcommands = append(subcommands, cmdutil.CreateAlias(runDocs, "run"))
 
+	drawDocs := &cobra.Command{
+		Short: "Draw a diagram of Pachyderm resources.",
+		Long:  "Draw a diagram of Pachyderm resources.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(drawDocs, "draw"))
+
 	editDocs := &cobra.Command{
 		Short: "Edit the value of an existing Pachyderm resource.",
 		Long:  "Edit the value of an existing Pachyderm resource.",
 	}
 	subcommands = append(subcommands, cmdutil.CreateAlias(editDocs, "edit"))
 
+	pruneDocs := &cobra.Command{
+		Short: "Delete the Pachyderm resources that are no longer needed.",
+		Long:  "Delete the Pachyderm resources that are no longer needed.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(pruneDocs, "prune"))
+
 	subcommands = append(subcommands, pfscmds.Cmds()...)
 	subcommands = append(subcommands, ppscmds.Cmds()...)
 	subcommands = append(subcommands, deploycmds.Cmds()...)
@@ -883,6 +913,7 @@ func applyRootUsageFunc(rootCmd *cobra.Command) {
 			"glob",
 			"inspect",
 			"list",
+			"merge",
 			"put",
 			"restart",
 			"start",
@@ -10,6 +10,7 @@ import (
 	"runtime/debug"
 	"runtime/pprof"
 	"strconv"
+	"time"
 
 	"github.com/pachyderm/pachyderm/src/client"
 	adminclient "github.com/pachyderm/pachyderm/src/client/admin"
@@ -35,16 +36,20 @@ import (
 	pach_http "github.com/pachyderm/pachyderm/src/server/http"
 	"github.com/pachyderm/pachyderm/src/server/pfs/s3"
 	pfs_server "github.com/pachyderm/pachyderm/src/server/pfs/server"
+	"github.com/pachyderm/pachyderm/src/server/pfs/webdav"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
 	cache_pb "github.com/pachyderm/pachyderm/src/server/pkg/cache/groupcachepb"
 	cache_server "github.com/pachyderm/pachyderm/src/server/pkg/cache/server"
 	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/deploy/assets"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
 	logutil "github.com/pachyderm/pachyderm/src/server/pkg/log"
 	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
 	"github.com/pachyderm/pachyderm/src/server/pkg/netutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/serviceenv"
+	"github.com/pachyderm/pachyderm/src/server/pkg/storage/gc"
 	txnenv "github.com/pachyderm/pachyderm/src/server/pkg/transactionenv"
 	"github.com/pachyderm/pachyderm/src/server/pkg/uuid"
 	pps_server "github.com/pachyderm/pachyderm/src/server/pps/server"
@@ -53,6 +58,7 @@ import (
 
 	etcd "github.com/coreos/etcd/clientv3"
 	units "github.com/docker/go-units"
+	"github.com/jinzhu/gorm"
 	"github.com/pachyderm/pachyderm/src/client/pkg/tls"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
@@ -63,13 +69,17 @@ import (
 
 const (
 	defaultTreeCacheSize = 8
+
+	// collectionReaperLockPath is the etcd key (under EtcdPrefix) of the lock
+	// held by the pachd that's reaping the postgres collection backend
+	collectionReaperLockPath = "_collection_reaper_lock"
 )
 
 var mode string
 var readiness bool
 
 func init() {
-	flag.StringVar(&mode, "mode", "full", "Pachd currently supports two modes: full and sidecar.  The former includes everything you need in a full pachd node.  The latter runs only PFS, the Auth service, and a stripped-down version of PPS.")
+	flag.StringVar(&mode, "mode", "full", "Pachd currently supports three modes: full, sidecar and migrate-collections.  The first includes everything you need in a full pachd node.  The second runs only PFS, the Auth service, and a stripped-down version of PPS.  The last copies the cluster's collections from etcd to postgres and exits.")
 	flag.BoolVar(&readiness, "readiness", false, "Run readiness check.")
 	flag.Parse()
 }
@@ -84,6 +94,8 @@ func main() {
 		cmdutil.Main(doFullMode, &serviceenv.PachdFullConfiguration{})
 	case mode == "sidecar":
 		cmdutil.Main(doSidecarMode, &serviceenv.PachdFullConfiguration{})
+	case mode == "migrate-collections":
+		cmdutil.Main(doMigrateCollectionsMode, &serviceenv.PachdFullConfiguration{})
 	default:
 		fmt.Printf("unrecognized mode: %s\n", mode)
 	}
@@ -308,6 +320,9 @@ func doFullMode(config interface{}) (retErr error) {
 	if err != nil {
 		return errors.Wrapf(err, "getClusterID")
 	}
+	if err := setupCollectionBackend(env); err != nil {
+		return err
+	}
 	var reporter *metrics.Reporter
 	if env.Metrics {
 		reporter = metrics.NewReporter(clusterID, env)
@@ -704,7 +719,7 @@ func doFullMode(config interface{}) (retErr error) {
 		return server.ListenAndServeTLS(certPath, keyPath)
 	})
 	go waitForError("Githook Server", errChan, requireNoncriticalServers, func() error {
-		return githook.RunGitHookServer(address, etcdAddress, path.Join(env.EtcdPrefix, env.PPSEtcdPrefix))
+		return githook.RunGitHookServer(address, env.GetCollectionBackend(), path.Join(env.EtcdPrefix, env.PPSEtcdPrefix), env.GetKubeClient(), kubeNamespace)
 	})
 	go waitForError("S3 Server", errChan, requireNoncriticalServers, func() error {
 		server, err := s3.Server(env.S3GatewayPort, s3.NewMasterDriver(), func() (*client.APIClient, error) {
@@ -727,6 +742,31 @@ func doFullMode(config interface{}) (retErr error) {
 		server.TLSConfig = &gotls.Config{GetCertificate: cLoader.GetCertificate}
 		return server.ListenAndServeTLS(certPath, keyPath)
 	})
+	go waitForError("WebDAV Server", errChan, requireNoncriticalServers, func() error {
+		sessionTimeout, err := time.ParseDuration(env.WebDAVSessionTimeout)
+		if err != nil {
+			return errors.Wrapf(err, "could not parse WebDAV session timeout %q", env.WebDAVSessionTimeout)
+		}
+		server, err := webdav.Server(env.WebDAVPort, sessionTimeout, func() (*client.APIClient, error) {
+			return client.NewFromAddress(fmt.Sprintf("localhost:%d", env.PeerPort))
+		})
+		if err != nil {
+			return err
+		}
+		certPath, keyPath, err := tls.GetCertPaths()
+		if err != nil {
+			log.Warnf("WebDAV TLS disabled: %v", err)
+			return server.ListenAndServe()
+		}
+		cLoader := tls.NewCertLoader(certPath, keyPath, tls.CertCheckFrequency)
+		// Read TLS cert and key
+		err = cLoader.LoadAndStart()
+		if err != nil {
+			return errors.Wrapf(err, "couldn't load TLS cert for WebDAV: %v", err)
+		}
+		server.TLSConfig = &gotls.Config{GetCertificate: cLoader.GetCertificate}
+		return server.ListenAndServeTLS(certPath, keyPath)
+	})
 	go waitForError("Prometheus Server", errChan, requireNoncriticalServers, func() error {
 		http.Handle("/metrics", promhttp.Handler())
 		return http.ListenAndServe(fmt.Sprintf(":%v", assets.PrometheusPort), nil)
@@ -734,6 +774,64 @@ func doFullMode(config interface{}) (retErr error) {
 	return <-errChan
 }
 
+func doMigrateCollectionsMode(config interface{}) error {
+	env := serviceenv.InitServiceEnv(serviceenv.NewConfiguration(config))
+	if env.EtcdPrefix == "" {
+		env.EtcdPrefix = col.DefaultPrefix
+	}
+	db, err := getCollectionDB(env)
+	if err != nil {
+		return err
+	}
+	count, err := col.MigrateEtcdToSQL(context.Background(), env.GetEtcdClient(), db, env.EtcdPrefix)
+	if err != nil {
+		return errors.Wrapf(err, "error migrating collections to postgres")
+	}
+	log.Printf("migrated %d keys under %q from etcd to postgres", count, env.EtcdPrefix)
+	return nil
+}
+
+// setupCollectionBackend checks that the configured collection backend is
+// usable and, if it's postgres, starts the collection reaper. The reaper is run
+// under a master lock, as ReapSQL must only run in one pachd per database.
+func setupCollectionBackend(env *serviceenv.ServiceEnv) error {
+	if env.CollectionBackend != "" {
+		if err := col.ValidateBackend(env.CollectionBackend); err != nil {
+			return err
+		}
+	}
+	db := env.GetCollectionDB()
+	if db == nil {
+		return nil
+	}
+	go func() {
+		ctx := context.Background()
+		reaperLock := dlock.NewDLock(env.GetEtcdClient(), path.Join(env.EtcdPrefix, collectionReaperLockPath))
+		backoff.RetryNotify(func() error {
+			masterCtx, err := reaperLock.Lock(ctx)
+			if err != nil {
+				return err
+			}
+			defer reaperLock.Unlock(masterCtx)
+			return col.ReapSQL(masterCtx, db, time.Second, col.DefaultSQLEventRetention)
+		}, backoff.NewInfiniteBackOff(), func(err error, retryIn time.Duration) error {
+			log.Errorf("error reaping collections: %v; retrying in %v", err, retryIn)
+			return nil
+		})
+	}()
+	return nil
+}
+
+// getCollectionDB connects to the postgres database that collections are
+// migrated into. It's independent of the COLLECTION_BACKEND setting, as
+// collections are migrated before pachd is switched to the postgres backend.
+func getCollectionDB(env *serviceenv.ServiceEnv) (*gorm.DB, error) {
+	if env.PostgresHost == "" || env.PostgresPort == "" {
+		return nil, errors.Errorf("postgres service not found (POSTGRES_SERVICE_HOST and POSTGRES_SERVICE_PORT must be set)")
+	}
+	return gc.NewDB(env.PostgresHost, env.PostgresPort)
+}
+
 func getEtcdClient(etcdAddress string) discovery.Client {
 	return discovery.NewEtcdClient(etcdAddress)
 }
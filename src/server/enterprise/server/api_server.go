@@ -77,8 +77,7 @@ func NewEnterpriseServer(env *serviceenv.ServiceEnv, etcdPrefix string) (ec.APIS
 	s := &apiServer{
 		pachLogger: log.NewLogger("enterprise.API"),
 		env:        env,
-		enterpriseToken: col.NewCollection(
-			env.GetEtcdClient(),
+		enterpriseToken: env.GetCollectionBackend().NewCollection(
 			etcdPrefix, // only one collection--no extra prefix needed
 			nil,
 			&ec.EnterpriseRecord{},
@@ -225,7 +224,7 @@ func (a *apiServer) Activate(ctx context.Context, req *ec.ActivateRequest) (resp
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not convert expiration time \"%s\" to proto", expiration.String())
 	}
-	if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		e := a.enterpriseToken.ReadWrite(stm)
 		// blind write
 		return e.Put(enterpriseTokenKey, &ec.EnterpriseRecord{
@@ -305,7 +304,7 @@ func (a *apiServer) Deactivate(ctx context.Context, req *ec.DeactivateRequest) (
 		return nil, errors.Wrapf(err, "could not delete all pachyderm data")
 	}
 
-	if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		err := a.enterpriseToken.ReadWrite(stm).Delete(enterpriseTokenKey)
 		if err != nil && !col.IsErrNotFound(err) {
 			return err
@@ -13,9 +13,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/auth"
 	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
 
-	"github.com/gogo/protobuf/types"
 	"github.com/julienschmidt/httprouter"
-	"golang.org/x/net/context"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -27,10 +25,19 @@ func versionPath(p string) string {
 }
 
 var (
-	getFilePath = versionPath("pfs/repos/:repoName/commits/:commitID/files/*filePath")
-	servicePath = versionPath("pps/services/:serviceName/*path")
-	loginPath   = versionPath("auth/login")
-	logoutPath  = versionPath("auth/logout")
+	reposPath        = versionPath("pfs/repos")
+	repoPath         = versionPath("pfs/repos/:repoName")
+	branchesPath     = versionPath("pfs/repos/:repoName/branches")
+	commitsPath      = versionPath("pfs/repos/:repoName/commits")
+	commitPath       = versionPath("pfs/repos/:repoName/commits/:commitID")
+	finishCommitPath = versionPath("pfs/repos/:repoName/commits/:commitID/finish")
+	getFilePath      = versionPath("pfs/repos/:repoName/commits/:commitID/files/*filePath")
+	listFilePath     = versionPath("pfs/repos/:repoName/commits/:commitID/list/*filePath")
+	globFilePath     = versionPath("pfs/repos/:repoName/commits/:commitID/glob")
+	diffFilePath     = versionPath("pfs/repos/:repoName/commits/:commitID/diff/*filePath")
+	servicePath      = versionPath("pps/services/:serviceName/*path")
+	loginPath        = versionPath("auth/login")
+	logoutPath       = versionPath("auth/logout")
 )
 
 type router = *httprouter.Router
@@ -52,52 +59,29 @@ func NewHTTPServer(address string) (http.Handler, error) {
 		httpClient: &http.Client{},
 	}
 
+	router.GET(reposPath, s.listRepoHandler)
+	router.GET(repoPath, s.inspectRepoHandler)
+	router.GET(branchesPath, s.listBranchHandler)
+	router.GET(commitsPath, s.listCommitHandler)
+	router.GET(commitPath, s.inspectCommitHandler)
 	router.GET(getFilePath, s.getFileHandler)
+	router.GET(listFilePath, s.listFileHandler)
+	router.GET(globFilePath, s.globFileHandler)
+	router.GET(diffFilePath, s.diffFileHandler)
 	router.GET(servicePath, s.serviceHandler)
 
+	router.POST(commitsPath, s.startCommitHandler)
+	router.POST(finishCommitPath, s.finishCommitHandler)
 	router.POST(loginPath, s.authLoginHandler)
 	router.POST(logoutPath, s.authLogoutHandler)
 	router.POST(servicePath, s.serviceHandler)
 
+	router.PUT(getFilePath, s.putFileHandler)
+
 	router.NotFound = http.HandlerFunc(notFound)
 	return s, nil
 }
 
-func (s *server) getFileHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	filePaths := strings.Split(ps.ByName("filePath"), "/")
-	fileName := filePaths[len(filePaths)-1]
-	ctx := context.Background()
-	for _, cookie := range r.Cookies() {
-		if cookie.Name == auth.ContextTokenKey {
-			ctx = metadata.NewIncomingContext(
-				ctx,
-				metadata.Pairs(auth.ContextTokenKey, cookie.Value),
-			)
-		}
-	}
-	downloadValues := r.URL.Query()["download"]
-	if len(downloadValues) == 1 && downloadValues[0] == "true" {
-		w.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=\"%v\"", fileName))
-	}
-	c := s.getPachClient().WithCtx(ctx)
-	commitInfo, err := c.InspectCommit(ps.ByName("repoName"), ps.ByName("commitID"))
-	if err != nil {
-		httpError(w, err)
-		return
-	}
-	content, err := c.GetFileReadSeeker(ps.ByName("repoName"), ps.ByName("commitID"), ps.ByName("filePath"))
-	if err != nil {
-		httpError(w, err)
-		return
-	}
-	modtime, err := types.TimestampFromProto(commitInfo.Finished)
-	if err != nil {
-		httpError(w, err)
-		return
-	}
-	http.ServeContent(w, r, fileName, modtime, content)
-}
-
 func (s *server) serviceHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	c := s.getPachClient()
 	serviceName := ps.ByName("serviceName")
@@ -143,13 +127,35 @@ func notFound(w http.ResponseWriter, r *http.Request) {
 }
 
 func httpError(w http.ResponseWriter, err error) {
-	if errutil.IsNotFoundError(err) {
+	switch {
+	case errutil.IsNotFoundError(err):
 		http.Error(w, err.Error(), http.StatusNotFound)
-	} else {
+	case auth.IsErrNotSignedIn(err), auth.IsErrBadToken(err):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case auth.IsErrNotAuthorized(err), auth.IsErrBranchProtected(err):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
+// clientFor returns a pach client that authenticates as the caller of 'r'.
+// Callers may pass their token as a bearer token in the Authorization header,
+// or in the cookie set by the login handler.
+func (s *server) clientFor(r *http.Request) *client.APIClient {
+	ctx := r.Context()
+	token := ""
+	if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		token = strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+	} else if cookie, err := r.Cookie(auth.ContextTokenKey); err == nil {
+		token = cookie.Value
+	}
+	if token != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(auth.ContextTokenKey, token))
+	}
+	return s.getPachClient().WithCtx(ctx)
+}
+
 func (s *server) getPachClient() *client.APIClient {
 	s.pachClientOnce.Do(func() {
 		var err error
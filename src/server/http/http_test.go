@@ -0,0 +1,245 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/server/pkg/testpachd"
+)
+
+// newTestServer returns an HTTP server that sends its requests with
+// 'pachClient', rather than dialing pachd
+func newTestServer(t *testing.T, pachClient *client.APIClient) http.Handler {
+	handler, err := NewHTTPServer("")
+	require.NoError(t, err)
+	s := handler.(*server)
+	s.pachClientOnce.Do(func() { s.pachClient = pachClient })
+	return s
+}
+
+// serve sends a request to 'handler' and returns the response
+func serve(handler http.Handler, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestReadProto(t *testing.T) {
+	request := &pfs.StartCommitRequest{}
+	r := httptest.NewRequest("POST", commitsPath, strings.NewReader(`{"branch": "master", "description": "test"}`))
+	w := httptest.NewRecorder()
+	require.True(t, readProto(w, r, request))
+	require.Equal(t, "master", request.Branch)
+	require.Equal(t, "test", request.Description)
+
+	// An empty body leaves the request unset
+	request = &pfs.StartCommitRequest{}
+	r = httptest.NewRequest("POST", commitsPath, strings.NewReader(""))
+	require.True(t, readProto(w, r, request))
+	require.Equal(t, "", request.Branch)
+
+	r = httptest.NewRequest("POST", commitsPath, strings.NewReader(`{"branch": `))
+	w = httptest.NewRecorder()
+	require.False(t, readProto(w, r, request))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestQueryBool(t *testing.T) {
+	w := httptest.NewRecorder()
+	value, ok := queryBool(w, httptest.NewRequest("GET", "/?shallow=true", nil), "shallow")
+	require.True(t, ok)
+	require.True(t, value)
+
+	value, ok = queryBool(w, httptest.NewRequest("GET", "/", nil), "shallow")
+	require.True(t, ok)
+	require.False(t, value)
+
+	_, ok = queryBool(w, httptest.NewRequest("GET", "/?shallow=maybe", nil), "shallow")
+	require.False(t, ok)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRoutes(t *testing.T) {
+	// Registering conflicting routes panics, so this checks that the routes
+	// are compatible
+	handler, err := NewHTTPServer("")
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/nonexistent", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetFile(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		handler := newTestServer(t, env.PachClient)
+		require.NoError(t, env.PachClient.CreateRepo("test"))
+		_, err := env.PachClient.PutFile("test", "master", "dir/file", strings.NewReader("foobar"))
+		require.NoError(t, err)
+		fileInfo, err := env.PachClient.InspectFile("test", "master", "dir/file")
+		require.NoError(t, err)
+		etag := fmt.Sprintf("%q", hex.EncodeToString(fileInfo.Hash))
+		target := "/v1/pfs/repos/test/commits/master/files/dir/file"
+
+		w := serve(handler, "GET", target, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "foobar", w.Body.String())
+		require.Equal(t, etag, w.Header().Get("ETag"))
+		require.Equal(t, "", w.Header().Get("Content-Disposition"))
+
+		w = serve(handler, "GET", target+"?download=true", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, `attachment; filename="file"`, w.Header().Get("Content-Disposition"))
+
+		w = serve(handler, "GET", target, "", "Range", "bytes=1-3")
+		require.Equal(t, http.StatusPartialContent, w.Code)
+		require.Equal(t, "oob", w.Body.String())
+		require.Equal(t, "bytes 1-3/6", w.Header().Get("Content-Range"))
+
+		w = serve(handler, "GET", target, "", "If-None-Match", etag)
+		require.Equal(t, http.StatusNotModified, w.Code)
+		require.Equal(t, 0, w.Body.Len())
+
+		// Once the file changes, its ETag no longer matches
+		_, err = env.PachClient.PutFileOverwrite("test", "master", "dir/file", strings.NewReader("barfoo"), 0)
+		require.NoError(t, err)
+		w = serve(handler, "GET", target, "", "If-None-Match", etag)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "barfoo", w.Body.String())
+		require.NotEqual(t, etag, w.Header().Get("ETag"))
+
+		require.Equal(t, http.StatusBadRequest, serve(handler, "GET", "/v1/pfs/repos/test/commits/master/files/dir", "").Code)
+		require.Equal(t, http.StatusNotFound, serve(handler, "GET", "/v1/pfs/repos/test/commits/master/files/missing", "").Code)
+		require.Equal(t, http.StatusNotFound, serve(handler, "GET", "/v1/pfs/repos/missing/commits/master/files/dir/file", "").Code)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestPutFile(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		handler := newTestServer(t, env.PachClient)
+		require.NoError(t, env.PachClient.CreateRepo("test"))
+		target := "/v1/pfs/repos/test/commits/master/files/file"
+		content := func() string {
+			var buf bytes.Buffer
+			require.NoError(t, env.PachClient.GetFile("test", "master", "file", 0, 0, &buf))
+			return buf.String()
+		}
+
+		w := serve(handler, "PUT", target, "foo")
+		require.Equal(t, http.StatusCreated, w.Code)
+		file := &pfs.File{}
+		require.NoError(t, jsonpb.Unmarshal(w.Body, file))
+		require.Equal(t, "test", file.Commit.Repo.Name)
+		require.Equal(t, "/file", file.Path)
+		require.Equal(t, "foo", content())
+
+		// Content is appended, unless 'overwrite' is set
+		require.Equal(t, http.StatusCreated, serve(handler, "PUT", target, "bar").Code)
+		require.Equal(t, "foobar", content())
+		require.Equal(t, http.StatusCreated, serve(handler, "PUT", target+"?overwrite=true", "baz").Code)
+		require.Equal(t, "baz", content())
+
+		require.Equal(t, http.StatusBadRequest, serve(handler, "PUT", target+"?overwrite=maybe", "qux").Code)
+		require.Equal(t, "baz", content())
+		require.Equal(t, http.StatusNotFound, serve(handler, "PUT", "/v1/pfs/repos/missing/commits/master/files/file", "foo").Code)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestListFile(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		handler := newTestServer(t, env.PachClient)
+		require.NoError(t, env.PachClient.CreateRepo("test"))
+		commit, err := env.PachClient.StartCommit("test", "master")
+		require.NoError(t, err)
+		for _, p := range []string{"a", "dir/b", "dir/c"} {
+			_, err = env.PachClient.PutFile("test", commit.ID, p, strings.NewReader("foo"))
+			require.NoError(t, err)
+		}
+		require.NoError(t, env.PachClient.FinishCommit("test", commit.ID))
+		paths := func(w *httptest.ResponseRecorder) []string {
+			require.Equal(t, http.StatusOK, w.Code)
+			fileInfos := &pfs.FileInfos{}
+			require.NoError(t, jsonpb.Unmarshal(w.Body, fileInfos))
+			var result []string
+			for _, fileInfo := range fileInfos.FileInfo {
+				result = append(result, fileInfo.File.Path)
+			}
+			return result
+		}
+
+		require.ElementsEqual(t, []string{"/a", "/dir"}, paths(serve(handler, "GET", "/v1/pfs/repos/test/commits/master/list/", "")))
+		require.ElementsEqual(t, []string{"/dir/b", "/dir/c"}, paths(serve(handler, "GET", "/v1/pfs/repos/test/commits/"+commit.ID+"/list/dir", "")))
+		require.ElementsEqual(t, []string{"/dir/b", "/dir/c"}, paths(serve(handler, "GET", "/v1/pfs/repos/test/commits/master/glob?pattern=/dir/*", "")))
+		require.Equal(t, http.StatusBadRequest, serve(handler, "GET", "/v1/pfs/repos/test/commits/master/glob", "").Code)
+		require.Equal(t, http.StatusNotFound, serve(handler, "GET", "/v1/pfs/repos/test/commits/master/list/missing", "").Code)
+
+		w := serve(handler, "GET", "/v1/pfs/repos", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		repos := &pfs.ListRepoResponse{}
+		require.NoError(t, jsonpb.Unmarshal(w.Body, repos))
+		require.Equal(t, 1, len(repos.RepoInfo))
+		require.Equal(t, "test", repos.RepoInfo[0].Repo.Name)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestAuthToken(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithMockEnv(func(env *testpachd.MockEnv) error {
+		handler := newTestServer(t, env.PachClient)
+		// Record the token that pachd receives with each request
+		var tokens []string
+		env.MockPachd.PFS.ListRepo.Use(func(ctx context.Context, request *pfs.ListRepoRequest) (*pfs.ListRepoResponse, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			tokens = md.Get(auth.ContextTokenKey)
+			return &pfs.ListRepoResponse{}, nil
+		})
+		cookie := (&http.Cookie{Name: auth.ContextTokenKey, Value: "cookie-token"}).String()
+
+		require.Equal(t, http.StatusOK, serve(handler, "GET", "/v1/pfs/repos", "", "Authorization", "Bearer bearer-token").Code)
+		require.Equal(t, []string{"bearer-token"}, tokens)
+
+		require.Equal(t, http.StatusOK, serve(handler, "GET", "/v1/pfs/repos", "", "Cookie", cookie).Code)
+		require.Equal(t, []string{"cookie-token"}, tokens)
+
+		// A bearer token takes precedence over the cookie
+		require.Equal(t, http.StatusOK, serve(handler, "GET", "/v1/pfs/repos", "", "Authorization", "Bearer bearer-token", "Cookie", cookie).Code)
+		require.Equal(t, []string{"bearer-token"}, tokens)
+
+		// Other authorization schemes are ignored
+		require.Equal(t, http.StatusOK, serve(handler, "GET", "/v1/pfs/repos", "", "Authorization", "Basic Zm9vOmJhcg==").Code)
+		require.Equal(t, 0, len(tokens))
+
+		// Auth errors are reported with the corresponding HTTP status
+		env.MockPachd.PFS.ListRepo.Use(func(context.Context, *pfs.ListRepoRequest) (*pfs.ListRepoResponse, error) {
+			return nil, auth.ErrBadToken
+		})
+		require.Equal(t, http.StatusUnauthorized, serve(handler, "GET", "/v1/pfs/repos", "", "Authorization", "Bearer bad-token").Code)
+		return nil
+	})
+	require.NoError(t, err)
+}
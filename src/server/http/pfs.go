@@ -0,0 +1,301 @@
+package http
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxRequestBytes limits the size of JSON request bodies (file uploads are
+// streamed, and aren't subject to this limit)
+const maxRequestBytes = 1024 * 1024
+
+var marshaler = &jsonpb.Marshaler{OrigName: true}
+
+// writeProto writes 'msg' to 'w' as JSON
+func writeProto(w http.ResponseWriter, status int, msg proto.Message) {
+	data, err := marshaler.MarshalToString(msg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := io.WriteString(w, data); err != nil {
+		log.Errorf("error writing http response: %v", err)
+	}
+}
+
+// readProto reads the JSON body of 'r' (if any) into 'msg'. It returns false
+// (after writing an error to 'w') if the body couldn't be read.
+func readProto(w http.ResponseWriter, r *http.Request, msg proto.Message) bool {
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestBytes+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if len(data) > maxRequestBytes {
+		http.Error(w, fmt.Sprintf("request body exceeds %d bytes", maxRequestBytes), http.StatusRequestEntityTooLarge)
+		return false
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return true
+	}
+	if err := jsonpb.Unmarshal(bytes.NewReader(data), msg); err != nil {
+		http.Error(w, fmt.Sprintf("could not parse request body: %v", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// queryBool returns the boolean query parameter 'name' of 'r' (false if it's
+// unset). It returns ok=false (after writing an error to 'w') if the parameter
+// isn't a boolean.
+func queryBool(w http.ResponseWriter, r *http.Request, name string) (value bool, ok bool) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return false, true
+	}
+	value, err := strconv.ParseBool(s)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid value for %q: %q", name, s), http.StatusBadRequest)
+		return false, false
+	}
+	return value, true
+}
+
+func (s *server) listRepoHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	repoInfos, err := s.clientFor(r).ListRepo()
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	writeProto(w, http.StatusOK, &pfs.ListRepoResponse{RepoInfo: repoInfos})
+}
+
+func (s *server) inspectRepoHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	repoInfo, err := s.clientFor(r).InspectRepo(ps.ByName("repoName"))
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	writeProto(w, http.StatusOK, repoInfo)
+}
+
+func (s *server) listBranchHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	branchInfos, err := s.clientFor(r).ListBranch(ps.ByName("repoName"))
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	writeProto(w, http.StatusOK, &pfs.BranchInfos{BranchInfo: branchInfos})
+}
+
+func (s *server) listCommitHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	query := r.URL.Query()
+	var number uint64
+	if n := query.Get("number"); n != "" {
+		var err error
+		number, err = strconv.ParseUint(n, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid value for \"number\": %q", n), http.StatusBadRequest)
+			return
+		}
+	}
+	commitInfos, err := s.clientFor(r).ListCommit(ps.ByName("repoName"), query.Get("to"), query.Get("from"), number)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	writeProto(w, http.StatusOK, &pfs.CommitInfos{CommitInfo: commitInfos})
+}
+
+func (s *server) inspectCommitHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	commitInfo, err := s.clientFor(r).InspectCommit(ps.ByName("repoName"), ps.ByName("commitID"))
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	writeProto(w, http.StatusOK, commitInfo)
+}
+
+// startCommitHandler starts a commit in the repo in the URL. The (optional)
+// request body is a pfs.StartCommitRequest, whose parent repo is ignored.
+func (s *server) startCommitHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	request := &pfs.StartCommitRequest{}
+	if !readProto(w, r, request) {
+		return
+	}
+	if request.Parent == nil {
+		request.Parent = &pfs.Commit{}
+	}
+	request.Parent.Repo = client.NewRepo(ps.ByName("repoName"))
+	c := s.clientFor(r)
+	commit, err := c.PfsAPIClient.StartCommit(c.Ctx(), request)
+	if err != nil {
+		httpError(w, grpcutil.ScrubGRPC(err))
+		return
+	}
+	writeProto(w, http.StatusCreated, commit)
+}
+
+// finishCommitHandler finishes the commit in the URL. The (optional) request
+// body is a pfs.FinishCommitRequest, whose commit is ignored.
+func (s *server) finishCommitHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	request := &pfs.FinishCommitRequest{}
+	if !readProto(w, r, request) {
+		return
+	}
+	request.Commit = client.NewCommit(ps.ByName("repoName"), ps.ByName("commitID"))
+	c := s.clientFor(r)
+	if _, err := c.PfsAPIClient.FinishCommit(c.Ctx(), request); err != nil {
+		httpError(w, grpcutil.ScrubGRPC(err))
+		return
+	}
+	commitInfo, err := c.InspectCommit(request.Commit.Repo.Name, request.Commit.ID)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	writeProto(w, http.StatusOK, commitInfo)
+}
+
+func (s *server) getFileHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	repoName, commitID, filePath := ps.ByName("repoName"), ps.ByName("commitID"), ps.ByName("filePath")
+	filePaths := strings.Split(filePath, "/")
+	fileName := filePaths[len(filePaths)-1]
+	downloadValues := r.URL.Query()["download"]
+	if len(downloadValues) == 1 && downloadValues[0] == "true" {
+		w.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=\"%v\"", fileName))
+	}
+	c := s.clientFor(r)
+	commitInfo, err := c.InspectCommit(repoName, commitID)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	// Read the file from the resolved commit, so that its content matches its
+	// ETag even if 'commitID' is a branch that moves
+	fileInfo, err := c.InspectFile(repoName, commitInfo.Commit.ID, filePath)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	if fileInfo.FileType != pfs.FileType_FILE {
+		http.Error(w, fmt.Sprintf("%q is not a file", filePath), http.StatusBadRequest)
+		return
+	}
+	content, err := c.GetFileReadSeeker(repoName, commitInfo.Commit.ID, filePath)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	// http.ServeContent handles Range, If-None-Match and If-Modified-Since
+	// using these headers
+	if len(fileInfo.Hash) > 0 {
+		w.Header().Set("ETag", fmt.Sprintf("%q", hex.EncodeToString(fileInfo.Hash)))
+	}
+	var modtime time.Time
+	if commitInfo.Finished != nil {
+		modtime, err = types.TimestampFromProto(commitInfo.Finished)
+		if err != nil {
+			httpError(w, err)
+			return
+		}
+	}
+	http.ServeContent(w, r, fileName, modtime, content)
+}
+
+// putFileHandler streams the request body into the file in the URL. The
+// content is appended to the file, unless the 'overwrite' query parameter is
+// set.
+func (s *server) putFileHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	overwrite, ok := queryBool(w, r, "overwrite")
+	if !ok {
+		return
+	}
+	file := client.NewFile(ps.ByName("repoName"), ps.ByName("commitID"), ps.ByName("filePath"))
+	c := s.clientFor(r)
+	var err error
+	if overwrite {
+		_, err = c.PutFileOverwrite(file.Commit.Repo.Name, file.Commit.ID, file.Path, r.Body, 0)
+	} else {
+		_, err = c.PutFile(file.Commit.Repo.Name, file.Commit.ID, file.Path, r.Body)
+	}
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	writeProto(w, http.StatusCreated, file)
+}
+
+func (s *server) listFileHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	fileInfos, err := s.clientFor(r).ListFile(ps.ByName("repoName"), ps.ByName("commitID"), ps.ByName("filePath"))
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	writeProto(w, http.StatusOK, &pfs.FileInfos{FileInfo: fileInfos})
+}
+
+func (s *server) globFileHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	pattern := r.URL.Query().Get("pattern")
+	if pattern == "" {
+		http.Error(w, "the \"pattern\" query parameter must be set", http.StatusBadRequest)
+		return
+	}
+	fileInfos, err := s.clientFor(r).GlobFile(ps.ByName("repoName"), ps.ByName("commitID"), pattern)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	writeProto(w, http.StatusOK, &pfs.FileInfos{FileInfo: fileInfos})
+}
+
+// diffFileHandler diffs the path in the URL against the same path in the
+// commit's parent, or against the file given by the 'old_repo', 'old_commit'
+// and 'old_path' query parameters (which default to the new file's repo,
+// commit and path).
+func (s *server) diffFileHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	shallow, ok := queryBool(w, r, "shallow")
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	newFile := client.NewFile(ps.ByName("repoName"), ps.ByName("commitID"), ps.ByName("filePath"))
+	oldRepo, oldCommit, oldPath := query.Get("old_repo"), query.Get("old_commit"), query.Get("old_path")
+	if oldRepo != "" || oldCommit != "" || oldPath != "" {
+		if oldRepo == "" {
+			oldRepo = newFile.Commit.Repo.Name
+		}
+		if oldCommit == "" {
+			oldCommit = newFile.Commit.ID
+		}
+		if oldPath == "" {
+			oldPath = newFile.Path
+		}
+	}
+	newFiles, oldFiles, err := s.clientFor(r).DiffFile(newFile.Commit.Repo.Name, newFile.Commit.ID, newFile.Path,
+		oldRepo, oldCommit, oldPath, shallow)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	writeProto(w, http.StatusOK, &pfs.DiffFileResponse{NewFiles: newFiles, OldFiles: oldFiles})
+}
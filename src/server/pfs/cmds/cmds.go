@@ -10,11 +10,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	gosync "sync"
+	"time"
 
 	prompt "github.com/c-bata/go-prompt"
+	units "github.com/docker/go-units"
 	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/limit"
 	pfsclient "github.com/pachyderm/pachyderm/src/client/pfs"
@@ -96,10 +100,21 @@ or type (e.g. csv, binary, images, etc).`,
 	createRepo.Flags().StringVarP(&description, "description", "d", "", "A description of the repo.")
 	commands = append(commands, cmdutil.CreateAlias(createRepo, "create repo"))
 
-	updateRepo := &cobra.Command{
+	var quotaSize string
+	var quotaFiles uint64
+	var updateRepo *cobra.Command // standalone declaration so Run() can refer
+	updateRepo = &cobra.Command{
 		Use:   "{{alias}} <repo>",
 		Short: "Update a repo.",
-		Long:  "Update a repo.",
+		Long: `Update a repo's description or quota.
+
+A repo's quota limits the size and number of files of each of its commits; commits that would exceed it can't be finished. Only cluster admins may set quotas.`,
+		Example: `
+# Limit the commits in repo "logs" to 10GB and 1000 files
+$ {{alias}} logs --quota-size 10GB --quota-files 1000
+
+# Remove the size limit from repo "logs"
+$ {{alias}} logs --quota-size 0`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
@@ -107,6 +122,35 @@ or type (e.g. csv, binary, images, etc).`,
 			}
 			defer c.Close()
 
+			sizeChanged := updateRepo.Flags().Changed("quota-size")
+			filesChanged := updateRepo.Flags().Changed("quota-files")
+			if sizeChanged || filesChanged {
+				repoInfo, err := c.InspectRepo(args[0])
+				if err != nil {
+					return err
+				}
+				quota := repoInfo.Quota
+				if quota == nil {
+					quota = &pfsclient.RepoQuota{}
+				}
+				if sizeChanged {
+					size, err := units.FromHumanSize(quotaSize)
+					if err != nil {
+						return errors.Wrapf(err, "invalid --quota-size")
+					}
+					quota.SizeBytes = uint64(size)
+				}
+				if filesChanged {
+					quota.FileCount = quotaFiles
+				}
+				if err := c.SetRepoQuota(args[0], quota.SizeBytes, quota.FileCount); err != nil {
+					return err
+				}
+				if !updateRepo.Flags().Changed("description") {
+					return nil
+				}
+			}
+
 			err = txncmds.WithActiveTransaction(c, func(c *client.APIClient) error {
 				_, err = c.PfsAPIClient.CreateRepo(
 					c.Ctx(),
@@ -122,6 +166,8 @@ or type (e.g. csv, binary, images, etc).`,
 		}),
 	}
 	updateRepo.Flags().StringVarP(&description, "description", "d", "", "A description of the repo.")
+	updateRepo.Flags().StringVar(&quotaSize, "quota-size", "", "The maximum size of each commit in the repo, e.g. 10GB (0 means no limit).")
+	updateRepo.Flags().Uint64Var(&quotaFiles, "quota-files", 0, "The maximum number of files in each commit in the repo (0 means no limit).")
 	shell.RegisterCompletionFunc(updateRepo, shell.RepoCompletion)
 	commands = append(commands, cmdutil.CreateAlias(updateRepo, "update repo"))
 
@@ -249,6 +295,7 @@ Commits can be created with another commit as a parent.`,
 	commands = append(commands, cmdutil.CreateDocsAlias(commitDocs, "commit", " commit$"))
 
 	var parent string
+	var metadata cmdutil.RepeatedStringArg
 	startCommit := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>",
 		Short: "Start a new commit.",
@@ -263,12 +310,19 @@ $ {{alias}} test@master
 $ {{alias}} test@patch -p master
 
 # Start a commit with XXX as the parent in repo "test", not on any branch
-$ {{alias}} test -p XXX`,
+$ {{alias}} test -p XXX
+
+# Start a commit in repo "test" on branch "master", tagged with its source
+$ {{alias}} test@master --metadata source=s3://bucket/path`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			branch, err := cmdutil.ParseBranch(args[0])
 			if err != nil {
 				return err
 			}
+			md, err := parseMetadata(metadata)
+			if err != nil {
+				return err
+			}
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
 				return err
@@ -284,6 +338,7 @@ $ {{alias}} test -p XXX`,
 						Branch:      branch.Name,
 						Parent:      client.NewCommit(branch.Repo.Name, parent),
 						Description: description,
+						Metadata:    md,
 					},
 				)
 				return err
@@ -298,6 +353,7 @@ $ {{alias}} test -p XXX`,
 	startCommit.MarkFlagCustom("parent", "__pachctl_get_commit $(__parse_repo ${nouns[0]})")
 	startCommit.Flags().StringVarP(&description, "message", "m", "", "A description of this commit's contents")
 	startCommit.Flags().StringVar(&description, "description", "", "A description of this commit's contents (synonym for --message)")
+	startCommit.Flags().Var(&metadata, "metadata", "Metadata to attach to this commit, as key=value. May be repeated.")
 	shell.RegisterCompletionFunc(startCommit, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(startCommit, "start commit"))
 
@@ -310,6 +366,10 @@ $ {{alias}} test -p XXX`,
 			if err != nil {
 				return err
 			}
+			md, err := parseMetadata(metadata)
+			if err != nil {
+				return err
+			}
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
 				return err
@@ -322,6 +382,7 @@ $ {{alias}} test -p XXX`,
 					&pfsclient.FinishCommitRequest{
 						Commit:      commit,
 						Description: description,
+						Metadata:    md,
 					},
 				)
 				return err
@@ -331,6 +392,7 @@ $ {{alias}} test -p XXX`,
 	}
 	finishCommit.Flags().StringVarP(&description, "message", "m", "", "A description of this commit's contents (overwrites any existing commit description)")
 	finishCommit.Flags().StringVar(&description, "description", "", "A description of this commit's contents (synonym for --message)")
+	finishCommit.Flags().Var(&metadata, "metadata", "Metadata to add to this commit, as key=value (an empty value removes the key). May be repeated.")
 	shell.RegisterCompletionFunc(finishCommit, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(finishCommit, "finish commit"))
 
@@ -388,7 +450,10 @@ $ {{alias}} foo@master
 $ {{alias}} foo@master -n 20
 
 # return commits in repo "foo" since commit XXX
-$ {{alias}} foo@master --from XXX`,
+$ {{alias}} foo@master --from XXX
+
+# return commits in repo "foo" whose metadata has "source" set to "camera-1"
+$ {{alias}} foo --metadata source=camera-1`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) (retErr error) {
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
@@ -400,14 +465,18 @@ $ {{alias}} foo@master --from XXX`,
 			if err != nil {
 				return err
 			}
+			md, err := parseMetadata(metadata)
+			if err != nil {
+				return err
+			}
 
 			if raw {
-				return c.ListCommitF(branch.Repo.Name, branch.Name, from, uint64(number), false, func(ci *pfsclient.CommitInfo) error {
+				return c.ListCommitByMetadataF(branch.Repo.Name, branch.Name, from, uint64(number), false, md, func(ci *pfsclient.CommitInfo) error {
 					return marshaller.Marshal(os.Stdout, ci)
 				})
 			}
 			writer := tabwriter.NewWriter(os.Stdout, pretty.CommitHeader)
-			if err := c.ListCommitF(branch.Repo.Name, branch.Name, from, uint64(number), false, func(ci *pfsclient.CommitInfo) error {
+			if err := c.ListCommitByMetadataF(branch.Repo.Name, branch.Name, from, uint64(number), false, md, func(ci *pfsclient.CommitInfo) error {
 				pretty.PrintCommitInfo(writer, ci, fullTimestamps)
 				return nil
 			}); err != nil {
@@ -418,6 +487,7 @@ $ {{alias}} foo@master --from XXX`,
 	}
 	listCommit.Flags().StringVarP(&from, "from", "f", "", "list all commits since this commit")
 	listCommit.Flags().IntVarP(&number, "number", "n", 0, "list only this many commits; if set to zero, list all commits")
+	listCommit.Flags().Var(&metadata, "metadata", "list only commits whose metadata contains this key=value pair; may be repeated")
 	listCommit.MarkFlagCustom("from", "__pachctl_get_commit $(__parse_repo ${nouns[0]})")
 	listCommit.Flags().AddFlagSet(rawFlags)
 	listCommit.Flags().AddFlagSet(fullTimestampsFlags)
@@ -539,7 +609,12 @@ $ {{alias}} test@master --new`,
 				prov = client.NewCommitProvenance(ppsconsts.SpecRepo, pipeline, pipelineInfo.SpecCommit.ID)
 			}
 
-			commitIter, err := c.SubscribeCommit(branch.Repo.Name, branch.Name, prov, from, pfsclient.CommitState_STARTED)
+			md, err := parseMetadata(metadata)
+			if err != nil {
+				return err
+			}
+
+			commitIter, err := c.SubscribeCommitByMetadata(branch.Repo.Name, branch.Name, prov, from, pfsclient.CommitState_STARTED, md)
 			if err != nil {
 				return err
 			}
@@ -549,6 +624,7 @@ $ {{alias}} test@master --new`,
 	}
 	subscribeCommit.Flags().StringVar(&from, "from", "", "subscribe to all commits since this commit")
 	subscribeCommit.Flags().StringVar(&pipeline, "pipeline", "", "subscribe to all commits created by this pipeline")
+	subscribeCommit.Flags().Var(&metadata, "metadata", "subscribe only to commits whose metadata contains this key=value pair; may be repeated")
 	subscribeCommit.MarkFlagCustom("from", "__pachctl_get_commit $(__parse_repo ${nouns[0]})")
 	subscribeCommit.Flags().BoolVar(&newCommits, "new", false, "subscribe to only new commits created from now on")
 	subscribeCommit.Flags().AddFlagSet(rawFlags)
@@ -730,6 +806,276 @@ Any pachctl command that can take a Commit ID, can take a branch name instead.`,
 	shell.RegisterCompletionFunc(deleteBranch, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(deleteBranch, "delete branch"))
 
+	var mergeStrategy string
+	var mergeResolutions []string
+	var mergeDescription string
+	mergeBranch := &cobra.Command{
+		Use:   "{{alias}} <repo>@<source-branch> <target-branch>",
+		Short: "Merge the changes on one branch into another.",
+		Long: `Merge the changes made on the source branch since its common ancestor with the target branch into the target branch, by adding a merge commit to the target branch.
+
+Files that were changed differently on both branches are conflicts. Each conflict is resolved by the first --resolve flag whose glob matches its path, or else by --strategy:
+  fail:   leave the conflict unresolved. If any conflict is unresolved, no merge commit is created.
+  ours:   keep the target branch's version of the file.
+  theirs: take the source branch's version of the file.`,
+		Example: `
+# Merge the changes on branch "review" into branch "master" of repo "images"
+$ {{alias}} images@review master
+
+# Merge "review" into "master", taking review's version of any conflicting
+# file under /labels, and master's version of any other conflicting file
+$ {{alias}} images@review master --resolve '/labels/**=theirs' --strategy ours`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			source, err := cmdutil.ParseBranch(args[0])
+			if err != nil {
+				return err
+			}
+			strategy, err := parseMergeStrategy(mergeStrategy)
+			if err != nil {
+				return err
+			}
+			var resolutions []*pfsclient.MergeResolution
+			for _, r := range mergeResolutions {
+				i := strings.LastIndex(r, "=")
+				if i < 0 {
+					return errors.Errorf("invalid resolution %q, must be of the form <glob>=<strategy>", r)
+				}
+				strategy, err := parseMergeStrategy(r[i+1:])
+				if err != nil {
+					return err
+				}
+				resolutions = append(resolutions, &pfsclient.MergeResolution{
+					Glob:     r[:i],
+					Strategy: strategy,
+				})
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			response, err := c.MergeBranch(source.Repo.Name, source.Name, args[1], strategy, resolutions, mergeDescription)
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, response)
+			}
+			if len(response.Conflicts) > 0 {
+				writer := tabwriter.NewWriter(os.Stdout, pretty.MergeConflictHeader)
+				for _, conflict := range response.Conflicts {
+					pretty.PrintMergeConflict(writer, conflict)
+				}
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+			}
+			if response.Commit == nil {
+				return errors.Errorf("merge failed because of unresolved conflicts")
+			}
+			fmt.Println(response.Commit.ID)
+			return nil
+		}),
+	}
+	mergeBranch.Flags().StringVarP(&mergeStrategy, "strategy", "s", "fail", "How to resolve conflicts that don't match any --resolve glob: one of fail, ours or theirs.")
+	mergeBranch.Flags().StringArrayVarP(&mergeResolutions, "resolve", "r", nil, "How to resolve conflicts at paths matching a glob, as <glob>=<fail|ours|theirs>. May be repeated; the first matching glob is used.")
+	mergeBranch.Flags().StringVarP(&mergeDescription, "message", "m", "", "A description of the merge commit.")
+	mergeBranch.Flags().AddFlagSet(rawFlags)
+	shell.RegisterCompletionFunc(mergeBranch, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(mergeBranch, "merge branch"))
+
+	var keepLast int64
+	var keepWithin string
+	var keepDaily int64
+	var removeRetention bool
+	var updateRetention *cobra.Command // standalone declaration so Run() can refer
+	updateRetention = &cobra.Command{
+		Use:   "{{alias}} <repo>[@<branch>]",
+		Short: "Update the retention policy of a repo or branch.",
+		Long: `Update the retention policy of a branch, or the default retention policy of a repo's branches if no branch is given.
+
+Commits that fall outside the retention policy of every branch containing them are pruned periodically by pachd, or by 'pachctl prune commits'. A commit is kept if any of the policy's rules keeps it. The HEAD of a branch, open commits and commits whose downstream commits are still in use are never pruned, and branches without a policy keep all of their commits.`,
+		Example: `
+# Keep the last 10 commits in each branch of repo "logs"
+$ {{alias}} logs --keep-last 10
+
+# Keep every commit on "master" from the last week, and one commit per day
+# before that
+$ {{alias}} logs@master --keep-within 7d --keep-daily -1
+
+# Remove the retention policy of "master"
+$ {{alias}} logs@master --remove`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			branch, err := cmdutil.ParseBranch(args[0])
+			if err != nil {
+				return err
+			}
+			var policy *pfsclient.RetentionPolicy
+			if !removeRetention {
+				if !updateRetention.Flags().Changed("keep-last") &&
+					!updateRetention.Flags().Changed("keep-within") &&
+					!updateRetention.Flags().Changed("keep-daily") {
+					return errors.Errorf("at least one of --keep-last, --keep-within, --keep-daily or --remove must be set")
+				}
+				policy = &pfsclient.RetentionPolicy{
+					KeepLast:  keepLast,
+					KeepDaily: keepDaily,
+				}
+				if keepWithin != "" {
+					d, err := parseRetentionDuration(keepWithin)
+					if err != nil {
+						return errors.Wrapf(err, "invalid --keep-within")
+					}
+					policy.KeepWithin = types.DurationProto(d)
+				}
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			return c.SetRetentionPolicy(branch.Repo.Name, branch.Name, policy)
+		}),
+	}
+	updateRetention.Flags().Int64Var(&keepLast, "keep-last", 0, "Keep the last N commits.")
+	updateRetention.Flags().StringVar(&keepWithin, "keep-within", "", "Keep the commits finished within this duration, e.g. 36h or 7d.")
+	updateRetention.Flags().Int64Var(&keepDaily, "keep-daily", 0, "Keep the newest commit of each of the last N days that have commits (-1 means every day).")
+	updateRetention.Flags().BoolVar(&removeRetention, "remove", false, "Remove the retention policy.")
+	shell.RegisterCompletionFunc(updateRetention, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(updateRetention, "update retention"))
+
+	var noDelete bool
+	var fastForwardOnly bool
+	var allowedCommitters cmdutil.RepeatedStringArg
+	var pipelineOnly bool
+	var removeProtection bool
+	var updateProtection *cobra.Command // standalone declaration so Run() can refer
+	updateProtection = &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch>",
+		Short: "Update the protection rules of a branch.",
+		Long: `Update the protection rules of a branch, replacing its existing rules. Protection rules restrict what users with WRITER access to the branch's repo can do with it, and can only be changed by the repo's OWNERs.
+
+Allowed committers are only enforced when auth is active.`,
+		Example: `
+# Prevent "master" in repo "images" from being deleted or rewritten
+$ {{alias}} images@master --no-delete --fast-forward-only
+
+# Only allow alice and the "ingest" pipeline to commit to "master"
+$ {{alias}} images@master --allowed-committer github:alice --allowed-committer pipeline:ingest
+
+# Only allow the pipeline "edges" to write to its output branch
+$ {{alias}} edges@master --pipeline-only
+
+# Remove the protection rules of "master"
+$ {{alias}} images@master --remove`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			branch, err := cmdutil.ParseBranch(args[0])
+			if err != nil {
+				return err
+			}
+			if branch.Name == "" {
+				return errors.Errorf("a branch must be given, e.g. %s@master", branch.Repo.Name)
+			}
+			var protection *pfsclient.BranchProtection
+			if !removeProtection {
+				if !updateProtection.Flags().Changed("no-delete") &&
+					!updateProtection.Flags().Changed("fast-forward-only") &&
+					!updateProtection.Flags().Changed("allowed-committer") &&
+					!updateProtection.Flags().Changed("pipeline-only") {
+					return errors.Errorf("at least one of --no-delete, --fast-forward-only, --allowed-committer, --pipeline-only or --remove must be set")
+				}
+				protection = &pfsclient.BranchProtection{
+					NoDelete:          noDelete,
+					FastForwardOnly:   fastForwardOnly,
+					AllowedCommitters: allowedCommitters,
+					PipelineOnly:      pipelineOnly,
+				}
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			return c.SetBranchProtection(branch.Repo.Name, branch.Name, protection)
+		}),
+	}
+	updateProtection.Flags().BoolVar(&noDelete, "no-delete", false, "Prevent the branch from being deleted.")
+	updateProtection.Flags().BoolVar(&fastForwardOnly, "fast-forward-only", false, "Only allow the branch's HEAD to move to descendants of its current HEAD.")
+	updateProtection.Flags().Var(&allowedCommitters, "allowed-committer", "A principal allowed to commit to the branch, e.g. github:alice or pipeline:edges. May be repeated.")
+	updateProtection.Flags().BoolVar(&pipelineOnly, "pipeline-only", false, "Only allow pipelines to commit to the branch.")
+	updateProtection.Flags().BoolVar(&removeProtection, "remove", false, "Remove the branch's protection rules.")
+	shell.RegisterCompletionFunc(updateProtection, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(updateProtection, "update protection"))
+
+	updateMetadata := &cobra.Command{
+		Use:   "{{alias}} <repo>[@<branch-or-commit>] <key>=<value> ...",
+		Short: "Update the metadata of a repo, branch or commit.",
+		Long:  "Update the metadata of a repo, branch or commit. The given keys are added to (or overwrite keys in) the existing metadata, and keys with an empty value are removed. If <branch-or-commit> names a branch, the branch's metadata is updated rather than its HEAD commit's.",
+		Example: `
+# Set the owner of repo "images"
+$ {{alias}} images owner=data-team
+
+# Label a commit in repo "images", and remove its "draft" key
+$ {{alias}} images@XXX label=golden draft=`,
+		Run: cmdutil.RunMinimumArgs(2, func(args []string) error {
+			commit, err := cmdutil.ParseCommit(args[0])
+			if err != nil {
+				return err
+			}
+			md, err := parseMetadata(args[1:])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			if commit.ID == "" {
+				return c.SetRepoMetadata(commit.Repo.Name, md)
+			}
+			if _, err := c.InspectBranch(commit.Repo.Name, commit.ID); err == nil {
+				return c.SetBranchMetadata(commit.Repo.Name, commit.ID, md)
+			}
+			return c.SetCommitMetadata(commit.Repo.Name, commit.ID, md)
+		}),
+	}
+	shell.RegisterCompletionFunc(updateMetadata, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(updateMetadata, "update metadata"))
+
+	var dryRun bool
+	pruneCommits := &cobra.Command{
+		Use:   "{{alias}} [<repo>]",
+		Short: "Delete the commits that no retention policy keeps.",
+		Long:  "Delete the commits in a repo (or in all repos, if no repo is given) that fall outside the retention policy of every branch containing them, and print them.",
+		Example: `
+# Print the commits in repo "logs" that would be pruned
+$ {{alias}} logs --dry-run`,
+		Run: cmdutil.RunBoundedArgs(0, 1, func(args []string) error {
+			var repo string
+			if len(args) > 0 {
+				repo = args[0]
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			commits, err := c.PruneCommits(repo, dryRun)
+			if err != nil {
+				return err
+			}
+			for _, commit := range commits {
+				fmt.Println(pretty.CompactPrintCommit(commit))
+			}
+			return nil
+		}),
+	}
+	pruneCommits.Flags().BoolVar(&dryRun, "dry-run", false, "Only print the commits that would be deleted.")
+	shell.RegisterCompletionFunc(pruneCommits, shell.RepoCompletion)
+	commands = append(commands, cmdutil.CreateAlias(pruneCommits, "prune commits"))
+
 	fileDocs := &cobra.Command{
 		Short: "Docs for files.",
 		Long: `Files are the lowest level data objects in Pachyderm.
@@ -750,6 +1096,7 @@ from commits with 'get file'.`,
 	var headerRecords uint
 	var putFileCommit bool
 	var overwrite bool
+	var syncSource bool
 	var compress bool
 	putFile := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>[:<path/to/file>]",
@@ -786,6 +1133,10 @@ $ {{alias}} repo@branch -f http://host/path
 # Put the data from an S3 bucket as repo/branch/s3_object:
 $ {{alias}} repo@branch -r -f s3://my_bucket
 
+# Mirror an S3 prefix into repo/branch/path, committing only the objects
+# that were added, changed or deleted since the last sync:
+$ {{alias}} repo@branch:/path --sync -f s3://my_bucket/prefix
+
 # Put several files or URLs that are listed in file.
 # Files and URLs should be newline delimited.
 $ {{alias}} repo@branch -i file
@@ -809,6 +1160,23 @@ $ {{alias}} repo@branch -i http://host/path`,
 			}
 			defer c.Close()
 
+			if syncSource {
+				if inputFile != "" || len(filePaths) != 1 || split != "" || overwrite {
+					return errors.New("--sync requires a single object store URL, and can't be used with --input-file, --split or --overwrite")
+				}
+				response, err := c.SyncFile(file.Commit.Repo.Name, file.Commit.ID, file.Path, filePaths[0])
+				if err != nil {
+					return err
+				}
+				if response.Commit == nil {
+					fmt.Printf("Already in sync (%d unchanged objects)\n", response.Unchanged)
+					return nil
+				}
+				fmt.Printf("Synced %d added, %d changed and %d deleted objects (%d unchanged) in commit %s\n",
+					response.Added, response.Changed, response.Deleted, response.Unchanged, response.Commit.ID)
+				return nil
+			}
+
 			// load data into pachyderm
 			pfc, err := c.NewPutFileClient()
 			if err != nil {
@@ -900,12 +1268,13 @@ $ {{alias}} repo@branch -i http://host/path`,
 	putFile.Flags().BoolVarP(&recursive, "recursive", "r", false, "Recursively put the files in a directory.")
 	putFile.Flags().BoolVarP(&compress, "compress", "", false, "Compress data during upload. This parameter might help you upload your uncompressed data, such as CSV files, to Pachyderm faster. Use 'compress' with caution, because if your data is already compressed, this parameter might slow down the upload speed instead of increasing.")
 	putFile.Flags().IntVarP(&parallelism, "parallelism", "p", DefaultParallelism, "The maximum number of files that can be uploaded in parallel.")
-	putFile.Flags().StringVar(&split, "split", "", "Split the input file into smaller files, subject to the constraints of --target-file-datums and --target-file-bytes. Permissible values are `line`, `json`, `sql` and `csv`.")
+	putFile.Flags().StringVar(&split, "split", "", "Split the input file into smaller files, subject to the constraints of --target-file-datums and --target-file-bytes. Permissible values are `line`, `json`, `sql`, `csv`, `avro` and `parquet`.")
 	putFile.Flags().UintVar(&targetFileDatums, "target-file-datums", 0, "The upper bound of the number of datums that each file contains, the last file will contain fewer if the datums don't divide evenly; needs to be used with --split.")
 	putFile.Flags().UintVar(&targetFileBytes, "target-file-bytes", 0, "The target upper bound of the number of bytes that each file contains; needs to be used with --split.")
 	putFile.Flags().UintVar(&headerRecords, "header-records", 0, "the number of records that will be converted to a PFS 'header', and prepended to future retrievals of any subset of data from PFS; needs to be used with --split=(json|line|csv)")
 	putFile.Flags().BoolVarP(&putFileCommit, "commit", "c", false, "DEPRECATED: Put file(s) in a new commit.")
 	putFile.Flags().BoolVarP(&overwrite, "overwrite", "o", false, "Overwrite the existing content of the file, either from previous commits or previous calls to 'put file' within this commit.")
+	putFile.Flags().BoolVar(&syncSource, "sync", false, "Mirror the object store prefix given by -f into the path, in a new commit containing only the objects that were added, changed or deleted since the last sync. The commit must be a branch.")
 	shell.RegisterCompletionFunc(putFile,
 		func(flag, text string, maxCompletions int64) ([]prompt.Suggest, shell.CacheFunc) {
 			if flag == "-f" || flag == "--file" || flag == "-i" || flag == "input-file" {
@@ -1316,26 +1685,55 @@ Tags are a low-level resource and should not be accessed directly by most users.
 	commands = append(commands, cmdutil.CreateAlias(getTag, "get tag"))
 
 	var fix bool
+	var deep bool
+	var verifyContent bool
+	var fsckParallelism int64
+	var resumeFrom string
 	fsck := &cobra.Command{
 		Use:   "{{alias}}",
 		Short: "Run a file system consistency check on pfs.",
-		Long:  "Run a file system consistency check on the pachyderm file system, ensuring the correct provenance relationships are satisfied.",
+		Long: `Run a file system consistency check on the pachyderm file system, ensuring the correct provenance relationships are satisfied.
+
+With --deep, fsck also checks that the data referenced by every finished commit exists in object storage (and, with --verify-content, that it is intact). A deep fsck periodically prints checkpoints; if it is interrupted, it can be continued by passing the last checkpoint to --resume-from.`,
+		Example: `
+# check provenance relationships
+$ {{alias}}
+
+# also check that every commit's data exists in object storage
+$ {{alias}} --deep
+
+# also re-hash every object, checking 20 commits at a time
+$ {{alias}} --deep --verify-content --parallelism 20
+
+# continue an interrupted deep fsck
+$ {{alias}} --deep --resume-from images/1042`,
 		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			if !deep && (verifyContent || fsckParallelism != 0 || resumeFrom != "") {
+				return errors.New("--verify-content, --parallelism and --resume-from require --deep")
+			}
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
 				return err
 			}
 			defer c.Close()
 			errors := false
-			if err = c.Fsck(fix, func(resp *pfsclient.FsckResponse) error {
+			cb := func(resp *pfsclient.FsckResponse) error {
 				if resp.Error != "" {
 					errors = true
 					fmt.Printf("Error: %s\n", resp.Error)
+				} else if resp.Checkpoint != "" {
+					fmt.Printf("Checkpoint: %s\n", resp.Checkpoint)
 				} else {
 					fmt.Printf("Fix applied: %v", resp.Fix)
 				}
 				return nil
-			}); err != nil {
+			}
+			if deep {
+				err = c.DeepFsck(fix, verifyContent, fsckParallelism, resumeFrom, cb)
+			} else {
+				err = c.Fsck(fix, cb)
+			}
+			if err != nil {
 				return err
 			}
 			if !errors {
@@ -1345,6 +1743,10 @@ Tags are a low-level resource and should not be accessed directly by most users.
 		}),
 	}
 	fsck.Flags().BoolVarP(&fix, "fix", "f", false, "Attempt to fix as many issues as possible.")
+	fsck.Flags().BoolVar(&deep, "deep", false, "Also check that the data referenced by every finished commit exists in object storage.")
+	fsck.Flags().BoolVar(&verifyContent, "verify-content", false, "During a deep fsck, re-hash the content of every object to check that it is intact (much slower).")
+	fsck.Flags().Int64Var(&fsckParallelism, "parallelism", 0, "The number of commits that a deep fsck checks concurrently (0 uses the server's default).")
+	fsck.Flags().StringVar(&resumeFrom, "resume-from", "", "Continue an interrupted deep fsck from this checkpoint.")
 	commands = append(commands, cmdutil.CreateAlias(fsck, "fsck"))
 
 	// Add the mount commands (which aren't available on Windows, so they're in
@@ -1398,9 +1800,13 @@ func putFileHelper(c *client.APIClient, pfc client.PutFileClient,
 			delimiter = pfsclient.Delimiter_SQL
 		case "csv":
 			delimiter = pfsclient.Delimiter_CSV
+		case "avro":
+			delimiter = pfsclient.Delimiter_AVRO
+		case "parquet":
+			delimiter = pfsclient.Delimiter_PARQUET
 		default:
 			return errors.Errorf("unrecognized delimiter '%s'; only accepts one of "+
-				"{json,line,sql,csv}", split)
+				"{json,line,sql,csv,avro,parquet}", split)
 		}
 		_, err := pfc.PutFileSplit(repo, commit, path, delimiter, int64(targetFileDatums), int64(targetFileBytes), int64(headerRecords), overwrite, reader)
 		return err
@@ -1461,6 +1867,43 @@ func putFileHelper(c *client.APIClient, pfc client.PutFileClient,
 	return putFile(f)
 }
 
+func parseMergeStrategy(s string) (pfsclient.MergeStrategy, error) {
+	strategy, ok := pfsclient.MergeStrategy_value[strings.ToUpper(s)]
+	if !ok {
+		return 0, errors.Errorf("unrecognized merge strategy %q; only accepts one of {fail,ours,theirs}", s)
+	}
+	return pfsclient.MergeStrategy(strategy), nil
+}
+
+// parseRetentionDuration parses a Go duration, or a number of days such as
+// "7d" (which time.ParseDuration doesn't accept)
+func parseRetentionDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseMetadata parses a list of key=value pairs into a metadata map
+func parseMetadata(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	metadata := make(map[string]string)
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid metadata %q; expected key=value", pair)
+		}
+		metadata[parts[0]] = parts[1]
+	}
+	return metadata, nil
+}
+
 func joinPaths(prefix, filePath string) string {
 	if url, err := url.Parse(filePath); err == nil && url.Scheme != "" {
 		if url.Scheme == "pfs" {
@@ -93,12 +93,22 @@ func TestMountParsing(t *testing.T) {
 		"repo3": {
 			Branch: "master",
 		},
+		"repo4": {
+			Commit: "master^3",
+		},
+		"repo5": {
+			Commit: "0123456789ab4def0123456789abcdef",
+		},
 	}
-	opts, err := parseRepoOpts([]string{"repo1@branch+w", "repo2+w", "repo3"})
+	opts, err := parseRepoOpts([]string{"repo1@branch+w", "repo2+w", "repo3", "repo4@master^3", "repo5@0123456789ab4def0123456789abcdef"})
 	require.NoError(t, err)
-	require.Equal(t, 3, len(opts))
+	require.Equal(t, 5, len(opts))
 	fmt.Printf("%+v\n", opts)
 	for repo, ro := range expected {
 		require.Equal(t, ro, opts[repo])
 	}
+	_, err = parseRepoOpts([]string{"repo@master^3+w"})
+	require.YesError(t, err)
+	_, err = parseRepoOpts([]string{"@master+w"})
+	require.YesError(t, err)
 }
@@ -13,8 +13,11 @@ import (
 	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	"github.com/pachyderm/pachyderm/src/server/pfs/fuse"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ancestry"
 	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/uuid"
 
+	units "github.com/docker/go-units"
 	"github.com/hanwen/go-fuse/v2/fs"
 	gofuse "github.com/hanwen/go-fuse/v2/fuse"
 	"github.com/spf13/cobra"
@@ -24,28 +27,35 @@ const (
 	name = "pfs"
 )
 
+// parseRepoOpts parses arguments of the form "repo[@branch-or-commit][+w]".
+// Commits may use ancestry syntax (e.g. "repo@master^3"), like in the other
+// pfs commands.
 func parseRepoOpts(args []string) (map[string]*fuse.RepoOptions, error) {
 	result := make(map[string]*fuse.RepoOptions)
 	for _, arg := range args {
-		var repo string
 		var flag string
+		repoAndRef := arg
+		if i := strings.LastIndex(arg, "+"); i != -1 {
+			repoAndRef, flag = arg[:i], arg[i+1:]
+		}
 		opts := &fuse.RepoOptions{}
-		repoAndRest := strings.Split(arg, "@")
-		if len(repoAndRest) == 1 {
+		commit, err := cmdutil.ParseCommit(repoAndRef)
+		if err != nil {
+			return nil, err
+		}
+		repo := commit.Repo.Name
+		ref, ancestors, err := ancestry.Parse(commit.ID)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case ref == "":
 			// No branch specified
 			opts.Branch = "master"
-			repoAndFlag := strings.Split(repoAndRest[0], "+")
-			repo = repoAndFlag[0]
-			if len(repoAndFlag) > 1 {
-				flag = repoAndFlag[1]
-			}
-		} else {
-			repo = repoAndRest[0]
-			branchAndFlag := strings.Split(repoAndRest[1], "+")
-			opts.Branch = branchAndFlag[0]
-			if len(branchAndFlag) > 1 {
-				flag = branchAndFlag[1]
-			}
+		case ancestors != 0 || uuid.IsUUIDWithoutDashes(ref):
+			opts.Commit = commit.ID
+		default:
+			opts.Branch = ref
 		}
 		if flag != "" {
 			for _, c := range flag {
@@ -54,12 +64,12 @@ func parseRepoOpts(args []string) (map[string]*fuse.RepoOptions, error) {
 				}
 			}
 			if strings.Contains("w", flag) {
+				if opts.Commit != "" {
+					return nil, errors.Errorf("invalid format %q: commits can't be mounted for writing", arg)
+				}
 				opts.Write = true
 			}
 		}
-		if repo == "" {
-			return nil, errors.Errorf("invalid format %q: repo cannot be empty", arg)
-		}
 		result[repo] = opts
 	}
 	return result, nil
@@ -70,6 +80,9 @@ func mountCmds() []*cobra.Command {
 
 	var write bool
 	var debug bool
+	var download bool
+	var history bool
+	var cacheSize string
 	var repoOpts cmdutil.RepeatedStringArg
 	mount := &cobra.Command{
 		Use:   "{{alias}} <path/to/mount/point>",
@@ -86,8 +99,15 @@ func mountCmds() []*cobra.Command {
 			if err != nil {
 				return err
 			}
+			cacheSizeBytes, err := units.RAMInBytes(cacheSize)
+			if err != nil {
+				return errors.Wrapf(err, "invalid cache size %q", cacheSize)
+			}
 			opts := &fuse.Options{
-				Write: write,
+				Write:     write,
+				Download:  download,
+				History:   history,
+				CacheSize: cacheSizeBytes,
 				Fuse: &fs.Options{
 					MountOptions: gofuse.MountOptions{
 						Debug:  debug,
@@ -104,7 +124,10 @@ func mountCmds() []*cobra.Command {
 	}
 	mount.Flags().BoolVarP(&write, "write", "w", false, "Allow writing to pfs through the mount.")
 	mount.Flags().BoolVarP(&debug, "debug", "d", false, "Turn on debug messages.")
-	mount.Flags().VarP(&repoOpts, "repos", "r", "Repos and branches / commits to mount, arguments should be of the form \"repo@branch+w\", where the trailing flag \"+w\" indicates write.")
+	mount.Flags().BoolVar(&download, "download", false, "Download files in full when they're opened, rather than fetching only the parts that are read.")
+	mount.Flags().BoolVar(&history, "history", false, "Expose the commits in the history of each mounted repo as read only directories under <repo>/.history/<commit-id>.")
+	mount.Flags().StringVar(&cacheSize, "cache-size", "256M", "The amount of memory used to cache file content fetched from pfs.")
+	mount.Flags().VarP(&repoOpts, "repos", "r", "Repos and branches / commits to mount, arguments should be of the form \"repo@branch+w\", where the trailing flag \"+w\" indicates write. Commits may use ancestry syntax, e.g. \"repo@master^3\", and can't be written to.")
 	mount.MarkFlagCustom("repos", "__pachctl_get_repo_branch")
 	commands = append(commands, cmdutil.CreateAlias(mount, "mount"))
 
@@ -3,6 +3,7 @@ package fuse
 import (
 	"bytes"
 	"crypto/sha256"
+	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -126,6 +127,61 @@ func TestSeek(t *testing.T) {
 	})
 }
 
+func TestStreamingRead(t *testing.T) {
+	c := server.GetPachClient(t, server.GetBasicConfig())
+	require.NoError(t, c.CreateRepo("repo"))
+	data := workload.RandString(rand.New(rand.NewSource(123)), 10*MB+17)
+	_, err := c.PutFile("repo", "master", "file", strings.NewReader(data))
+	require.NoError(t, err)
+	// A cache smaller than the file forces blocks to be evicted and refetched.
+	opts := &Options{BlockSize: MB, CacheSize: 2 * MB}
+	withMount(t, c, opts, func(mountPoint string) {
+		f, err := os.Open(filepath.Join(mountPoint, "repo", "file"))
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, f.Close())
+		}()
+
+		testReadAt := func(offset int64, size int) {
+			buf := make([]byte, size)
+			n, err := f.ReadAt(buf, offset)
+			if offset+int64(size) > int64(len(data)) {
+				require.Equal(t, len(data)-int(offset), n)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, size, n)
+			}
+			require.Equal(t, data[offset:offset+int64(n)], string(buf[:n]))
+		}
+
+		testReadAt(0, 10)
+		testReadAt(MB-5, 10)
+		testReadAt(5*MB+3, 2*MB)
+		testReadAt(10*MB, MB)
+		testReadAt(0, MB)
+
+		// The file isn't downloaded to the loopback directory
+		_, err = f.Seek(0, 0)
+		require.NoError(t, err)
+		d, err := ioutil.ReadAll(f)
+		require.NoError(t, err)
+		require.Equal(t, sha256.Sum256([]byte(data)), sha256.Sum256(d))
+	})
+}
+
+func TestDownload(t *testing.T) {
+	c := server.GetPachClient(t, server.GetBasicConfig())
+	require.NoError(t, c.CreateRepo("repo"))
+	data := strings.Repeat("foo", MB)
+	_, err := c.PutFile("repo", "master", "file", strings.NewReader(data))
+	require.NoError(t, err)
+	withMount(t, c, &Options{Download: true}, func(mountPoint string) {
+		d, err := ioutil.ReadFile(filepath.Join(mountPoint, "repo", "file"))
+		require.NoError(t, err)
+		require.Equal(t, data, string(d))
+	})
+}
+
 func TestHeadlessBranch(t *testing.T) {
 	c := server.GetPachClient(t, server.GetBasicConfig())
 	require.NoError(t, c.CreateRepo("repo"))
@@ -332,6 +388,57 @@ func TestRepoOpts(t *testing.T) {
 	require.Equal(t, "fizz\n", b.String())
 }
 
+func TestMountCommit(t *testing.T) {
+	c := server.GetPachClient(t, server.GetBasicConfig())
+	require.NoError(t, c.CreateRepo("repo"))
+	for i := 0; i < 4; i++ {
+		_, err := c.PutFileOverwrite("repo", "master", "file", strings.NewReader(fmt.Sprintf("%d\n", i)), 0)
+		require.NoError(t, err)
+	}
+	withMount(t, c, &Options{
+		RepoOptions: map[string]*RepoOptions{
+			"repo": {Commit: "master^2"},
+		},
+	}, func(mountPoint string) {
+		data, err := ioutil.ReadFile(filepath.Join(mountPoint, "repo", "file"))
+		require.NoError(t, err)
+		require.Equal(t, "1\n", string(data))
+		require.YesError(t, ioutil.WriteFile(filepath.Join(mountPoint, "repo", "file"), []byte("foo\n"), 0644))
+	})
+	require.YesError(t, Mount(c, "", &Options{
+		RepoOptions: map[string]*RepoOptions{
+			"repo": {Commit: "master^2", Write: true},
+		},
+	}))
+}
+
+func TestHistory(t *testing.T) {
+	c := server.GetPachClient(t, server.GetBasicConfig())
+	require.NoError(t, c.CreateRepo("repo"))
+	for i := 0; i < 3; i++ {
+		_, err := c.PutFileOverwrite("repo", "master", "file", strings.NewReader(fmt.Sprintf("%d\n", i)), 0)
+		require.NoError(t, err)
+	}
+	cis, err := c.ListCommit("repo", "master", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(cis))
+	withMount(t, c, &Options{History: true}, func(mountPoint string) {
+		commits, err := ioutil.ReadDir(filepath.Join(mountPoint, "repo", ".history"))
+		require.NoError(t, err)
+		require.Equal(t, 3, len(commits))
+		// ListCommit returns the newest commit first
+		for i, ci := range cis {
+			data, err := ioutil.ReadFile(filepath.Join(mountPoint, "repo", ".history", ci.Commit.ID, "file"))
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("%d\n", len(cis)-1-i), string(data))
+		}
+		data, err := ioutil.ReadFile(filepath.Join(mountPoint, "repo", "file"))
+		require.NoError(t, err)
+		require.Equal(t, "2\n", string(data))
+		require.YesError(t, ioutil.WriteFile(filepath.Join(mountPoint, "repo", ".history", cis[0].Commit.ID, "file"), []byte("foo\n"), 0644))
+	})
+}
+
 func TestOpenCommit(t *testing.T) {
 	c := server.GetPachClient(t, server.GetBasicConfig())
 	require.NoError(t, c.CreateRepo("in"))
@@ -32,6 +32,10 @@ const (
 	dirty                  // we have full content for this file and the user has written to it
 )
 
+// historyDir is the name of the directory, in each repo, which contains the
+// commits in the ancestry of the mounted commit (if Options.History is set).
+const historyDir = ".history"
+
 type loopbackRoot struct {
 	loopbackNode
 
@@ -44,6 +48,13 @@ type loopbackRoot struct {
 
 	c *client.APIClient
 
+	// download is true if files are downloaded in full when they're opened,
+	// otherwise reads are served from blocks.
+	download bool
+	blocks   *blockCache
+
+	history bool
+
 	repoOpts map[string]*RepoOptions
 	branches map[string]string
 	commits  map[string]string
@@ -377,6 +388,12 @@ func (n *loopbackNode) Open(ctx context.Context, flags uint32) (fh fs.FileHandle
 		}
 		state = dirty
 	}
+	if state == full && !isCreate(flags) && !n.root().download && n.getFileState(p) < full {
+		fh, errno := n.openStream(p, flags)
+		if fh != nil || errno != 0 {
+			return fh, 0, errno
+		}
+	}
 	if err := n.download(p, state); err != nil {
 		return nil, 0, fs.ToErrno(err)
 	}
@@ -395,6 +412,43 @@ func (n *loopbackNode) Open(ctx context.Context, flags uint32) (fh fs.FileHandle
 	return lf, 0, 0
 }
 
+// openStream opens a file for reading without downloading it, its content is
+// fetched as it's read. It returns a nil handle if the file doesn't exist in
+// pfs, in which case the caller should fall back to the local file.
+func (n *loopbackNode) openStream(p string, flags uint32) (fs.FileHandle, syscall.Errno) {
+	if err := n.download(p, meta); err != nil {
+		return nil, fs.ToErrno(err)
+	}
+	repo, commit, file, err := n.pfsFile(p)
+	if err != nil {
+		return nil, fs.ToErrno(err)
+	}
+	if commit == "" || file == "" {
+		return nil, 0
+	}
+	fd, err := syscall.Open(p, int(flags), 0)
+	if err != nil {
+		return nil, fs.ToErrno(err)
+	}
+	st := syscall.Stat_t{}
+	if err := syscall.Fstat(fd, &st); err != nil {
+		syscall.Close(fd)
+		return nil, fs.ToErrno(err)
+	}
+	if st.Mode&syscall.S_IFMT != syscall.S_IFREG {
+		syscall.Close(fd)
+		return nil, 0
+	}
+	return &streamFile{
+		loopbackFile: &loopbackFile{fd: fd},
+		cache:        n.root().blocks,
+		repo:         repo,
+		commit:       commit,
+		path:         file,
+		size:         st.Size,
+	}, 0
+}
+
 func (n *loopbackNode) Opendir(ctx context.Context) syscall.Errno {
 	if err := n.download(n.path(), meta); err != nil {
 		return fs.ToErrno(err)
@@ -516,12 +570,20 @@ func newLoopbackRoot(root, target string, c *client.APIClient, opts *Options) (*
 		return nil, errors.WithStack(err)
 	}
 
+	blocks, err := newBlockCache(c, opts.getBlockSize(), opts.getCacheSize())
+	if err != nil {
+		return nil, err
+	}
+
 	n := &loopbackRoot{
 		rootPath:   root,
 		rootDev:    uint64(st.Dev),
 		targetPath: target,
 		write:      opts.getWrite(),
 		c:          c,
+		download:   opts.getDownload(),
+		blocks:     blocks,
+		history:    opts.getHistory(),
 		repoOpts:   opts.getRepoOpts(),
 		branches:   opts.getBranches(),
 		commits:    make(map[string]string),
@@ -552,6 +614,11 @@ func (n *loopbackNode) downloadRepos() (retErr error) {
 		if err := os.MkdirAll(p, 0777); err != nil {
 			return errors.WithStack(err)
 		}
+		if n.root().history {
+			if err := os.MkdirAll(filepath.Join(p, historyDir), 0777); err != nil {
+				return errors.WithStack(err)
+			}
+		}
 	}
 	return nil
 }
@@ -579,6 +646,9 @@ func (n *loopbackNode) download(path string, state fileState) (retErr error) {
 	if len(parts) < 1 || parts[0] == "" {
 		return nil //already downloaded in downloadRepos
 	}
+	if n.root().history && len(parts) > 1 && parts[1] == historyDir {
+		return n.downloadHistory(parts[0], parts[2:], state)
+	}
 	commit, err := n.commit(parts[0])
 	if err != nil {
 		return err
@@ -586,12 +656,40 @@ func (n *loopbackNode) download(path string, state fileState) (retErr error) {
 	if commit == "" {
 		return nil
 	}
-	if err := n.c().ListFileF(parts[0], commit, pathpkg.Join(parts[1:]...), 0,
+	return n.downloadFiles(filepath.Join(n.root().rootPath, parts[0]), parts[0], commit, pathpkg.Join(parts[1:]...), state)
+}
+
+// downloadHistory downloads the history directory of a repo, parts are the
+// path components after the history directory. The history directory
+// contains a directory for each commit in the ancestry of the mounted commit,
+// which contains the files in that commit.
+func (n *loopbackNode) downloadHistory(repo string, parts []string, state fileState) error {
+	historyPath := filepath.Join(n.root().rootPath, repo, historyDir)
+	if len(parts) == 0 {
+		commit, err := n.commit(repo)
+		if err != nil {
+			return err
+		}
+		if commit == "" {
+			return nil
+		}
+		return n.c().ListCommitF(repo, commit, "", 0, false, func(ci *pfs.CommitInfo) error {
+			return errors.EnsureStack(os.MkdirAll(filepath.Join(historyPath, ci.Commit.ID), 0777))
+		})
+	}
+	return n.downloadFiles(filepath.Join(historyPath, parts[0]), repo, parts[0], pathpkg.Join(parts[1:]...), state)
+}
+
+// downloadFiles downloads the files under path in a commit into dir, which is
+// the directory in the loopback filesystem that corresponds to the root of
+// the commit.
+func (n *loopbackNode) downloadFiles(dir, repo, commit, path string, state fileState) error {
+	if err := n.c().ListFileF(repo, commit, path, 0,
 		func(fi *pfs.FileInfo) (retErr error) {
+			p := filepath.Join(dir, fi.File.Path)
 			if fi.FileType == pfs.FileType_DIR {
-				return os.MkdirAll(n.filePath(fi), 0777)
+				return os.MkdirAll(p, 0777)
 			}
-			p := n.filePath(fi)
 			// Make sure the directory exists
 			// I think this may be unnecessary based on the constraints the
 			// OS imposes, but don't want to rely on that, especially
@@ -649,6 +747,18 @@ func (n *loopbackNode) commit(repo string) (string, error) {
 	}(); ok {
 		return commit, nil
 	}
+	if ro, ok := n.root().repoOpts[repo]; ok && ro.Commit != "" {
+		// Resolve the commit once, so that ancestry references keep
+		// pointing at the same commit for the lifetime of the mount.
+		ci, err := n.root().c.InspectCommit(repo, ro.Commit)
+		if err != nil {
+			return "", err
+		}
+		n.root().mu.Lock()
+		defer n.root().mu.Unlock()
+		n.root().commits[repo] = ci.Commit.ID
+		return ci.Commit.ID, nil
+	}
 	branch := n.root().branch(repo)
 	bi, err := n.root().c.InspectBranch(repo, branch)
 	if err != nil && !errutil.IsNotFoundError(err) {
@@ -667,12 +777,22 @@ func (n *loopbackNode) commit(repo string) (string, error) {
 	return bi.Head.ID, nil
 }
 
-func (n *loopbackNode) repoPath(ri *pfs.RepoInfo) string {
-	return filepath.Join(n.root().rootPath, ri.Repo.Name)
+// pfsFile returns the repo, commit and path in pfs of a path in the loopback
+// filesystem. The commit is "" if there's no commit to read the path from.
+func (n *loopbackNode) pfsFile(path string) (string, string, string, error) {
+	parts := strings.Split(n.trimPath(path), "/")
+	if n.root().history && len(parts) > 2 && parts[1] == historyDir {
+		return parts[0], parts[2], pathpkg.Join(parts[3:]...), nil
+	}
+	commit, err := n.commit(parts[0])
+	if err != nil {
+		return "", "", "", err
+	}
+	return parts[0], commit, pathpkg.Join(parts[1:]...), nil
 }
 
-func (n *loopbackNode) filePath(fi *pfs.FileInfo) string {
-	return filepath.Join(n.root().rootPath, fi.File.Commit.Repo.Name, fi.File.Path)
+func (n *loopbackNode) repoPath(ri *pfs.RepoInfo) string {
+	return filepath.Join(n.root().rootPath, ri.Repo.Name)
 }
 
 func (n *loopbackNode) getFileState(path string) fileState {
@@ -688,7 +808,11 @@ func (n *loopbackNode) setFileState(path string, state fileState) {
 }
 
 func (n *loopbackNode) checkWrite(path string) syscall.Errno {
-	repo := strings.Split(n.trimPath(path), "/")[0]
+	parts := strings.Split(n.trimPath(path), "/")
+	repo := parts[0]
+	if n.root().history && len(parts) > 1 && parts[1] == historyDir {
+		return syscall.EROFS
+	}
 	ros := n.root().repoOpts
 	if len(ros) > 0 {
 		ro, ok := ros[repo]
@@ -25,12 +25,41 @@ type Options struct {
 	// Unmount is a channel that will be closed when the filesystem has been
 	// unmounted. It can be nil in which case it's ignored.
 	Unmount chan struct{}
+
+	// Download indicates that files should be downloaded in full to local
+	// disk when they're opened for reading. By default only the byte ranges
+	// that are read are fetched.
+	Download bool
+
+	// BlockSize is the size of the byte ranges fetched from pfs when reading
+	// a file, it defaults to DefaultBlockSize.
+	BlockSize int64
+
+	// CacheSize is the maximum number of bytes of fetched file content that
+	// are kept in memory, it defaults to DefaultCacheSize.
+	CacheSize int64
+
+	// History indicates that each repo should contain a read only
+	// `.history` directory, with a subdirectory for each commit in the
+	// ancestry of the mounted commit, named by its ID.
+	History bool
 }
 
+const (
+	// DefaultBlockSize is the default value of Options.BlockSize.
+	DefaultBlockSize = 4 * 1024 * 1024
+	// DefaultCacheSize is the default value of Options.CacheSize.
+	DefaultCacheSize = 256 * 1024 * 1024
+)
+
 // RepoOptions are the options associated with a mounted repo.
 type RepoOptions struct {
 	// Branch is the branch of the repo to mount
 	Branch string
+	// Commit is the commit of the repo to mount, it may be a commit ID or a
+	// branch or commit with ancestry syntax (e.g. master^3). If set, Branch
+	// is ignored and the repo can't be written to.
+	Commit string
 	// Write indicates that the repo should be mounted for writing.
 	Write bool
 }
@@ -71,6 +100,34 @@ func (o *Options) getWrite() bool {
 	return o.Write
 }
 
+func (o *Options) getDownload() bool {
+	if o == nil {
+		return false
+	}
+	return o.Download
+}
+
+func (o *Options) getBlockSize() int64 {
+	if o == nil || o.BlockSize == 0 {
+		return DefaultBlockSize
+	}
+	return o.BlockSize
+}
+
+func (o *Options) getCacheSize() int64 {
+	if o == nil || o.CacheSize == 0 {
+		return DefaultCacheSize
+	}
+	return o.CacheSize
+}
+
+func (o *Options) getHistory() bool {
+	if o == nil {
+		return false
+	}
+	return o.History
+}
+
 func (o *Options) getUnmount() chan struct{} {
 	if o == nil {
 		return nil
@@ -82,7 +139,16 @@ func (o *Options) validate(c *client.APIClient) error {
 	if o == nil {
 		return nil
 	}
+	if o.BlockSize < 0 {
+		return errors.Errorf("block size must be positive")
+	}
+	if o.CacheSize < 0 {
+		return errors.Errorf("cache size must be positive")
+	}
 	for repo, opts := range o.RepoOptions {
+		if opts.Write && opts.Commit != "" {
+			return errors.Errorf("can't mount commit %s@%s in Write mode (mount a branch instead)", repo, opts.Commit)
+		}
 		if opts.Write {
 			if uuid.IsUUIDWithoutDashes(opts.Branch) {
 				return errors.Errorf("can't mount commit %s@%s in Write mode (mount a branch instead)", repo, opts.Branch)
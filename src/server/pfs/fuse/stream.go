@@ -0,0 +1,120 @@
+package fuse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/pachyderm/pachyderm/src/client"
+)
+
+// blockCache fetches fixed size blocks of files from pfs and keeps the most
+// recently used ones in memory.
+type blockCache struct {
+	c         *client.APIClient
+	blockSize int64
+	cache     *lru.Cache
+	fetches   singleflight.Group
+}
+
+type blockKey struct {
+	repo, commit, path string
+	index              int64
+}
+
+func newBlockCache(c *client.APIClient, blockSize, cacheSize int64) (*blockCache, error) {
+	numBlocks := int(cacheSize / blockSize)
+	if numBlocks < 1 {
+		numBlocks = 1
+	}
+	cache, err := lru.New(numBlocks)
+	if err != nil {
+		return nil, err
+	}
+	return &blockCache{
+		c:         c,
+		blockSize: blockSize,
+		cache:     cache,
+	}, nil
+}
+
+// block returns the block of a file with the given index. Concurrent misses
+// for the same block only fetch it once.
+func (bc *blockCache) block(key blockKey) ([]byte, error) {
+	if data, ok := bc.cache.Get(key); ok {
+		return data.([]byte), nil
+	}
+	data, err, _ := bc.fetches.Do(fmt.Sprintf("%v", key), func() (interface{}, error) {
+		buf := &bytes.Buffer{}
+		if err := bc.c.GetFile(key.repo, key.commit, key.path, key.index*bc.blockSize, bc.blockSize, buf); err != nil {
+			return nil, err
+		}
+		bc.cache.Add(key, buf.Bytes())
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data.([]byte), nil
+}
+
+// readAt reads the file into buf starting at off, size is the size of the
+// file and reads are truncated to it.
+func (bc *blockCache) readAt(repo, commit, path string, size int64, buf []byte, off int64) (int, error) {
+	end := off + int64(len(buf))
+	if end > size {
+		end = size
+	}
+	var n int
+	for pos := off; pos < end; {
+		index := pos / bc.blockSize
+		data, err := bc.block(blockKey{repo, commit, path, index})
+		if err != nil {
+			return n, err
+		}
+		start := pos - index*bc.blockSize
+		if start >= int64(len(data)) {
+			// The file is shorter than expected
+			break
+		}
+		stop := int64(len(data))
+		if index*bc.blockSize+stop > end {
+			stop = end - index*bc.blockSize
+		}
+		n += copy(buf[n:], data[start:stop])
+		pos = index*bc.blockSize + stop
+	}
+	return n, nil
+}
+
+// streamFile is a read only file handle which reads file content from pfs
+// as it's requested, rather than from a local copy of the file. It's backed
+// by the zero-filled placeholder of the file in the loopback directory,
+// which provides the file's metadata.
+type streamFile struct {
+	*loopbackFile
+	cache              *blockCache
+	repo, commit, path string
+	size               int64
+}
+
+var _ = (fs.FileReader)((*streamFile)(nil))
+var _ = (fs.FileWriter)((*streamFile)(nil))
+
+func (f *streamFile) Read(ctx context.Context, buf []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	n, err := f.cache.readAt(f.repo, f.commit, f.path, f.size, buf, off)
+	if err != nil {
+		return nil, fs.ToErrno(err)
+	}
+	return fuse.ReadResultData(buf[:n]), fs.OK
+}
+
+func (f *streamFile) Write(ctx context.Context, data []byte, off int64) (uint32, syscall.Errno) {
+	return 0, syscall.EBADF
+}
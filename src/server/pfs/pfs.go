@@ -67,6 +67,15 @@ type ErrCommitNotFinished struct {
 	Commit *pfs.Commit
 }
 
+// ErrRepoQuotaExceeded represents an error where a commit would exceed its
+// repo's quota
+type ErrRepoQuotaExceeded struct {
+	Repo     *pfs.Repo
+	Resource string // "bytes" or "files"
+	Usage    uint64
+	Quota    uint64
+}
+
 func (e ErrFileNotFound) Error() string {
 	return fmt.Sprintf("file %v not found in repo %v at commit %v", e.File.Path, e.File.Commit.Repo.Name, e.File.Commit.ID)
 }
@@ -112,6 +121,10 @@ func (e ErrCommitNotFinished) Error() string {
 	return fmt.Sprintf("commit %v not finished", e.Commit.ID)
 }
 
+func (e ErrRepoQuotaExceeded) Error() string {
+	return fmt.Sprintf("repo %v quota exceeded: %d %s is over the limit of %d %s", e.Repo.Name, e.Usage, e.Resource, e.Quota, e.Resource)
+}
+
 // ByteRangeSize returns byteRange.Upper - byteRange.Lower.
 func ByteRangeSize(byteRange *pfs.ByteRange) uint64 {
 	return byteRange.Upper - byteRange.Lower
@@ -128,6 +141,7 @@ var (
 	hasNoHeadRe               = regexp.MustCompile(`the branch .+ has no head \(create one with 'start commit'\)`)
 	outputCommitNotFinishedRe = regexp.MustCompile("output commit .+ not finished")
 	commitNotFinishedRe       = regexp.MustCompile("commit .+ not finished")
+	repoQuotaExceededRe       = regexp.MustCompile("repo [^ ]+ quota exceeded")
 )
 
 // IsCommitNotFoundErr returns true if 'err' has an error message that matches
@@ -219,3 +233,12 @@ func IsCommitNotFinishedErr(err error) bool {
 	}
 	return commitNotFinishedRe.MatchString(err.Error())
 }
+
+// IsRepoQuotaExceededErr returns true if the err is due to a commit that would
+// exceed its repo's quota
+func IsRepoQuotaExceededErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return repoQuotaExceededRe.MatchString(err.Error())
+}
@@ -20,4 +20,9 @@ func TestErrorMatching(t *testing.T) {
 	require.False(t, IsCommitFinishedErr(ErrCommitNotFound{c}))
 	require.False(t, IsCommitFinishedErr(ErrCommitDeleted{c}))
 	require.True(t, IsCommitFinishedErr(ErrCommitFinished{c}))
+
+	quotaErr := ErrRepoQuotaExceeded{Repo: c.Repo, Resource: "bytes", Usage: 2, Quota: 1}
+	require.True(t, IsRepoQuotaExceededErr(quotaErr))
+	require.False(t, IsRepoQuotaExceededErr(ErrCommitFinished{c}))
+	require.False(t, IsCommitFinishedErr(quotaErr))
 }
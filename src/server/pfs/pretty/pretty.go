@@ -5,10 +5,12 @@ import (
 	"html/template"
 	"io"
 	"os"
+	"sort"
 	"strings"
 
 	units "github.com/docker/go-units"
 	"github.com/fatih/color"
+	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pkg/pretty"
 )
@@ -28,6 +30,8 @@ const (
 	FileHeaderWithCommit = "COMMIT\tNAME\tTYPE\tCOMMITTED\tSIZE\t\n"
 	// DiffFileHeader is the header for files produced by diff file.
 	DiffFileHeader = "OP\t" + FileHeader
+	// MergeConflictHeader is the header for conflicts produced by merge branch.
+	MergeConflictHeader = "PATH\tOURS\tTHEIRS\tRESOLUTION\t\n"
 )
 
 // PrintRepoInfo pretty-prints repo info.
@@ -67,7 +71,10 @@ func PrintDetailedRepoInfo(repoInfo *PrintableRepoInfo) error {
 Description: {{.Description}}{{end}}{{if .FullTimestamps}}
 Created: {{.Created}}{{else}}
 Created: {{prettyAgo .Created}}{{end}}
-Size of HEAD on master: {{prettySize .SizeBytes}}{{if .AuthInfo}}
+Size of HEAD on master: {{prettySize .SizeBytes}}{{if .Quota}}{{if .Quota.SizeBytes}} (quota: {{prettySize .Quota.SizeBytes}}){{end}}{{if .Quota.FileCount}}
+Files in HEAD on master: {{.FileCount}} (quota: {{.Quota.FileCount}}){{end}}{{end}}{{if .Retention}}
+Retention: {{printRetention .Retention}}{{end}}{{if .Metadata}}
+Metadata: {{printMetadata .Metadata}}{{end}}{{if .AuthInfo}}
 Access level: {{ .AuthInfo.AccessLevel.String }}{{end}}
 `)
 	if err != nil {
@@ -100,6 +107,56 @@ func printTrigger(trigger *pfs.Trigger) string {
 	return fmt.Sprintf("%s on %s", trigger.Branch, cond)
 }
 
+func printRetention(policy *pfs.RetentionPolicy) string {
+	var rules []string
+	if policy.KeepLast != 0 {
+		rules = append(rules, fmt.Sprintf("last %d commits", policy.KeepLast))
+	}
+	if policy.KeepWithin != nil {
+		if keepWithin, err := types.DurationFromProto(policy.KeepWithin); err == nil && keepWithin != 0 {
+			rules = append(rules, fmt.Sprintf("commits from the last %v", keepWithin))
+		}
+	}
+	if policy.KeepDaily < 0 {
+		rules = append(rules, "one commit per day")
+	} else if policy.KeepDaily != 0 {
+		rules = append(rules, fmt.Sprintf("one commit per day for %d days", policy.KeepDaily))
+	}
+	if len(rules) == 0 {
+		return "keep only HEAD"
+	}
+	return "keep " + strings.Join(rules, ", ")
+}
+
+func printProtection(protection *pfs.BranchProtection) string {
+	var rules []string
+	if protection.NoDelete {
+		rules = append(rules, "no delete")
+	}
+	if protection.FastForwardOnly {
+		rules = append(rules, "fast-forward only")
+	}
+	if len(protection.AllowedCommitters) > 0 {
+		rules = append(rules, fmt.Sprintf("committers: %s", strings.Join(protection.AllowedCommitters, " ")))
+	}
+	if protection.PipelineOnly {
+		rules = append(rules, "pipeline only")
+	}
+	if len(rules) == 0 {
+		return "none"
+	}
+	return strings.Join(rules, ", ")
+}
+
+func printMetadata(metadata map[string]string) string {
+	var pairs []string
+	for k, v := range metadata {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
 // PrintBranch pretty-prints a Branch.
 func PrintBranch(w io.Writer, branchInfo *pfs.BranchInfo) {
 	fmt.Fprintf(w, "%s\t", branchInfo.Branch.Name)
@@ -122,7 +179,10 @@ func PrintDetailedBranchInfo(branchInfo *pfs.BranchInfo) error {
 		`Name: {{.Branch.Repo.Name}}@{{.Branch.Name}}{{if .Head}}
 Head Commit: {{ .Head.Repo.Name}}@{{.Head.ID}} {{end}}{{if .Provenance}}
 Provenance: {{range .Provenance}} {{.Repo.Name}}@{{.Name}} {{end}} {{end}}{{if .Trigger}}
-Trigger: {{printTrigger .Trigger}} {{end}}
+Trigger: {{printTrigger .Trigger}} {{end}}{{if .Retention}}
+Retention: {{printRetention .Retention}} {{end}}{{if .Protection}}
+Protection: {{printProtection .Protection}} {{end}}{{if .Metadata}}
+Metadata: {{printMetadata .Metadata}} {{end}}
 `)
 	if err != nil {
 		return err
@@ -196,7 +256,8 @@ Started: {{prettyAgo .Started}}{{end}}{{if .Finished}}{{if .FullTimestamps}}
 Finished: {{.Finished}}{{else}}
 Finished: {{prettyAgo .Finished}}{{end}}{{end}}
 Size: {{prettySize .SizeBytes}}{{if .Provenance}}
-Provenance: {{range .Provenance}} {{.Commit.Repo.Name}}@{{.Commit.ID}} ({{.Branch.Name}}) {{end}} {{end}}
+Provenance: {{range .Provenance}} {{.Commit.Repo.Name}}@{{.Commit.ID}} ({{.Branch.Name}}) {{end}} {{end}}{{if .Metadata}}
+Metadata: {{printMetadata .Metadata}}{{end}}
 `)
 	if err != nil {
 		return err
@@ -234,6 +295,26 @@ func PrintFileInfo(w io.Writer, fileInfo *pfs.FileInfo, fullTimestamps, withComm
 	fmt.Fprintln(w)
 }
 
+// PrintMergeConflict pretty-prints a conflict from merge branch.
+func PrintMergeConflict(w io.Writer, conflict *pfs.MergeConflict) {
+	fmt.Fprintf(w, "%s\t", conflict.Path)
+	for _, fileInfo := range []*pfs.FileInfo{conflict.Ours, conflict.Theirs} {
+		if fileInfo == nil {
+			fmt.Fprint(w, "deleted\t")
+		} else if fileInfo.FileType == pfs.FileType_DIR {
+			fmt.Fprint(w, "dir\t")
+		} else {
+			fmt.Fprintf(w, "%s\t", units.BytesSize(float64(fileInfo.SizeBytes)))
+		}
+	}
+	if conflict.Resolution == pfs.MergeStrategy_FAIL {
+		fmt.Fprint(w, color.RedString("unresolved\t"))
+	} else {
+		fmt.Fprintf(w, "%s\t", strings.ToLower(conflict.Resolution.String()))
+	}
+	fmt.Fprintln(w)
+}
+
 // PrintDiffFileInfo pretty-prints a file info from diff file.
 func PrintDiffFileInfo(w io.Writer, added bool, fileInfo *pfs.FileInfo, fullTimestamps bool) {
 	if added {
@@ -266,10 +347,13 @@ func fileType(fileType pfs.FileType) string {
 }
 
 var funcMap = template.FuncMap{
-	"prettyAgo":    pretty.Ago,
-	"prettySize":   pretty.Size,
-	"fileType":     fileType,
-	"printTrigger": printTrigger,
+	"prettyAgo":       pretty.Ago,
+	"prettySize":      pretty.Size,
+	"fileType":        fileType,
+	"printTrigger":    printTrigger,
+	"printRetention":  printRetention,
+	"printMetadata":   printMetadata,
+	"printProtection": printProtection,
 }
 
 // CompactPrintBranch renders 'b' as a compact string, e.g.
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/auth"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 )
@@ -15,21 +16,16 @@ func (c *controller) SecretKey(r *http.Request, accessKey string, region *string
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not create a pach client for auth")
 	}
-	pc.SetAuthToken(accessKey)
-
-	// WhoAmI will simultaneously check that auth is enabled, and that the
-	// user is who they say they are
-	_, err = pc.WhoAmI(pc.Ctx(), &auth.WhoAmIRequest{})
-	if err != nil {
+	active, err := AuthenticateToken(pc, accessKey)
+	if !active {
 		// Some S3 clients (like minio) require the use of authenticated
 		// requests, so in the case that auth is not enabled on pachyderm,
 		// just allow any access credentials.
-		if auth.IsErrNotActivated(err) {
-			vars := mux.Vars(r)
-			vars["s3gAuth"] = "disabled"
-			return &accessKey, nil
-		}
-
+		vars := mux.Vars(r)
+		vars["s3gAuth"] = "disabled"
+		return &accessKey, nil
+	}
+	if err != nil {
 		// Auth failed, return nil secret key, signifying that the auth failed
 		return nil, nil
 	}
@@ -38,6 +34,24 @@ func (c *controller) SecretKey(r *http.Request, accessKey string, region *string
 	return &accessKey, nil
 }
 
+// AuthenticateToken sets 'token' as the auth token of 'pc' and checks that it
+// identifies a user. 'active' is false if auth isn't activated, in which case
+// any token (including an empty one) may be used. Otherwise, a non-nil error
+// means that the token is invalid.
+func AuthenticateToken(pc *client.APIClient, token string) (active bool, _ error) {
+	pc.SetAuthToken(token)
+
+	// WhoAmI will simultaneously check that auth is enabled, and that the
+	// user is who they say they are
+	if _, err := pc.WhoAmI(pc.Ctx(), &auth.WhoAmIRequest{}); err != nil {
+		if auth.IsErrNotActivated(err) {
+			return false, nil
+		}
+		return true, err
+	}
+	return true, nil
+}
+
 func (c *controller) CustomAuth(r *http.Request) (bool, error) {
 	c.logger.Debug("CustomAuth")
 
@@ -3,6 +3,7 @@ package s3
 import (
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/gogo/protobuf/types"
@@ -254,12 +255,145 @@ func (c *controller) DeleteBucket(r *http.Request, bucketName string) error {
 }
 
 func (c *controller) ListObjectVersions(r *http.Request, bucketName, prefix, keyMarker, versionIDMarker string, delimiter string, maxKeys int) (*s2.ListObjectVersionsResult, error) {
-	// NOTE: because this endpoint isn't implemented, conformance tests will
-	// fail on teardown. It's nevertheless unimplemented because it's too
-	// expensive to pull off with PFS until this is implemented:
-	// https://github.com/pachyderm/pachyderm/issues/3896
 	c.logger.Debugf("ListObjectVersions: bucketName=%+v, prefix=%+v, keyMarker=%+v, versionIDMarker=%+v, delimiter=%+v, maxKeys=%+v", bucketName, prefix, keyMarker, versionIDMarker, delimiter, maxKeys)
-	return nil, s2.NotImplementedError(r)
+
+	pc, err := c.requestClient(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if delimiter != "" && delimiter != "/" {
+		return nil, invalidDelimiterError(r)
+	}
+
+	bucket, err := c.driver.bucket(pc, r, bucketName)
+	if err != nil {
+		return nil, err
+	}
+	bucketCaps, err := c.driver.bucketCapabilities(pc, r, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s2.ListObjectVersionsResult{
+		Versions:       []*s2.Version{},
+		DeleteMarkers:  []*s2.DeleteMarker{},
+		CommonPrefixes: []*s2.CommonPrefixes{},
+	}
+
+	if !bucketCaps.readable {
+		// serve empty results if we can't read the bucket; this helps with s3
+		// conformance
+		return &result, nil
+	}
+
+	var versions map[string][]*objectVersion
+	if bucketCaps.historicVersions {
+		versions, err = historicObjectVersions(pc, bucket)
+	} else {
+		versions, err = currentObjectVersions(pc, bucket)
+	}
+	if err != nil {
+		return nil, s2.InternalError(r, err)
+	}
+
+	// keys holds the keys and common prefixes to list, in order
+	keys := make([]string, 0, len(versions))
+	commonPrefixes := make(map[string]bool)
+	for key := range versions {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				// when a delimiter is set, the keys that contain it after the
+				// prefix are rolled up into one common prefix
+				commonPrefix := key[:len(prefix)+i+len(delimiter)]
+				if commonPrefix > keyMarker && !commonPrefixes[commonPrefix] {
+					commonPrefixes[commonPrefix] = true
+					keys = append(keys, commonPrefix)
+				}
+				continue
+			}
+		}
+		if key < keyMarker {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// truncate ends the listing if it's full, with markers that resume it
+	// after the last version or common prefix that was listed
+	var lastKey, lastVersion string
+	truncate := func() bool {
+		if len(result.Versions)+len(result.DeleteMarkers)+len(result.CommonPrefixes) < maxKeys {
+			return false
+		}
+		if maxKeys > 0 {
+			result.IsTruncated = true
+			result.NextKeyMarker = lastKey
+			result.NextVersionIDMarker = lastVersion
+		}
+		return true
+	}
+	for _, key := range keys {
+		if commonPrefixes[key] {
+			if truncate() {
+				return &result, nil
+			}
+			result.CommonPrefixes = append(result.CommonPrefixes, &s2.CommonPrefixes{
+				Prefix: key,
+				Owner:  defaultUser,
+			})
+			lastKey, lastVersion = key, ""
+			continue
+		}
+		keyVersions := versions[key]
+		if key == keyMarker {
+			// If a version ID marker is set, resume listing after that
+			// version of the key marker, otherwise the key marker itself is
+			// skipped.
+			if versionIDMarker == "" {
+				continue
+			}
+			for i, v := range keyVersions {
+				if v.version == versionIDMarker {
+					keyVersions = keyVersions[i+1:]
+					break
+				}
+			}
+		}
+		for _, v := range keyVersions {
+			if truncate() {
+				return &result, nil
+			}
+			lastKey, lastVersion = key, v.version
+			isLatest := v == versions[key][0]
+			if v.deleted {
+				result.DeleteMarkers = append(result.DeleteMarkers, &s2.DeleteMarker{
+					Key:          key,
+					Version:      v.version,
+					IsLatest:     isLatest,
+					LastModified: v.lastModified,
+					Owner:        defaultUser,
+				})
+				continue
+			}
+			result.Versions = append(result.Versions, &s2.Version{
+				Key:          key,
+				Version:      v.version,
+				IsLatest:     isLatest,
+				LastModified: v.lastModified,
+				ETag:         v.etag,
+				Size:         v.size,
+				StorageClass: globalStorageClass,
+				Owner:        defaultUser,
+			})
+		}
+	}
+
+	return &result, nil
 }
 
 func (c *controller) GetBucketVersioning(r *http.Request, bucketName string) (string, error) {
@@ -60,7 +60,7 @@ func (d *MasterDriver) listBuckets(pc *client.APIClient, r *http.Request, bucket
 		}
 		for _, branch := range repo.Branches {
 			*buckets = append(*buckets, &s2.Bucket{
-				Name:         fmt.Sprintf("%s.%s", branch.Name, branch.Repo.Name),
+				Name:         BucketName(branch.Repo.Name, branch.Name),
 				CreationDate: t,
 			})
 		}
@@ -69,17 +69,25 @@ func (d *MasterDriver) listBuckets(pc *client.APIClient, r *http.Request, bucket
 	return nil
 }
 
-func (d *MasterDriver) bucket(pc *client.APIClient, r *http.Request, name string) (*Bucket, error) {
-	branch := "master"
-	var repo string
+// BucketName returns the name of the bucket that serves 'branch' of 'repo' on
+// pachd master
+func BucketName(repo, branch string) string {
+	return fmt.Sprintf("%s.%s", branch, repo)
+}
+
+// ParseBucketName returns the repo and branch served by the bucket 'name' on
+// pachd master. Names have the form `<branch>.<repo>`, or just `<repo>`, in
+// which case the branch is master.
+func ParseBucketName(name string) (repo string, branch string) {
 	parts := strings.SplitN(name, ".", 2)
 	if len(parts) == 2 {
-		branch = parts[0]
-		repo = parts[1]
-	} else {
-		repo = parts[0]
+		return parts[1], parts[0]
 	}
+	return parts[0], "master"
+}
 
+func (d *MasterDriver) bucket(pc *client.APIClient, r *http.Request, name string) (*Bucket, error) {
+	repo, branch := ParseBucketName(name)
 	return &Bucket{
 		Repo:   repo,
 		Commit: branch,
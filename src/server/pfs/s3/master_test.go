@@ -424,6 +424,39 @@ func masterAuthV2(t *testing.T, pachClient *client.APIClient, minioClient *minio
 	require.NoError(t, err)
 }
 
+func masterListObjectVersions(t *testing.T, pachClient *client.APIClient, minioClient *minio.Client) {
+	repo := tu.UniqueString("testlistobjectversions")
+	require.NoError(t, pachClient.CreateRepo(repo))
+
+	_, err := pachClient.PutFile(repo, "master", "file", strings.NewReader("content1"))
+	require.NoError(t, err)
+	_, err = pachClient.PutFile(repo, "master", "other", strings.NewReader("content"))
+	require.NoError(t, err)
+	_, err = pachClient.PutFileOverwrite(repo, "master", "file", strings.NewReader("content2"), 0)
+	require.NoError(t, err)
+	require.NoError(t, pachClient.DeleteFile(repo, "master", "other"))
+
+	commitInfos, err := pachClient.ListCommit(repo, "master", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(commitInfos))
+
+	versions, err := historicObjectVersions(pachClient, &Bucket{Repo: repo, Commit: "master"})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(versions))
+
+	// versions are ordered from newest to oldest
+	require.Equal(t, 2, len(versions["file"]))
+	require.Equal(t, commitInfos[1].Commit.ID, versions["file"][0].version)
+	require.Equal(t, uint64(8), versions["file"][0].size)
+	require.Equal(t, commitInfos[3].Commit.ID, versions["file"][1].version)
+
+	require.Equal(t, 2, len(versions["other"]))
+	require.Equal(t, commitInfos[0].Commit.ID, versions["other"][0].version)
+	require.True(t, versions["other"][0].deleted)
+	require.Equal(t, commitInfos[2].Commit.ID, versions["other"][1].version)
+	require.False(t, versions["other"][1].deleted)
+}
+
 func TestMasterDriver(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -496,6 +529,9 @@ func TestMasterDriver(t *testing.T) {
 		t.Run("ListObjectsRecursive", func(t *testing.T) {
 			masterListObjectsRecursive(t, pachClient, minioClient)
 		})
+		t.Run("ListObjectVersions", func(t *testing.T) {
+			masterListObjectVersions(t, pachClient, minioClient)
+		})
 		t.Run("AuthV2", func(t *testing.T) {
 			masterAuthV2(t, pachClient, minioClient)
 		})
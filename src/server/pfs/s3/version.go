@@ -0,0 +1,111 @@
+package s3
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	pfsClient "github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// objectVersion is a version of an object. Versions correspond to the commits
+// in which the object was created, modified or deleted, and the ID of the
+// commit is used as the version ID.
+type objectVersion struct {
+	version      string
+	lastModified time.Time
+	etag         string
+	size         uint64
+	deleted      bool
+}
+
+func newObjectVersion(version string, fileInfo *pfsClient.FileInfo) (*objectVersion, error) {
+	t, err := types.TimestampFromProto(fileInfo.Committed)
+	if err != nil {
+		return nil, err
+	}
+	return &objectVersion{
+		version:      version,
+		lastModified: t,
+		etag:         fmt.Sprintf("%x", fileInfo.Hash),
+		size:         fileInfo.SizeBytes,
+	}, nil
+}
+
+// historicObjectVersions walks the commit history of the bucket's branch and
+// returns the versions of each object, keyed by object key and ordered from
+// newest to oldest.
+func historicObjectVersions(pc *client.APIClient, bucket *Bucket) (map[string][]*objectVersion, error) {
+	versions := make(map[string][]*objectVersion)
+	if err := pc.ListCommitF(bucket.Repo, bucket.Commit, "", 0, false, func(commitInfo *pfsClient.CommitInfo) error {
+		// Only finished commits on the bucket's branch are versions, since
+		// those are the only commits that can be read through the bucket.
+		if commitInfo.Finished == nil || commitInfo.Branch == nil || commitInfo.Branch.Name != bucket.Commit {
+			return nil
+		}
+		finished, err := types.TimestampFromProto(commitInfo.Finished)
+		if err != nil {
+			return err
+		}
+		commitID := commitInfo.Commit.ID
+		newFiles, oldFiles, err := pc.DiffFile(bucket.Repo, commitID, "", "", "", "", false)
+		if err != nil {
+			return err
+		}
+		changed := make(map[string]bool)
+		for _, fileInfo := range newFiles {
+			if fileInfo.FileType != pfsClient.FileType_FILE {
+				continue
+			}
+			key := fileInfo.File.Path[1:] // strip leading slash
+			v, err := newObjectVersion(commitID, fileInfo)
+			if err != nil {
+				return err
+			}
+			versions[key] = append(versions[key], v)
+			changed[key] = true
+		}
+		// Files that are only present in the parent commit were deleted in
+		// this commit.
+		for _, fileInfo := range oldFiles {
+			if fileInfo.FileType != pfsClient.FileType_FILE {
+				continue
+			}
+			key := fileInfo.File.Path[1:] // strip leading slash
+			if changed[key] {
+				continue
+			}
+			versions[key] = append(versions[key], &objectVersion{
+				version:      commitID,
+				lastModified: finished,
+				deleted:      true,
+			})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// currentObjectVersions returns the objects in the bucket's commit as the
+// only version of each object, this is used for buckets that do not keep
+// historic versions.
+func currentObjectVersions(pc *client.APIClient, bucket *Bucket) (map[string][]*objectVersion, error) {
+	versions := make(map[string][]*objectVersion)
+	if err := pc.Walk(bucket.Repo, bucket.Commit, "", func(fileInfo *pfsClient.FileInfo) error {
+		if fileInfo.FileType != pfsClient.FileType_FILE {
+			return nil
+		}
+		v, err := newObjectVersion(fileInfo.File.Commit.ID, fileInfo)
+		if err != nil {
+			return err
+		}
+		versions[fileInfo.File.Path[1:]] = []*objectVersion{v}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
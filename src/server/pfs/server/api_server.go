@@ -64,7 +64,7 @@ func (a *apiServer) CreateRepoInTransaction(
 	txnCtx *txnenv.TransactionContext,
 	request *pfs.CreateRepoRequest,
 ) error {
-	return a.driver.createRepo(txnCtx, request.Repo, request.Description, request.Update)
+	return a.driver.createRepo(txnCtx, request.Repo, request.Description, request.Update, request.Metadata)
 }
 
 // CreateRepo implements the protobuf pfs.CreateRepo RPC
@@ -141,6 +141,70 @@ func (a *apiServer) DeleteRepo(ctx context.Context, request *pfs.DeleteRepoReque
 	return &types.Empty{}, nil
 }
 
+// SetRepoQuota implements the protobuf pfs.SetRepoQuota RPC
+func (a *apiServer) SetRepoQuota(ctx context.Context, request *pfs.SetRepoQuotaRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+		return a.driver.setRepoQuota(txnCtx, request.Repo, request.Quota)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// SetRetentionPolicy implements the protobuf pfs.SetRetentionPolicy RPC
+func (a *apiServer) SetRetentionPolicy(ctx context.Context, request *pfs.SetRetentionPolicyRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+		return a.driver.setRetentionPolicy(txnCtx, request.Repo, request.Branch, request.Policy)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// PruneCommits implements the protobuf pfs.PruneCommits RPC
+func (a *apiServer) PruneCommits(ctx context.Context, request *pfs.PruneCommitsRequest) (response *pfs.PruneCommitsResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	commits, err := a.driver.pruneCommits(a.env.GetPachClient(ctx), request.Repo, request.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.PruneCommitsResponse{Commits: commits}, nil
+}
+
+// SetMetadata implements the protobuf pfs.SetMetadata RPC
+func (a *apiServer) SetMetadata(ctx context.Context, request *pfs.SetMetadataRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+		return a.driver.setMetadata(txnCtx, request.Repo, request.Branch, request.Commit, request.Metadata)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// SetBranchProtection implements the protobuf pfs.SetBranchProtection RPC
+func (a *apiServer) SetBranchProtection(ctx context.Context, request *pfs.SetBranchProtectionRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+		return a.driver.setBranchProtection(txnCtx, request.Branch, request.Protection)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
 // Fsckimplements the protobuf pfs.Fsck RPC
 func (a *apiServer) Fsck(request *pfs.FsckRequest, fsckServer pfs.API_FsckServer) (retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
@@ -148,12 +212,20 @@ func (a *apiServer) Fsck(request *pfs.FsckRequest, fsckServer pfs.API_FsckServer
 	defer func(start time.Time) {
 		a.Log(request, fmt.Sprintf("stream containing %d messages", sent), retErr, time.Since(start))
 	}(time.Now())
-	if err := a.driver.fsck(a.env.GetPachClient(fsckServer.Context()), request.Fix, func(resp *pfs.FsckResponse) error {
+	if !request.Deep && (request.VerifyContent || request.Parallelism != 0 || request.ResumeFrom != "") {
+		return errors.New("verify_content, parallelism and resume_from can only be set for a deep fsck")
+	}
+	pachClient := a.env.GetPachClient(fsckServer.Context())
+	send := func(resp *pfs.FsckResponse) error {
 		sent++
 		return fsckServer.Send(resp)
-	}); err != nil {
+	}
+	if err := a.driver.fsck(pachClient, request.Fix, send); err != nil {
 		return err
 	}
+	if request.Deep {
+		return a.driver.deepFsck(pachClient, request, send)
+	}
 	return nil
 }
 
@@ -171,7 +243,7 @@ func (a *apiServer) StartCommitInTransaction(
 	if commit != nil {
 		id = commit.ID
 	}
-	return a.driver.startCommit(txnCtx, id, request.Parent, request.Branch, request.Provenance, request.Description)
+	return a.driver.startCommit(txnCtx, id, request.Parent, request.Branch, request.Provenance, request.Description, request.Metadata)
 }
 
 // StartCommit implements the protobuf pfs.StartCommit RPC
@@ -212,7 +284,7 @@ func (a *apiServer) BuildCommit(ctx context.Context, request *pfs.BuildCommitReq
 		}
 	}
 
-	commit, err := a.driver.buildCommit(ctx, request.ID, request.Parent, request.Branch, request.Origin, request.Provenance, request.Tree, request.Trees, request.Datums, started, finished, request.SizeBytes)
+	commit, err := a.driver.buildCommit(ctx, request.ID, request.Parent, request.Branch, request.Origin, request.Provenance, request.Tree, request.Trees, request.Datums, request.Metadata, started, finished, request.SizeBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -225,6 +297,11 @@ func (a *apiServer) FinishCommitInTransaction(
 	txnCtx *txnenv.TransactionContext,
 	request *pfs.FinishCommitRequest,
 ) error {
+	if len(request.Metadata) > 0 {
+		if err := a.driver.setCommitMetadata(txnCtx, request.Commit, request.Metadata); err != nil {
+			return err
+		}
+	}
 	if request.Trees != nil {
 		return a.driver.finishOutputCommit(txnCtx, request.Commit, request.Trees, request.Datums, request.SizeBytes)
 	}
@@ -257,7 +334,7 @@ func (a *apiServer) ListCommit(ctx context.Context, request *pfs.ListCommitReque
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	commitInfos, err := a.driver.listCommit(a.env.GetPachClient(ctx), request.Repo, request.To, request.From, request.Number, request.Reverse)
+	commitInfos, err := a.driver.listCommit(a.env.GetPachClient(ctx), request.Repo, request.To, request.From, request.Number, request.Reverse, request.Metadata)
 	if err != nil {
 		return nil, err
 	}
@@ -273,7 +350,7 @@ func (a *apiServer) ListCommitStream(request *pfs.ListCommitRequest, respServer
 	defer func(start time.Time) {
 		a.Log(request, fmt.Sprintf("stream containing %d commits", sent), retErr, time.Since(start))
 	}(time.Now())
-	return a.driver.listCommitF(a.env.GetPachClient(respServer.Context()), request.Repo, request.To, request.From, request.Number, request.Reverse, func(ci *pfs.CommitInfo) error {
+	return a.driver.listCommitF(a.env.GetPachClient(respServer.Context()), request.Repo, request.To, request.From, request.Number, request.Reverse, request.Metadata, func(ci *pfs.CommitInfo) error {
 		sent++
 		return respServer.Send(ci)
 	})
@@ -285,7 +362,7 @@ func (a *apiServer) CreateBranchInTransaction(
 	txnCtx *txnenv.TransactionContext,
 	request *pfs.CreateBranchRequest,
 ) error {
-	return a.driver.createBranch(txnCtx, request.Branch, request.Head, request.Provenance, request.Trigger)
+	return a.driver.createBranch(txnCtx, request.Branch, request.Head, request.Provenance, request.Trigger, request.Metadata)
 }
 
 // CreateBranch implements the protobuf pfs.CreateBranch RPC
@@ -351,6 +428,13 @@ func (a *apiServer) DeleteBranch(ctx context.Context, request *pfs.DeleteBranchR
 	return &types.Empty{}, nil
 }
 
+// MergeBranch implements the protobuf pfs.MergeBranch RPC
+func (a *apiServer) MergeBranch(ctx context.Context, request *pfs.MergeBranchRequest) (response *pfs.MergeBranchResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.mergeBranch(a.env.GetPachClient(ctx), request.Source, request.Target, request.Strategy, request.Resolutions, request.Description)
+}
+
 // DeleteCommitInTransaction is identical to DeleteCommit except that it can run
 // inside an existing etcd STM transaction.  This is not an RPC.
 func (a *apiServer) DeleteCommitInTransaction(
@@ -386,7 +470,7 @@ func (a *apiServer) SubscribeCommit(request *pfs.SubscribeCommitRequest, stream
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
 
-	return a.driver.subscribeCommit(a.env.GetPachClient(stream.Context()), request.Repo, request.Branch, request.Prov, request.From, request.State, stream.Send)
+	return a.driver.subscribeCommit(a.env.GetPachClient(stream.Context()), request.Repo, request.Branch, request.Prov, request.From, request.State, request.Metadata, stream.Send)
 }
 
 // PutFile implements the protobuf pfs.PutFile RPC
@@ -423,6 +507,13 @@ func (a *apiServer) CopyFile(ctx context.Context, request *pfs.CopyFileRequest)
 	return &types.Empty{}, nil
 }
 
+// SyncFile implements the protobuf pfs.SyncFile RPC
+func (a *apiServer) SyncFile(ctx context.Context, request *pfs.SyncFileRequest) (response *pfs.SyncFileResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.syncFile(a.env.GetPachClient(ctx), request.File, request.Url)
+}
+
 // GetFile implements the protobuf pfs.GetFile RPC
 func (a *apiServer) GetFile(request *pfs.GetFileRequest, apiGetFileServer pfs.API_GetFileServer) (retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
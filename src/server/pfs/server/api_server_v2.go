@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"time"
 
@@ -59,6 +60,11 @@ func (a *apiServerV2) FinishCommitInTransaction(txnCtx *txnenv.TransactionContex
 		if request.Empty {
 			request.Description += pfs.EmptyStr
 		}
+		if len(request.Metadata) > 0 {
+			if err := a.driver.setCommitMetadata(txnCtx, request.Commit, request.Metadata); err != nil {
+				return err
+			}
+		}
 		return a.driver.finishCommitV2(txnCtx, request.Commit, request.Description)
 	})
 }
@@ -74,11 +80,28 @@ func (a *apiServerV2) BuildCommit(_ context.Context, _ *pfs.BuildCommitRequest)
 	return nil, errV1NotImplemented
 }
 
+// MergeBranch implements the protobuf pfs.MergeBranch RPC
+func (a *apiServerV2) MergeBranch(ctx context.Context, request *pfs.MergeBranchRequest) (response *pfs.MergeBranchResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.mergeBranchV2(a.env.GetPachClient(ctx), request.Source, request.Target, request.Strategy, request.Resolutions, request.Description)
+}
+
+// SetRepoQuota is not implemented in V2.
+func (a *apiServerV2) SetRepoQuota(_ context.Context, _ *pfs.SetRepoQuotaRequest) (*types.Empty, error) {
+	return nil, errV1NotImplemented
+}
+
 // PutFile is not implemented in V2.
 func (a *apiServerV2) PutFile(_ pfs.API_PutFileServer) error {
 	return errV1NotImplemented
 }
 
+// SyncFile is not implemented in V2.
+func (a *apiServerV2) SyncFile(_ context.Context, _ *pfs.SyncFileRequest) (*pfs.SyncFileResponse, error) {
+	return nil, errV1NotImplemented
+}
+
 // CopyFile implements the protobuf pfs.CopyFile RPC
 func (a *apiServerV2) CopyFile(ctx context.Context, request *pfs.CopyFileRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
@@ -156,9 +179,28 @@ func (a *apiServerV2) DeleteAll(ctx context.Context, request *types.Empty) (resp
 	return &types.Empty{}, nil
 }
 
-// Fsck is not implemented in V2.
-func (a *apiServerV2) Fsck(_ *pfs.FsckRequest, _ pfs.API_FsckServer) error {
-	return errV1NotImplemented
+// Fsck implements the protobuf pfs.Fsck RPC
+func (a *apiServerV2) Fsck(request *pfs.FsckRequest, fsckServer pfs.API_FsckServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	sent := 0
+	defer func(start time.Time) {
+		a.Log(request, fmt.Sprintf("stream containing %d messages", sent), retErr, time.Since(start))
+	}(time.Now())
+	if !request.Deep && (request.VerifyContent || request.Parallelism != 0 || request.ResumeFrom != "") {
+		return errors.New("verify_content, parallelism and resume_from can only be set for a deep fsck")
+	}
+	pachClient := a.env.GetPachClient(fsckServer.Context())
+	send := func(resp *pfs.FsckResponse) error {
+		sent++
+		return fsckServer.Send(resp)
+	}
+	if err := a.driver.fsck(pachClient, request.Fix, send); err != nil {
+		return err
+	}
+	if request.Deep {
+		return a.driver.deepFsck(pachClient, request, send)
+	}
+	return nil
 }
 
 func (a *apiServerV2) FileOperationV2(server pfs.API_FileOperationV2Server) (retErr error) {
@@ -316,5 +358,5 @@ func (a *apiServerV2) CreateRepoInTransaction(
 	if repo := request.GetRepo(); repo != nil && repo.Name == tmpRepo {
 		return errors.Errorf("%s is a reserved name", tmpRepo)
 	}
-	return a.driver.createRepo(txnCtx, request.Repo, request.Description, request.Update)
+	return a.driver.createRepo(txnCtx, request.Repo, request.Description, request.Update, request.Metadata)
 }
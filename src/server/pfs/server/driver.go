@@ -31,10 +31,12 @@ import (
 	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pfs/pretty"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ancestry"
+	"github.com/pachyderm/pachyderm/src/server/pkg/avro"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
 	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	"github.com/pachyderm/pachyderm/src/server/pkg/parquet"
 	ppath "github.com/pachyderm/pachyderm/src/server/pkg/path"
 	"github.com/pachyderm/pachyderm/src/server/pkg/pfsdb"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ppsconsts"
@@ -89,7 +91,10 @@ type collectionFactory func(string) col.Collection
 
 type driver struct {
 	env *serviceenv.ServiceEnv
-	// etcdClient and prefix write repo and other metadata to etcd
+	// backend and prefix write repo and other metadata to the collection
+	// backend (etcd or postgres), while etcdClient is used for locks and the
+	// storage task queue, which are always in etcd
+	backend    col.Backend
 	etcdClient *etcd.Client
 	txnEnv     *txnenv.TransactionEnv
 	prefix     string
@@ -129,21 +134,22 @@ func newDriver(
 		return nil, errors.Errorf("cannot initialize driver with nil treeCache")
 	}
 	// Initialize driver
-	etcdClient := env.GetEtcdClient()
+	backend := env.GetCollectionBackend()
 	d := &driver{
 		env:            env,
 		txnEnv:         txnEnv,
-		etcdClient:     etcdClient,
+		backend:        backend,
+		etcdClient:     env.GetEtcdClient(),
 		prefix:         etcdPrefix,
-		repos:          pfsdb.Repos(etcdClient, etcdPrefix),
-		putFileRecords: pfsdb.PutFileRecords(etcdClient, etcdPrefix),
+		repos:          pfsdb.Repos(backend, etcdPrefix),
+		putFileRecords: pfsdb.PutFileRecords(backend, etcdPrefix),
 		commits: func(repo string) col.Collection {
-			return pfsdb.Commits(etcdClient, etcdPrefix, repo)
+			return pfsdb.Commits(backend, etcdPrefix, repo)
 		},
 		branches: func(repo string) col.Collection {
-			return pfsdb.Branches(etcdClient, etcdPrefix, repo)
+			return pfsdb.Branches(backend, etcdPrefix, repo)
 		},
-		openCommits: pfsdb.OpenCommits(etcdClient, etcdPrefix),
+		openCommits: pfsdb.OpenCommits(backend, etcdPrefix),
 		treeCache:   treeCache,
 		storageRoot: storageRoot,
 		// Allow up to a third of the requested memory to be used for memory intensive operations
@@ -159,20 +165,30 @@ func newDriver(
 		Repo:    repo,
 		Created: types.TimestampNow(),
 	}
-	if _, err := col.NewSTM(context.Background(), etcdClient, func(stm col.STM) error {
+	if err := backend.NewSTM(context.Background(), func(stm col.STM) error {
 		repos := d.repos.ReadWrite(stm)
 		return repos.Create(repo.Name, repoInfo)
 	}); err != nil && !col.IsErrExists(err) {
 		return nil, err
 	}
+	if env.PFSRetentionInterval != "" {
+		retentionInterval, err := time.ParseDuration(env.PFSRetentionInterval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid PFS_RETENTION_INTERVAL")
+		}
+		go d.retentionMaster(retentionInterval)
+	}
 	return d, nil
 }
 
-func (d *driver) createRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, description string, update bool) error {
+func (d *driver) createRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, description string, update bool, metadata map[string]string) error {
 	// Validate arguments
 	if repo == nil {
 		return errors.New("repo cannot be nil")
 	}
+	if err := validateMetadata(metadata); err != nil {
+		return err
+	}
 
 	// Check that the user is logged in (user doesn't need any access level to
 	// create a repo, but they must be authenticated if auth is active)
@@ -200,7 +216,7 @@ func (d *driver) createRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, d
 			return pfsserver.ErrRepoExists{repo}
 		}
 
-		if existingRepoInfo.Description == description {
+		if existingRepoInfo.Description == description && len(metadata) == 0 {
 			// Don't overwrite the stored proto with an identical value. This
 			// optimization is impactful because pps will frequently update the __spec__
 			// repo to make sure it exists.
@@ -217,6 +233,7 @@ func (d *driver) createRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, d
 			return errors.Wrapf(err, "could not update description of %q", repo)
 		}
 		existingRepoInfo.Description = description
+		existingRepoInfo.Metadata = mergeMetadata(existingRepoInfo.Metadata, metadata)
 		return repos.Put(repo.Name, &existingRepoInfo)
 	} else {
 		// New repo case
@@ -238,6 +255,7 @@ func (d *driver) createRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, d
 			Repo:        repo,
 			Created:     types.TimestampNow(),
 			Description: description,
+			Metadata:    mergeMetadata(nil, metadata),
 		})
 	}
 }
@@ -841,7 +859,7 @@ func (d *driver) fsck(pachClient *client.APIClient, fix bool, cb func(*pfs.FsckR
 		}
 	}
 	if fix {
-		_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		err := d.backend.NewSTM(ctx, func(stm col.STM) error {
 			for _, ci := range newCommitInfos {
 				// We've observed users getting ErrExists from this create,
 				// which doesn't make a lot of sense, but we insulate against
@@ -921,6 +939,21 @@ func (d *driver) deleteRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, f
 			return errors.Wrapf(err, "repos.Get")
 		}
 	}
+	// Protected branches can only be deleted by force-deleting their repo
+	if !force {
+		for _, branch := range repoInfo.Branches {
+			branchInfo := &pfs.BranchInfo{}
+			if err := d.branches(repo.Name).ReadWrite(txnCtx.Stm).Get(branch.Name, branchInfo); err != nil {
+				if col.IsErrNotFound(err) {
+					continue
+				}
+				return err
+			}
+			if err := checkBranchDelete(branchInfo); err != nil {
+				return err
+			}
+		}
+	}
 
 	// make a list of all the commits
 	commits := d.commits(repo.Name).ReadOnly(txnCtx.ClientContext)
@@ -1051,19 +1084,19 @@ func (d *driver) deleteRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, f
 
 // ID can be passed in for transactions, which need to ensure the ID doesn't
 // change after the commit ID has been reported to a client.
-func (d *driver) startCommit(txnCtx *txnenv.TransactionContext, ID string, parent *pfs.Commit, branch string, provenance []*pfs.CommitProvenance, description string) (*pfs.Commit, error) {
-	return d.makeCommit(txnCtx, ID, parent, branch, nil, provenance, nil, nil, nil, nil, nil, description, time.Time{}, time.Time{}, 0)
+func (d *driver) startCommit(txnCtx *txnenv.TransactionContext, ID string, parent *pfs.Commit, branch string, provenance []*pfs.CommitProvenance, description string, metadata map[string]string) (*pfs.Commit, error) {
+	return d.makeCommit(txnCtx, ID, parent, branch, nil, provenance, nil, nil, nil, nil, nil, description, metadata, time.Time{}, time.Time{}, 0)
 }
 
 func (d *driver) buildCommit(ctx context.Context, ID string, parent *pfs.Commit,
 	branch string, origin *pfs.CommitOrigin, provenance []*pfs.CommitProvenance,
 	tree *pfs.Object, trees []*pfs.Object, datums *pfs.Object,
-	started, finished time.Time, sizeBytes uint64) (*pfs.Commit, error) {
+	metadata map[string]string, started, finished time.Time, sizeBytes uint64) (*pfs.Commit, error) {
 	commit := &pfs.Commit{}
 	err := d.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
 		var err error
 		commit, err = d.makeCommit(txnCtx, ID, parent, branch, origin, provenance, tree, trees,
-			datums, nil, nil, "", started, finished, sizeBytes)
+			datums, nil, nil, "", metadata, started, finished, sizeBytes)
 		return err
 	})
 	return commit, err
@@ -1094,6 +1127,7 @@ func (d *driver) makeCommit(
 	recordFiles []string,
 	records []*pfs.PutFileRecords,
 	description string,
+	metadata map[string]string,
 	started time.Time,
 	finished time.Time,
 	sizeBytes uint64,
@@ -1102,6 +1136,9 @@ func (d *driver) makeCommit(
 	if parent == nil {
 		return nil, errors.Errorf("parent cannot be nil")
 	}
+	if err := validateMetadata(metadata); err != nil {
+		return nil, err
+	}
 
 	// Check that caller is authorized
 	if err := authserver.CheckIsAuthorizedInTransaction(txnCtx, parent.Repo, auth.Scope_WRITER); err != nil {
@@ -1123,6 +1160,7 @@ func (d *driver) makeCommit(
 		Commit:      newCommit,
 		Origin:      origin,
 		Description: description,
+		Metadata:    mergeMetadata(nil, metadata),
 	}
 	if branch != "" {
 		if err := ancestry.ValidateName(branch); err != nil {
@@ -1181,12 +1219,17 @@ func (d *driver) makeCommit(
 	// addition, 'parent.ID' was not set)
 	key := path.Join
 	branchProvMap := make(map[string]bool)
+	// set if 'branch' is fast-forward only, and 'parent' must be checked
+	var fastForwardBranch *pfs.BranchInfo
 	if branch != "" {
 		branchInfo := &pfs.BranchInfo{}
 		if err := branches.Upsert(branch, branchInfo, func() error {
 			// validate branch
+			fastForwardBranch = nil
 			if parent.ID == "" && branchInfo.Head != nil {
 				parent.ID = branchInfo.Head.ID
+			} else if branchInfo.Head != nil && branchInfo.Protection != nil && branchInfo.Protection.FastForwardOnly {
+				fastForwardBranch = proto.Clone(branchInfo).(*pfs.BranchInfo)
 			}
 			// include the branch and its provenance in the branch provenance map
 			branchProvMap[key(newCommit.Repo.Name, branch)] = true
@@ -1225,6 +1268,9 @@ func (d *driver) makeCommit(
 			if provenanceCount > 0 && treeRef == nil && !hasSpec {
 				return errors.Errorf("cannot start a commit on an output branch")
 			}
+			if err := d.checkCommitAllowed(txnCtx, branchInfo, hasSpec); err != nil {
+				return err
+			}
 			// Point 'branch' at the new commit
 			branchInfo.Name = branch // set in case 'branch' is new
 			branchInfo.Head = newCommit
@@ -1243,6 +1289,9 @@ func (d *driver) makeCommit(
 	// 2. Finish 'newCommit' (if treeRef != nil or records != nil); see
 	//    "FinishCommit case" above)
 	if treeRef != nil || treesRefs != nil || records != nil || newCommitInfo.Finished != nil {
+		count := func() (uint64, error) {
+			return d.countCommitFiles(txnCtx.Client, newCommitInfo)
+		}
 		if records != nil {
 			parentTree, err := d.getTreeForCommit(txnCtx, parent)
 			if err != nil {
@@ -1266,6 +1315,9 @@ func (d *driver) makeCommit(
 				return nil, err
 			}
 			sizeBytes = uint64(tree.FSSize())
+			count = func() (uint64, error) {
+				return countTreeFiles(tree)
+			}
 		}
 
 		// at this point, either 'treeRef' is set OR 'newCommit' is tree-less
@@ -1276,11 +1328,16 @@ func (d *driver) makeCommit(
 		if newCommitInfo.Finished == nil {
 			newCommitInfo.Finished = types.TimestampNow()
 		}
+		fileCount, err := d.checkRepoQuota(txnCtx, parent.Repo, sizeBytes, count)
+		if err != nil {
+			return nil, err
+		}
 
 		// If we're updating the master branch, also update the repo size (see
 		// "Update repoInfo" below)
 		if branch == "master" {
 			repoInfo.SizeBytes = newCommitInfo.SizeBytes
+			repoInfo.FileCount = fileCount
 		}
 	} else {
 		if err := d.openCommits.ReadWrite(txnCtx.Stm).Put(newCommit.ID, newCommit); err != nil {
@@ -1307,6 +1364,12 @@ func (d *driver) makeCommit(
 		if parentCommitInfo.Finished == nil {
 			return nil, errors.Errorf("parent commit %s@%s has not been finished", parent.Repo.Name, parent.ID)
 		}
+		// fail if this would move a fast-forward only branch off of its history
+		if fastForwardBranch != nil {
+			if err := d.checkHeadMove(txnCtx, fastForwardBranch, parentCommitInfo.Commit); err != nil {
+				return nil, err
+			}
+		}
 		if err := commits.Update(parent.ID, parentCommitInfo, func() error {
 			newCommitInfo.ParentCommit = parent
 			// If we don't know the branch the commit belongs to at this point, assume it is the same as the parent branch
@@ -1467,6 +1530,7 @@ func (d *driver) finishCommit(txnCtx *txnenv.TransactionContext, commit *pfs.Com
 	}
 
 	var parentTree, finishedTree hashtree.HashTree
+	var fileCount uint64
 	if !empty {
 		// Retrieve the parent commit's tree (to apply writes from etcd or just
 		// compute the size change). If parentCommit.Tree == nil, walk up the branch
@@ -1515,12 +1579,18 @@ func (d *driver) finishCommit(txnCtx *txnenv.TransactionContext, commit *pfs.Com
 			commitInfo.Tree = tree
 		}
 		commitInfo.SizeBytes = uint64(finishedTree.FSSize())
+		fileCount, err = d.checkRepoQuota(txnCtx, commit.Repo, commitInfo.SizeBytes, func() (uint64, error) {
+			return countTreeFiles(finishedTree)
+		})
+		if err != nil {
+			return err
+		}
 	}
 	commitInfo.Finished = types.TimestampNow()
 	if err := d.updateProvenanceProgress(txnCtx, !empty, commitInfo); err != nil {
 		return err
 	}
-	if err := d.writeFinishedCommit(txnCtx.Stm, commit, commitInfo); err != nil {
+	if err := d.writeFinishedCommit(txnCtx.Stm, commit, commitInfo, fileCount); err != nil {
 		return err
 	}
 	triggeredBranches, err := d.triggerCommit(txnCtx, commitInfo.Commit)
@@ -1549,11 +1619,17 @@ func (d *driver) finishOutputCommit(txnCtx *txnenv.TransactionContext, commit *p
 	commitInfo.Trees = trees
 	commitInfo.Datums = datums
 	commitInfo.SizeBytes = size
+	fileCount, err := d.checkRepoQuota(txnCtx, commit.Repo, size, func() (uint64, error) {
+		return d.countCommitFiles(txnCtx.Client, commitInfo)
+	})
+	if err != nil {
+		return err
+	}
 	commitInfo.Finished = types.TimestampNow()
 	if err := d.updateProvenanceProgress(txnCtx, true, commitInfo); err != nil {
 		return err
 	}
-	if err := d.writeFinishedCommit(txnCtx.Stm, commit, commitInfo); err != nil {
+	if err := d.writeFinishedCommit(txnCtx.Stm, commit, commitInfo, fileCount); err != nil {
 		return err
 	}
 	triggeredBranches, err := d.triggerCommit(txnCtx, commitInfo.Commit)
@@ -1591,8 +1667,9 @@ func (d *driver) updateProvenanceProgress(txnCtx *txnenv.TransactionContext, suc
 // writeFinishedCommit writes these changes to etcd:
 // 1) it closes the input commit (i.e., it writes any changes made to it and
 //    removes it from the open commits)
-// 2) if the commit is the new HEAD of master, it updates the repo size
-func (d *driver) writeFinishedCommit(stm col.STM, commit *pfs.Commit, commitInfo *pfs.CommitInfo) error {
+// 2) if the commit is the new HEAD of master, it updates the repo size (and
+//    file count, which is only computed if the repo has a file count quota)
+func (d *driver) writeFinishedCommit(stm col.STM, commit *pfs.Commit, commitInfo *pfs.CommitInfo, fileCount uint64) error {
 	commits := d.commits(commit.Repo.Name).ReadWrite(stm)
 	if err := commits.Put(commit.ID, commitInfo); err != nil {
 		return err
@@ -1616,6 +1693,7 @@ func (d *driver) writeFinishedCommit(stm col.STM, commit *pfs.Commit, commitInfo
 			// had shared its head commit with master, and then we created a new commit on that branch
 			if branchInfo.Head != nil && branchInfo.Head.ID == commit.ID {
 				repoInfo.SizeBytes = commitInfo.SizeBytes
+				repoInfo.FileCount = fileCount
 				if err := repos.Put(commit.Repo.Name, repoInfo); err != nil {
 					return err
 				}
@@ -1880,7 +1958,7 @@ func (d *driver) inspectCommit(pachClient *client.APIClient, commit *pfs.Commit,
 
 	// Check if the commitID is a branch name
 	var commitInfo *pfs.CommitInfo
-	if err := col.NewDryrunSTM(ctx, d.etcdClient, func(stm col.STM) error {
+	if err := d.backend.NewDryrunSTM(ctx, func(stm col.STM) error {
 		var err error
 		commitInfo, err = d.resolveCommit(stm, commit)
 		return err
@@ -2018,9 +2096,9 @@ func (d *driver) resolveCommit(stm col.STM, userCommit *pfs.Commit) (*pfs.Commit
 }
 
 func (d *driver) listCommit(pachClient *client.APIClient, repo *pfs.Repo,
-	to *pfs.Commit, from *pfs.Commit, number uint64, reverse bool) ([]*pfs.CommitInfo, error) {
+	to *pfs.Commit, from *pfs.Commit, number uint64, reverse bool, metadata map[string]string) ([]*pfs.CommitInfo, error) {
 	var result []*pfs.CommitInfo
-	if err := d.listCommitF(pachClient, repo, to, from, number, reverse, func(ci *pfs.CommitInfo) error {
+	if err := d.listCommitF(pachClient, repo, to, from, number, reverse, metadata, func(ci *pfs.CommitInfo) error {
 		result = append(result, ci)
 		return nil
 	}); err != nil {
@@ -2029,8 +2107,10 @@ func (d *driver) listCommit(pachClient *client.APIClient, repo *pfs.Repo,
 	return result, nil
 }
 
+// listCommitF calls 'f' on the commits in 'repo' (between 'from' and 'to', if
+// set) whose metadata contains 'metadata', up to 'number' commits.
 func (d *driver) listCommitF(pachClient *client.APIClient, repo *pfs.Repo,
-	to *pfs.Commit, from *pfs.Commit, number uint64, reverse bool, f func(*pfs.CommitInfo) error) error {
+	to *pfs.Commit, from *pfs.Commit, number uint64, reverse bool, metadata map[string]string, f func(*pfs.CommitInfo) error) error {
 	// Validate arguments
 	if repo == nil {
 		return errors.New("repo cannot be nil")
@@ -2098,11 +2178,13 @@ func (d *driver) listCommitF(pachClient *client.APIClient, repo *pfs.Repo,
 				if number == 0 {
 					return errutil.ErrBreak
 				}
-				number--
-
 				if reverse {
 					ci = cis[len(cis)-1-i]
 				}
+				if !matchMetadata(ci.Metadata, metadata) {
+					continue
+				}
+				number--
 				if err := f(ci); err != nil {
 					return err
 				}
@@ -2140,13 +2222,16 @@ func (d *driver) listCommitF(pachClient *client.APIClient, repo *pfs.Repo,
 			if err := commits.Get(cursor.ID, &commitInfo); err != nil {
 				return err
 			}
+			cursor = commitInfo.ParentCommit
+			if !matchMetadata(commitInfo.Metadata, metadata) {
+				continue
+			}
 			if err := f(&commitInfo); err != nil {
 				if errors.Is(err, errutil.ErrBreak) {
 					return nil
 				}
 				return err
 			}
-			cursor = commitInfo.ParentCommit
 			number--
 		}
 	}
@@ -2154,7 +2239,7 @@ func (d *driver) listCommitF(pachClient *client.APIClient, repo *pfs.Repo,
 }
 
 func (d *driver) subscribeCommit(pachClient *client.APIClient, repo *pfs.Repo, branch string, prov *pfs.CommitProvenance,
-	from *pfs.Commit, state pfs.CommitState, f func(*pfs.CommitInfo) error) error {
+	from *pfs.Commit, state pfs.CommitState, metadata map[string]string, f func(*pfs.CommitInfo) error) error {
 	// Validate arguments
 	if repo == nil {
 		return errors.New("repo cannot be nil")
@@ -2221,6 +2306,11 @@ func (d *driver) subscribeCommit(pachClient *client.APIClient, repo *pfs.Repo, b
 				if err != nil {
 					return err
 				}
+				// Commits that don't match yet may match once their metadata is
+				// updated, so they aren't marked as seen
+				if !matchMetadata(commitInfo.Metadata, metadata) {
+					continue
+				}
 				if err := f(commitInfo); err != nil {
 					return err
 				}
@@ -2562,6 +2652,12 @@ func (d *driver) deleteCommit(txnCtx *txnenv.TransactionContext, userCommit *pfs
 			var branchInfo pfs.BranchInfo
 			if err := d.branches(brokenBranch.Repo.Name).ReadWrite(txnCtx.Stm).Update(brokenBranch.Name, &branchInfo, func() error {
 				prevHead := branchInfo.Head
+				if prevHead != nil && deleted[prevHead.ID] != nil {
+					// Moving the branch back to a surviving ancestor rewrites its history
+					if err := d.checkHeadMove(txnCtx, &branchInfo, nil); err != nil {
+						return err
+					}
+				}
 				for {
 					if branchInfo.Head == nil {
 						return nil // no commits left in branch
@@ -2650,7 +2746,7 @@ func (d *driver) resolveCommitProvenance(stm col.STM, userCommitProvenance *pfs.
 //
 // This invariant is assumed to hold for all branches upstream of 'branch', but not
 // for 'branch' itself once 'b.Provenance' has been set.
-func (d *driver) createBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Branch, commit *pfs.Commit, provenance []*pfs.Branch, trigger *pfs.Trigger) error {
+func (d *driver) createBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Branch, commit *pfs.Commit, provenance []*pfs.Branch, trigger *pfs.Trigger, metadata map[string]string) error {
 	// Validate arguments
 	if branch == nil {
 		return errors.New("branch cannot be nil")
@@ -2658,6 +2754,9 @@ func (d *driver) createBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Bra
 	if branch.Repo == nil {
 		return errors.New("branch repo cannot be nil")
 	}
+	if err := validateMetadata(metadata); err != nil {
+		return err
+	}
 	if err := d.validateTrigger(txnCtx, branch, trigger); err != nil {
 		return err
 	}
@@ -2717,6 +2816,9 @@ func (d *driver) createBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Bra
 	branches := d.branches(branch.Repo.Name).ReadWrite(txnCtx.Stm)
 	branchInfo := &pfs.BranchInfo{}
 	if err := branches.Upsert(branch.Name, branchInfo, func() error {
+		if err := d.checkHeadMove(txnCtx, branchInfo, commit); err != nil {
+			return err
+		}
 		branchInfo.Name = branch.Name // set in case 'branch' is new
 		branchInfo.Branch = branch
 		branchInfo.Head = commit
@@ -2730,6 +2832,7 @@ func (d *driver) createBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Bra
 		if trigger != nil && trigger.Branch != "" {
 			branchInfo.Trigger = trigger
 		}
+		branchInfo.Metadata = mergeMetadata(branchInfo.Metadata, metadata)
 		return nil
 	}); err != nil {
 		return err
@@ -2929,6 +3032,9 @@ func (d *driver) deleteBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Bra
 		}
 	}
 	if branchInfo.Branch != nil {
+		if err := checkBranchDelete(branchInfo); err != nil {
+			return err
+		}
 		if !force {
 			if len(branchInfo.Subvenance) > 0 {
 				return errors.Errorf("branch %s has %v as subvenance, deleting it would break those branches", branch.Name, branchInfo.Subvenance)
@@ -3035,10 +3141,13 @@ func (d *driver) putFiles(pachClient *client.APIClient, s *putFileServer) error
 		// a commit with no ID, that ID will be filled in with the head of
 		// branch (if it exists).
 		return d.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
-			_, err := d.makeCommit(txnCtx, "", client.NewCommit(repo, ""), branch, nil, nil, nil, nil, nil, putFilePaths, putFileRecords, "", time.Time{}, time.Time{}, 0)
+			_, err := d.makeCommit(txnCtx, "", client.NewCommit(repo, ""), branch, nil, nil, nil, nil, nil, putFilePaths, putFileRecords, "", nil, time.Time{}, time.Time{}, 0)
 			return err
 		})
 	}
+	if err := d.checkPutFileQuota(pachClient, files, putFileRecords); err != nil {
+		return err
+	}
 	for i, file := range files {
 		if err := d.upsertPutFileRecords(pachClient, file, putFileRecords[i]); err != nil {
 			return err
@@ -3058,6 +3167,9 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 	if hasPutFileOptions && delimiter == pfs.Delimiter_NONE {
 		return nil, errors.Errorf("cannot set split options--targetFileBytes, targetFileDatums, or headerRecords--with delimiter == NONE, split disabled")
 	}
+	if headerRecords != 0 && (delimiter == pfs.Delimiter_AVRO || delimiter == pfs.Delimiter_PARQUET) {
+		return nil, errors.Errorf("cannot set headerRecords with delimiter == %s, the header is read from the file's schema", delimiter)
+	}
 	records := &pfs.PutFileRecords{}
 	if del {
 		records.Tombstone = true
@@ -3111,16 +3223,20 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 			// Note: this code generally distinguishes between nil header/footer (no
 			// header) and empty header/footer. To create a header-enabled directory
 			// with an empty header, allocate an empty slice & store it here
-			header    []byte
-			footer    []byte
-			EOF       = false
-			eg        errgroup.Group
-			bufioR    = bufio.NewReader(reader)
-			decoder   = json.NewDecoder(bufioR)
-			sqlReader = sql.NewPGDumpReader(bufioR)
-			csvReader = csv.NewReader(bufioR)
-			csvBuffer bytes.Buffer
-			csvWriter = csv.NewWriter(&csvBuffer)
+			header     []byte
+			footer     []byte
+			EOF        = false
+			eg         errgroup.Group
+			bufioR     = bufio.NewReader(reader)
+			decoder    = json.NewDecoder(bufioR)
+			sqlReader  = sql.NewPGDumpReader(bufioR)
+			csvReader  = csv.NewReader(bufioR)
+			csvBuffer  bytes.Buffer
+			csvWriter  = csv.NewWriter(&csvBuffer)
+			avroReader = avro.NewReader(bufioR)
+			// parquetReader is created by the first read, as it consumes the
+			// whole file
+			parquetReader *parquet.Reader
 			// indexToRecord serves as a de-facto slice of PutFileRecords. We can't
 			// use a real slice of PutFileRecords b/c indexToRecord has data appended
 			// to it by concurrent processes, and you can't append() to a slice
@@ -3135,6 +3251,7 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 			var err error
 			var value []byte
 			var csvRow []string // only used if delimiter == CSV
+			var count int64 = 1 // the number of datums in 'value'
 			switch delimiter {
 			case pfs.Delimiter_JSON:
 				var jsonValue json.RawMessage
@@ -3165,6 +3282,27 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 					}
 					value = csvBuffer.Bytes()
 				}
+			case pfs.Delimiter_AVRO:
+				// each value is a block of records, which is valid after the
+				// file's header (which holds its schema)
+				value, count, err = avroReader.ReadBlock()
+				if errors.Is(err, io.EOF) {
+					header = avroReader.Header
+				}
+			case pfs.Delimiter_PARQUET:
+				// each value is a complete parquet file (minus its leading magic
+				// bytes) holding one or more row groups, which parquetReader
+				// groups according to targetFileDatums and targetFileBytes
+				if parquetReader == nil {
+					if parquetReader, err = parquet.NewReader(bufioR, targetFileDatums, targetFileBytes); err != nil {
+						return nil, err
+					}
+					defer parquetReader.Close()
+				}
+				value, count, err = parquetReader.Read()
+				if errors.Is(err, io.EOF) {
+					header = parquetReader.Header
+				}
 			default:
 				return nil, errors.Errorf("unrecognized delimiter %s", delimiter.String())
 			}
@@ -3177,16 +3315,18 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 			}
 			buffer.Write(value)
 			bytesWritten += int64(len(value))
-			datumsWritten++
+			datumsWritten += count
 			var (
 				headerDone         = headerRecords == 0 || header != nil
 				headerReady        = !headerDone && datumsWritten >= headerRecords
 				hitFileBytesLimit  = headerDone && targetFileBytes != 0 && bytesWritten >= targetFileBytes
 				hitFileDatumsLimit = headerDone && targetFileDatums != 0 && datumsWritten >= targetFileDatums
 				noLimitsSet        = headerDone && targetFileBytes == 0 && targetFileDatums == 0
+				// parquet files can't be concatenated, so each value is its own file
+				isWholeFile = headerDone && delimiter == pfs.Delimiter_PARQUET
 			)
 			if buffer.Len() != 0 &&
-				(headerReady || hitFileBytesLimit || hitFileDatumsLimit || noLimitsSet || EOF) {
+				(headerReady || hitFileBytesLimit || hitFileDatumsLimit || noLimitsSet || isWholeFile || EOF) {
 				_buffer := buffer
 				if !headerDone /* implies headerReady || EOF */ {
 					header = _buffer.Bytes() // record header
@@ -3468,7 +3608,7 @@ func (d *driver) copyFile(pachClient *client.APIClient, src *pfs.File, dst *pfs.
 	// dst is finished => all PutFileRecords are in 'records'--put in a new commit
 	if !dstIsOpenCommit {
 		return d.txnEnv.WithWriteContext(pachClient.Ctx(), func(txnCtx *txnenv.TransactionContext) error {
-			_, err = d.makeCommit(txnCtx, "", client.NewCommit(dst.Commit.Repo.Name, ""), branch, nil, nil, nil, nil, nil, paths, records, "", time.Time{}, time.Time{}, 0)
+			_, err = d.makeCommit(txnCtx, "", client.NewCommit(dst.Commit.Repo.Name, ""), branch, nil, nil, nil, nil, nil, paths, records, "", nil, time.Time{}, time.Time{}, 0)
 			return err
 		})
 	}
@@ -4322,7 +4462,7 @@ func (d *driver) deleteFile(pachClient *client.APIClient, file *pfs.File) error
 			return pfsserver.ErrCommitFinished{file.Commit}
 		}
 		return d.txnEnv.WithWriteContext(pachClient.Ctx(), func(txnCtx *txnenv.TransactionContext) error {
-			_, err := d.makeCommit(txnCtx, "", client.NewCommit(file.Commit.Repo.Name, ""), branch, nil, nil, nil, nil, nil, []string{file.Path}, []*pfs.PutFileRecords{&pfs.PutFileRecords{Tombstone: true}}, "", time.Time{}, time.Time{}, 0)
+			_, err := d.makeCommit(txnCtx, "", client.NewCommit(file.Commit.Repo.Name, ""), branch, nil, nil, nil, nil, nil, []string{file.Path}, []*pfs.PutFileRecords{&pfs.PutFileRecords{Tombstone: true}}, "", nil, time.Time{}, time.Time{}, 0)
 			return err
 		})
 	}
@@ -4360,7 +4500,7 @@ func (d *driver) upsertPutFileRecords(
 	}
 
 	ctx := pachClient.Ctx()
-	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+	err = d.backend.NewSTM(ctx, func(stm col.STM) error {
 		commitsCol := d.openCommits.ReadWrite(stm)
 		var commit pfs.Commit
 		err := commitsCol.Get(file.Commit.ID, &commit)
@@ -4372,6 +4512,9 @@ func (d *driver) upsertPutFileRecords(
 		if commit.ID != file.Commit.ID {
 			return errors.Errorf("commit %v is not open", file.Commit.ID)
 		}
+		if err := d.checkOpenCommitWrite(pachClient, stm, file.Commit); err != nil {
+			return err
+		}
 		recordsCol := d.putFileRecords.ReadWrite(stm)
 		var existingRecords pfs.PutFileRecords
 		return recordsCol.Upsert(prefix, &existingRecords, func() error {
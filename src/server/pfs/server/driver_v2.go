@@ -140,7 +140,7 @@ func (d *driverV2) finishCommitV2(txnCtx *txnenv.TransactionContext, commit *pfs
 		}
 		commitInfo.SizeBytes = uint64(compactRes.OutputSize)
 		commitInfo.Finished = types.TimestampNow()
-		return d.writeFinishedCommit(txnCtx.Stm, commit, commitInfo)
+		return d.writeFinishedCommit(txnCtx.Stm, commit, commitInfo, 0)
 	})
 }
 
@@ -175,7 +175,7 @@ func (d *driverV2) fileOperation(pachClient *client.APIClient, commit *pfs.Commi
 // TODO: Cleanup after failure?
 func (d *driverV2) oneOffFileOperation(ctx context.Context, repo, branch string, cb func(*fileset.UnorderedWriter) error) error {
 	return d.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) (retErr error) {
-		commit, err := d.startCommit(txnCtx, "", client.NewCommit(repo, ""), branch, nil, "")
+		commit, err := d.startCommit(txnCtx, "", client.NewCommit(repo, ""), branch, nil, "", nil)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,435 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/proto"
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/limit"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	"github.com/pachyderm/pachyderm/src/server/pkg/storage/chunk"
+)
+
+const (
+	// defaultFsckParallelism is the number of commits that a deep fsck checks
+	// concurrently if the request doesn't set a parallelism
+	defaultFsckParallelism = 10
+	// fsckCheckpointInterval is the number of commits that a deep fsck checks
+	// between checkpoints
+	fsckCheckpointInterval = 100
+	// fsckCacheSize is the number of objects and blocks whose state a deep fsck
+	// remembers, so that data shared by many commits is usually only checked
+	// once
+	fsckCacheSize = 100000
+)
+
+// fsckCheckpoint returns the checkpoint that a deep fsck sends once it has
+// checked every commit in 'repo' up to the one created at revision
+// 'createRev' (and every commit in the repos before it).
+func fsckCheckpoint(repo string, createRev int64) string {
+	return fmt.Sprintf("%s/%d", repo, createRev)
+}
+
+// parseFsckCheckpoint parses a checkpoint returned by fsckCheckpoint. An empty
+// checkpoint is before every commit.
+func parseFsckCheckpoint(checkpoint string) (string, int64, error) {
+	if checkpoint == "" {
+		return "", 0, nil
+	}
+	i := strings.LastIndex(checkpoint, "/")
+	if i < 0 {
+		return "", 0, errors.Errorf("invalid fsck checkpoint %q", checkpoint)
+	}
+	createRev, err := strconv.ParseInt(checkpoint[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, errors.Errorf("invalid fsck checkpoint %q", checkpoint)
+	}
+	return checkpoint[:i], createRev, nil
+}
+
+// dataChecker checks that objects and blocks (or, in storage v2, chunks)
+// exist in object storage and, optionally, that their content is intact
+type dataChecker struct {
+	ctx           context.Context
+	pachClient    *client.APIClient
+	objClient     obj.Client
+	chunks        *chunk.Storage
+	verifyContent bool
+	// cache maps objects and blocks to the problem found with them ("" if
+	// there was none)
+	cache *lru.Cache
+}
+
+// checkObject returns a description of the problem with 'object', or "" if it
+// is intact. If 'verifyContent' is set (and the checker verifies content),
+// the object's content is re-hashed and compared with its hash.
+func (c *dataChecker) checkObject(object *pfs.Object, verifyContent bool) (string, error) {
+	verifyContent = verifyContent && c.verifyContent
+	key := "object/" + object.Hash
+	if verifyContent {
+		key += "/verified"
+	}
+	if problem, ok := c.cache.Get(key); ok {
+		return problem.(string), nil
+	}
+	problem, err := func() (string, error) {
+		info, err := c.pachClient.InspectObject(object.Hash)
+		if err != nil {
+			if c.ctx.Err() != nil {
+				return "", c.ctx.Err()
+			}
+			return fmt.Sprintf("object %s could not be found: %v", object.Hash, err), nil
+		}
+		if !verifyContent {
+			problem, err := c.checkBlockRef(info.BlockRef, nil)
+			if err != nil || problem == "" {
+				return "", err
+			}
+			return fmt.Sprintf("object %s is missing: %s", object.Hash, problem), nil
+		}
+		hash := pfs.NewHash()
+		problem, err := c.checkBlockRef(info.BlockRef, hash)
+		if err != nil {
+			return "", err
+		}
+		if problem != "" {
+			return fmt.Sprintf("object %s is corrupt: %s", object.Hash, problem), nil
+		}
+		if actual := pfs.EncodeHash(hash.Sum(nil)); actual != object.Hash {
+			return fmt.Sprintf("object %s is corrupt: its content hashes to %s", object.Hash, actual), nil
+		}
+		return "", nil
+	}()
+	if err != nil {
+		return "", err
+	}
+	c.cache.Add(key, problem)
+	return problem, nil
+}
+
+// checkBlockRef returns a description of the problem with 'blockRef', or "" if
+// its block exists. If 'w' is set, the range of the block referenced by
+// 'blockRef' is also read into 'w', and it's a problem if the block is too
+// short to contain it.
+func (c *dataChecker) checkBlockRef(blockRef *pfs.BlockRef, w io.Writer) (string, error) {
+	path, err := BlockPathFromEnv(blockRef.Block)
+	if err != nil {
+		return "", err
+	}
+	var size uint64
+	if blockRef.Range != nil {
+		size = blockRef.Range.Upper - blockRef.Range.Lower
+	}
+	// An empty range can't be read (a size of 0 means "read to the end"), so
+	// only check that its block exists
+	if w == nil || size == 0 {
+		if !c.objClient.Exists(c.ctx, path) {
+			return fmt.Sprintf("block %s does not exist", blockRef.Block.Hash), nil
+		}
+		return "", nil
+	}
+	r, err := c.objClient.Reader(c.ctx, path, blockRef.Range.Lower, size)
+	if err != nil {
+		if c.objClient.IsNotExist(err) {
+			return fmt.Sprintf("block %s does not exist", blockRef.Block.Hash), nil
+		}
+		return "", err
+	}
+	defer r.Close()
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return "", err
+	}
+	if uint64(n) != size {
+		return fmt.Sprintf("block %s is truncated (read %d of the %d bytes in [%d, %d))",
+			blockRef.Block.Hash, n, size, blockRef.Range.Lower, blockRef.Range.Upper), nil
+	}
+	return "", nil
+}
+
+// checkBlockRefCached is like checkBlockRef, but caches its result
+func (c *dataChecker) checkBlockRefCached(blockRef *pfs.BlockRef) (string, error) {
+	key := "block/" + blockRef.Block.Hash
+	var w io.Writer
+	if c.verifyContent && blockRef.Range != nil {
+		key += fmt.Sprintf("/%d-%d", blockRef.Range.Lower, blockRef.Range.Upper)
+		w = ioutil.Discard
+	}
+	if problem, ok := c.cache.Get(key); ok {
+		return problem.(string), nil
+	}
+	problem, err := c.checkBlockRef(blockRef, w)
+	if err != nil {
+		return "", err
+	}
+	c.cache.Add(key, problem)
+	return problem, nil
+}
+
+// commitDataChecker checks the data referenced by a commit with a
+// dataChecker, calling 'onProblem' for each problem found (see
+// checkCommitData)
+type commitDataChecker func(c *dataChecker, commitInfo *pfs.CommitInfo, onProblem func(path, problem string) error) error
+
+// checkChunk returns a description of the problem with the chunk referenced
+// by 'chunkInfo', or "" if it is intact (see chunk.Storage.Check).
+func (c *dataChecker) checkChunk(chunkInfo *chunk.ChunkInfo) (string, error) {
+	key := "chunk/" + chunkInfo.Chunk.Hash
+	if c.verifyContent {
+		key += "/verified"
+	}
+	if problem, ok := c.cache.Get(key); ok {
+		return problem.(string), nil
+	}
+	problem := ""
+	if err := c.chunks.Check(c.ctx, chunkInfo, c.verifyContent); err != nil {
+		if c.ctx.Err() != nil {
+			return "", c.ctx.Err()
+		}
+		problem = err.Error()
+	}
+	c.cache.Add(key, problem)
+	return problem, nil
+}
+
+// deepFsck checks that the data referenced by every finished commit in pfs
+// exists in object storage and, if request.VerifyContent is set, that it's
+// intact (see checkCommits).
+func (d *driver) deepFsck(pachClient *client.APIClient, request *pfs.FsckRequest, cb func(*pfs.FsckResponse) error) error {
+	objClient, err := obj.NewClientFromSecret(d.storageRoot)
+	if err != nil {
+		return err
+	}
+	return d.checkCommits(pachClient, request, &dataChecker{objClient: objClient}, d.checkCommitData, cb)
+}
+
+// checkCommits checks every finished commit in pfs with 'check'. Problems are
+// sent to 'cb' as errors naming the affected commit and path. Repos are
+// checked in name order, and the commits in each repo in the order they were
+// created (skipping those at or before request.ResumeFrom). Commits are
+// streamed from the collection as they're checked, and checkpoints are sent
+// to 'cb' as the check progresses so that it can be resumed if it's
+// interrupted.
+func (d *driver) checkCommits(pachClient *client.APIClient, request *pfs.FsckRequest, checker *dataChecker, check commitDataChecker, cb func(*pfs.FsckResponse) error) error {
+	resumeRepo, resumeRev, err := parseFsckCheckpoint(request.ResumeFrom)
+	if err != nil {
+		return err
+	}
+	cache, err := lru.New(fsckCacheSize)
+	if err != nil {
+		return err
+	}
+	ctx := pachClient.Ctx()
+	var repos []string
+	if err := d.repos.ReadOnly(ctx).List(&pfs.RepoInfo{}, col.DefaultOptions, func(repoName string) error {
+		if repoName >= resumeRepo {
+			repos = append(repos, repoName)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	sort.Strings(repos)
+
+	// mu guards 'cb' and the checkpoint state below
+	var mu sync.Mutex
+	send := func(resp *pfs.FsckResponse) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return cb(resp)
+	}
+	// Commits may finish out of order, so the checkpoint is that of the last
+	// commit before the first one that hasn't finished. 'pending' holds the
+	// checkpoints of the commits started since then (the first of which is
+	// commit number 'first'), and 'done' which of them have finished.
+	var pending []string
+	var done []bool
+	first, sinceCheckpoint := 0, 0
+	lastCheckpoint := ""
+	start := func(checkpoint string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		pending = append(pending, checkpoint)
+		done = append(done, false)
+		return first + len(pending) - 1
+	}
+	finish := func(i int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		done[i-first] = true
+		for len(done) > 0 && done[0] {
+			lastCheckpoint = pending[0]
+			pending, done = pending[1:], done[1:]
+			first++
+			sinceCheckpoint++
+		}
+		if sinceCheckpoint >= fsckCheckpointInterval {
+			sinceCheckpoint = 0
+			return cb(&pfs.FsckResponse{Checkpoint: lastCheckpoint})
+		}
+		return nil
+	}
+
+	parallelism := request.Parallelism
+	if parallelism <= 0 {
+		parallelism = defaultFsckParallelism
+	}
+	limiter := limit.New(int(parallelism))
+	eg, ctx := errgroup.WithContext(ctx)
+	checker.ctx = ctx
+	checker.pachClient = pachClient.WithCtx(ctx)
+	checker.verifyContent = request.VerifyContent
+	checker.cache = cache
+	var listErr error
+	for _, repo := range repos {
+		repo := repo
+		commitInfo := &pfs.CommitInfo{}
+		listErr = d.commits(repo).ReadOnly(ctx).ListRev(commitInfo, &col.Options{etcd.SortByCreateRevision, etcd.SortAscend, false}, func(_ string, createRev int64) error {
+			if commitInfo.Finished == nil || (repo == resumeRepo && createRev <= resumeRev) {
+				return nil
+			}
+			commitInfo := proto.Clone(commitInfo).(*pfs.CommitInfo)
+			limiter.Acquire()
+			if ctx.Err() != nil {
+				limiter.Release()
+				return ctx.Err() // a check failed, so stop starting new ones
+			}
+			i := start(fsckCheckpoint(repo, createRev))
+			eg.Go(func() error {
+				defer limiter.Release()
+				if err := check(checker, commitInfo, func(path, problem string) error {
+					msg := fmt.Sprintf("data error in commit %s@%s", repo, commitInfo.Commit.ID)
+					if path != "" {
+						msg += fmt.Sprintf(" at %q", path)
+					}
+					return send(&pfs.FsckResponse{
+						Error:  msg + ": " + problem,
+						Commit: commitInfo.Commit,
+						Path:   path,
+					})
+				}); err != nil {
+					return err
+				}
+				return finish(i)
+			})
+			return nil
+		})
+		if listErr != nil {
+			break
+		}
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	if listErr != nil {
+		return listErr
+	}
+	if sinceCheckpoint > 0 {
+		return cb(&pfs.FsckResponse{Checkpoint: lastCheckpoint})
+	}
+	return nil
+}
+
+// checkCommitData checks the hashtrees of 'commitInfo' and all of the data
+// that they reference, calling 'onProblem' for each problem found
+func (d *driver) checkCommitData(c *dataChecker, commitInfo *pfs.CommitInfo, onProblem func(path, problem string) error) (retErr error) {
+	report := func(path, problem string, err error) error {
+		if err != nil {
+			return err
+		}
+		if problem != "" {
+			return onProblem(path, problem)
+		}
+		return nil
+	}
+	treesMissing := false
+	for _, object := range append([]*pfs.Object{commitInfo.Tree}, commitInfo.Trees...) {
+		if object == nil {
+			continue
+		}
+		problem, err := c.checkObject(object, false)
+		if err := report("", problem, err); err != nil {
+			return err
+		}
+		treesMissing = treesMissing || problem != ""
+	}
+	if commitInfo.Datums != nil {
+		problem, err := c.checkObject(commitInfo.Datums, false)
+		if err := report("", problem, err); err != nil {
+			return err
+		}
+	}
+	if treesMissing {
+		return nil // the commit's files can't be checked without its hashtrees
+	}
+
+	checkNode := func(path string, node *hashtree.NodeProto) error {
+		if node.FileNode != nil {
+			for _, object := range node.FileNode.Objects {
+				problem, err := c.checkObject(object, true)
+				if err := report(path, problem, err); err != nil {
+					return err
+				}
+			}
+			for _, blockRef := range node.FileNode.BlockRefs {
+				problem, err := c.checkBlockRefCached(blockRef)
+				if err := report(path, problem, err); err != nil {
+					return err
+				}
+			}
+		}
+		if node.DirNode != nil && node.DirNode.Shared != nil {
+			for _, object := range []*pfs.Object{node.DirNode.Shared.Header, node.DirNode.Shared.Footer} {
+				if object == nil {
+					continue
+				}
+				problem, err := c.checkObject(object, true)
+				if err := report(path, problem, err); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// Handle commits that use the old hashtree format.
+	if commitInfo.Tree != nil {
+		tree, err := hashtree.GetHashTreeObject(c.pachClient, d.storageRoot, commitInfo.Tree)
+		if err != nil {
+			return report("", fmt.Sprintf("hashtree %s could not be read: %v", commitInfo.Tree.Hash, err), c.ctx.Err())
+		}
+		defer destroyHashtree(tree)
+		return tree.Walk("/", checkNode)
+	}
+	// Handle commits that use the newer hashtree format.
+	if len(commitInfo.Trees) == 0 {
+		return nil
+	}
+	rs, err := d.getTrees(c.pachClient, commitInfo, "/")
+	if err != nil {
+		return report("", fmt.Sprintf("hashtrees could not be read: %v", err), c.ctx.Err())
+	}
+	defer func() {
+		for _, r := range rs {
+			if err := r.Close(); err != nil && retErr == nil {
+				retErr = err
+			}
+		}
+	}()
+	return hashtree.Walk(rs, "/", checkNode)
+}
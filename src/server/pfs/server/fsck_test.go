@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+)
+
+func TestCheckBlockRef(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsck")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv(PachRootEnvVar)
+	defer os.Unsetenv(obj.StorageBackendEnvVar)
+	require.NoError(t, os.Setenv(PachRootEnvVar, dir))
+	require.NoError(t, os.Setenv(obj.StorageBackendEnvVar, obj.Local))
+
+	objClient, err := obj.NewLocalClient(dir)
+	require.NoError(t, err)
+	block := &pfs.Block{Hash: "block"}
+	path, err := BlockPathFromEnv(block)
+	require.NoError(t, err)
+	w, err := objClient.Writer(context.Background(), path)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("0123456789"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	cache, err := lru.New(fsckCacheSize)
+	require.NoError(t, err)
+	c := &dataChecker{
+		ctx:           context.Background(),
+		objClient:     objClient,
+		verifyContent: true,
+		cache:         cache,
+	}
+	blockRef := func(hash string, lower, upper uint64) *pfs.BlockRef {
+		return &pfs.BlockRef{
+			Block: &pfs.Block{Hash: hash},
+			Range: &pfs.ByteRange{Lower: lower, Upper: upper},
+		}
+	}
+
+	problem, err := c.checkBlockRefCached(blockRef("block", 2, 10))
+	require.NoError(t, err)
+	require.Equal(t, "", problem)
+
+	problem, err = c.checkBlockRefCached(blockRef("block", 5, 15))
+	require.NoError(t, err)
+	require.True(t, strings.Contains(problem, "truncated"), problem)
+
+	problem, err = c.checkBlockRefCached(blockRef("missing", 0, 10))
+	require.NoError(t, err)
+	require.True(t, strings.Contains(problem, "does not exist"), problem)
+
+	// Without content verification, only the block's existence is checked
+	c.verifyContent = false
+	problem, err = c.checkBlockRefCached(blockRef("block", 5, 15))
+	require.NoError(t, err)
+	require.Equal(t, "", problem)
+}
+
+func TestFsckCheckpoint(t *testing.T) {
+	repo, createRev, err := parseFsckCheckpoint(fsckCheckpoint("images", 1042))
+	require.NoError(t, err)
+	require.Equal(t, "images", repo)
+	require.Equal(t, int64(1042), createRev)
+
+	repo, createRev, err = parseFsckCheckpoint("")
+	require.NoError(t, err)
+	require.Equal(t, "", repo)
+	require.Equal(t, int64(0), createRev)
+
+	_, _, err = parseFsckCheckpoint("images@9d56ac3d4fe94a6d8c4c4bd2b9e2c2b8")
+	require.YesError(t, err)
+}
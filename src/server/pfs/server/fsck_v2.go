@@ -0,0 +1,36 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/storage/chunk"
+)
+
+// deepFsck is deepFsck for storage V2, which checks the indexes of every
+// finished commit's file set and the chunks that they reference.
+func (d *driverV2) deepFsck(pachClient *client.APIClient, request *pfs.FsckRequest, cb func(*pfs.FsckResponse) error) error {
+	checker := &dataChecker{chunks: d.storage.ChunkStorage()}
+	return d.checkCommits(pachClient, request, checker, d.checkCommitDataV2, cb)
+}
+
+// checkCommitDataV2 checks the file set of 'commitInfo' and all of the chunks
+// that it references, calling 'onProblem' for each problem found
+func (d *driverV2) checkCommitDataV2(c *dataChecker, commitInfo *pfs.CommitInfo, onProblem func(path, problem string) error) error {
+	var cbErr error
+	if err := d.storage.WalkChunks(c.ctx, compactedCommitPath(commitInfo.Commit), func(path string, chunkInfo *chunk.ChunkInfo) error {
+		problem, err := c.checkChunk(chunkInfo)
+		if err == nil && problem != "" {
+			err = onProblem(path, problem)
+		}
+		cbErr = err
+		return err
+	}); err != nil {
+		if cbErr != nil || c.ctx.Err() != nil {
+			return err
+		}
+		return onProblem("", fmt.Sprintf("file set could not be read: %v", err))
+	}
+	return nil
+}
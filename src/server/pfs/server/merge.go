@@ -0,0 +1,441 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	globlib "github.com/pachyderm/ohmyglob"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	authserver "github.com/pachyderm/pachyderm/src/server/auth/server"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	ppath "github.com/pachyderm/pachyderm/src/server/pkg/path"
+	txnenv "github.com/pachyderm/pachyderm/src/server/pkg/transactionenv"
+)
+
+// mergeBranch merges the changes made on 'source' since its merge base with
+// 'target' into 'target', by adding a merge commit to 'target'. Paths that
+// were changed differently on both branches are resolved by 'resolutions' and
+// 'strategy'. If any of them is resolved with MergeStrategy_FAIL, no commit is
+// created and the response only lists the conflicts.
+func (d *driver) mergeBranch(pachClient *client.APIClient, source, target *pfs.Branch,
+	strategy pfs.MergeStrategy, resolutions []*pfs.MergeResolution, description string) (*pfs.MergeBranchResponse, error) {
+	resolve, err := newMergeResolver(strategy, resolutions)
+	if err != nil {
+		return nil, err
+	}
+	theirs, ours, base, err := d.mergeHeads(pachClient, source, target)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil && base.Commit.ID == theirs.Commit.ID {
+		// 'target' already contains every change on 'source'
+		return &pfs.MergeBranchResponse{Commit: ours.Commit}, nil
+	}
+
+	// Apply the changes on 'source' to the tree of 'target'
+	var baseCommit, oursCommit *pfs.Commit
+	if base != nil {
+		baseCommit = base.Commit
+	}
+	if ours != nil {
+		oursCommit = ours.Commit
+	}
+	baseTree, err := d.getTreeForFile(pachClient, &pfs.File{Commit: baseCommit})
+	if err != nil {
+		return nil, err
+	}
+	defer destroyHashtree(baseTree)
+	oursTree, err := d.getTreeForFile(pachClient, &pfs.File{Commit: oursCommit})
+	if err != nil {
+		return nil, err
+	}
+	defer destroyHashtree(oursTree)
+	theirsTree, err := d.getTreeForFile(pachClient, &pfs.File{Commit: theirs.Commit})
+	if err != nil {
+		return nil, err
+	}
+	defer destroyHashtree(theirsTree)
+	conflicts, err := mergeTrees(baseTree, oursTree, theirsTree, resolve)
+	if err != nil {
+		return nil, err
+	}
+	response := &pfs.MergeBranchResponse{}
+	unresolved := false
+	for _, c := range conflicts {
+		conflict := &pfs.MergeConflict{
+			Path:       c.path,
+			Resolution: c.resolution,
+		}
+		if c.ours != nil {
+			conflict.Ours = nodeToFileInfo(ours, c.path, c.ours, false)
+		}
+		if c.theirs != nil {
+			conflict.Theirs = nodeToFileInfo(theirs, c.path, c.theirs, false)
+		}
+		response.Conflicts = append(response.Conflicts, conflict)
+		unresolved = unresolved || c.resolution == pfs.MergeStrategy_FAIL
+	}
+	if unresolved {
+		return response, nil
+	}
+
+	// Create the merge commit
+	treeRef, err := hashtree.PutHashTree(pachClient, oursTree)
+	if err != nil {
+		return nil, err
+	}
+	if description == "" {
+		description = fmt.Sprintf("merge branch %s into %s", source.Name, target.Name)
+	}
+	if err := d.txnEnv.WithWriteContext(pachClient.Ctx(), func(txnCtx *txnenv.TransactionContext) error {
+		parent, err := d.mergeParent(txnCtx, target, oursCommit)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		commit, err := d.makeCommit(txnCtx, "", parent, target.Name, nil, nil, treeRef, nil, nil, nil, nil,
+			description, nil, now, now, uint64(oursTree.FSSize()))
+		if err != nil {
+			return err
+		}
+		if err := d.setMergedCommit(txnCtx, commit, theirs.Commit); err != nil {
+			return err
+		}
+		response.Commit = commit
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// mergeHeads validates a merge of 'source' into 'target', and returns the
+// heads of 'source' ('theirs') and 'target' ('ours', nil if 'target' has no
+// head), and their merge base (nil if they have no common ancestor).
+func (d *driver) mergeHeads(pachClient *client.APIClient, source, target *pfs.Branch) (theirs, ours, base *pfs.CommitInfo, _ error) {
+	// Validate arguments
+	if source == nil || target == nil {
+		return nil, nil, nil, errors.New("source and target branches cannot be nil")
+	}
+	if source.Repo == nil || target.Repo == nil {
+		return nil, nil, nil, errors.New("branch repo cannot be nil")
+	}
+	if source.Repo.Name != target.Repo.Name {
+		return nil, nil, nil, errors.Errorf("cannot merge branches of different repos (%s and %s)", source.Repo.Name, target.Repo.Name)
+	}
+	if source.Name == target.Name {
+		return nil, nil, nil, errors.Errorf("cannot merge branch %s into itself", source.Name)
+	}
+	if err := authserver.CheckIsAuthorized(pachClient, target.Repo, auth.Scope_WRITER); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Find the heads of both branches, and their merge base
+	if err := d.txnEnv.WithReadContext(pachClient.Ctx(), func(txnCtx *txnenv.TransactionContext) error {
+		sourceInfo, err := d.inspectBranch(txnCtx, source)
+		if err != nil {
+			return err
+		}
+		if sourceInfo.Head == nil {
+			return pfsserver.ErrNoHead{source}
+		}
+		targetInfo, err := d.inspectBranch(txnCtx, target)
+		if err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		if targetInfo != nil && len(targetInfo.Provenance) > 0 {
+			return errors.Errorf("cannot merge into branch %s, as it has provenance", target.Name)
+		}
+		if theirs, err = d.resolveCommit(txnCtx.Stm, sourceInfo.Head); err != nil {
+			return err
+		}
+		if theirs.Finished == nil {
+			return pfsserver.ErrCommitNotFinished{theirs.Commit}
+		}
+		if targetInfo == nil || targetInfo.Head == nil {
+			return nil
+		}
+		if ours, err = d.resolveCommit(txnCtx.Stm, targetInfo.Head); err != nil {
+			return err
+		}
+		if ours.Finished == nil {
+			return pfsserver.ErrCommitNotFinished{ours.Commit}
+		}
+		base, err = d.mergeBase(txnCtx.Stm, ours.Commit, theirs.Commit)
+		return err
+	}); err != nil {
+		return nil, nil, nil, err
+	}
+	return theirs, ours, base, nil
+}
+
+// mergeParent returns the parent of a merge commit on 'target', whose head
+// must still be 'ours' (which is nil if 'target' had no head).
+func (d *driver) mergeParent(txnCtx *txnenv.TransactionContext, target *pfs.Branch, ours *pfs.Commit) (*pfs.Commit, error) {
+	parent := client.NewCommit(target.Repo.Name, "")
+	targetInfo, err := d.inspectBranch(txnCtx, target)
+	if err != nil && !col.IsErrNotFound(err) {
+		return nil, err
+	}
+	var head *pfs.Commit
+	if targetInfo != nil {
+		head = targetInfo.Head
+	}
+	if (head == nil) != (ours == nil) || (head != nil && head.ID != ours.ID) {
+		return nil, errors.Errorf("branch %s was updated during the merge, please retry", target.Name)
+	}
+	if head != nil {
+		parent.ID = head.ID
+	}
+	return parent, nil
+}
+
+// setMergedCommit records that 'commit' is the merge of 'merged' into it.
+func (d *driver) setMergedCommit(txnCtx *txnenv.TransactionContext, commit, merged *pfs.Commit) error {
+	commitInfo := &pfs.CommitInfo{}
+	return d.commits(commit.Repo.Name).ReadWrite(txnCtx.Stm).Update(commit.ID, commitInfo, func() error {
+		commitInfo.MergedCommit = merged
+		return nil
+	})
+}
+
+// mergeBase returns the nearest commit that's an ancestor of both 'ours' and
+// 'theirs' (following both the parents and the merged commits of merge
+// commits), or nil if they have no common ancestor.
+func (d *driver) mergeBase(stm col.STM, ours, theirs *pfs.Commit) (*pfs.CommitInfo, error) {
+	commits := d.commits(ours.Repo.Name).ReadWrite(stm)
+	// walk visits every ancestor of 'commit' (including 'commit' itself),
+	// nearest first, until f returns true
+	walk := func(commit *pfs.Commit, f func(*pfs.CommitInfo) bool) error {
+		visited := make(map[string]bool)
+		queue := []*pfs.Commit{commit}
+		for len(queue) > 0 {
+			commit, queue = queue[0], queue[1:]
+			if visited[commit.ID] {
+				continue
+			}
+			visited[commit.ID] = true
+			commitInfo := &pfs.CommitInfo{}
+			if err := commits.Get(commit.ID, commitInfo); err != nil {
+				if col.IsErrNotFound(err) {
+					// A merged commit may since have been deleted (e.g. pruned
+					// by a retention policy)
+					continue
+				}
+				return err
+			}
+			if f(commitInfo) {
+				return nil
+			}
+			if commitInfo.ParentCommit != nil {
+				queue = append(queue, commitInfo.ParentCommit)
+			}
+			if commitInfo.MergedCommit != nil {
+				queue = append(queue, commitInfo.MergedCommit)
+			}
+		}
+		return nil
+	}
+	ancestors := make(map[string]bool)
+	if err := walk(ours, func(commitInfo *pfs.CommitInfo) bool {
+		ancestors[commitInfo.Commit.ID] = true
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	var base *pfs.CommitInfo
+	if err := walk(theirs, func(commitInfo *pfs.CommitInfo) bool {
+		if ancestors[commitInfo.Commit.ID] {
+			base = commitInfo
+			return true
+		}
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	return base, nil
+}
+
+// newMergeResolver returns a function that returns the strategy used to
+// resolve a conflict at a given path: that of the first resolution whose glob
+// matches the path, or 'strategy' if none do.
+func newMergeResolver(strategy pfs.MergeStrategy, resolutions []*pfs.MergeResolution) (func(string) pfs.MergeStrategy, error) {
+	var globs []*globlib.Glob
+	for _, r := range resolutions {
+		g, err := globlib.Compile(ppath.Clean(r.Glob), '/')
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid merge resolution glob %q", r.Glob)
+		}
+		globs = append(globs, g)
+	}
+	return func(p string) pfs.MergeStrategy {
+		for i, g := range globs {
+			if g.Match(ppath.Clean(p)) {
+				return resolutions[i].Strategy
+			}
+		}
+		return strategy
+	}, nil
+}
+
+// mergeConflict is a path that was changed differently in two trees. 'ours'
+// and 'theirs' are the path's nodes in each tree (nil if it was deleted).
+type mergeConflict struct {
+	path         string
+	ours, theirs *hashtree.NodeProto
+	resolution   pfs.MergeStrategy
+}
+
+// mergeTrees applies the changes made to files in 'theirs' since 'base' to
+// 'ours', which is modified in place. It returns the conflicts: paths that
+// were also changed in 'ours' (in a different way), or that can't be written
+// in 'ours' because a file became a directory or vice versa. Each conflict is
+// resolved with the strategy returned by 'resolve'; the change from 'theirs'
+// is only applied if that's MergeStrategy_THEIRS.
+func mergeTrees(base, ours, theirs hashtree.HashTree, resolve func(string) pfs.MergeStrategy) ([]*mergeConflict, error) {
+	oursChanges, err := treeChanges(base, ours)
+	if err != nil {
+		return nil, err
+	}
+	theirsChanges, err := treeChanges(base, theirs)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for p := range theirsChanges {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	var conflicts []*mergeConflict
+	for _, p := range paths {
+		theirsNode := theirsChanges[p]
+		oursNode, changed := oursChanges[p]
+		conflict := changed && !sameNode(oursNode, theirsNode)
+		if !changed && theirsNode != nil {
+			// 'ours' may have a directory at 'p', or a file at one of its parents
+			for dir := p; dir != "/" && !conflict; dir = path.Dir(dir) {
+				node, err := getNode(ours, dir)
+				if err != nil {
+					return nil, err
+				}
+				if node == nil {
+					continue
+				}
+				if (dir == p && node.DirNode != nil) || (dir != p && node.FileNode != nil) {
+					conflict, oursNode = true, node
+				}
+			}
+		}
+		if conflict {
+			c := &mergeConflict{
+				path:       p,
+				ours:       oursNode,
+				theirs:     theirsNode,
+				resolution: resolve(p),
+			}
+			conflicts = append(conflicts, c)
+			if c.resolution != pfs.MergeStrategy_THEIRS {
+				continue
+			}
+		}
+		if err := copyNode(theirs, ours, p, theirsNode); err != nil {
+			return nil, err
+		}
+	}
+	if err := ours.Hash(); err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// treeChanges returns the files that are different in 'tree' and 'base',
+// mapped to their node in 'tree' (or nil if they don't exist in 'tree').
+func treeChanges(base, tree hashtree.HashTree) (map[string]*hashtree.NodeProto, error) {
+	changes := make(map[string]*hashtree.NodeProto)
+	if err := tree.Diff(base, "/", "/", -1, func(p string, node *hashtree.NodeProto, isNew bool) error {
+		if node.FileNode == nil {
+			return nil
+		}
+		// Diff visits the new version of a path before the old one
+		if isNew {
+			changes[p] = node
+		} else if _, ok := changes[p]; !ok {
+			changes[p] = nil
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func sameNode(a, b *hashtree.NodeProto) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return bytes.Equal(a.Hash, b.Hash)
+}
+
+// getNode returns the node at 'p' in 'tree', or nil if there isn't one.
+func getNode(tree hashtree.HashTree, p string) (*hashtree.NodeProto, error) {
+	node, err := tree.Get(p)
+	if hashtree.Code(err) == hashtree.PathNotFound {
+		return nil, nil
+	}
+	return node, err
+}
+
+// copyNode replaces whatever is at 'p' in 'dst' (and any file at one of its
+// parents) with 'node', which is the file at 'p' in 'src', or deletes it if
+// 'node' is nil.
+func copyNode(src, dst hashtree.HashTree, p string, node *hashtree.NodeProto) error {
+	existing, err := getNode(dst, p)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if err := dst.DeleteFile(p); err != nil {
+			return err
+		}
+	}
+	if node == nil {
+		return nil
+	}
+	for dir := path.Dir(p); dir != "/"; dir = path.Dir(dir) {
+		existing, err := getNode(dst, dir)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.FileNode != nil {
+			if err := dst.DeleteFile(dir); err != nil {
+				return err
+			}
+		}
+	}
+	if !node.FileNode.HasHeaderFooter {
+		if len(node.FileNode.BlockRefs) > 0 {
+			return dst.PutFileBlockRefs(p, node.FileNode.BlockRefs, node.SubtreeSize)
+		}
+		return dst.PutFile(p, node.FileNode.Objects, node.SubtreeSize)
+	}
+	// The file's header and footer are stored in its parent directory
+	parent, err := src.Get(path.Dir(p))
+	if err != nil {
+		return err
+	}
+	if shared := parent.DirNode.Shared; shared != nil {
+		if err := dst.PutDirHeaderFooter(path.Dir(p), shared.Header, shared.Footer, shared.HeaderSize, shared.FooterSize); err != nil {
+			return err
+		}
+	}
+	return dst.PutFileHeaderFooter(p, node.FileNode.Objects, node.SubtreeSize)
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+)
+
+// newMergeTree returns a hashtree containing 'files', each of which is
+// stored in a single object whose hash is the file's content.
+func newMergeTree(t *testing.T, files map[string]string) hashtree.HashTree {
+	tree, err := hashtree.NewDBHashTree("")
+	require.NoError(t, err)
+	for path, content := range files {
+		require.NoError(t, tree.PutFile(path, []*pfs.Object{{Hash: content}}, int64(len(content))))
+	}
+	require.NoError(t, tree.Hash())
+	return tree
+}
+
+func requireTreeFiles(t *testing.T, expected map[string]string, tree hashtree.HashTree) {
+	t.Helper()
+	actual := make(map[string]string)
+	require.NoError(t, tree.Walk("/", func(path string, node *hashtree.NodeProto) error {
+		if node.FileNode != nil {
+			actual[path] = node.FileNode.Objects[0].Hash
+		}
+		return nil
+	}))
+	require.Equal(t, expected, actual)
+}
+
+func mergeConflictPaths(conflicts []*mergeConflict) []string {
+	var paths []string
+	for _, c := range conflicts {
+		paths = append(paths, c.path)
+	}
+	return paths
+}
+
+func TestMergeTrees(t *testing.T) {
+	base := map[string]string{"/a": "a", "/b": "b", "/c": "c", "/dir/d": "d"}
+	newTrees := func() (hashtree.HashTree, hashtree.HashTree, hashtree.HashTree) {
+		return newMergeTree(t, base),
+			// ours modifies /a, deletes /c, and modifies /dir/d
+			newMergeTree(t, map[string]string{"/a": "a2", "/b": "b", "/dir/d": "d2"}),
+			// theirs modifies /b, deletes /c, modifies /dir/d differently, and adds /e
+			newMergeTree(t, map[string]string{"/a": "a", "/b": "b2", "/dir/d": "d3", "/e": "e"})
+	}
+	resolver := func(strategy pfs.MergeStrategy) func(string) pfs.MergeStrategy {
+		return func(string) pfs.MergeStrategy { return strategy }
+	}
+
+	for _, strategy := range []pfs.MergeStrategy{pfs.MergeStrategy_FAIL, pfs.MergeStrategy_OURS} {
+		b, ours, theirs := newTrees()
+		conflicts, err := mergeTrees(b, ours, theirs, resolver(strategy))
+		require.NoError(t, err)
+		require.Equal(t, []string{"/dir/d"}, mergeConflictPaths(conflicts))
+		require.Equal(t, strategy, conflicts[0].resolution)
+		requireTreeFiles(t, map[string]string{"/a": "a2", "/b": "b2", "/dir/d": "d2", "/e": "e"}, ours)
+	}
+
+	b, ours, theirs := newTrees()
+	conflicts, err := mergeTrees(b, ours, theirs, resolver(pfs.MergeStrategy_THEIRS))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/dir/d"}, mergeConflictPaths(conflicts))
+	requireTreeFiles(t, map[string]string{"/a": "a2", "/b": "b2", "/dir/d": "d3", "/e": "e"}, ours)
+}
+
+func TestMergeTreesIdenticalChanges(t *testing.T) {
+	base := newMergeTree(t, map[string]string{"/a": "a", "/b": "b"})
+	ours := newMergeTree(t, map[string]string{"/a": "a2", "/c": "c"})
+	theirs := newMergeTree(t, map[string]string{"/a": "a2", "/c": "c"})
+	conflicts, err := mergeTrees(base, ours, theirs, func(string) pfs.MergeStrategy { return pfs.MergeStrategy_FAIL })
+	require.NoError(t, err)
+	require.Equal(t, 0, len(conflicts))
+	requireTreeFiles(t, map[string]string{"/a": "a2", "/c": "c"}, ours)
+}
+
+func TestMergeTreesFileDirectoryConflict(t *testing.T) {
+	base := newMergeTree(t, map[string]string{"/dir/a": "a"})
+	// ours replaces the directory with a file, theirs adds a file to it
+	ours := newMergeTree(t, map[string]string{"/dir": "dir"})
+	theirs := newMergeTree(t, map[string]string{"/dir/a": "a", "/dir/b": "b"})
+	conflicts, err := mergeTrees(base, ours, theirs, func(string) pfs.MergeStrategy { return pfs.MergeStrategy_FAIL })
+	require.NoError(t, err)
+	require.Equal(t, []string{"/dir/b"}, mergeConflictPaths(conflicts))
+	require.Equal(t, "dir", conflicts[0].ours.FileNode.Objects[0].Hash)
+
+	ours = newMergeTree(t, map[string]string{"/dir": "dir"})
+	conflicts, err = mergeTrees(base, ours, theirs, func(string) pfs.MergeStrategy { return pfs.MergeStrategy_THEIRS })
+	require.NoError(t, err)
+	require.Equal(t, 1, len(conflicts))
+	requireTreeFiles(t, map[string]string{"/dir/b": "b"}, ours)
+}
+
+func TestMergeResolver(t *testing.T) {
+	resolve, err := newMergeResolver(pfs.MergeStrategy_FAIL, []*pfs.MergeResolution{
+		{Glob: "/labels/*", Strategy: pfs.MergeStrategy_THEIRS},
+		{Glob: "/**.csv", Strategy: pfs.MergeStrategy_OURS},
+	})
+	require.NoError(t, err)
+	require.Equal(t, pfs.MergeStrategy_THEIRS, resolve("/labels/a.csv"))
+	require.Equal(t, pfs.MergeStrategy_OURS, resolve("/data/a.csv"))
+	require.Equal(t, pfs.MergeStrategy_FAIL, resolve("/data/a.json"))
+
+	_, err = newMergeResolver(pfs.MergeStrategy_FAIL, []*pfs.MergeResolution{{Glob: "/["}})
+	require.YesError(t, err)
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	glob "github.com/pachyderm/ohmyglob"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/storage/fileset"
+	txnenv "github.com/pachyderm/pachyderm/src/server/pkg/transactionenv"
+)
+
+// mergeBranchV2 is mergeBranch for storage V2. The changes on each branch
+// since their merge base are found with diffFileV2, and the changes on
+// 'source' are written to the merge commit on top of the head of 'target'.
+func (d *driverV2) mergeBranchV2(pachClient *client.APIClient, source, target *pfs.Branch,
+	strategy pfs.MergeStrategy, resolutions []*pfs.MergeResolution, description string) (*pfs.MergeBranchResponse, error) {
+	resolve, err := newMergeResolver(strategy, resolutions)
+	if err != nil {
+		return nil, err
+	}
+	theirs, ours, base, err := d.mergeHeads(pachClient, source, target)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil && base.Commit.ID == theirs.Commit.ID {
+		// 'target' already contains every change on 'source'
+		return &pfs.MergeBranchResponse{Commit: ours.Commit}, nil
+	}
+
+	// Find the changes on both branches, and which of theirs conflict
+	var baseCommit, oursCommit *pfs.Commit
+	if base != nil {
+		baseCommit = base.Commit
+	}
+	if ours != nil {
+		oursCommit = ours.Commit
+	}
+	oursChanges := make(map[string]*pfs.FileInfo)
+	if oursCommit != nil {
+		if oursChanges, err = d.fileChanges(pachClient, baseCommit, oursCommit); err != nil {
+			return nil, err
+		}
+	}
+	theirsChanges, err := d.fileChanges(pachClient, baseCommit, theirs.Commit)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for p := range theirsChanges {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	response := &pfs.MergeBranchResponse{}
+	unresolved := false
+	var puts, deletes []string
+	for _, p := range paths {
+		theirsFi := theirsChanges[p]
+		oursFi, changed := oursChanges[p]
+		conflict := changed && !sameFileInfo(oursFi, theirsFi)
+		if !changed && theirsFi != nil && oursCommit != nil {
+			// A file can't be written over a directory, or under a file
+			if oursFi, err = d.mergeObstruction(pachClient, oursCommit, p); err != nil {
+				return nil, err
+			}
+			conflict = oursFi != nil
+		}
+		if conflict {
+			resolution := resolve(p)
+			response.Conflicts = append(response.Conflicts, &pfs.MergeConflict{
+				Path:       p,
+				Resolution: resolution,
+				Ours:       oursFi,
+				Theirs:     theirsFi,
+			})
+			unresolved = unresolved || resolution == pfs.MergeStrategy_FAIL
+			if resolution != pfs.MergeStrategy_THEIRS {
+				continue
+			}
+			if oursFi != nil && oursFi.File.Path != p {
+				deletes = append(deletes, obstructionPath(oursFi))
+			}
+		}
+		if theirsFi == nil {
+			deletes = append(deletes, p)
+		} else {
+			puts = append(puts, p)
+		}
+	}
+	if unresolved {
+		return response, nil
+	}
+
+	// Create the merge commit
+	if description == "" {
+		description = fmt.Sprintf("merge branch %s into %s", source.Name, target.Name)
+	}
+	if err := d.txnEnv.WithWriteContext(pachClient.Ctx(), func(txnCtx *txnenv.TransactionContext) error {
+		parent, err := d.mergeParent(txnCtx, target, oursCommit)
+		if err != nil {
+			return err
+		}
+		commit, err := d.startCommit(txnCtx, "", parent, target.Name, nil, description, nil)
+		if err != nil {
+			return err
+		}
+		if err := d.withCommitWriter(txnCtx.ClientContext, commit, func(uw *fileset.UnorderedWriter) error {
+			for _, p := range deletes {
+				uw.Delete(p)
+			}
+			for _, p := range puts {
+				if err := d.putMergedFile(pachClient, theirs.Commit, p, uw); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := d.finishCommitV2(txnCtx, commit, ""); err != nil {
+			return err
+		}
+		if err := d.setMergedCommit(txnCtx, commit, theirs.Commit); err != nil {
+			return err
+		}
+		response.Commit = commit
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// fileChanges returns the files that differ between 'base' (which may be nil)
+// and 'commit', mapped to their info in 'commit', or to nil if they were
+// deleted.
+func (d *driverV2) fileChanges(pachClient *client.APIClient, base, commit *pfs.Commit) (map[string]*pfs.FileInfo, error) {
+	changes := make(map[string]*pfs.FileInfo)
+	if err := d.diffFileV2(pachClient, &pfs.File{Commit: base, Path: "/"}, &pfs.File{Commit: commit, Path: "/"}, func(oldFi, newFi *pfs.FileInfo) error {
+		if newFi != nil && newFi.FileType == pfs.FileType_FILE {
+			changes[newFi.File.Path] = newFi
+		} else if oldFi != nil && oldFi.FileType == pfs.FileType_FILE {
+			changes[oldFi.File.Path] = nil
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// mergeObstruction returns the directory at 'p' in 'commit', or the file at
+// one of the parents of 'p', which would prevent a file being merged at 'p',
+// or nil if there's neither.
+func (d *driverV2) mergeObstruction(pachClient *client.APIClient, commit *pfs.Commit, p string) (*pfs.FileInfo, error) {
+	for dir := p; dir != "/" && dir != "."; dir = path.Dir(dir) {
+		fi, err := d.inspectFile(pachClient, &pfs.File{Commit: commit, Path: dir})
+		if err != nil {
+			if pfsserver.IsFileNotFoundErr(err) {
+				continue
+			}
+			return nil, err
+		}
+		if (dir == p) == (fi.FileType == pfs.FileType_DIR) {
+			return fi, nil
+		}
+	}
+	return nil, nil
+}
+
+// obstructionPath returns the path that deletes the obstruction 'fi' (see
+// mergeObstruction). Directories must be deleted with a trailing slash.
+func obstructionPath(fi *pfs.FileInfo) string {
+	p := path.Clean(fi.File.Path)
+	if fi.FileType == pfs.FileType_DIR {
+		p += "/"
+	}
+	return p
+}
+
+// putMergedFile writes the file at 'p' in 'commit' to 'uw', overwriting the
+// file at 'p' in the merge commit.
+func (d *driverV2) putMergedFile(pachClient *client.APIClient, commit *pfs.Commit, p string, uw *fileset.UnorderedWriter) error {
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		pw.CloseWithError(d.getTar(pachClient, commit, glob.QuoteMeta(p), pw))
+	}()
+	return uw.Put(pr, true)
+}
+
+// sameFileInfo returns true if 'a' and 'b' are the same change to a file
+// (either of them may be nil, if the file was deleted).
+func sameFileInfo(a, b *pfs.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return bytes.Equal(a.Hash, b.Hash)
+}
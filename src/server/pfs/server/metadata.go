@@ -0,0 +1,133 @@
+package server
+
+import (
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	authserver "github.com/pachyderm/pachyderm/src/server/auth/server"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	txnenv "github.com/pachyderm/pachyderm/src/server/pkg/transactionenv"
+)
+
+// mergeMetadata returns 'metadata' with the key/value pairs in 'update' added
+// to it. Keys whose value in 'update' is empty are removed.
+func mergeMetadata(metadata map[string]string, update map[string]string) map[string]string {
+	if len(update) == 0 {
+		return metadata
+	}
+	result := make(map[string]string)
+	for k, v := range metadata {
+		result[k] = v
+	}
+	for k, v := range update {
+		if v == "" {
+			delete(result, k)
+		} else {
+			result[k] = v
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// matchMetadata returns true if 'metadata' contains every key/value pair in
+// 'filter'
+func matchMetadata(metadata map[string]string, filter map[string]string) bool {
+	for k, v := range filter {
+		if value, ok := metadata[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+func validateMetadata(metadata map[string]string) error {
+	for k := range metadata {
+		if k == "" {
+			return errors.New("metadata keys cannot be empty")
+		}
+	}
+	return nil
+}
+
+// setMetadata merges 'metadata' into the metadata of exactly one of 'repo',
+// 'branch' or 'commit'.
+func (d *driver) setMetadata(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, branch *pfs.Branch, commit *pfs.Commit, metadata map[string]string) error {
+	var targets int
+	for _, set := range []bool{repo != nil, branch != nil, commit != nil} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return errors.New("exactly one of repo, branch or commit must be set")
+	}
+	switch {
+	case repo != nil:
+		return d.setRepoMetadata(txnCtx, repo, metadata)
+	case branch != nil:
+		return d.setBranchMetadata(txnCtx, branch, metadata)
+	default:
+		return d.setCommitMetadata(txnCtx, commit, metadata)
+	}
+}
+
+func (d *driver) setRepoMetadata(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, metadata map[string]string) error {
+	if err := validateMetadata(metadata); err != nil {
+		return err
+	}
+	if err := authserver.CheckIsAuthorizedInTransaction(txnCtx, repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadWrite(txnCtx.Stm).Update(repo.Name, repoInfo, func() error {
+		repoInfo.Metadata = mergeMetadata(repoInfo.Metadata, metadata)
+		return nil
+	}); err != nil {
+		if col.IsErrNotFound(err) {
+			return pfsserver.ErrRepoNotFound{Repo: repo}
+		}
+		return err
+	}
+	return nil
+}
+
+func (d *driver) setBranchMetadata(txnCtx *txnenv.TransactionContext, branch *pfs.Branch, metadata map[string]string) error {
+	if branch.Repo == nil {
+		return errors.New("branch repo cannot be nil")
+	}
+	if err := validateMetadata(metadata); err != nil {
+		return err
+	}
+	if err := authserver.CheckIsAuthorizedInTransaction(txnCtx, branch.Repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	branchInfo := &pfs.BranchInfo{}
+	return d.branches(branch.Repo.Name).ReadWrite(txnCtx.Stm).Update(branch.Name, branchInfo, func() error {
+		branchInfo.Metadata = mergeMetadata(branchInfo.Metadata, metadata)
+		return nil
+	})
+}
+
+// setCommitMetadata merges 'metadata' into the metadata of 'commit', which
+// may be open or finished.
+func (d *driver) setCommitMetadata(txnCtx *txnenv.TransactionContext, commit *pfs.Commit, metadata map[string]string) error {
+	if commit.Repo == nil {
+		return errors.New("commit repo cannot be nil")
+	}
+	if err := validateMetadata(metadata); err != nil {
+		return err
+	}
+	if err := authserver.CheckIsAuthorizedInTransaction(txnCtx, commit.Repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	commitInfo, err := d.resolveCommit(txnCtx.Stm, commit)
+	if err != nil {
+		return err
+	}
+	commitInfo.Metadata = mergeMetadata(commitInfo.Metadata, metadata)
+	return d.commits(commit.Repo.Name).ReadWrite(txnCtx.Stm).Put(commitInfo.Commit.ID, commitInfo)
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	metadata := map[string]string{"a": "1", "b": "2"}
+	require.Equal(t, metadata, mergeMetadata(metadata, nil))
+	require.Equal(t, map[string]string{"a": "1", "b": "3", "c": "4"}, mergeMetadata(metadata, map[string]string{"b": "3", "c": "4"}))
+	require.Equal(t, map[string]string{"b": "2"}, mergeMetadata(metadata, map[string]string{"a": ""}))
+	// The original map isn't modified
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, metadata)
+	require.Nil(t, mergeMetadata(metadata, map[string]string{"a": "", "b": ""}))
+	require.Nil(t, mergeMetadata(nil, map[string]string{"a": ""}))
+}
+
+func TestMatchMetadata(t *testing.T) {
+	metadata := map[string]string{"a": "1", "b": "2"}
+	require.True(t, matchMetadata(metadata, nil))
+	require.True(t, matchMetadata(nil, nil))
+	require.True(t, matchMetadata(metadata, map[string]string{"a": "1"}))
+	require.True(t, matchMetadata(metadata, map[string]string{"a": "1", "b": "2"}))
+	require.False(t, matchMetadata(metadata, map[string]string{"a": "2"}))
+	require.False(t, matchMetadata(metadata, map[string]string{"c": "1"}))
+	require.False(t, matchMetadata(nil, map[string]string{"a": "1"}))
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	authserver "github.com/pachyderm/pachyderm/src/server/auth/server"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	txnenv "github.com/pachyderm/pachyderm/src/server/pkg/transactionenv"
+)
+
+// whoAmI returns the subject making the request in 'ctx', or "" if auth isn't
+// active
+func whoAmI(pachClient *client.APIClient, ctx context.Context) (string, error) {
+	resp, err := pachClient.WhoAmI(ctx, &auth.WhoAmIRequest{})
+	if err != nil {
+		if auth.IsErrNotActivated(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return resp.Username, nil
+}
+
+func (d *driver) setBranchProtection(txnCtx *txnenv.TransactionContext, branch *pfs.Branch, protection *pfs.BranchProtection) error {
+	if branch == nil {
+		return errors.New("branch cannot be nil")
+	}
+	if branch.Repo == nil {
+		return errors.New("branch repo cannot be nil")
+	}
+	if protection != nil {
+		for _, principal := range protection.AllowedCommitters {
+			if principal == "" {
+				return errors.New("allowed committers cannot be empty")
+			}
+		}
+	}
+	// Protection rules restrict what WRITERs can do, so only OWNERs can change
+	// them
+	if err := authserver.CheckIsAuthorizedInTransaction(txnCtx, branch.Repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	branchInfo := &pfs.BranchInfo{}
+	return d.branches(branch.Repo.Name).ReadWrite(txnCtx.Stm).Update(branch.Name, branchInfo, func() error {
+		branchInfo.Protection = protection
+		return nil
+	})
+}
+
+func branchProtectedErr(branchInfo *pfs.BranchInfo, subject, rule, reason string) error {
+	return &auth.ErrBranchProtected{
+		Subject: subject,
+		Repo:    branchInfo.Branch.Repo.Name,
+		Branch:  branchInfo.Branch.Name,
+		Rule:    rule,
+		Reason:  reason,
+	}
+}
+
+// checkBranchDelete returns an error if 'branchInfo' may not be deleted
+func checkBranchDelete(branchInfo *pfs.BranchInfo) error {
+	if branchInfo.Protection != nil && branchInfo.Protection.NoDelete {
+		return branchProtectedErr(branchInfo, "", "no_delete", "it cannot be deleted")
+	}
+	return nil
+}
+
+// checkCommitter returns an error if 'subject' may not commit to
+// 'branchInfo'. 'pipelineCommit' indicates that the commit is being created
+// for a pipeline's job, rather than by a user.
+func checkCommitter(branchInfo *pfs.BranchInfo, subject string, pipelineCommit bool) error {
+	protection := branchInfo.Protection
+	// 'pipeline_only' and 'allowed_committers' can only be enforced if we know
+	// who the caller is (i.e. auth is active)
+	if protection == nil || pipelineCommit || subject == "" {
+		return nil
+	}
+	if protection.PipelineOnly && !strings.HasPrefix(subject, auth.PipelinePrefix) {
+		return branchProtectedErr(branchInfo, subject, "pipeline_only", "only pipelines may commit to it")
+	}
+	if len(protection.AllowedCommitters) > 0 {
+		for _, principal := range protection.AllowedCommitters {
+			if principal == subject {
+				return nil
+			}
+		}
+		return branchProtectedErr(branchInfo, subject, "allowed_committers",
+			fmt.Sprintf("only %s may commit to it", strings.Join(protection.AllowedCommitters, ", ")))
+	}
+	return nil
+}
+
+// checkCommitAllowed is like checkCommitter, but looks up the caller in
+// 'txnCtx' if 'branchInfo' is protected
+func (d *driver) checkCommitAllowed(txnCtx *txnenv.TransactionContext, branchInfo *pfs.BranchInfo, pipelineCommit bool) error {
+	if branchInfo.Protection == nil {
+		return nil
+	}
+	subject, err := whoAmI(txnCtx.Client, txnCtx.ClientContext)
+	if err != nil {
+		return err
+	}
+	return checkCommitter(branchInfo, subject, pipelineCommit)
+}
+
+// checkHeadMove returns an error if the caller in 'txnCtx' may not move the
+// HEAD of 'branchInfo' to 'newHead' (which may be nil, and must have been
+// resolved to a commit ID)
+func (d *driver) checkHeadMove(txnCtx *txnenv.TransactionContext, branchInfo *pfs.BranchInfo, newHead *pfs.Commit) error {
+	if branchInfo.Protection == nil {
+		return nil
+	}
+	oldHead := branchInfo.Head
+	if (oldHead == nil && newHead == nil) ||
+		(oldHead != nil && newHead != nil && oldHead.ID == newHead.ID) {
+		return nil // HEAD isn't moving
+	}
+	if err := d.checkCommitAllowed(txnCtx, branchInfo, false); err != nil {
+		return err
+	}
+	if !branchInfo.Protection.FastForwardOnly || oldHead == nil {
+		return nil
+	}
+	if newHead != nil {
+		isDescendant, err := d.isAncestor(txnCtx, oldHead, newHead)
+		if err != nil {
+			return err
+		}
+		if isDescendant {
+			return nil
+		}
+	}
+	return branchProtectedErr(branchInfo, "", "fast_forward_only",
+		fmt.Sprintf("its HEAD can only move to descendants of %s", oldHead.ID))
+}
+
+// isAncestor returns true if 'ancestor' is 'commit' or one of its ancestors
+func (d *driver) isAncestor(txnCtx *txnenv.TransactionContext, ancestor *pfs.Commit, commit *pfs.Commit) (bool, error) {
+	if ancestor.Repo.Name != commit.Repo.Name {
+		return false, nil
+	}
+	commits := d.commits(commit.Repo.Name).ReadWrite(txnCtx.Stm)
+	for commit != nil {
+		if commit.ID == ancestor.ID {
+			return true, nil
+		}
+		commitInfo := &pfs.CommitInfo{}
+		if err := commits.Get(commit.ID, commitInfo); err != nil {
+			if col.IsErrNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		commit = commitInfo.ParentCommit
+	}
+	return false, nil
+}
+
+// checkOpenCommitWrite returns an error if the caller in 'pachClient' may not
+// write to the open commit 'commit', because its branch is protected
+func (d *driver) checkOpenCommitWrite(pachClient *client.APIClient, stm col.STM, commit *pfs.Commit) error {
+	commitInfo := &pfs.CommitInfo{}
+	if err := d.commits(commit.Repo.Name).ReadWrite(stm).Get(commit.ID, commitInfo); err != nil {
+		return err
+	}
+	if commitInfo.Branch == nil {
+		return nil
+	}
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches(commitInfo.Branch.Repo.Name).ReadWrite(stm).Get(commitInfo.Branch.Name, branchInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if branchInfo.Protection == nil {
+		return nil
+	}
+	subject, err := whoAmI(pachClient, pachClient.Ctx())
+	if err != nil {
+		return err
+	}
+	return checkCommitter(branchInfo, subject, false)
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestCheckCommitter(t *testing.T) {
+	branchInfo := &pfs.BranchInfo{Branch: client.NewBranch("repo", "master")}
+	require.NoError(t, checkCommitter(branchInfo, "github:alice", false))
+
+	branchInfo.Protection = &pfs.BranchProtection{AllowedCommitters: []string{"github:alice", "pipeline:edges"}}
+	require.NoError(t, checkCommitter(branchInfo, "github:alice", false))
+	require.NoError(t, checkCommitter(branchInfo, "pipeline:edges", false))
+	err := checkCommitter(branchInfo, "github:bob", false)
+	require.YesError(t, err)
+	require.True(t, auth.IsErrBranchProtected(err))
+	// Without auth, callers can't be identified
+	require.NoError(t, checkCommitter(branchInfo, "", false))
+
+	branchInfo.Protection = &pfs.BranchProtection{PipelineOnly: true}
+	require.NoError(t, checkCommitter(branchInfo, "pipeline:edges", false))
+	require.YesError(t, checkCommitter(branchInfo, "github:alice", false))
+	require.NoError(t, checkCommitter(branchInfo, "", false))
+	// Commits that PPS creates for jobs are always allowed
+	require.NoError(t, checkCommitter(branchInfo, "github:alice", true))
+
+	branchInfo.Protection = &pfs.BranchProtection{NoDelete: true}
+	require.NoError(t, checkCommitter(branchInfo, "github:alice", false))
+	require.YesError(t, checkBranchDelete(branchInfo))
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"io"
+	"path"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	txnenv "github.com/pachyderm/pachyderm/src/server/pkg/transactionenv"
+)
+
+// setRepoQuota sets 'repo's quota (a nil or zero quota removes it). Only
+// cluster admins may set quotas.
+func (d *driver) setRepoQuota(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, quota *pfs.RepoQuota) error {
+	if repo == nil {
+		return errors.New("repo cannot be nil")
+	}
+	if me, err := txnCtx.Client.WhoAmI(txnCtx.ClientContext, &auth.WhoAmIRequest{}); err == nil {
+		var isAdmin bool
+		if me.ClusterRoles != nil {
+			for _, s := range me.ClusterRoles.Roles {
+				if s == auth.ClusterRole_SUPER {
+					isAdmin = true
+					break
+				}
+			}
+		}
+		if !isAdmin {
+			return &auth.ErrNotAuthorized{
+				Subject: me.Username,
+				AdminOp: "SetRepoQuota",
+			}
+		}
+	} else if !auth.IsErrNotActivated(err) {
+		return errors.Wrapf(grpcutil.ScrubGRPC(err), "error during authorization check")
+	}
+
+	repos := d.repos.ReadWrite(txnCtx.Stm)
+	repoInfo := &pfs.RepoInfo{}
+	if err := repos.Get(repo.Name, repoInfo); err != nil {
+		return err
+	}
+	if quota.GetFileCount() == 0 {
+		repoInfo.FileCount = 0
+	} else if repoInfo.Quota.GetFileCount() == 0 {
+		// Files in master weren't being counted, so count them now
+		fileCount, err := d.countMasterFiles(txnCtx, repo)
+		if err != nil {
+			return err
+		}
+		repoInfo.FileCount = fileCount
+	}
+	if quota.GetSizeBytes() == 0 && quota.GetFileCount() == 0 {
+		quota = nil
+	}
+	repoInfo.Quota = quota
+	return repos.Put(repo.Name, repoInfo)
+}
+
+// countMasterFiles returns the number of files in the HEAD of 'repo's master
+// branch. If the HEAD is still open, its files are counted once it's finished.
+func (d *driver) countMasterFiles(txnCtx *txnenv.TransactionContext, repo *pfs.Repo) (uint64, error) {
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches(repo.Name).ReadWrite(txnCtx.Stm).Get("master", branchInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if branchInfo.Head == nil {
+		return 0, nil
+	}
+	commitInfo, err := d.resolveCommit(txnCtx.Stm, branchInfo.Head)
+	if err != nil {
+		return 0, err
+	}
+	if commitInfo.Finished == nil {
+		return 0, nil
+	}
+	return d.countCommitFiles(txnCtx.Client, commitInfo)
+}
+
+// checkRepoQuota returns ErrRepoQuotaExceeded if a commit to 'repo' with
+// 'size' bytes would exceed the repo's quota. The commit's files are counted
+// with 'count' only if the repo has a file count quota, and the count (or 0)
+// is returned so that it can be recorded in the repo's usage.
+func (d *driver) checkRepoQuota(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, size uint64, count func() (uint64, error)) (uint64, error) {
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadWrite(txnCtx.Stm).Get(repo.Name, repoInfo); err != nil {
+		return 0, err
+	}
+	quota := repoInfo.Quota
+	if quota == nil {
+		return 0, nil
+	}
+	if quota.SizeBytes != 0 && size > quota.SizeBytes {
+		return 0, pfsserver.ErrRepoQuotaExceeded{
+			Repo:     repo,
+			Resource: "bytes",
+			Usage:    size,
+			Quota:    quota.SizeBytes,
+		}
+	}
+	if quota.FileCount == 0 {
+		return 0, nil
+	}
+	fileCount, err := count()
+	if err != nil {
+		return 0, err
+	}
+	if fileCount > quota.FileCount {
+		return 0, pfsserver.ErrRepoQuotaExceeded{
+			Repo:     repo,
+			Resource: "files",
+			Usage:    fileCount,
+			Quota:    quota.FileCount,
+		}
+	}
+	return fileCount, nil
+}
+
+// checkPutFileQuota returns ErrRepoQuotaExceeded if appending 'records' to
+// 'files' (in open commits) would exceed a repo's byte quota. Only appended
+// data is counted, on top of the size of each commit's parent and the data
+// already put in the commit, as the size of overwritten data isn't known until
+// the commit is finished (at which point finishCommit checks the commit's
+// actual size).
+func (d *driver) checkPutFileQuota(pachClient *client.APIClient, files []*pfs.File, records []*pfs.PutFileRecords) error {
+	indices := make(map[string][]int) // commit -> indices of its files
+	var keys []string
+	for i, file := range files {
+		key := path.Join(file.Commit.Repo.Name, file.Commit.ID)
+		if indices[key] == nil {
+			keys = append(keys, key)
+		}
+		indices[key] = append(indices[key], i)
+	}
+	for _, key := range keys {
+		commit := files[indices[key][0]].Commit
+		repoInfo := &pfs.RepoInfo{}
+		if err := d.repos.ReadOnly(pachClient.Ctx()).Get(commit.Repo.Name, repoInfo); err != nil {
+			return err
+		}
+		quota := repoInfo.Quota.GetSizeBytes()
+		if quota == 0 {
+			continue
+		}
+		commitInfo, err := d.inspectCommit(pachClient, commit, pfs.CommitState_STARTED)
+		if err != nil {
+			return err
+		}
+		pending, err := d.pendingFileSizes(pachClient, commitInfo.Commit)
+		if err != nil {
+			return err
+		}
+		for _, i := range indices[key] {
+			p := path.Clean("/" + files[i].Path)
+			if records[i].Tombstone {
+				// The file's pending data is discarded, and the size of the
+				// new data is checked by finishCommit
+				pending[p] = 0
+				continue
+			}
+			for _, record := range records[i].Records {
+				pending[p] += uint64(record.SizeBytes)
+			}
+		}
+		var size uint64
+		for _, fileSize := range pending {
+			size += fileSize
+		}
+		if commitInfo.ParentCommit != nil {
+			parentInfo, err := d.inspectCommit(pachClient, commitInfo.ParentCommit, pfs.CommitState_STARTED)
+			if err != nil {
+				return err
+			}
+			size += parentInfo.SizeBytes
+		}
+		if size > quota {
+			return pfsserver.ErrRepoQuotaExceeded{
+				Repo:     commit.Repo,
+				Resource: "bytes",
+				Usage:    size,
+				Quota:    quota,
+			}
+		}
+	}
+	return nil
+}
+
+// pendingFileSizes returns the number of bytes that have been put in each file
+// of the open commit 'commit' so far.
+func (d *driver) pendingFileSizes(pachClient *client.APIClient, commit *pfs.Commit) (map[string]uint64, error) {
+	sizes := make(map[string]uint64)
+	putFileRecords := &pfs.PutFileRecords{}
+	if err := d.putFileRecords.ReadOnly(pachClient.Ctx()).ListPrefix(d.scratchCommitPrefix(commit), putFileRecords, col.DefaultOptions, func(key string) error {
+		p := path.Clean("/" + key)
+		for _, record := range putFileRecords.Records {
+			sizes[p] += uint64(record.SizeBytes)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+// countCommitFiles returns the number of files in the tree(s) of
+// 'commitInfo', which needn't have been written to etcd yet.
+func (d *driver) countCommitFiles(pachClient *client.APIClient, commitInfo *pfs.CommitInfo) (_ uint64, retErr error) {
+	if commitInfo.Trees != nil {
+		rs, err := d.getTrees(pachClient, commitInfo, "/")
+		if err != nil {
+			return 0, err
+		}
+		defer func() {
+			for _, r := range rs {
+				if err := r.Close(); err != nil && retErr == nil {
+					retErr = err
+				}
+			}
+		}()
+		return countFiles(rs)
+	}
+	if commitInfo.Tree == nil {
+		return 0, nil
+	}
+	tree, err := hashtree.GetHashTreeObject(pachClient, d.storageRoot, commitInfo.Tree)
+	if err != nil {
+		return 0, err
+	}
+	defer destroyHashtree(tree)
+	return countTreeFiles(tree)
+}
+
+// countFiles returns the number of files in the hashtree chunks in 'rs'
+func countFiles(rs []io.ReadCloser) (uint64, error) {
+	var count uint64
+	if err := hashtree.Walk(rs, "/", func(_ string, node *hashtree.NodeProto) error {
+		if node.FileNode != nil {
+			count++
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// countTreeFiles returns the number of files in 'tree'
+func countTreeFiles(tree hashtree.HashTree) (uint64, error) {
+	var count uint64
+	if err := tree.Walk("/", func(_ string, node *hashtree.NodeProto) error {
+		if node.FileNode != nil {
+			count++
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,380 @@
+package server
+
+import (
+	"path"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	authserver "github.com/pachyderm/pachyderm/src/server/auth/server"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsconsts"
+	txnenv "github.com/pachyderm/pachyderm/src/server/pkg/transactionenv"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+const (
+	retentionLockPath = "pfs-retention-lock"
+)
+
+// setRetentionPolicy sets the retention policy of 'branch' in 'repo', or the
+// repo's default policy if 'branch' is empty. A nil policy removes it.
+func (d *driver) setRetentionPolicy(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, branch string, policy *pfs.RetentionPolicy) error {
+	if repo == nil {
+		return errors.New("repo cannot be nil")
+	}
+	if err := authserver.CheckIsAuthorizedInTransaction(txnCtx, repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	if policy != nil {
+		if policy.KeepLast < 0 {
+			return errors.Errorf("keep_last must be non-negative, but was %d", policy.KeepLast)
+		}
+		if policy.KeepWithin != nil {
+			keepWithin, err := types.DurationFromProto(policy.KeepWithin)
+			if err != nil {
+				return errors.Wrapf(err, "invalid keep_within")
+			}
+			if keepWithin < 0 {
+				return errors.Errorf("keep_within must be non-negative, but was %v", keepWithin)
+			}
+		}
+	}
+	if branch == "" {
+		repoInfo := &pfs.RepoInfo{}
+		return d.repos.ReadWrite(txnCtx.Stm).Update(repo.Name, repoInfo, func() error {
+			repoInfo.Retention = policy
+			return nil
+		})
+	}
+	branchInfo := &pfs.BranchInfo{}
+	return d.branches(repo.Name).ReadWrite(txnCtx.Stm).Update(branch, branchInfo, func() error {
+		branchInfo.Retention = policy
+		return nil
+	})
+}
+
+// retainedCommits returns the IDs of the commits in 'history' (a branch's
+// commits, newest first) that 'policy' keeps at time 'now'. The newest commit
+// (the branch's HEAD) and unfinished commits are always kept.
+func retainedCommits(policy *pfs.RetentionPolicy, history []*pfs.CommitInfo, now time.Time) (map[string]bool, error) {
+	kept := make(map[string]bool)
+	var keepWithin time.Duration
+	if policy.KeepWithin != nil {
+		var err error
+		keepWithin, err = types.DurationFromProto(policy.KeepWithin)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var days int64
+	lastDay := ""
+	for i, ci := range history {
+		id := ci.Commit.ID
+		if ci.Finished == nil {
+			kept[id] = true
+			continue
+		}
+		finished, err := types.TimestampFromProto(ci.Finished)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 || int64(i) < policy.KeepLast {
+			kept[id] = true
+		}
+		if keepWithin > 0 && now.Sub(finished) <= keepWithin {
+			kept[id] = true
+		}
+		// Keep the newest commit of each day (history is newest first)
+		if day := finished.UTC().Format("2006-01-02"); day != lastDay {
+			lastDay = day
+			days++
+			if policy.KeepDaily < 0 || days <= policy.KeepDaily {
+				kept[id] = true
+			}
+		}
+	}
+	return kept, nil
+}
+
+// pruneCommits deletes the commits in 'repo' (or in every repo if 'repo' is
+// nil) that aren't kept by the retention policy of any branch containing them,
+// and returns them. If 'dryRun' is set, the commits are only returned.
+//
+// Commits that aren't in any branch with a policy, commits that are the HEAD of
+// a branch, open commits and commits whose subvenance is still in use (i.e.
+// includes an open commit, the HEAD of a branch, such as a pipeline's current
+// output, or a commit in a branch without a policy) are never pruned.
+func (d *driver) pruneCommits(pachClient *client.APIClient, repo *pfs.Repo, dryRun bool) ([]*pfs.Commit, error) {
+	ctx := pachClient.Ctx()
+	scope := auth.Scope_WRITER
+	if dryRun {
+		scope = auth.Scope_READER
+	}
+	var repos []*pfs.Repo
+	if repo != nil {
+		if err := authserver.CheckIsAuthorized(pachClient, repo, scope); err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	} else {
+		repoInfos, err := d.listRepo(pachClient, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, repoInfo := range repoInfos.RepoInfo {
+			if err := authserver.CheckIsAuthorized(pachClient, repoInfo.Repo, scope); err != nil {
+				if auth.IsErrNotAuthorized(err) {
+					continue
+				}
+				return nil, err
+			}
+			repos = append(repos, repoInfo.Repo)
+		}
+	}
+	var result []*pfs.Commit
+	for _, repo := range repos {
+		candidates, err := d.pruneCandidates(ctx, repo, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		for _, commit := range candidates {
+			if dryRun {
+				result = append(result, commit)
+				continue
+			}
+			// Each commit is deleted in its own transaction, as deleting one
+			// commit may delete others (e.g. its subvenance)
+			if err := d.txnEnv.WithTransaction(ctx, func(txn txnenv.Transaction) error {
+				return txn.DeleteCommit(&pfs.DeleteCommitRequest{Commit: commit})
+			}); err != nil {
+				if pfsserver.IsCommitNotFoundErr(err) || col.IsErrNotFound(err) {
+					continue
+				}
+				return result, err
+			}
+			result = append(result, commit)
+		}
+	}
+	return result, nil
+}
+
+// pruneCandidates returns the commits in 'repo' that its retention policies
+// don't keep at time 'now', newest first within each branch.
+func (d *driver) pruneCandidates(ctx context.Context, repo *pfs.Repo, now time.Time) ([]*pfs.Commit, error) {
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadOnly(ctx).Get(repo.Name, repoInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, pfsserver.ErrRepoNotFound{Repo: repo}
+		}
+		return nil, err
+	}
+	commits := d.commits(repo.Name).ReadOnly(ctx)
+	protected := make(map[string]map[string]bool) // repo -> IDs of protected commits
+	var order []*pfs.CommitInfo
+	prunable := make(map[string]bool) // in a branch with a policy
+	kept := make(map[string]bool)     // kept by some branch
+	for _, branch := range repoInfo.Branches {
+		branchInfo := &pfs.BranchInfo{}
+		if err := d.branches(repo.Name).ReadOnly(ctx).Get(branch.Name, branchInfo); err != nil {
+			return nil, err
+		}
+		if branchInfo.Head == nil {
+			continue
+		}
+		var history []*pfs.CommitInfo
+		for commit := branchInfo.Head; commit != nil; {
+			commitInfo := &pfs.CommitInfo{}
+			if err := commits.Get(commit.ID, commitInfo); err != nil {
+				return nil, err
+			}
+			history = append(history, commitInfo)
+			commit = commitInfo.ParentCommit
+		}
+		policy := branchInfo.Retention
+		if policy == nil {
+			policy = repoInfo.Retention
+		}
+		if policy == nil {
+			// Branches without a policy keep their whole history
+			for _, ci := range history {
+				kept[ci.Commit.ID] = true
+			}
+			continue
+		}
+		retained, err := retainedCommits(policy, history, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, ci := range history {
+			if retained[ci.Commit.ID] {
+				kept[ci.Commit.ID] = true
+			} else if !prunable[ci.Commit.ID] {
+				prunable[ci.Commit.ID] = true
+				order = append(order, ci)
+			}
+		}
+	}
+	var result []*pfs.Commit
+	for _, ci := range order {
+		if kept[ci.Commit.ID] || ci.Finished == nil || provenantOnInput(ci.Provenance) {
+			continue
+		}
+		inUse, err := d.subvenanceInUse(ctx, ci, protected)
+		if err != nil {
+			return nil, err
+		}
+		if inUse {
+			continue
+		}
+		result = append(result, ci.Commit)
+	}
+	return result, nil
+}
+
+// subvenanceInUse returns true if any commit in the subvenance of 'commitInfo'
+// is open or protected, as deleting 'commitInfo' would delete it too.
+// 'protected' caches the protected commits of each repo (see
+// protectedCommits).
+func (d *driver) subvenanceInUse(ctx context.Context, commitInfo *pfs.CommitInfo, protected map[string]map[string]bool) (bool, error) {
+	for _, subv := range commitInfo.Subvenance {
+		repo := subv.Upper.Repo.Name
+		if protected[repo] == nil {
+			var err error
+			if protected[repo], err = d.protectedCommits(ctx, repo); err != nil {
+				return false, err
+			}
+		}
+		commits := d.commits(repo).ReadOnly(ctx)
+		for commit := subv.Upper; commit != nil; {
+			if protected[repo][commit.ID] {
+				return true, nil
+			}
+			ci := &pfs.CommitInfo{}
+			if err := commits.Get(commit.ID, ci); err != nil {
+				if col.IsErrNotFound(err) {
+					break
+				}
+				return false, err
+			}
+			if ci.Finished == nil {
+				return true, nil
+			}
+			if commit.ID == subv.Lower.ID {
+				break
+			}
+			commit = ci.ParentCommit
+		}
+	}
+	return false, nil
+}
+
+// protectedCommits returns the IDs of the commits in 'repo' that are the HEAD
+// of a branch, or in the history of a branch without a retention policy (which
+// keeps its whole history).
+func (d *driver) protectedCommits(ctx context.Context, repo string) (map[string]bool, error) {
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadOnly(ctx).Get(repo, repoInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return map[string]bool{}, nil
+		}
+		return nil, err
+	}
+	protected := make(map[string]bool)
+	commits := d.commits(repo).ReadOnly(ctx)
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches(repo).ReadOnly(ctx).List(branchInfo, col.DefaultOptions, func(string) error {
+		if branchInfo.Head == nil {
+			return nil
+		}
+		protected[branchInfo.Head.ID] = true
+		if branchInfo.Retention != nil || repoInfo.Retention != nil {
+			return nil
+		}
+		for commit := branchInfo.Head; commit != nil; {
+			commitInfo := &pfs.CommitInfo{}
+			if err := commits.Get(commit.ID, commitInfo); err != nil {
+				if col.IsErrNotFound(err) {
+					break
+				}
+				return err
+			}
+			protected[commit.ID] = true
+			commit = commitInfo.ParentCommit
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return protected, nil
+}
+
+// retentionMaster enforces the retention policies of all repos every
+// 'interval', while holding the retention lock (so that only one pachd does it
+// at a time). Storage freed by pruned commits is reclaimed by garbage
+// collection, which is run after any commits are pruned (in storage v2, chunks
+// are garbage collected continuously by the pfs master).
+func (d *driver) retentionMaster(interval time.Duration) {
+	ctx := context.Background()
+	retentionLock := dlock.NewDLock(d.etcdClient, path.Join(d.prefix, retentionLockPath))
+	backoff.RetryNotify(func() error {
+		masterCtx, err := retentionLock.Lock(ctx)
+		if err != nil {
+			return err
+		}
+		defer retentionLock.Unlock(masterCtx)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := d.enforceRetention(masterCtx); err != nil {
+				return err
+			}
+			select {
+			case <-ticker.C:
+			case <-masterCtx.Done():
+				return masterCtx.Err()
+			}
+		}
+	}, backoff.NewInfiniteBackOff(), func(err error, retryIn time.Duration) error {
+		log.Errorf("error enforcing retention policies: %v; retrying in %v", err, retryIn)
+		return nil
+	})
+}
+
+// enforceRetention prunes the commits that no retention policy keeps, as a
+// superuser, and then garbage collects their data.
+func (d *driver) enforceRetention(ctx context.Context) error {
+	pachClient := d.env.GetPachClient(ctx)
+	var token types.StringValue
+	if err := d.backend.NewCollection(ppsconsts.PPSTokenKey, nil, &types.StringValue{}, nil, nil).ReadOnly(ctx).Get("", &token); err != nil {
+		if !col.IsErrNotFound(err) {
+			return err
+		}
+		// Auth has never been activated, so no token is needed
+	}
+	pachClient.SetAuthToken(token.Value)
+	pruned, err := d.pruneCommits(pachClient, nil, false)
+	if err != nil {
+		return err
+	}
+	if len(pruned) == 0 {
+		return nil
+	}
+	log.Infof("pruned %d commits that are outside their branches' retention policies", len(pruned))
+	if d.env.StorageV2 {
+		return nil
+	}
+	if err := pachClient.GarbageCollect(0); err != nil {
+		// e.g. if pipelines are running. The pruned commits' objects will be
+		// collected by the next successful garbage collection.
+		log.Warnf("could not garbage collect pruned commits: %v", err)
+	}
+	return nil
+}
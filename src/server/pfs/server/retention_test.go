@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+// newHistory returns a branch history (newest first) with one finished commit
+// per entry in 'ages', each finished that long before 'now'.
+func newHistory(t *testing.T, now time.Time, ages ...time.Duration) []*pfs.CommitInfo {
+	var history []*pfs.CommitInfo
+	for i, age := range ages {
+		finished, err := types.TimestampProto(now.Add(-age))
+		require.NoError(t, err)
+		history = append(history, &pfs.CommitInfo{
+			Commit:   client.NewCommit("repo", fmt.Sprint(i)),
+			Finished: finished,
+		})
+	}
+	return history
+}
+
+func keptIDs(kept map[string]bool, n int) []string {
+	var ids []string
+	for i := 0; i < n; i++ {
+		if kept[fmt.Sprint(i)] {
+			ids = append(ids, fmt.Sprint(i))
+		}
+	}
+	return ids
+}
+
+func TestRetainedCommits(t *testing.T) {
+	day := 24 * time.Hour
+	now := time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+	// Two commits per day for five days
+	history := newHistory(t, now, 0, time.Hour, day, day+time.Hour, 2*day, 2*day+time.Hour,
+		3*day, 3*day+time.Hour, 4*day, 4*day+time.Hour)
+
+	// An empty policy only keeps HEAD
+	kept, err := retainedCommits(&pfs.RetentionPolicy{}, history, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0"}, keptIDs(kept, len(history)))
+
+	kept, err = retainedCommits(&pfs.RetentionPolicy{KeepLast: 3}, history, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "1", "2"}, keptIDs(kept, len(history)))
+
+	kept, err = retainedCommits(&pfs.RetentionPolicy{KeepWithin: types.DurationProto(day + time.Hour)}, history, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "1", "2", "3"}, keptIDs(kept, len(history)))
+
+	kept, err = retainedCommits(&pfs.RetentionPolicy{KeepDaily: 2}, history, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "2"}, keptIDs(kept, len(history)))
+
+	// Keep everything from the last two days, and one commit per day before that
+	kept, err = retainedCommits(&pfs.RetentionPolicy{
+		KeepWithin: types.DurationProto(2 * day),
+		KeepDaily:  -1,
+	}, history, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "1", "2", "3", "4", "6", "8"}, keptIDs(kept, len(history)))
+
+	// Open commits are always kept
+	history[5].Finished = nil
+	kept, err = retainedCommits(&pfs.RetentionPolicy{KeepLast: 1}, history, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "5"}, keptIDs(kept, len(history)))
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/limit"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	authserver "github.com/pachyderm/pachyderm/src/server/auth/server"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	txnenv "github.com/pachyderm/pachyderm/src/server/pkg/transactionenv"
+	"github.com/pachyderm/pachyderm/src/server/pkg/uuid"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// syncParallelism is the number of objects that syncFile copies at once
+const syncParallelism = 10
+
+// syncStatePathFromEnv gets the path in object storage of the sync state of
+// 'file' based on environment variables. Sync states are stored outside of
+// the object and block directories, so that they aren't garbage collected.
+func syncStatePathFromEnv(file *pfs.File) (string, error) {
+	storageRoot, ok := os.LookupEnv(PachRootEnvVar)
+	if !ok {
+		return "", errors.Errorf("%s not found", PachRootEnvVar)
+	}
+	var err error
+	storageRoot, err = obj.StorageRootFromEnv(storageRoot)
+	if err != nil {
+		return "", err
+	}
+	key := sha256.Sum256([]byte(fmt.Sprintf("%s@%s:%s", file.Commit.Repo.Name, file.Commit.ID, file.Path)))
+	return filepath.Join(storageRoot, "sync", hex.EncodeToString(key[:])), nil
+}
+
+// syncPath returns the path in PFS that the source object 'name' is synced to
+func syncPath(file *pfs.File, prefix, name string) string {
+	return path.Join("/", file.Path, strings.TrimPrefix(name, prefix))
+}
+
+// syncChange is a change that syncFile makes to the synced path
+type syncChange struct {
+	// name is the name of the source object, or "" if the file is deleted
+	name string
+	path string
+}
+
+// syncFile mirrors the objects under 'url' into 'file', in a single commit
+// that only contains the objects that were added, changed or deleted. An
+// object is considered unchanged if the file it was synced to has the same
+// size and, if it was last written by syncFile, its ETag is the same. If
+// nothing changed, no commit is created.
+func (d *driver) syncFile(pachClient *client.APIClient, file *pfs.File, url string) (*pfs.SyncFileResponse, error) {
+	// Validate arguments
+	if file == nil {
+		return nil, errors.New("file cannot be nil")
+	}
+	if file.Commit == nil {
+		return nil, errors.New("file commit cannot be nil")
+	}
+	if file.Commit.Repo == nil {
+		return nil, errors.New("file commit repo cannot be nil")
+	}
+	if file.Commit.ID == "" || uuid.IsUUIDWithoutDashes(file.Commit.ID) {
+		return nil, errors.New("the destination of a sync must be a branch, not a commit")
+	}
+	file = client.NewFile(file.Commit.Repo.Name, file.Commit.ID, path.Join("/", file.Path))
+	if err := authserver.CheckIsAuthorized(pachClient, file.Commit.Repo, auth.Scope_WRITER); err != nil {
+		return nil, err
+	}
+	srcURL, err := obj.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	srcClient, err := obj.NewClientFromURLAndSecret(srcURL, false)
+	if err != nil {
+		return nil, err
+	}
+	objClient, err := obj.NewClientFromSecret(d.storageRoot)
+	if err != nil {
+		return nil, err
+	}
+	statePath, err := syncStatePathFromEnv(file)
+	if err != nil {
+		return nil, err
+	}
+	ctx := pachClient.Ctx()
+
+	// Find the files currently in 'file'
+	var head *pfs.Commit
+	if err := d.txnEnv.WithReadContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+		branchInfo, err := d.inspectBranch(txnCtx, client.NewBranch(file.Commit.Repo.Name, file.Commit.ID))
+		if err != nil {
+			if col.IsErrNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if branchInfo.Head == nil {
+			return nil
+		}
+		headInfo, err := d.resolveCommit(txnCtx.Stm, branchInfo.Head)
+		if err != nil {
+			return err
+		}
+		if headInfo.Finished == nil {
+			return pfsserver.ErrCommitNotFinished{headInfo.Commit}
+		}
+		head = headInfo.Commit
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]int64)
+	if head != nil {
+		if err := d.walkFile(pachClient, client.NewFile(head.Repo.Name, head.ID, file.Path), func(fi *pfs.FileInfo) error {
+			if fi.FileType == pfs.FileType_FILE {
+				sizes[path.Join("/", fi.File.Path)] = int64(fi.SizeBytes)
+			}
+			return nil
+		}); err != nil && !isNotFoundErr(err) {
+			return nil, err
+		}
+	}
+	prevState, err := readSyncState(ctx, objClient, statePath)
+	if err != nil {
+		return nil, err
+	}
+	var prevETags map[string]string
+	if prevState.Url == url {
+		prevETags = prevState.Etags
+	}
+
+	// Compare the source objects with them
+	response := &pfs.SyncFileResponse{}
+	state := &pfs.SyncState{Url: url, Etags: make(map[string]string)}
+	var changes []syncChange
+	prefix := strings.TrimPrefix(srcURL.Object, "/")
+	if err := srcClient.WalkInfo(ctx, prefix, func(info *obj.ObjectInfo) error {
+		if strings.HasSuffix(info.Name, "/") {
+			// Creating a file with a "/" suffix breaks pfs' directory model,
+			// so we don't
+			log.Warnf("ambiguous key %v, not syncing this entry as a file", info.Name)
+			return nil
+		}
+		p := syncPath(file, prefix, info.Name)
+		if _, ok := state.Etags[p]; ok {
+			return errors.Errorf("multiple objects would be synced to %q", p)
+		}
+		state.Etags[p] = info.ETag
+		size, ok := sizes[p]
+		delete(sizes, p)
+		switch {
+		case !ok:
+			response.Added++
+		case size != info.Size:
+			response.Changed++
+		case prevETags[p] != "" && prevETags[p] != info.ETag:
+			response.Changed++
+		default:
+			response.Unchanged++
+			return nil
+		}
+		changes = append(changes, syncChange{name: info.Name, path: p})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	// Any files that weren't visited don't exist in the source anymore
+	for p := range sizes {
+		response.Deleted++
+		changes = append(changes, syncChange{path: p})
+	}
+	if len(changes) == 0 {
+		if head != nil && !syncStateEqual(prevState, state) {
+			state.Commit = head
+			if err := writeSyncState(ctx, objClient, statePath, state); err != nil {
+				return nil, err
+			}
+		}
+		return response, nil
+	}
+
+	// Apply the changes in a new commit
+	commit, err := pachClient.StartCommit(file.Commit.Repo.Name, file.Commit.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := func() error {
+		var eg errgroup.Group
+		limiter := limit.New(syncParallelism)
+		for _, change := range changes {
+			change := change
+			limiter.Acquire()
+			eg.Go(func() (retErr error) {
+				defer limiter.Release()
+				if change.name == "" {
+					return pachClient.DeleteFile(commit.Repo.Name, commit.ID, change.path)
+				}
+				r, err := srcClient.Reader(ctx, change.name, 0, 0)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := r.Close(); err != nil && retErr == nil {
+						retErr = err
+					}
+				}()
+				_, err = pachClient.PutFileOverwrite(commit.Repo.Name, commit.ID, change.path, r, 0)
+				return err
+			})
+		}
+		if err := eg.Wait(); err != nil {
+			return err
+		}
+		return pachClient.FinishCommit(commit.Repo.Name, commit.ID)
+	}(); err != nil {
+		// Don't leave a partial sync in the branch
+		if err := pachClient.DeleteCommit(commit.Repo.Name, commit.ID); err != nil {
+			log.Errorf("could not delete commit %s@%s of failed sync: %v", commit.Repo.Name, commit.ID, err)
+		}
+		return nil, err
+	}
+	state.Commit = commit
+	if err := writeSyncState(ctx, objClient, statePath, state); err != nil {
+		return nil, err
+	}
+	response.Commit = commit
+	return response, nil
+}
+
+// readSyncState reads the sync state at 'p', returning an empty state if
+// there isn't one
+func readSyncState(ctx context.Context, objClient obj.Client, p string) (*pfs.SyncState, error) {
+	state := &pfs.SyncState{}
+	r, err := objClient.Reader(ctx, p, 0, 0)
+	if err != nil {
+		if objClient.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := state.Unmarshal(data); err != nil {
+		return nil, errors.Wrapf(err, "could not unmarshal sync state %s", p)
+	}
+	return state, nil
+}
+
+// writeSyncState replaces the sync state at 'p' with 'state'
+func writeSyncState(ctx context.Context, objClient obj.Client, p string, state *pfs.SyncState) (retErr error) {
+	data, err := state.Marshal()
+	if err != nil {
+		return err
+	}
+	// Object clients may not overwrite existing objects
+	if err := objClient.Delete(ctx, p); err != nil && !objClient.IsNotExist(err) {
+		return err
+	}
+	w, err := objClient.Writer(ctx, p)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := w.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	_, err = w.Write(data)
+	return err
+}
+
+// syncStateEqual returns true if 'a' and 'b' record the same source objects
+func syncStateEqual(a, b *pfs.SyncState) bool {
+	if a.Url != b.Url || len(a.Etags) != len(b.Etags) {
+		return false
+	}
+	for p, etag := range a.Etags {
+		if b.Etags[p] != etag {
+			return false
+		}
+	}
+	return true
+}
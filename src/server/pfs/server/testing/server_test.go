@@ -20,9 +20,11 @@ import (
 	"time"
 
 	pclient "github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ancestry"
 	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
@@ -3714,6 +3716,399 @@ func TestDiff(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestMergeBranch(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+
+		_, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, "master", "a", strings.NewReader("a\n"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, "master", "b", strings.NewReader("b\n"))
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master"))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "feature", "master", nil))
+
+		// master modifies 'a', feature modifies 'b' and adds 'c'
+		_, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFileOverwrite(repo, "master", "a", strings.NewReader("a2\n"), 0)
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master"))
+		_, err = env.PachClient.StartCommit(repo, "feature")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFileOverwrite(repo, "feature", "b", strings.NewReader("b2\n"), 0)
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, "feature", "c", strings.NewReader("c\n"))
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, "feature"))
+
+		resp, err := env.PachClient.MergeBranch(repo, "feature", "master", pfs.MergeStrategy_FAIL, nil, "")
+		require.NoError(t, err)
+		require.Equal(t, 0, len(resp.Conflicts))
+		commitInfo, err := env.PachClient.InspectCommit(repo, "master")
+		require.NoError(t, err)
+		require.Equal(t, resp.Commit.ID, commitInfo.Commit.ID)
+		featureInfo, err := env.PachClient.InspectCommit(repo, "feature")
+		require.NoError(t, err)
+		require.Equal(t, featureInfo.Commit.ID, commitInfo.MergedCommit.ID)
+		for path, content := range map[string]string{"a": "a2\n", "b": "b2\n", "c": "c\n"} {
+			var buffer bytes.Buffer
+			require.NoError(t, env.PachClient.GetFile(repo, "master", path, 0, 0, &buffer))
+			require.Equal(t, content, buffer.String())
+		}
+
+		// Merging again is a no-op
+		resp, err = env.PachClient.MergeBranch(repo, "feature", "master", pfs.MergeStrategy_FAIL, nil, "")
+		require.NoError(t, err)
+		require.Equal(t, commitInfo.Commit.ID, resp.Commit.ID)
+
+		// Both branches modify 'c'
+		for _, branch := range []string{"master", "feature"} {
+			_, err = env.PachClient.StartCommit(repo, branch)
+			require.NoError(t, err)
+			_, err = env.PachClient.PutFileOverwrite(repo, branch, "c", strings.NewReader(branch+"\n"), 0)
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.FinishCommit(repo, branch))
+		}
+		resp, err = env.PachClient.MergeBranch(repo, "feature", "master", pfs.MergeStrategy_FAIL, nil, "")
+		require.NoError(t, err)
+		require.Nil(t, resp.Commit)
+		require.Equal(t, 1, len(resp.Conflicts))
+		require.Equal(t, "/c", resp.Conflicts[0].Path)
+
+		resp, err = env.PachClient.MergeBranch(repo, "feature", "master", pfs.MergeStrategy_FAIL,
+			[]*pfs.MergeResolution{{Glob: "/c", Strategy: pfs.MergeStrategy_THEIRS}}, "")
+		require.NoError(t, err)
+		require.NotNil(t, resp.Commit)
+		var buffer bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(repo, "master", "c", 0, 0, &buffer))
+		require.Equal(t, "feature\n", buffer.String())
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRepoQuota(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		require.NoError(t, env.PachClient.SetRepoQuota(repo, 10, 0))
+
+		_, err := env.PachClient.PutFile(repo, "master", "foo", strings.NewReader("foo\n"))
+		require.NoError(t, err)
+		repoInfo, err := env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), repoInfo.Quota.SizeBytes)
+		require.Equal(t, uint64(4), repoInfo.SizeBytes)
+
+		// Appending 7 bytes to the 4 already in master exceeds the quota
+		_, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, "master", "bar", strings.NewReader("barbar\n"))
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoQuotaExceededErr(err))
+		_, err = env.PachClient.PutFile(repo, "master", "bar", strings.NewReader("bar\n"))
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master"))
+
+		// Data already put in the open commit counts towards the quota
+		_, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, "master", "baz", strings.NewReader("b\n"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, "master", "qux", strings.NewReader("q\n"))
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoQuotaExceededErr(err))
+		require.NoError(t, env.PachClient.DeleteCommit(repo, "master"))
+
+		// Overwriting isn't counted by PutFile, but FinishCommit enforces the quota
+		_, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFileOverwrite(repo, "master", "bar", strings.NewReader("barbar\n"), 0)
+		require.NoError(t, err)
+		err = env.PachClient.FinishCommit(repo, "master")
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoQuotaExceededErr(err))
+		require.NoError(t, env.PachClient.DeleteCommit(repo, "master"))
+
+		// Limit the number of files instead
+		require.NoError(t, env.PachClient.SetRepoQuota(repo, 0, 2))
+		repoInfo, err = env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), repoInfo.Quota.SizeBytes)
+		require.Equal(t, uint64(2), repoInfo.FileCount)
+		_, err = env.PachClient.PutFile(repo, "master", "buzz", strings.NewReader("buzz\n"))
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoQuotaExceededErr(err))
+
+		require.NoError(t, env.PachClient.SetRepoQuota(repo, 0, 0))
+		_, err = env.PachClient.PutFile(repo, "master", "buzz", strings.NewReader("buzz\n"))
+		require.NoError(t, err)
+		repoInfo, err = env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Nil(t, repoInfo.Quota)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestPruneCommits(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		var commits []*pfs.Commit
+		for i := 0; i < 5; i++ {
+			commit, err := env.PachClient.StartCommit(repo, "master")
+			require.NoError(t, err)
+			_, err = env.PachClient.PutFile(repo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader("foo\n"))
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+			commits = append(commits, commit)
+		}
+		commitIDs := func(commits []*pfs.Commit) []string {
+			var ids []string
+			for _, commit := range commits {
+				ids = append(ids, commit.ID)
+			}
+			return ids
+		}
+
+		// Without a policy, nothing is pruned
+		pruned, err := env.PachClient.PruneCommits(repo, true)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(pruned))
+
+		require.NoError(t, env.PachClient.SetRetentionPolicy(repo, "", &pfs.RetentionPolicy{KeepLast: 2}))
+		repoInfo, err := env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), repoInfo.Retention.KeepLast)
+		pruned, err = env.PachClient.PruneCommits(repo, true)
+		require.NoError(t, err)
+		require.ElementsEqual(t, commitIDs(commits[:3]), commitIDs(pruned))
+		_, err = env.PachClient.InspectCommit(repo, commits[0].ID)
+		require.NoError(t, err)
+
+		// A branch without a policy keeps its whole history
+		require.NoError(t, env.PachClient.CreateBranch(repo, "keep", commits[1].ID, nil))
+		pruned, err = env.PachClient.PruneCommits(repo, false)
+		require.NoError(t, err)
+		require.Equal(t, []string{commits[2].ID}, commitIDs(pruned))
+		_, err = env.PachClient.InspectCommit(repo, commits[2].ID)
+		require.YesError(t, err)
+		for _, commit := range []*pfs.Commit{commits[0], commits[1], commits[3], commits[4]} {
+			_, err = env.PachClient.InspectCommit(repo, commit.ID)
+			require.NoError(t, err)
+		}
+
+		// A branch's own policy overrides the repo's
+		require.NoError(t, env.PachClient.SetRetentionPolicy(repo, "keep", &pfs.RetentionPolicy{KeepLast: 1}))
+		branchInfo, err := env.PachClient.InspectBranch(repo, "keep")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), branchInfo.Retention.KeepLast)
+		pruned, err = env.PachClient.PruneCommits(repo, true)
+		require.NoError(t, err)
+		require.Equal(t, []string{commits[0].ID}, commitIDs(pruned))
+
+		// Once master has no policy, it keeps commits[0]
+		require.NoError(t, env.PachClient.SetRetentionPolicy(repo, "", nil))
+		pruned, err = env.PachClient.PruneCommits("", true)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(pruned))
+
+		require.YesError(t, env.PachClient.SetRetentionPolicy(repo, "missing", &pfs.RetentionPolicy{KeepLast: 1}))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestPruneCommitsUnpoliciedSubvenance(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		require.NoError(t, env.PachClient.CreateRepo("in"))
+		require.NoError(t, env.PachClient.CreateRepo("out"))
+		require.NoError(t, env.PachClient.CreateBranch("out", "master", "", []*pfs.Branch{pclient.NewBranch("in", "master")}))
+		var commits []*pfs.Commit
+		for i := 0; i < 3; i++ {
+			commit, err := env.PachClient.StartCommit("in", "master")
+			require.NoError(t, err)
+			_, err = env.PachClient.PutFile("in", commit.ID, fmt.Sprintf("file%d", i), strings.NewReader("foo\n"))
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.FinishCommit("in", commit.ID))
+			require.NoError(t, env.PachClient.FinishCommit("out", "master"))
+			commits = append(commits, commit)
+		}
+		require.NoError(t, env.PachClient.SetRetentionPolicy("in", "", &pfs.RetentionPolicy{KeepLast: 1}))
+
+		// Deleting the old input commits would delete the output commits
+		// derived from them, which "out" (having no policy) keeps
+		pruned, err := env.PachClient.PruneCommits("in", false)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(pruned))
+		for _, commit := range commits {
+			_, err = env.PachClient.InspectCommit("in", commit.ID)
+			require.NoError(t, err)
+		}
+		outCommits, err := env.PachClient.ListCommitByRepo("out")
+		require.NoError(t, err)
+		require.Equal(t, 3, len(outCommits))
+
+		// Once "out" has a policy too, they can be pruned
+		require.NoError(t, env.PachClient.SetRetentionPolicy("out", "", &pfs.RetentionPolicy{KeepLast: 1}))
+		pruned, err = env.PachClient.PruneCommits("in", true)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(pruned))
+		var ids []string
+		for _, commit := range pruned {
+			ids = append(ids, commit.ID)
+		}
+		require.ElementsEqual(t, []string{commits[0].ID, commits[1].ID}, ids)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestMetadata(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+
+		// Metadata can be set when a commit is started and finished
+		commit1, err := env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
+			Parent:   pclient.NewCommit(repo, ""),
+			Branch:   "master",
+			Metadata: map[string]string{"source": "camera-1", "draft": "true"},
+		})
+		require.NoError(t, err)
+		_, err = env.PachClient.PfsAPIClient.FinishCommit(env.PachClient.Ctx(), &pfs.FinishCommitRequest{
+			Commit:   commit1,
+			Metadata: map[string]string{"draft": "", "label": "golden"},
+		})
+		require.NoError(t, err)
+		commitInfo, err := env.PachClient.InspectCommit(repo, commit1.ID)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"source": "camera-1", "label": "golden"}, commitInfo.Metadata)
+
+		commit2, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.SetCommitMetadata(repo, commit2.ID, map[string]string{"source": "camera-2"}))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit2.ID))
+
+		// ListCommit filters by metadata
+		var ids []string
+		require.NoError(t, env.PachClient.ListCommitByMetadataF(repo, "master", "", 0, false, map[string]string{"source": "camera-1"}, func(ci *pfs.CommitInfo) error {
+			ids = append(ids, ci.Commit.ID)
+			return nil
+		}))
+		require.Equal(t, []string{commit1.ID}, ids)
+		commitInfos, err := env.PachClient.ListCommit(repo, "master", "", 0)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(commitInfos))
+
+		// SubscribeCommit filters by metadata
+		iter, err := env.PachClient.SubscribeCommitByMetadata(repo, "master", nil, "", pfs.CommitState_FINISHED, map[string]string{"source": "camera-2"})
+		require.NoError(t, err)
+		commitInfo, err = iter.Next()
+		require.NoError(t, err)
+		require.Equal(t, commit2.ID, commitInfo.Commit.ID)
+		iter.Close()
+
+		// Repos and branches have their own metadata
+		require.NoError(t, env.PachClient.SetRepoMetadata(repo, map[string]string{"owner": "data-team"}))
+		repoInfo, err := env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"owner": "data-team"}, repoInfo.Metadata)
+		require.NoError(t, env.PachClient.SetBranchMetadata(repo, "master", map[string]string{"stage": "prod"}))
+		branchInfo, err := env.PachClient.InspectBranch(repo, "master")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"stage": "prod"}, branchInfo.Metadata)
+		require.NoError(t, env.PachClient.SetBranchMetadata(repo, "master", map[string]string{"stage": ""}))
+		branchInfo, err = env.PachClient.InspectBranch(repo, "master")
+		require.NoError(t, err)
+		require.Equal(t, 0, len(branchInfo.Metadata))
+
+		require.YesError(t, env.PachClient.SetBranchMetadata(repo, "missing", map[string]string{"stage": "prod"}))
+		require.YesError(t, env.PachClient.SetRepoMetadata(repo, map[string]string{"": "empty"}))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBranchProtection(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		var commits []*pfs.Commit
+		for i := 0; i < 2; i++ {
+			commit, err := env.PachClient.StartCommit(repo, "master")
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+			commits = append(commits, commit)
+		}
+		require.YesError(t, env.PachClient.SetBranchProtection(repo, "missing", &pfs.BranchProtection{NoDelete: true}))
+
+		// no_delete blocks deleting the branch, and deleting its repo without force
+		require.NoError(t, env.PachClient.SetBranchProtection(repo, "master", &pfs.BranchProtection{NoDelete: true}))
+		branchInfo, err := env.PachClient.InspectBranch(repo, "master")
+		require.NoError(t, err)
+		require.True(t, branchInfo.Protection.NoDelete)
+		err = env.PachClient.DeleteBranch(repo, "master", true)
+		require.YesError(t, err)
+		require.True(t, auth.IsErrBranchProtected(err))
+		err = env.PachClient.DeleteRepo(repo, false)
+		require.YesError(t, err)
+		require.True(t, auth.IsErrBranchProtected(err))
+
+		// fast_forward_only blocks moving HEAD anywhere but its descendants
+		require.NoError(t, env.PachClient.SetBranchProtection(repo, "master", &pfs.BranchProtection{FastForwardOnly: true}))
+		err = env.PachClient.CreateBranch(repo, "master", commits[0].ID, nil)
+		require.YesError(t, err)
+		require.True(t, auth.IsErrBranchProtected(err))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "dev", "master", nil))
+		commit, err := env.PachClient.StartCommit(repo, "dev")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "master", commit.ID, nil))
+		err = env.PachClient.DeleteCommit(repo, commit.ID)
+		require.YesError(t, err)
+		require.True(t, auth.IsErrBranchProtected(err))
+		_, err = env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
+			Parent: pclient.NewCommit(repo, commits[0].ID),
+			Branch: "master",
+		})
+		require.YesError(t, err)
+		require.True(t, auth.IsErrBranchProtected(err))
+
+		// Neither pipeline_only nor allowed committers can be checked without
+		// auth, so they don't block writes (e.g. from spouts)
+		openCommit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.SetBranchProtection(repo, "master", &pfs.BranchProtection{PipelineOnly: true}))
+		_, err = env.PachClient.PutFile(repo, openCommit.ID, "file", strings.NewReader("foo\n"))
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, openCommit.ID))
+		commit, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+		require.NoError(t, env.PachClient.SetBranchProtection(repo, "master", &pfs.BranchProtection{AllowedCommitters: []string{"robot:ci"}}))
+		commit, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+
+		require.NoError(t, env.PachClient.SetBranchProtection(repo, "master", nil))
+		require.NoError(t, env.PachClient.DeleteBranch(repo, "master", false))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func TestGlobFile(t *testing.T) {
 	if os.Getenv("RUN_BAD_TESTS") == "" {
 		t.Skip("Skipping because RUN_BAD_TESTS was empty")
@@ -5715,6 +6110,79 @@ func TestPutFilesObjURL(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// TestSyncFileFromObjectStore isn't parallel, as it sets the environment
+// variables that pachd uses to store sync states in object storage
+func TestSyncFileFromObjectStore(t *testing.T) {
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		defer os.Unsetenv("PACH_ROOT")
+		defer os.Unsetenv(obj.StorageBackendEnvVar)
+		require.NoError(t, os.Setenv("PACH_ROOT", env.LocalStorageDirectory))
+		require.NoError(t, os.Setenv(obj.StorageBackendEnvVar, obj.Local))
+
+		dir, err := ioutil.TempDir("", "sync")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		objC, err := obj.NewLocalClient(dir)
+		require.NoError(t, err)
+		writeObj(t, objC, "a", "a")
+		writeObj(t, objC, "b", "b")
+		writeObj(t, objC, "dir/c", "c")
+		url := fmt.Sprintf("local://%s", dir)
+
+		repo := "repo"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		checkFiles := func(files map[string]string) {
+			actual := make(map[string]string)
+			require.NoError(t, env.PachClient.Walk(repo, "master", "/mirror", func(fi *pfs.FileInfo) error {
+				if fi.FileType == pfs.FileType_FILE {
+					var b bytes.Buffer
+					require.NoError(t, env.PachClient.GetFile(repo, "master", fi.File.Path, 0, 0, &b))
+					actual[fi.File.Path] = b.String()
+				}
+				return nil
+			}))
+			require.Equal(t, files, actual)
+		}
+
+		response, err := env.PachClient.SyncFile(repo, "master", "mirror", url)
+		require.NoError(t, err)
+		require.NotNil(t, response.Commit)
+		require.Equal(t, int64(3), response.Added)
+		checkFiles(map[string]string{"/mirror/a": "a", "/mirror/b": "b", "/mirror/dir/c": "c"})
+
+		// Syncing an unchanged source doesn't create a commit
+		response, err = env.PachClient.SyncFile(repo, "master", "mirror", url)
+		require.NoError(t, err)
+		require.Nil(t, response.Commit)
+		require.Equal(t, int64(3), response.Unchanged)
+		commitInfos, err := env.PachClient.ListCommit(repo, "master", "", 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(commitInfos))
+
+		// Change 'b' without changing its size, delete 'a' and add 'd'
+		require.NoError(t, objC.Delete(context.Background(), "b"))
+		writeObj(t, objC, "b", "B")
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "b"), later, later))
+		require.NoError(t, objC.Delete(context.Background(), "a"))
+		writeObj(t, objC, "dir/d", "d")
+		response, err = env.PachClient.SyncFile(repo, "master", "mirror", url)
+		require.NoError(t, err)
+		require.NotNil(t, response.Commit)
+		require.Equal(t, int64(1), response.Added)
+		require.Equal(t, int64(1), response.Changed)
+		require.Equal(t, int64(1), response.Deleted)
+		require.Equal(t, int64(1), response.Unchanged)
+		checkFiles(map[string]string{"/mirror/b": "B", "/mirror/dir/c": "c", "/mirror/dir/d": "d"})
+
+		// Syncs must be to a branch
+		_, err = env.PachClient.SyncFile(repo, response.Commit.ID, "mirror", url)
+		require.YesError(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func TestPutFileOutputRepo(t *testing.T) {
 	t.Parallel()
 	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
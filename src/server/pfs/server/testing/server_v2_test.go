@@ -1038,6 +1038,68 @@ func TestPutFileOverwriteV2(t *testing.T) {
 	}, newPachdConfig()))
 }
 
+func TestMergeBranchV2(t *testing.T) {
+	require.NoError(t, testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := "TestMergeBranchV2"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		checkFiles := func(files map[string]string) {
+			for path, content := range files {
+				var buf bytes.Buffer
+				require.NoError(t, env.PachClient.GetFile(repo, "master", path, 0, 0, &buf))
+				require.Equal(t, content, buf.String())
+			}
+		}
+
+		_, err := env.PachClient.PutFileOverwrite(repo, "master", "a", strings.NewReader("a\n"), 0)
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFileOverwrite(repo, "master", "b", strings.NewReader("b\n"), 0)
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.CreateBranch(repo, "feature", "master", nil))
+
+		// master modifies 'a', feature modifies 'b' and adds 'c'
+		_, err = env.PachClient.PutFileOverwrite(repo, "master", "a", strings.NewReader("a2\n"), 0)
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFileOverwrite(repo, "feature", "b", strings.NewReader("b2\n"), 0)
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFileOverwrite(repo, "feature", "c", strings.NewReader("c\n"), 0)
+		require.NoError(t, err)
+
+		resp, err := env.PachClient.MergeBranch(repo, "feature", "master", pfs.MergeStrategy_FAIL, nil, "")
+		require.NoError(t, err)
+		require.Equal(t, 0, len(resp.Conflicts))
+		commitInfo, err := env.PachClient.InspectCommit(repo, "master")
+		require.NoError(t, err)
+		require.Equal(t, resp.Commit.ID, commitInfo.Commit.ID)
+		featureInfo, err := env.PachClient.InspectCommit(repo, "feature")
+		require.NoError(t, err)
+		require.Equal(t, featureInfo.Commit.ID, commitInfo.MergedCommit.ID)
+		checkFiles(map[string]string{"a": "a2\n", "b": "b2\n", "c": "c\n"})
+
+		// Merging again is a no-op
+		resp, err = env.PachClient.MergeBranch(repo, "feature", "master", pfs.MergeStrategy_FAIL, nil, "")
+		require.NoError(t, err)
+		require.Equal(t, commitInfo.Commit.ID, resp.Commit.ID)
+
+		// Both branches modify 'c'
+		for _, branch := range []string{"master", "feature"} {
+			_, err = env.PachClient.PutFileOverwrite(repo, branch, "c", strings.NewReader(branch+"\n"), 0)
+			require.NoError(t, err)
+		}
+		resp, err = env.PachClient.MergeBranch(repo, "feature", "master", pfs.MergeStrategy_FAIL, nil, "")
+		require.NoError(t, err)
+		require.Nil(t, resp.Commit)
+		require.Equal(t, 1, len(resp.Conflicts))
+		require.Equal(t, "/c", resp.Conflicts[0].Path)
+
+		resp, err = env.PachClient.MergeBranch(repo, "feature", "master", pfs.MergeStrategy_FAIL,
+			[]*pfs.MergeResolution{{Glob: "/c", Strategy: pfs.MergeStrategy_THEIRS}}, "")
+		require.NoError(t, err)
+		require.NotNil(t, resp.Commit)
+		checkFiles(map[string]string{"a": "a2\n", "b": "b2\n", "c": "feature\n"})
+		return nil
+	}, newPachdConfig()))
+}
+
 func TestTmpFileSet(t *testing.T) {
 	require.NoError(t, testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
 		pclient, err := env.PachClient.NewCreateTmpFileSetClient()
@@ -0,0 +1,552 @@
+package webdav
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/server/pfs/s3"
+	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
+
+	"github.com/gogo/protobuf/types"
+	"golang.org/x/net/webdav"
+)
+
+// location is the location in PFS that a WebDAV path refers to. The first
+// component of each path names a branch, using the S3 gateway's bucket names
+// (`<branch>.<repo>`, or `<repo>` for master), or a commit (`<repo>@<commit>`).
+// Branches are writable, while commits are read-only.
+type location struct {
+	bucket string
+	repo   string
+	// branch is set if the location is in a branch
+	branch string
+	// commit is set if the location is in a commit
+	commit string
+	// path is the path of the location in its branch or commit
+	path string
+}
+
+func parseLocation(name string) *location {
+	name = path.Clean("/" + name)
+	if name == "/" {
+		return &location{path: "/"}
+	}
+	parts := strings.SplitN(strings.TrimPrefix(name, "/"), "/", 2)
+	loc := &location{bucket: parts[0], path: "/"}
+	if len(parts) == 2 {
+		loc.path = "/" + parts[1]
+	}
+	if i := strings.Index(loc.bucket, "@"); i >= 0 {
+		loc.repo, loc.commit = loc.bucket[:i], loc.bucket[i+1:]
+	} else {
+		loc.repo, loc.branch = s3.ParseBucketName(loc.bucket)
+	}
+	return loc
+}
+
+// isRoot returns true if 'l' is the root of the server, which lists branches
+func (l *location) isRoot() bool {
+	return l.bucket == ""
+}
+
+// isBucket returns true if 'l' is the root of a branch or commit
+func (l *location) isBucket() bool {
+	return l.bucket != "" && l.path == "/"
+}
+
+// pathError converts errors from pachd to the errors that the webdav package
+// understands
+func pathError(op, name string, err error) error {
+	switch {
+	case errutil.IsNotFoundError(err):
+		err = os.ErrNotExist
+	case auth.IsErrNotAuthorized(err), auth.IsErrBranchProtected(err), errors.Is(err, errSessionInUse):
+		err = os.ErrPermission
+	}
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
+
+// fileSystem is a webdav.FileSystem backed by PFS. A fileSystem is created
+// for each request.
+type fileSystem struct {
+	// pc is the request's client
+	pc *client.APIClient
+	// user is the user making the request ("" if auth isn't active)
+	user string
+	// sessionClient has the request's auth token, but outlives the request,
+	// so that it can finish commits after the request ends
+	sessionClient *client.APIClient
+	sessions      *sessions
+}
+
+// ref returns the commit to read 'loc' from: the open commit of its branch's
+// session if there is one, so that writes made in the session are visible
+// before they're committed, or else the head of its branch. 'ref' is "" if
+// the branch has no head.
+func (fs *fileSystem) ref(loc *location) (ref string, err error) {
+	if loc.commit != "" {
+		return loc.commit, nil
+	}
+	if sess := fs.sessions.get(fs.user, loc.repo, loc.branch); sess != nil {
+		if commit := sess.openCommit(); commit != "" {
+			return commit, nil
+		}
+	}
+	branchInfo, err := fs.pc.InspectBranch(loc.repo, loc.branch)
+	if err != nil {
+		return "", err
+	}
+	if branchInfo.Head == nil {
+		return "", nil
+	}
+	return branchInfo.Head.ID, nil
+}
+
+// hasSessionDir returns true if 'loc' is a directory created in its branch's
+// session
+func (fs *fileSystem) hasSessionDir(loc *location) bool {
+	if loc.branch == "" {
+		return false
+	}
+	sess := fs.sessions.get(fs.user, loc.repo, loc.branch)
+	return sess != nil && sess.hasDir(loc.path)
+}
+
+// writableSession acquires the session of the branch containing 'loc', or
+// returns an error if 'loc' can't be written to
+func (fs *fileSystem) writableSession(op, name string, loc *location) (*session, error) {
+	if loc.isRoot() || loc.isBucket() || loc.branch == "" {
+		return nil, &os.PathError{Op: op, Path: name, Err: os.ErrPermission}
+	}
+	sess, err := fs.sessions.acquire(fs.sessionClient, fs.user, loc.repo, loc.branch)
+	if err != nil {
+		return nil, pathError(op, name, err)
+	}
+	return sess, nil
+}
+
+func (fs *fileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	loc := parseLocation(name)
+	if loc.isRoot() {
+		return &fileInfo{name: "/", dir: true}, nil
+	}
+	ref, err := fs.ref(loc)
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+	if loc.isBucket() {
+		if loc.commit != "" {
+			if _, err := fs.pc.InspectCommit(loc.repo, loc.commit); err != nil {
+				return nil, pathError("stat", name, err)
+			}
+		}
+		return &fileInfo{name: loc.bucket, dir: true}, nil
+	}
+	if ref != "" {
+		fi, err := fs.pc.InspectFile(loc.repo, ref, loc.path)
+		if err == nil {
+			return newFileInfo(fi), nil
+		}
+		if !errutil.IsNotFoundError(err) {
+			return nil, pathError("stat", name, err)
+		}
+	}
+	if fs.hasSessionDir(loc) {
+		return &fileInfo{name: path.Base(loc.path), dir: true}, nil
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+func (fs *fileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	loc := parseLocation(name)
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return fs.openWrite(name, loc, flag&os.O_APPEND != 0)
+	}
+	info, err := fs.Stat(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &dirFile{info: info, list: func() ([]os.FileInfo, error) { return fs.readDir(name, loc) }}, nil
+	}
+	ref, err := fs.ref(loc)
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+	return &readFile{info: info, open: func() (io.ReadSeeker, error) {
+		return fs.pc.GetFileReadSeeker(loc.repo, ref, loc.path)
+	}}, nil
+}
+
+// openWrite returns a file that streams what's written to it into the file
+// at 'loc' in its branch's session commit
+func (fs *fileSystem) openWrite(name string, loc *location, appendToFile bool) (webdav.File, error) {
+	sess, err := fs.writableSession("open", name, loc)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := sess.ensureCommit(fs.pc)
+	if err != nil {
+		fs.sessions.release(sess)
+		return nil, pathError("open", name, err)
+	}
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		if appendToFile {
+			_, err = fs.pc.PutFile(loc.repo, commit, loc.path, pr)
+		} else {
+			_, err = fs.pc.PutFileOverwrite(loc.repo, commit, loc.path, pr, 0)
+		}
+		pr.CloseWithError(err)
+		done <- err
+	}()
+	return &writeFile{
+		name: path.Base(loc.path),
+		pw:   pw,
+		done: done,
+		release: func() {
+			fs.sessions.release(sess)
+		},
+	}, nil
+}
+
+// readDir lists the directory 'loc'
+func (fs *fileSystem) readDir(name string, loc *location) ([]os.FileInfo, error) {
+	var infos []os.FileInfo
+	if loc.isRoot() {
+		repoInfos, err := fs.pc.ListRepo()
+		if err != nil {
+			return nil, pathError("readdir", name, err)
+		}
+		for _, repoInfo := range repoInfos {
+			for _, branch := range repoInfo.Branches {
+				infos = append(infos, &fileInfo{name: s3.BucketName(branch.Repo.Name, branch.Name), dir: true})
+			}
+		}
+		return infos, nil
+	}
+	ref, err := fs.ref(loc)
+	if err != nil {
+		return nil, pathError("readdir", name, err)
+	}
+	listed := make(map[string]bool)
+	if ref != "" {
+		if err := fs.pc.ListFileF(loc.repo, ref, loc.path, 0, func(fi *pfs.FileInfo) error {
+			info := newFileInfo(fi)
+			listed[info.name] = true
+			infos = append(infos, info)
+			return nil
+		}); err != nil && !(errutil.IsNotFoundError(err) && fs.hasSessionDir(loc)) {
+			return nil, pathError("readdir", name, err)
+		}
+	}
+	if loc.branch != "" {
+		if sess := fs.sessions.get(fs.user, loc.repo, loc.branch); sess != nil {
+			for _, child := range sess.childDirs(loc.path) {
+				if !listed[child] {
+					infos = append(infos, &fileInfo{name: child, dir: true})
+				}
+			}
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+// Mkdir records a directory in its branch's session. PFS doesn't store empty
+// directories, so it only persists if files are written to it before the
+// session ends.
+func (fs *fileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	loc := parseLocation(name)
+	if _, err := fs.Stat(ctx, name); err == nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrExist}
+	}
+	if _, err := fs.Stat(ctx, path.Dir(path.Clean("/"+name))); err != nil {
+		return err
+	}
+	sess, err := fs.writableSession("mkdir", name, loc)
+	if err != nil {
+		return err
+	}
+	defer fs.sessions.release(sess)
+	sess.addDir(loc.path)
+	return nil
+}
+
+func (fs *fileSystem) RemoveAll(ctx context.Context, name string) error {
+	loc := parseLocation(name)
+	sess, err := fs.writableSession("remove", name, loc)
+	if err != nil {
+		return err
+	}
+	defer fs.sessions.release(sess)
+	sess.takeDirs(loc.path)
+	ref, err := fs.ref(loc)
+	if err != nil {
+		return pathError("remove", name, err)
+	}
+	if ref == "" {
+		return nil
+	}
+	if _, err := fs.pc.InspectFile(loc.repo, ref, loc.path); err != nil {
+		if errutil.IsNotFoundError(err) {
+			return nil
+		}
+		return pathError("remove", name, err)
+	}
+	commit, err := sess.ensureCommit(fs.pc)
+	if err != nil {
+		return pathError("remove", name, err)
+	}
+	if err := fs.pc.DeleteFile(loc.repo, commit, loc.path); err != nil {
+		return pathError("remove", name, err)
+	}
+	return nil
+}
+
+// Rename moves a file or directory by copying it to its new location, and
+// then deleting it from its old one
+func (fs *fileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	src, dst := parseLocation(oldName), parseLocation(newName)
+	srcSess, err := fs.writableSession("rename", oldName, src)
+	if err != nil {
+		return err
+	}
+	defer fs.sessions.release(srcSess)
+	dstSess, err := fs.writableSession("rename", newName, dst)
+	if err != nil {
+		return err
+	}
+	defer fs.sessions.release(dstSess)
+	if dst.path == src.path || strings.HasPrefix(dst.path, src.path+"/") {
+		if dst.repo == src.repo && dst.branch == src.branch {
+			return &os.PathError{Op: "rename", Path: newName, Err: errors.New("cannot move a directory into itself")}
+		}
+	}
+
+	for _, dir := range srcSess.takeDirs(src.path) {
+		dstSess.addDir(dst.path + strings.TrimPrefix(dir, src.path))
+	}
+	ref, err := fs.ref(src)
+	if err != nil {
+		return pathError("rename", oldName, err)
+	}
+	if ref == "" {
+		return nil
+	}
+	if _, err := fs.pc.InspectFile(src.repo, ref, src.path); err != nil {
+		if errutil.IsNotFoundError(err) {
+			return nil // 'src' was a directory that only existed in the session
+		}
+		return pathError("rename", oldName, err)
+	}
+	dstCommit, err := dstSess.ensureCommit(fs.pc)
+	if err != nil {
+		return pathError("rename", newName, err)
+	}
+	srcCommit, err := srcSess.ensureCommit(fs.pc)
+	if err != nil {
+		return pathError("rename", oldName, err)
+	}
+	if err := fs.pc.CopyFile(src.repo, ref, src.path, dst.repo, dstCommit, dst.path, true); err != nil {
+		return pathError("rename", newName, err)
+	}
+	if err := fs.pc.DeleteFile(src.repo, srcCommit, src.path); err != nil {
+		return pathError("rename", oldName, err)
+	}
+	return nil
+}
+
+// fileInfo is the os.FileInfo of files and directories in PFS
+type fileInfo struct {
+	name    string
+	size    int64
+	dir     bool
+	modTime time.Time
+	hash    []byte
+}
+
+func newFileInfo(fi *pfs.FileInfo) *fileInfo {
+	info := &fileInfo{
+		name: path.Base(fi.File.Path),
+		size: int64(fi.SizeBytes),
+		dir:  fi.FileType == pfs.FileType_DIR,
+		hash: fi.Hash,
+	}
+	if fi.Committed != nil {
+		if modTime, err := types.TimestampFromProto(fi.Committed); err == nil {
+			info.modTime = modTime
+		}
+	}
+	return info
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.dir }
+func (i *fileInfo) Sys() interface{}   { return nil }
+
+func (i *fileInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// ETag implements webdav.ETager, using the file's hash as its ETag
+func (i *fileInfo) ETag(ctx context.Context) (string, error) {
+	if len(i.hash) == 0 {
+		return "", webdav.ErrNotImplemented
+	}
+	return fmt.Sprintf("%q", hex.EncodeToString(i.hash)), nil
+}
+
+// ContentType implements webdav.ContentTyper, so that files don't have to be
+// read to determine their content type if their extension is known
+func (i *fileInfo) ContentType(ctx context.Context) (string, error) {
+	if contentType := mime.TypeByExtension(path.Ext(i.name)); contentType != "" {
+		return contentType, nil
+	}
+	return "", webdav.ErrNotImplemented
+}
+
+// readFile is a file in PFS that's open for reading. Its content isn't
+// requested from pachd until it's read.
+type readFile struct {
+	info   os.FileInfo
+	open   func() (io.ReadSeeker, error)
+	reader io.ReadSeeker
+}
+
+func (f *readFile) init() error {
+	if f.reader == nil {
+		reader, err := f.open()
+		if err != nil {
+			return err
+		}
+		f.reader = reader
+	}
+	return nil
+}
+
+func (f *readFile) Read(p []byte) (int, error) {
+	if err := f.init(); err != nil {
+		return 0, err
+	}
+	return f.reader.Read(p)
+}
+
+func (f *readFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.init(); err != nil {
+		return 0, err
+	}
+	return f.reader.Seek(offset, whence)
+}
+
+func (f *readFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, errors.Errorf("%s is not a directory", f.info.Name())
+}
+
+func (f *readFile) Stat() (os.FileInfo, error) { return f.info, nil }
+func (f *readFile) Write(p []byte) (int, error) {
+	return 0, errors.Errorf("%s is not open for writing", f.info.Name())
+}
+func (f *readFile) Close() error { return nil }
+
+// dirFile is a directory in PFS that's open for reading
+type dirFile struct {
+	info     os.FileInfo
+	list     func() ([]os.FileInfo, error)
+	children []os.FileInfo
+	listed   bool
+}
+
+func (f *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.listed {
+		children, err := f.list()
+		if err != nil {
+			return nil, err
+		}
+		f.children, f.listed = children, true
+	}
+	if count <= 0 {
+		children := f.children
+		f.children = nil
+		return children, nil
+	}
+	if len(f.children) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(f.children) {
+		count = len(f.children)
+	}
+	children := f.children[:count]
+	f.children = f.children[count:]
+	return children, nil
+}
+
+func (f *dirFile) Stat() (os.FileInfo, error) { return f.info, nil }
+func (f *dirFile) Read(p []byte) (int, error) {
+	return 0, errors.Errorf("%s is a directory", f.info.Name())
+}
+func (f *dirFile) Seek(offset int64, whence int) (int64, error) {
+	return 0, errors.Errorf("%s is a directory", f.info.Name())
+}
+func (f *dirFile) Write(p []byte) (int, error) {
+	return 0, errors.Errorf("%s is a directory", f.info.Name())
+}
+func (f *dirFile) Close() error { return nil }
+
+// writeFile is a file in PFS that's open for writing. What's written to it is
+// streamed to pachd, and it's committed when its branch's session ends.
+type writeFile struct {
+	name    string
+	size    int64
+	pw      *io.PipeWriter
+	done    chan error
+	release func()
+}
+
+func (f *writeFile) Write(p []byte) (int, error) {
+	n, err := f.pw.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close finishes writing the file, and returns any error from pachd
+func (f *writeFile) Close() error {
+	defer f.release()
+	if err := f.pw.Close(); err != nil {
+		return err
+	}
+	return <-f.done
+}
+
+func (f *writeFile) Stat() (os.FileInfo, error) {
+	return &fileInfo{name: f.name, size: f.size, modTime: time.Now()}, nil
+}
+
+func (f *writeFile) Read(p []byte) (int, error) {
+	return 0, errors.Errorf("%s is not open for reading", f.name)
+}
+func (f *writeFile) Seek(offset int64, whence int) (int64, error) {
+	return 0, errors.Errorf("%s is not open for reading", f.name)
+}
+func (f *writeFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, errors.Errorf("%s is not a directory", f.name)
+}
@@ -0,0 +1,118 @@
+package webdav
+
+import (
+	"context"
+	"fmt"
+	stdlog "log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/server/pfs/s3"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/webdav"
+)
+
+const readTimeout = 10 * time.Second
+
+// Server runs a WebDAV server for PFS. This allows PFS to be mounted as a
+// network drive by OS file managers (e.g. Finder, Windows Explorer and
+// Nautilus), and browsed and edited with them.
+//
+// The top-level directories of the server are PFS branches, named
+// `<branch name>.<repo name>` as in the S3 gateway (or just `<repo name>` for
+// master). Commits can also be browsed (read-only) at `<repo name>@<commit
+// ID>`, though they aren't listed. The writes made to a branch are batched
+// into a single commit, which is finished once the branch has seen no
+// requests for `sessionTimeout`. Until then, other users can't write to the
+// branch.
+//
+// If auth is active, clients must authenticate with HTTP basic auth, using
+// their Pachyderm auth token as the password (the username is ignored).
+//
+// This returns an `http.Server` instance. It is the responsibility of the
+// caller to start the returned server.
+func Server(port uint16, sessionTimeout time.Duration, clientFactory s3.ClientFactory) (*http.Server, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"source": "webdav",
+	})
+	sessions := newSessions(logger, sessionTimeout)
+	lockSystem := webdav.NewMemLS()
+
+	// Share a single connection to pachd between requests
+	var baseClient *client.APIClient
+	var mu sync.Mutex
+	getClient := func() (*client.APIClient, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if baseClient == nil {
+			pc, err := clientFactory()
+			if err != nil {
+				return nil, err
+			}
+			baseClient = pc
+		}
+		return baseClient, nil
+	}
+
+	server := &http.Server{
+		Addr: fmt.Sprintf(":%d", port),
+		// There's no write timeout, as downloads of large files may take a
+		// long time
+		ReadHeaderTimeout: readTimeout,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger.Infof("http request: %s %s", r.Method, r.RequestURI)
+			base, err := getClient()
+			if err != nil {
+				logger.Errorf("could not create a pach client: %v", err)
+				http.Error(w, "could not connect to pachd", http.StatusInternalServerError)
+				return
+			}
+			pc := base.WithCtx(r.Context())
+			_, token, _ := r.BasicAuth()
+			user, err := authenticate(pc, token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Basic realm="Pachyderm"`)
+				http.Error(w, "a valid Pachyderm auth token is required", http.StatusUnauthorized)
+				return
+			}
+			sessionClient := base.WithCtx(context.Background())
+			sessionClient.SetAuthToken(token)
+			handler := &webdav.Handler{
+				FileSystem: &fileSystem{
+					pc:            pc,
+					user:          user,
+					sessionClient: sessionClient,
+					sessions:      sessions,
+				},
+				LockSystem: lockSystem,
+				Logger: func(r *http.Request, err error) {
+					if err != nil {
+						logger.Errorf("%s %s: %v", r.Method, r.RequestURI, err)
+					}
+				},
+			}
+			handler.ServeHTTP(w, r)
+		}),
+		// NOTE: this is not closed. If the standard logger gets customized, this will need to be fixed
+		ErrorLog: stdlog.New(logger.Writer(), "", 0),
+	}
+	return server, nil
+}
+
+// authenticate returns the name of the user whose auth token is 'token', or ""
+// if auth isn't active. It also sets 'token' as the auth token of 'pc'.
+func authenticate(pc *client.APIClient, token string) (string, error) {
+	pc.SetAuthToken(token)
+	resp, err := pc.WhoAmI(pc.Ctx(), &auth.WhoAmIRequest{})
+	if err != nil {
+		if auth.IsErrNotActivated(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return resp.Username, nil
+}
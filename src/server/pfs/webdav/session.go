@@ -0,0 +1,217 @@
+package webdav
+
+import (
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errSessionInUse is returned when a user writes to a branch that has a
+// session belonging to another user
+var errSessionInUse = errors.New("the branch has uncommitted writes by another user")
+
+// sessions batches the writes made to each branch into a single commit. A
+// branch's commit is started by the first write to it, and finished once
+// there have been no requests using it for 'timeout'.
+//
+// A session belongs to the user that started it: its commit is started and
+// finished with their credentials, so other users can't write to the branch
+// (or see the session's uncommitted writes) until the session has finished.
+type sessions struct {
+	logger  *logrus.Entry
+	timeout time.Duration
+
+	// mu guards 'sessions', and the 'active' and 'timer' fields of each
+	// session
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// session is the write session of a single branch
+type session struct {
+	repo   string
+	branch string
+	// user is the user that the session belongs to ("" if auth isn't active)
+	user string
+	// active is the number of requests using the session
+	active int
+	// timer finishes the session once it has been idle for long enough
+	timer *time.Timer
+
+	// mu guards the fields below
+	mu sync.Mutex
+	// pc finishes the session's commit. It has the auth token of the most
+	// recent request to use the session (which was made by 'user'), but not
+	// its context (which is canceled when the request ends).
+	pc *client.APIClient
+	// commit is the open commit that the session's writes go to (nil until
+	// the first write)
+	commit *pfs.Commit
+	// dirs are the directories created in the session (with MKCOL). PFS
+	// doesn't store empty directories, so they only exist until the session
+	// ends, unless files are written to them.
+	dirs map[string]bool
+}
+
+func newSessions(logger *logrus.Entry, timeout time.Duration) *sessions {
+	return &sessions{
+		logger:   logger,
+		timeout:  timeout,
+		sessions: make(map[string]*session),
+	}
+}
+
+func sessionKey(repo, branch string) string {
+	return repo + "@" + branch
+}
+
+// acquire returns the session of 'branch' in 'repo', creating it for 'user'
+// if necessary. The session won't be finished until it's released. If the
+// branch has a session belonging to another user, errSessionInUse is
+// returned.
+func (s *sessions) acquire(pc *client.APIClient, user, repo, branch string) (*session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := sessionKey(repo, branch)
+	sess, ok := s.sessions[key]
+	if !ok {
+		sess = &session{
+			repo:   repo,
+			branch: branch,
+			user:   user,
+			dirs:   make(map[string]bool),
+		}
+		s.sessions[key] = sess
+	} else if sess.user != user {
+		return nil, errSessionInUse
+	}
+	if sess.timer != nil {
+		sess.timer.Stop()
+		sess.timer = nil
+	}
+	sess.active++
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.pc = pc
+	return sess, nil
+}
+
+// release releases a session returned by acquire
+func (s *sessions) release(sess *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess.active--
+	if sess.active == 0 {
+		sess.timer = time.AfterFunc(s.timeout, func() { s.finish(sess) })
+	}
+}
+
+// get returns the session of 'branch' in 'repo', or nil if there isn't one
+// belonging to 'user'. Unlike acquire, it doesn't prevent the session from
+// finishing.
+func (s *sessions) get(user, repo, branch string) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.sessions[sessionKey(repo, branch)]
+	if sess == nil || sess.user != user {
+		return nil
+	}
+	return sess
+}
+
+// finish ends 'sess' (if it's still idle), finishing its commit
+func (s *sessions) finish(sess *session) {
+	s.mu.Lock()
+	key := sessionKey(sess.repo, sess.branch)
+	if sess.active > 0 || s.sessions[key] != sess {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.sessions, key)
+	s.mu.Unlock()
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.commit == nil {
+		return
+	}
+	if err := sess.pc.FinishCommit(sess.commit.Repo.Name, sess.commit.ID); err != nil {
+		s.logger.Errorf("could not finish commit %s@%s: %v", sess.commit.Repo.Name, sess.commit.ID, err)
+	}
+}
+
+// openCommit returns the ID of the session's open commit, or "" if it
+// doesn't have one yet
+func (sess *session) openCommit() string {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.commit == nil {
+		return ""
+	}
+	return sess.commit.ID
+}
+
+// ensureCommit returns the ID of the session's open commit, starting it if
+// necessary
+func (sess *session) ensureCommit(pc *client.APIClient) (string, error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.commit == nil {
+		commit, err := pc.StartCommit(sess.repo, sess.branch)
+		if err != nil {
+			return "", err
+		}
+		sess.commit = commit
+	}
+	return sess.commit.ID, nil
+}
+
+// hasDir returns true if the directory 'p' was created in the session
+func (sess *session) hasDir(p string) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.dirs[p]
+}
+
+// addDir records that the directory 'p' was created in the session
+func (sess *session) addDir(p string) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.dirs[p] = true
+}
+
+// takeDirs forgets the directories created in the session at or under 'p',
+// and returns them
+func (sess *session) takeDirs(p string) []string {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	var dirs []string
+	for dir := range sess.dirs {
+		if dir == p || strings.HasPrefix(dir, p+"/") {
+			delete(sess.dirs, dir)
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// childDirs returns the names of the directories created in the session that
+// are children of 'p'
+func (sess *session) childDirs(p string) []string {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	var children []string
+	for dir := range sess.dirs {
+		if path.Dir(dir) == p && dir != p {
+			children = append(children, path.Base(dir))
+		}
+	}
+	return children
+}
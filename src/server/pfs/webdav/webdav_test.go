@@ -0,0 +1,89 @@
+package webdav
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseLocation(t *testing.T) {
+	loc := parseLocation("/")
+	require.True(t, loc.isRoot())
+	require.Equal(t, "/", loc.path)
+
+	loc = parseLocation("/images")
+	require.True(t, loc.isBucket())
+	require.Equal(t, "images", loc.repo)
+	require.Equal(t, "master", loc.branch)
+	require.Equal(t, "", loc.commit)
+
+	loc = parseLocation("/dev.images/a/b.png/")
+	require.False(t, loc.isBucket())
+	require.Equal(t, "images", loc.repo)
+	require.Equal(t, "dev", loc.branch)
+	require.Equal(t, "/a/b.png", loc.path)
+
+	loc = parseLocation("images@abc123/a")
+	require.Equal(t, "images", loc.repo)
+	require.Equal(t, "", loc.branch)
+	require.Equal(t, "abc123", loc.commit)
+	require.Equal(t, "/a", loc.path)
+}
+
+func TestSessionDirs(t *testing.T) {
+	s := newSessions(logrus.WithFields(nil), time.Hour)
+	sess, err := s.acquire(nil, "alice", "images", "master")
+	require.NoError(t, err)
+	defer s.release(sess)
+	require.Equal(t, sess, s.get("alice", "images", "master"))
+	require.True(t, s.get("alice", "images", "dev") == nil)
+
+	sess.addDir("/a")
+	sess.addDir("/a/b")
+	sess.addDir("/a/c")
+	sess.addDir("/ab")
+	require.True(t, sess.hasDir("/a/b"))
+	require.Equal(t, []string{"b", "c"}, sortedStrings(sess.childDirs("/a")))
+	require.Equal(t, []string{"a", "ab"}, sortedStrings(sess.childDirs("/")))
+
+	require.Equal(t, []string{"/a", "/a/b", "/a/c"}, sortedStrings(sess.takeDirs("/a")))
+	require.False(t, sess.hasDir("/a/b"))
+	require.True(t, sess.hasDir("/ab"))
+}
+
+func TestSessionUsers(t *testing.T) {
+	s := newSessions(logrus.WithFields(nil), time.Hour)
+	sess, err := s.acquire(nil, "alice", "images", "master")
+	require.NoError(t, err)
+	sess.addDir("/a")
+
+	// The session's uncommitted writes are hidden from other users, who can't
+	// write to the branch until it's finished
+	require.True(t, s.get("bob", "images", "master") == nil)
+	_, err = s.acquire(nil, "bob", "images", "master")
+	require.YesError(t, err)
+	require.True(t, errors.Is(err, errSessionInUse))
+	bobSess, err := s.acquire(nil, "bob", "images", "dev")
+	require.NoError(t, err)
+	s.release(bobSess)
+
+	// Releasing the session doesn't end it until it's finished
+	s.release(sess)
+	_, err = s.acquire(nil, "bob", "images", "master")
+	require.YesError(t, err)
+	s.finish(sess)
+	bobSess, err = s.acquire(nil, "bob", "images", "master")
+	require.NoError(t, err)
+	require.False(t, bobSess.hasDir("/a"))
+	s.release(bobSess)
+}
+
+func sortedStrings(s []string) []string {
+	sort.Strings(s)
+	return s
+}
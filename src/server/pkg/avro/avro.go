@@ -0,0 +1,149 @@
+// Package avro splits Avro object container files into blocks, so that PFS
+// can store an Avro file as a set of smaller, self-contained Avro files.
+package avro
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+)
+
+const syncSize = 16
+
+var magic = []byte{'O', 'b', 'j', 1}
+
+// Reader parses an Avro object container file into a header (which holds
+// the file's schema, codec and sync marker) and data blocks. The header
+// followed by any sequence of blocks is a valid Avro file.
+type Reader struct {
+	Header []byte
+	rd     *bufio.Reader
+	sync   []byte
+}
+
+// NewReader creates a new Reader
+func NewReader(r *bufio.Reader) *Reader {
+	return &Reader{
+		rd: r,
+	}
+}
+
+// ReadBlock returns the next data block, including its trailing sync marker,
+// and the number of records in it. The Header is populated by the first
+// call. It returns io.EOF after the last block.
+func (r *Reader) ReadBlock() ([]byte, int64, error) {
+	if r.Header == nil {
+		if err := r.readHeader(); err != nil {
+			return nil, 0, err
+		}
+	}
+	if _, err := r.rd.Peek(1); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, 0, io.EOF
+		}
+		return nil, 0, errors.Wrapf(err, "error reading avro block")
+	}
+	block := &bytes.Buffer{}
+	count, err := r.readLong(block)
+	if err != nil {
+		return nil, 0, err
+	}
+	size, err := r.readLong(block)
+	if err != nil {
+		return nil, 0, err
+	}
+	if count < 0 || size < 0 {
+		return nil, 0, errors.Errorf("invalid avro block (count %d, size %d)", count, size)
+	}
+	if err := r.readN(block, size+syncSize); err != nil {
+		return nil, 0, err
+	}
+	if !bytes.Equal(block.Bytes()[block.Len()-syncSize:], r.sync) {
+		return nil, 0, errors.Errorf("invalid avro block: sync marker doesn't match header")
+	}
+	return block.Bytes(), count, nil
+}
+
+func (r *Reader) readHeader() error {
+	header := &bytes.Buffer{}
+	if err := r.readN(header, int64(len(magic))); err != nil {
+		return err
+	}
+	if !bytes.Equal(header.Bytes(), magic) {
+		return errors.Errorf("invalid avro file: missing magic bytes")
+	}
+	// The file metadata is an avro map of strings to bytes, which is encoded
+	// as a series of blocks of key/value pairs, terminated by an empty block
+	for {
+		count, err := r.readLong(header)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			// A negative count is followed by the size of the block in bytes
+			count = -count
+			if _, err := r.readLong(header); err != nil {
+				return err
+			}
+		}
+		for i := int64(0); i < 2*count; i++ {
+			n, err := r.readLong(header)
+			if err != nil {
+				return err
+			}
+			if err := r.readN(header, n); err != nil {
+				return err
+			}
+		}
+	}
+	if err := r.readN(header, syncSize); err != nil {
+		return err
+	}
+	r.Header = header.Bytes()
+	r.sync = r.Header[len(r.Header)-syncSize:]
+	return nil
+}
+
+// readLong reads a zig-zag encoded varint (avro's encoding of 'long'),
+// copying the raw bytes to w.
+func (r *Reader) readLong(w *bytes.Buffer) (int64, error) {
+	var u uint64
+	for shift := uint(0); ; shift += 7 {
+		if shift >= 64 {
+			return 0, errors.Errorf("invalid avro file: varint overflow")
+		}
+		b, err := r.rd.ReadByte()
+		if err != nil {
+			return 0, unexpectedEOF(err)
+		}
+		w.WriteByte(b)
+		u |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	return int64(u>>1) ^ -int64(u&1), nil
+}
+
+// readN copies n bytes to w.
+func (r *Reader) readN(w *bytes.Buffer, n int64) error {
+	if n < 0 {
+		return errors.Errorf("invalid avro file: negative length %d", n)
+	}
+	if _, err := io.CopyN(w, r.rd, n); err != nil {
+		return unexpectedEOF(err)
+	}
+	return nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return errors.Wrapf(err, "error reading avro file")
+}
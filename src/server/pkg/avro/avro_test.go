@@ -0,0 +1,103 @@
+package avro
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func writeLong(buf *bytes.Buffer, n int64) {
+	u := uint64((n << 1) ^ (n >> 63))
+	for u >= 0x80 {
+		buf.WriteByte(byte(u) | 0x80)
+		u >>= 7
+	}
+	buf.WriteByte(byte(u))
+}
+
+func writeBytes(buf *bytes.Buffer, b []byte) {
+	writeLong(buf, int64(len(b)))
+	buf.Write(b)
+}
+
+// testFile returns an avro file with the given blocks of (already encoded)
+// records.
+func testFile(sync []byte, blocks ...[][]byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(magic)
+	writeLong(buf, 2)
+	writeBytes(buf, []byte("avro.schema"))
+	writeBytes(buf, []byte(`"string"`))
+	writeBytes(buf, []byte("avro.codec"))
+	writeBytes(buf, []byte("null"))
+	writeLong(buf, 0)
+	buf.Write(sync)
+	for _, records := range blocks {
+		data := &bytes.Buffer{}
+		for _, record := range records {
+			writeBytes(data, record)
+		}
+		writeLong(buf, int64(len(records)))
+		writeLong(buf, int64(data.Len()))
+		buf.Write(data.Bytes())
+		buf.Write(sync)
+	}
+	return buf.Bytes()
+}
+
+func readAll(t *testing.T, file []byte) (*Reader, [][]byte, []int64) {
+	r := NewReader(bufio.NewReader(bytes.NewReader(file)))
+	var blocks [][]byte
+	var counts []int64
+	for {
+		block, count, err := r.ReadBlock()
+		if err == io.EOF {
+			return r, blocks, counts
+		}
+		require.NoError(t, err)
+		blocks = append(blocks, block)
+		counts = append(counts, count)
+	}
+}
+
+func TestReadBlocks(t *testing.T) {
+	sync := []byte("0123456789abcdef")
+	file := testFile(sync,
+		[][]byte{[]byte("a"), []byte("b")},
+		[][]byte{[]byte("c")},
+	)
+	r, blocks, counts := readAll(t, file)
+	require.Equal(t, []int64{2, 1}, counts)
+	require.Equal(t, file, append(append(append([]byte{}, r.Header...), blocks[0]...), blocks[1]...))
+
+	// The header followed by any block is a valid file
+	r2, blocks2, counts2 := readAll(t, append(append([]byte{}, r.Header...), blocks[1]...))
+	require.Equal(t, r.Header, r2.Header)
+	require.Equal(t, [][]byte{blocks[1]}, blocks2)
+	require.Equal(t, []int64{1}, counts2)
+
+	// An empty file has a header and no blocks
+	r, blocks, _ = readAll(t, testFile(sync))
+	require.Equal(t, testFile(sync), r.Header)
+	require.Equal(t, 0, len(blocks))
+}
+
+func TestInvalidFile(t *testing.T) {
+	_, _, err := NewReader(bufio.NewReader(bytes.NewReader([]byte("not avro")))).ReadBlock()
+	require.YesError(t, err)
+
+	// Truncated block
+	file := testFile([]byte("0123456789abcdef"), [][]byte{[]byte("a")})
+	r := NewReader(bufio.NewReader(bytes.NewReader(file[:len(file)-1])))
+	_, _, err = r.ReadBlock()
+	require.YesError(t, err)
+
+	// Mismatched sync marker
+	file[len(file)-1] = 'x'
+	r = NewReader(bufio.NewReader(bytes.NewReader(file)))
+	_, _, err = r.ReadBlock()
+	require.YesError(t, err)
+}
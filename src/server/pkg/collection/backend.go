@@ -0,0 +1,90 @@
+package collection
+
+import (
+	"context"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/proto"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// EtcdBackend is the name of the backend that stores collections in etcd
+	EtcdBackend = "etcd"
+	// PostgresBackend is the name of the backend that stores collections in
+	// postgres (see SetupSQL)
+	PostgresBackend = "postgres"
+)
+
+// Backend is a database that collections can be stored in. Collections must
+// only be modified by STMs run by the backend that created them (an STM
+// writes to its own backend, regardless of which collection it's passed to),
+// so every collection that's modified in the same transaction must come from
+// the same backend.
+type Backend interface {
+	// NewCollection creates a collection stored in this backend. Its arguments
+	// are the same as the package-level NewCollection's, minus the client.
+	NewCollection(prefix string, indexes []*Index, template proto.Message, keyCheck func(string) error, valCheck func(proto.Message) error) Collection
+	// NewSTM runs 'apply' in a serializable transaction, retrying it if the
+	// transaction conflicts with another one.
+	NewSTM(ctx context.Context, apply func(STM) error) error
+	// NewDryrunSTM is like NewSTM, but the transaction is never committed.
+	NewDryrunSTM(ctx context.Context, apply func(STM) error) error
+}
+
+type etcdBackend struct {
+	etcdClient *etcd.Client
+}
+
+// NewEtcdBackend returns a Backend that stores collections in etcd.
+func NewEtcdBackend(etcdClient *etcd.Client) Backend {
+	return &etcdBackend{etcdClient: etcdClient}
+}
+
+func (b *etcdBackend) NewCollection(prefix string, indexes []*Index, template proto.Message, keyCheck func(string) error, valCheck func(proto.Message) error) Collection {
+	return NewCollection(b.etcdClient, prefix, indexes, template, keyCheck, valCheck)
+}
+
+func (b *etcdBackend) NewSTM(ctx context.Context, apply func(STM) error) error {
+	_, err := NewSTM(ctx, b.etcdClient, apply)
+	return err
+}
+
+func (b *etcdBackend) NewDryrunSTM(ctx context.Context, apply func(STM) error) error {
+	return NewDryrunSTM(ctx, b.etcdClient, apply)
+}
+
+type sqlBackend struct {
+	db *gorm.DB
+}
+
+// NewSQLBackend returns a Backend that stores collections in the SQL database
+// 'db', whose tables must have been created by SetupSQL.
+func NewSQLBackend(db *gorm.DB) Backend {
+	return &sqlBackend{db: db}
+}
+
+func (b *sqlBackend) NewCollection(prefix string, indexes []*Index, template proto.Message, keyCheck func(string) error, valCheck func(proto.Message) error) Collection {
+	return NewSQLCollection(b.db, prefix, indexes, template, keyCheck, valCheck)
+}
+
+func (b *sqlBackend) NewSTM(ctx context.Context, apply func(STM) error) error {
+	return NewSQLSTM(ctx, b.db, apply)
+}
+
+func (b *sqlBackend) NewDryrunSTM(ctx context.Context, apply func(STM) error) error {
+	return NewSQLDryrunSTM(ctx, b.db, apply)
+}
+
+// ValidateBackend returns an error if 'name' isn't the name of a collection
+// backend.
+func ValidateBackend(name string) error {
+	switch name {
+	case EtcdBackend, PostgresBackend:
+		return nil
+	default:
+		return errors.Errorf("unrecognized collection backend: %q (must be %q or %q)", name, EtcdBackend, PostgresBackend)
+	}
+}
@@ -102,8 +102,21 @@ func (c *collection) ReadOnly(ctx context.Context) ReadonlyCollection {
 }
 
 func (c *collection) Claim(ctx context.Context, key string, val proto.Message, f func(context.Context) error) error {
+	return c.ClaimTTL(ctx, key, val, ttl, f)
+}
+
+func (c *collection) ClaimTTL(ctx context.Context, key string, val proto.Message, ttl int64, f func(context.Context) error) error {
+	return c.claim(ctx, key, val, ttl, f, func(ctx context.Context, apply func(STM) error) error {
+		_, err := NewSTM(ctx, c.etcdClient, apply)
+		return err
+	})
+}
+
+// claim implements Claim, newSTM runs a transaction against the collection's
+// backend.
+func (c *collection) claim(ctx context.Context, key string, val proto.Message, ttl int64, f func(context.Context) error, newSTM func(context.Context, func(STM) error) error) error {
 	var claimed bool
-	if _, err := NewSTM(ctx, c.etcdClient, func(stm STM) error {
+	if err := newSTM(ctx, func(stm STM) error {
 		readWriteC := c.ReadWrite(stm)
 		if err := readWriteC.Get(key, val); err != nil {
 			if !IsErrNotFound(err) {
@@ -128,7 +141,7 @@ func (c *collection) Claim(ctx context.Context, key string, val proto.Message, f
 			case <-time.After((time.Second * time.Duration(ttl)) / 2):
 				// (bryce) potential race condition, goroutine does PutTTL after Put for completion which deletes work
 				// potential way around this is to have this only update the lease and not do a put (maybe through keepalive?)
-				if _, err := NewSTM(claimCtx, c.etcdClient, func(stm STM) error {
+				if err := newSTM(claimCtx, func(stm STM) error {
 					readWriteC := c.ReadWrite(stm)
 					if err := readWriteC.Get(key, val); err != nil {
 						return err
@@ -661,10 +674,10 @@ func (c *readonlyCollection) WatchF(f func(e *watch.Event) error, opts ...watch.
 		return err
 	}
 	defer watcher.Close()
-	return c.watchF(watcher, f)
+	return watchF(c.ctx, watcher, f)
 }
 
-func (c *readonlyCollection) watchF(watcher watch.Watcher, f func(e *watch.Event) error) error {
+func watchF(ctx context.Context, watcher watch.Watcher, f func(e *watch.Event) error) error {
 	for {
 		select {
 		case e, ok := <-watcher.Watch():
@@ -680,20 +693,36 @@ func (c *readonlyCollection) watchF(watcher watch.Watcher, f func(e *watch.Event
 				}
 				return err
 			}
-		case <-c.ctx.Done():
-			return c.ctx.Err()
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
 
 // WatchByIndex watches items in a collection that match a particular index
 func (c *readonlyCollection) WatchByIndex(index *Index, val interface{}) (watch.Watcher, error) {
-	eventCh := make(chan *watch.Event)
-	done := make(chan struct{})
 	watcher, err := watch.NewWatcher(c.ctx, c.etcdClient, c.prefix, c.indexDir(index, val), c.template)
 	if err != nil {
 		return nil, err
 	}
+	return watchByIndex(watcher, c.template, func(key string) ([]byte, bool, error) {
+		resp, err := c.get(c.Path(key))
+		if err != nil {
+			return nil, false, err
+		}
+		if len(resp.Kvs) == 0 {
+			return nil, false, nil
+		}
+		return resp.Kvs[0].Value, true, nil
+	}), nil
+}
+
+// watchByIndex converts the events of a watcher on an index into events on
+// the items that the index points to, get returns the current value of an
+// item, or false if it doesn't exist.
+func watchByIndex(watcher watch.Watcher, template proto.Message, get func(key string) ([]byte, bool, error)) watch.Watcher {
+	eventCh := make(chan *watch.Event)
+	done := make(chan struct{})
 	go func() (retErr error) {
 		defer func() {
 			if retErr != nil {
@@ -725,32 +754,32 @@ func (c *readonlyCollection) WatchByIndex(index *Index, val interface{}) (watch.
 				// pass along the error
 				return ev.Err
 			case watch.EventPut:
-				resp, err := c.get(c.Path(path.Base(string(ev.Key))))
+				value, ok, err := get(path.Base(string(ev.Key)))
 				if err != nil {
 					return err
 				}
-				if len(resp.Kvs) == 0 {
+				if !ok {
 					// this happens only if the item was deleted shortly after
 					// we receive this event.
 					continue
 				}
 				directEv = &watch.Event{
 					Key:      []byte(path.Base(string(ev.Key))),
-					Value:    resp.Kvs[0].Value,
+					Value:    value,
 					Type:     ev.Type,
-					Template: c.template,
+					Template: template,
 				}
 			case watch.EventDelete:
 				directEv = &watch.Event{
 					Key:      []byte(path.Base(string(ev.Key))),
 					Type:     ev.Type,
-					Template: c.template,
+					Template: template,
 				}
 			}
 			eventCh <- directEv
 		}
 	}()
-	return watch.MakeWatcher(eventCh, done), nil
+	return watch.MakeWatcher(eventCh, done)
 }
 
 // WatchOne watches a given item.  The first value returned from the watch
@@ -767,5 +796,5 @@ func (c *readonlyCollection) WatchOneF(key string, f func(e *watch.Event) error,
 		return err
 	}
 	defer watcher.Close()
-	return c.watchF(watcher, f)
+	return watchF(c.ctx, watcher, f)
 }
@@ -0,0 +1,80 @@
+package collection
+
+import (
+	"context"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/jinzhu/gorm"
+)
+
+// migrateBatchSize is the number of keys copied in each SQL transaction by
+// MigrateEtcdToSQL.
+const migrateBatchSize = 1000
+
+// MigrateEtcdToSQL copies every key under prefix in etcd into the SQL
+// backend, along with its remaining TTL, so that a cluster's collections can
+// be moved from etcd to SQL. The keys are read at a single etcd revision, and
+// keys that already exist in the SQL backend are overwritten. It returns the
+// number of keys that were copied.
+func MigrateEtcdToSQL(ctx context.Context, etcdClient *etcd.Client, db *gorm.DB, prefix string) (int64, error) {
+	if err := SetupSQL(db); err != nil {
+		return 0, err
+	}
+	var count, rev int64
+	// ttls caches the remaining TTL of each lease, as the keys of a
+	// collection and its indexes usually share a lease
+	ttls := make(map[etcd.LeaseID]int64)
+	opts := []etcd.OpOption{
+		etcd.WithRange(etcd.GetPrefixRangeEnd(prefix)),
+		etcd.WithSort(etcd.SortByKey, etcd.SortAscend),
+		etcd.WithLimit(migrateBatchSize),
+	}
+	for key := prefix; ; {
+		getOpts := opts
+		if rev != 0 {
+			getOpts = append(getOpts, etcd.WithRev(rev))
+		}
+		resp, err := etcdClient.Get(ctx, key, getOpts...)
+		if err != nil {
+			return count, errors.EnsureStack(err)
+		}
+		rev = resp.Header.Revision
+		var copied int64
+		if err := NewSQLSTM(ctx, db, func(stm STM) error {
+			copied = 0
+			for _, kv := range resp.Kvs {
+				var ttl int64
+				if kv.Lease != 0 {
+					leaseID := etcd.LeaseID(kv.Lease)
+					var ok bool
+					if ttl, ok = ttls[leaseID]; !ok {
+						leaseResp, err := etcdClient.TimeToLive(ctx, leaseID)
+						if err != nil {
+							return errors.Wrapf(err, "could not fetch lease TTL")
+						}
+						ttl = leaseResp.TTL
+						ttls[leaseID] = ttl
+					}
+					if ttl <= 0 {
+						// The key has expired since it was read
+						continue
+					}
+				}
+				if err := stm.Put(string(kv.Key), string(kv.Value), ttl, 0); err != nil {
+					return err
+				}
+				copied++
+			}
+			return nil
+		}); err != nil {
+			return count, err
+		}
+		count += copied
+		if !resp.More {
+			return count, nil
+		}
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+}
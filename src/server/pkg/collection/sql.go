@@ -0,0 +1,339 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pkg/tracing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+// The SQL backend stores every collection in a single key/value table, so that
+// the keys (and index keys) are laid out exactly as they are in etcd. Each
+// transaction that writes is assigned the next revision, and every write is
+// also recorded in an event table, which watchers poll for new events.
+// Writers serialize on the revision row, so revisions become visible in
+// order and a watcher that has seen revision N never misses a later one.
+const sqlSchema = `
+CREATE TABLE IF NOT EXISTS collection_revision (
+	id boolean PRIMARY KEY DEFAULT true CHECK (id),
+	rev bigint NOT NULL
+);
+INSERT INTO collection_revision (id, rev) VALUES (true, 0) ON CONFLICT DO NOTHING;
+CREATE TABLE IF NOT EXISTS collection_compacted (
+	id boolean PRIMARY KEY DEFAULT true CHECK (id),
+	rev bigint NOT NULL
+);
+INSERT INTO collection_compacted (id, rev) VALUES (true, 0) ON CONFLICT DO NOTHING;
+CREATE TABLE IF NOT EXISTS collection_kvs (
+	key text COLLATE "C" PRIMARY KEY,
+	value bytea NOT NULL,
+	create_rev bigint NOT NULL,
+	mod_rev bigint NOT NULL,
+	version bigint NOT NULL,
+	expires_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS collection_kvs_expires_at ON collection_kvs (expires_at) WHERE expires_at IS NOT NULL;
+CREATE TABLE IF NOT EXISTS collection_events (
+	rev bigint NOT NULL,
+	key text COLLATE "C" NOT NULL,
+	value bytea,
+	version bigint NOT NULL,
+	deleted boolean NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	PRIMARY KEY (rev, key)
+);
+`
+
+const (
+	// notExpired is the condition that a row in collection_kvs hasn't outlived
+	// its TTL. Expired rows may linger until they're reaped, so all reads
+	// must filter on it.
+	notExpired = "(expires_at IS NULL OR expires_at > now())"
+	// recordEvents appends the rows returned by the 'kv' CTE to the event
+	// table, $1 must be the revision of the write.
+	recordEvents = `INSERT INTO collection_events (rev, key, value, version, deleted)
+SELECT $1::bigint, key, value, version, deleted FROM kv
+ON CONFLICT (rev, key) DO UPDATE SET value = EXCLUDED.value, version = EXCLUDED.version, deleted = EXCLUDED.deleted`
+)
+
+// DefaultSQLEventRetention is how long events are kept for watchers.
+// Watchers that fall further behind than this fail with an error.
+const DefaultSQLEventRetention = 10 * time.Minute
+
+// SetupSQL creates the tables used by SQL backed collections, if they don't
+// already exist.
+func SetupSQL(db *gorm.DB) error {
+	_, err := db.DB().Exec(sqlSchema)
+	return errors.EnsureStack(err)
+}
+
+// commitNotifier wakes up the watchers in this process whenever a
+// transaction in this process commits, so that local writes don't wait for
+// the next poll to be seen.
+var commitNotifier = struct {
+	mu sync.Mutex
+	ch chan struct{}
+}{ch: make(chan struct{})}
+
+func notifyCommit() {
+	commitNotifier.mu.Lock()
+	defer commitNotifier.mu.Unlock()
+	close(commitNotifier.ch)
+	commitNotifier.ch = make(chan struct{})
+}
+
+func commitNotification() <-chan struct{} {
+	commitNotifier.mu.Lock()
+	defer commitNotifier.mu.Unlock()
+	return commitNotifier.ch
+}
+
+// likePrefix returns a LIKE pattern matching all strings that begin with
+// prefix.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+func isRetriableSQLError(err error) bool {
+	pqErr := &pq.Error{}
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Class().Name() == "transaction_rollback"
+	}
+	return false
+}
+
+// NewSQLSTM runs apply in a serializable SQL transaction. It is the SQL
+// equivalent of NewSTM, and apply is retried if the transaction conflicts
+// with another one.
+func NewSQLSTM(ctx context.Context, db *gorm.DB, apply func(STM) error) error {
+	return runSQLSTM(ctx, db, apply, false)
+}
+
+// NewSQLDryrunSTM is like NewSQLSTM, but the transaction is always rolled
+// back.
+func NewSQLDryrunSTM(ctx context.Context, db *gorm.DB, apply func(STM) error) error {
+	return runSQLSTM(ctx, db, apply, true)
+}
+
+func runSQLSTM(ctx context.Context, db *gorm.DB, apply func(STM) error, dryrun bool) error {
+	span, ctx := tracing.AddSpanToAnyExisting(ctx, "/sql/STM")
+	defer tracing.FinishAnySpan(span)
+	for {
+		err := trySQLSTM(ctx, db, apply, dryrun)
+		if isRetriableSQLError(err) {
+			continue
+		}
+		return err
+	}
+}
+
+func trySQLSTM(ctx context.Context, db *gorm.DB, apply func(STM) error, dryrun bool) (retErr error) {
+	tx, err := db.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	s := &sqlSTM{
+		ctx:      ctx,
+		tx:       tx,
+		safePuts: make(map[string]uintptr),
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			e, ok := r.(stmError)
+			if !ok {
+				// client apply panicked
+				panic(r)
+			}
+			retErr = e.err
+		}
+	}()
+	if err := apply(s); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if dryrun {
+		return errors.EnsureStack(tx.Rollback())
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if s.rev != 0 {
+		notifyCommit()
+	}
+	return nil
+}
+
+// sqlSTM implements STM on top of a serializable SQL transaction. Unlike stm,
+// writes are applied to the transaction as they're made, and the database
+// detects conflicts with other transactions.
+type sqlSTM struct {
+	ctx context.Context
+	tx  *sql.Tx
+	// rev is the revision of this transaction's writes, it's 0 until the
+	// first write.
+	rev int64
+	// safePuts holds the pointer passed to the last Put of each key
+	safePuts map[string]uintptr
+}
+
+func (s *sqlSTM) Context() context.Context {
+	return s.ctx
+}
+
+// revision returns the revision of this transaction's writes, allocating it
+// if necessary.
+func (s *sqlSTM) revision() int64 {
+	if s.rev == 0 {
+		if err := s.tx.QueryRowContext(s.ctx, "UPDATE collection_revision SET rev = rev + 1 RETURNING rev").Scan(&s.rev); err != nil {
+			panic(stmError{errors.EnsureStack(err)})
+		}
+	}
+	return s.rev
+}
+
+func (s *sqlSTM) Get(key string) (string, error) {
+	var val []byte
+	if err := s.tx.QueryRowContext(s.ctx, "SELECT value FROM collection_kvs WHERE key = $1 AND "+notExpired, key).Scan(&val); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound{Key: key}
+		}
+		panic(stmError{errors.EnsureStack(err)})
+	}
+	return string(val), nil
+}
+
+func (s *sqlSTM) Put(key, val string, ttl int64, ptr uintptr) error {
+	// Puts over an expired (but not yet reaped) key recreate it
+	if _, err := s.tx.ExecContext(s.ctx, `WITH kv AS (
+	INSERT INTO collection_kvs (key, value, create_rev, mod_rev, version, expires_at)
+	VALUES ($2, $3, $1, $1, 1, CASE WHEN $4::bigint > 0 THEN now() + $4::bigint * interval '1 second' END)
+	ON CONFLICT (key) DO UPDATE SET
+		value = EXCLUDED.value,
+		mod_rev = EXCLUDED.mod_rev,
+		create_rev = CASE WHEN collection_kvs.expires_at <= now() THEN EXCLUDED.create_rev ELSE collection_kvs.create_rev END,
+		version = CASE WHEN collection_kvs.expires_at <= now() THEN 1 ELSE collection_kvs.version + 1 END,
+		expires_at = EXCLUDED.expires_at
+	RETURNING key, value, version, false AS deleted
+)
+`+recordEvents, s.revision(), key, []byte(val), ttl); err != nil {
+		return errors.EnsureStack(err)
+	}
+	s.safePuts[key] = ptr
+	return nil
+}
+
+func (s *sqlSTM) Rev(key string) int64 {
+	var rev int64
+	if err := s.tx.QueryRowContext(s.ctx, "SELECT mod_rev FROM collection_kvs WHERE key = $1 AND "+notExpired, key).Scan(&rev); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0
+		}
+		panic(stmError{errors.EnsureStack(err)})
+	}
+	return rev
+}
+
+func (s *sqlSTM) Del(key string) {
+	s.del("key = $2", key)
+	delete(s.safePuts, key)
+}
+
+func (s *sqlSTM) DelAll(prefix string) {
+	s.del("key LIKE $2", likePrefix(prefix))
+	for key := range s.safePuts {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.safePuts, key)
+		}
+	}
+}
+
+// del deletes the keys matching cond, which may refer to args starting at $2.
+func (s *sqlSTM) del(cond string, args ...interface{}) {
+	if _, err := s.tx.ExecContext(s.ctx, `WITH kv AS (
+	DELETE FROM collection_kvs WHERE `+cond+`
+	RETURNING key, NULL::bytea AS value, 0::bigint AS version, true AS deleted
+)
+`+recordEvents, append([]interface{}{s.revision()}, args...)...); err != nil {
+		panic(stmError{errors.EnsureStack(err)})
+	}
+}
+
+func (s *sqlSTM) TTL(key string) (int64, error) {
+	var ttl int64
+	if err := s.tx.QueryRowContext(s.ctx, `SELECT CASE WHEN expires_at IS NULL THEN 0
+	ELSE GREATEST(FLOOR(EXTRACT(EPOCH FROM expires_at - now())), 1)::bigint END
+FROM collection_kvs WHERE key = $1 AND `+notExpired, key).Scan(&ttl); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound{Key: key}
+		}
+		return 0, errors.EnsureStack(err)
+	}
+	return ttl, nil
+}
+
+func (s *sqlSTM) SetSafePutCheck(key string, ptr uintptr) {
+	if _, ok := s.safePuts[key]; ok {
+		s.safePuts[key] = ptr
+	}
+}
+
+func (s *sqlSTM) IsSafePut(key string, ptr uintptr) bool {
+	if safePtr, ok := s.safePuts[key]; ok && safePtr != 0 && ptr != safePtr {
+		return false
+	}
+	return true
+}
+
+// ReapSQL deletes expired keys from the SQL backend, which generates delete
+// events for them, and discards events older than retention. It runs every
+// interval until ctx is cancelled, one instance should be run per database.
+func ReapSQL(ctx context.Context, db *gorm.DB, interval, retention time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := reapExpired(ctx, db); err != nil {
+			log.Errorf("error reaping expired collection keys: %v", err)
+		}
+		if err := compactEvents(ctx, db, retention); err != nil {
+			log.Errorf("error compacting collection events: %v", err)
+		}
+	}
+}
+
+func reapExpired(ctx context.Context, db *gorm.DB) error {
+	var expired bool
+	if err := db.DB().QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM collection_kvs WHERE expires_at <= now())").Scan(&expired); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if !expired {
+		return nil
+	}
+	return NewSQLSTM(ctx, db, func(stm STM) error {
+		stm.(*sqlSTM).del("expires_at <= now()")
+		return nil
+	})
+}
+
+func compactEvents(ctx context.Context, db *gorm.DB, retention time.Duration) error {
+	if _, err := db.DB().ExecContext(ctx, `UPDATE collection_compacted SET rev = GREATEST(rev, (
+	SELECT COALESCE(MAX(rev), 0) FROM collection_events WHERE created_at < now() - $1::bigint * interval '1 second'
+))`, int64(retention.Seconds())); err != nil {
+		return errors.EnsureStack(err)
+	}
+	_, err := db.DB().ExecContext(ctx, "DELETE FROM collection_events WHERE rev <= (SELECT rev FROM collection_compacted)")
+	return errors.EnsureStack(err)
+}
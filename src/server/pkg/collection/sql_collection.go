@@ -0,0 +1,493 @@
+package collection
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pkg/tracing"
+	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/watch"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/proto"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// sqlPageSize is the number of rows fetched at a time when listing a
+	// collection.
+	sqlPageSize = 1000
+	// sqlPollInterval is how often watchers poll for events written by other
+	// processes.
+	sqlPollInterval = 200 * time.Millisecond
+)
+
+// sqlCollection is a collection stored in a SQL database, see SetupSQL. It
+// shares its key layout, indexes and read-write implementation with the etcd
+// collection, and is used with STMs created by NewSQLSTM.
+type sqlCollection struct {
+	*collection
+	db *gorm.DB
+}
+
+// NewSQLCollection creates a new collection that is stored in a SQL database
+// rather than etcd. Its arguments are the same as NewCollection's.
+func NewSQLCollection(db *gorm.DB, prefix string, indexes []*Index, template proto.Message, keyCheck func(string) error, valCheck func(proto.Message) error) Collection {
+	return &sqlCollection{
+		collection: NewCollection(nil, prefix, indexes, template, keyCheck, valCheck).(*collection),
+		db:         db,
+	}
+}
+
+func (c *sqlCollection) ReadOnly(ctx context.Context) ReadonlyCollection {
+	return &sqlReadonlyCollection{
+		sqlCollection: c,
+		ctx:           ctx,
+	}
+}
+
+func (c *sqlCollection) Claim(ctx context.Context, key string, val proto.Message, f func(context.Context) error) error {
+	return c.ClaimTTL(ctx, key, val, ttl, f)
+}
+
+func (c *sqlCollection) ClaimTTL(ctx context.Context, key string, val proto.Message, ttl int64, f func(context.Context) error) error {
+	return c.claim(ctx, key, val, ttl, f, func(ctx context.Context, apply func(STM) error) error {
+		return NewSQLSTM(ctx, c.db, apply)
+	})
+}
+
+type sqlReadonlyCollection struct {
+	*sqlCollection
+	ctx context.Context
+}
+
+// sqlKV is a row of collection_kvs.
+type sqlKV struct {
+	key       string
+	value     []byte
+	createRev int64
+	modRev    int64
+	version   int64
+}
+
+// get returns the value of a key, or false if it doesn't exist.
+func (c *sqlReadonlyCollection) get(key string) ([]byte, bool, error) {
+	var val []byte
+	if err := c.db.DB().QueryRowContext(c.ctx, "SELECT value FROM collection_kvs WHERE key = $1 AND "+notExpired, key).Scan(&val); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, errors.EnsureStack(err)
+	}
+	return val, true, nil
+}
+
+func (c *sqlReadonlyCollection) Get(key string, val proto.Message) error {
+	if err := watch.CheckType(c.template, val); err != nil {
+		return err
+	}
+	value, ok, err := c.get(c.Path(key))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound{c.prefix, key}
+	}
+	return proto.Unmarshal(value, val)
+}
+
+func (c *sqlReadonlyCollection) GetByIndex(index *Index, indexVal interface{}, val proto.Message, opts *Options, f func(key string) error) error {
+	span, _ := tracing.AddSpanToAnyExisting(c.ctx, "/sql.RO/GetByIndex", "col", c.prefix, "index", index, "indexVal", indexVal)
+	defer tracing.FinishAnySpan(span)
+	return c.list(c.indexDir(index, indexVal)+"/", opts, func(kv *sqlKV) error {
+		key := path.Base(kv.key)
+		if err := c.Get(key, val); err != nil {
+			if IsErrNotFound(err) {
+				// The item may have been deleted since the index was read
+				return nil
+			}
+			return err
+		}
+		return f(key)
+	})
+}
+
+func (c *sqlReadonlyCollection) GetBlock(key string, val proto.Message) error {
+	span, _ := tracing.AddSpanToAnyExisting(c.ctx, "/sql.RO/GetBlock",
+		"col", c.prefix, "key", strings.TrimPrefix(key, c.prefix))
+	defer tracing.FinishAnySpan(span)
+	if err := watch.CheckType(c.template, val); err != nil {
+		return err
+	}
+	watcher, err := c.WatchOne(key, watch.WithFilterDelete())
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	e, ok := <-watcher.Watch()
+	if !ok {
+		return c.ctx.Err()
+	}
+	if e.Err != nil {
+		return e.Err
+	}
+	return e.Unmarshal(&key, val)
+}
+
+func (c *sqlReadonlyCollection) TTL(key string) (int64, error) {
+	var ttl int64
+	if err := c.db.DB().QueryRowContext(c.ctx, `SELECT CASE WHEN expires_at IS NULL THEN 0
+	ELSE GREATEST(FLOOR(EXTRACT(EPOCH FROM expires_at - now())), 1)::bigint END
+FROM collection_kvs WHERE key = $1 AND `+notExpired, c.Path(key)).Scan(&ttl); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound{c.prefix, key}
+		}
+		return 0, errors.EnsureStack(err)
+	}
+	return ttl, nil
+}
+
+// ListPrefix returns keys (and values) that begin with prefix, f will be
+// called with each key, val will contain the value for the key.
+// You can break out of iteration by returning errutil.ErrBreak.
+func (c *sqlReadonlyCollection) ListPrefix(prefix string, val proto.Message, opts *Options, f func(string) error) error {
+	span, _ := tracing.AddSpanToAnyExisting(c.ctx, "/sql.RO/ListPrefix", "col", c.prefix, "prefix", prefix)
+	defer tracing.FinishAnySpan(span)
+	queryPrefix := c.prefix
+	if prefix != "" {
+		queryPrefix = filepath.Join(c.prefix, prefix)
+	}
+	return c.list(queryPrefix, opts, func(kv *sqlKV) error {
+		if err := proto.Unmarshal(kv.value, val); err != nil {
+			return err
+		}
+		return f(strings.TrimPrefix(kv.key, queryPrefix))
+	})
+}
+
+// List returns objects sorted based on the options passed in. f will be
+// called with each key, val will contain the corresponding value.
+// You can break out of iteration by returning errutil.ErrBreak.
+func (c *sqlReadonlyCollection) List(val proto.Message, opts *Options, f func(key string) error) error {
+	span, _ := tracing.AddSpanToAnyExisting(c.ctx, "/sql.RO/List", "col", c.prefix)
+	defer tracing.FinishAnySpan(span)
+	if err := watch.CheckType(c.template, val); err != nil {
+		return err
+	}
+	return c.list(c.prefix, opts, func(kv *sqlKV) error {
+		if err := proto.Unmarshal(kv.value, val); err != nil {
+			return err
+		}
+		return f(strings.TrimPrefix(kv.key, c.prefix))
+	})
+}
+
+// ListRev is like List, but f is also called with the create-revision of
+// each key.
+func (c *sqlReadonlyCollection) ListRev(val proto.Message, opts *Options, f func(key string, createRev int64) error) error {
+	span, _ := tracing.AddSpanToAnyExisting(c.ctx, "/sql.RO/List", "col", c.prefix)
+	defer tracing.FinishAnySpan(span)
+	if err := watch.CheckType(c.template, val); err != nil {
+		return err
+	}
+	return c.list(c.prefix, opts, func(kv *sqlKV) error {
+		if err := proto.Unmarshal(kv.value, val); err != nil {
+			return err
+		}
+		return f(strings.TrimPrefix(kv.key, c.prefix), kv.createRev)
+	})
+}
+
+// list calls f with each key under prefix, in the order given by opts. The
+// keys are read in pages, and no rows are held open while f is called, so f
+// may query the database itself.
+func (c *sqlReadonlyCollection) list(prefix string, opts *Options, f func(*sqlKV) error) error {
+	column := "create_rev"
+	if opts.Target == etcd.SortByModRevision {
+		column = "mod_rev"
+	}
+	cmp, order := ">", "ASC"
+	if opts.Order == etcd.SortDescend {
+		cmp, order = "<", "DESC"
+	}
+	query := fmt.Sprintf(`SELECT key, value, create_rev, mod_rev, version FROM collection_kvs
+WHERE key LIKE $1 AND %s AND ($2 OR (%s, key) %s ($3, $4))
+ORDER BY %s %s, key %s LIMIT %d`, notExpired, column, cmp, column, order, order, sqlPageSize)
+	first, lastRev, lastKey := true, int64(0), ""
+	for {
+		kvs, err := querySQLKVs(c.ctx, c.db, query, likePrefix(prefix), first, lastRev, lastKey)
+		if err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			if strings.Contains(strings.TrimPrefix(kv.key, prefix), indexIdentifier) {
+				continue
+			}
+			if err := f(kv); err != nil {
+				if errors.Is(err, errutil.ErrBreak) {
+					return nil
+				}
+				return err
+			}
+		}
+		if len(kvs) < sqlPageSize {
+			return nil
+		}
+		last := kvs[len(kvs)-1]
+		first, lastKey = false, last.key
+		lastRev = last.createRev
+		if column == "mod_rev" {
+			lastRev = last.modRev
+		}
+	}
+}
+
+func querySQLKVs(ctx context.Context, db *gorm.DB, query string, args ...interface{}) (_ []*sqlKV, retErr error) {
+	rows, err := db.DB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil && retErr == nil {
+			retErr = errors.EnsureStack(err)
+		}
+	}()
+	var kvs []*sqlKV
+	for rows.Next() {
+		kv := &sqlKV{}
+		if err := rows.Scan(&kv.key, &kv.value, &kv.createRev, &kv.modRev, &kv.version); err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		kvs = append(kvs, kv)
+	}
+	return kvs, errors.EnsureStack(rows.Err())
+}
+
+func (c *sqlReadonlyCollection) Count() (int64, error) {
+	var count int64
+	if err := c.db.DB().QueryRowContext(c.ctx, "SELECT COUNT(*) FROM collection_kvs WHERE key LIKE $1 AND "+notExpired, likePrefix(c.prefix)).Scan(&count); err != nil {
+		return 0, errors.EnsureStack(err)
+	}
+	return count, nil
+}
+
+// Watch a collection, returning the current content of the collection as
+// well as any future additions.
+func (c *sqlReadonlyCollection) Watch(opts ...watch.OpOption) (watch.Watcher, error) {
+	return newSQLWatcher(c.ctx, c.db, c.prefix, c.prefix, c.template, opts...)
+}
+
+// WatchF watches a collection and executes a callback function each time an event occurs.
+func (c *sqlReadonlyCollection) WatchF(f func(e *watch.Event) error, opts ...watch.OpOption) error {
+	watcher, err := c.Watch(opts...)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	return watchF(c.ctx, watcher, f)
+}
+
+// WatchByIndex watches items in a collection that match a particular index
+func (c *sqlReadonlyCollection) WatchByIndex(index *Index, val interface{}) (watch.Watcher, error) {
+	watcher, err := newSQLWatcher(c.ctx, c.db, c.prefix, c.indexDir(index, val)+"/", c.template)
+	if err != nil {
+		return nil, err
+	}
+	return watchByIndex(watcher, c.template, func(key string) ([]byte, bool, error) {
+		return c.get(c.Path(key))
+	}), nil
+}
+
+// WatchOne watches a given item.  The first value returned from the watch
+// will be the current value of the item.
+func (c *sqlReadonlyCollection) WatchOne(key string, opts ...watch.OpOption) (watch.Watcher, error) {
+	return newSQLWatcher(c.ctx, c.db, c.prefix, c.Path(key), c.template, opts...)
+}
+
+// WatchOneF watches a given item and executes a callback function each time an event occurs.
+// The first value returned from the watch will be the current value of the item.
+func (c *sqlReadonlyCollection) WatchOneF(key string, f func(e *watch.Event) error, opts ...watch.OpOption) error {
+	watcher, err := c.WatchOne(key, opts...)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	return watchF(c.ctx, watcher, f)
+}
+
+// sqlSortColumns maps etcd sort targets to columns of collection_kvs.
+var sqlSortColumns = map[etcd.SortTarget]string{
+	etcd.SortByKey:            "key",
+	etcd.SortByVersion:        "version",
+	etcd.SortByCreateRevision: "create_rev",
+	etcd.SortByModRevision:    "mod_rev",
+	etcd.SortByValue:          "value",
+}
+
+// newSQLWatcher is the SQL equivalent of watch.NewWatcher. It returns the
+// current items under prefix and then polls the event table for changes.
+func newSQLWatcher(ctx context.Context, db *gorm.DB, trimPrefix, prefix string, template proto.Message, opts ...watch.OpOption) (watch.Watcher, error) {
+	options := watch.ParseOptions(opts...)
+	// List the current items and the revision they're at in one snapshot,
+	// sorted by mod revision unless another sort was requested.
+	orderBy := "mod_rev ASC"
+	if options.Sort && options.SortOrder != etcd.SortNone {
+		orderBy = sqlSortColumns[options.SortTarget] + " ASC"
+		if options.SortOrder == etcd.SortDescend {
+			orderBy = sqlSortColumns[options.SortTarget] + " DESC"
+		}
+	}
+	tx, err := db.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	defer tx.Rollback()
+	var rev int64
+	if err := tx.QueryRowContext(ctx, "SELECT rev FROM collection_revision").Scan(&rev); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	rows, err := tx.QueryContext(ctx, "SELECT key, value, mod_rev, version FROM collection_kvs WHERE key LIKE $1 AND "+notExpired+" ORDER BY "+orderBy, likePrefix(prefix))
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	var initial []*watch.Event
+	for rows.Next() {
+		var key string
+		ev := &watch.Event{Type: watch.EventPut, Template: template}
+		if err := rows.Scan(&key, &ev.Value, &ev.Rev, &ev.Ver); err != nil {
+			rows.Close()
+			return nil, errors.EnsureStack(err)
+		}
+		ev.Key = bytes.TrimPrefix([]byte(key), []byte(trimPrefix))
+		initial = append(initial, ev)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+
+	eventCh := make(chan *watch.Event)
+	done := make(chan struct{})
+	go func() (retErr error) {
+		defer func() {
+			if retErr != nil {
+				select {
+				case eventCh <- &watch.Event{
+					Err:  retErr,
+					Type: watch.EventError,
+				}:
+				case <-done:
+				}
+			}
+			close(eventCh)
+		}()
+		send := func(ev *watch.Event) bool {
+			select {
+			case eventCh <- ev:
+				return true
+			case <-done:
+				return false
+			}
+		}
+		for _, ev := range initial {
+			if !send(ev) {
+				return nil
+			}
+		}
+		// key is the last key seen at rev, a revision's events may span
+		// several polls
+		var key string
+		ticker := time.NewTicker(sqlPollInterval)
+		defer ticker.Stop()
+		for {
+			// Get the notification channel before polling so that commits
+			// made during the poll aren't missed.
+			notification := commitNotification()
+			events, err := pollSQLEvents(ctx, db, prefix, rev, key)
+			if err != nil {
+				return err
+			}
+			for _, ev := range events {
+				rev, key = ev.Rev, string(ev.Key)
+				if (ev.Type == watch.EventPut && options.FilterPut) || (ev.Type == watch.EventDelete && options.FilterDelete) {
+					continue
+				}
+				ev.Key = bytes.TrimPrefix(ev.Key, []byte(trimPrefix))
+				ev.Template = template
+				if !send(ev) {
+					return nil
+				}
+			}
+			if len(events) == sqlPageSize {
+				continue
+			}
+			select {
+			case <-notification:
+			case <-ticker.C:
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}()
+	return watch.MakeWatcher(eventCh, done), nil
+}
+
+// pollSQLEvents returns the events under prefix after the event for key at
+// rev. It returns an error if some of those events have already been
+// compacted.
+func pollSQLEvents(ctx context.Context, db *gorm.DB, prefix string, rev int64, key string) (_ []*watch.Event, retErr error) {
+	// The compacted revision is read in the same statement as the events, so
+	// that the events can't be compacted between the two reads.
+	rows, err := db.DB().QueryContext(ctx, fmt.Sprintf(`SELECT c.rev, e.rev, e.key, e.value, e.version, e.deleted
+FROM collection_compacted c LEFT JOIN collection_events e ON (e.rev, e.key) > ($1, $3) AND e.key LIKE $2
+ORDER BY e.rev, e.key LIMIT %d`, sqlPageSize), rev, likePrefix(prefix), key)
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil && retErr == nil {
+			retErr = errors.EnsureStack(err)
+		}
+	}()
+	var events []*watch.Event
+	for rows.Next() {
+		var compacted int64
+		var eventRev, version sql.NullInt64
+		var eventKey sql.NullString
+		var value []byte
+		var deleted sql.NullBool
+		if err := rows.Scan(&compacted, &eventRev, &eventKey, &value, &version, &deleted); err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		if compacted > rev || (compacted == rev && key != "") {
+			return nil, errors.Errorf("watch revision %d has been compacted (compacted revision: %d)", rev, compacted)
+		}
+		if !eventRev.Valid {
+			// No new events
+			continue
+		}
+		ev := &watch.Event{
+			Key:   []byte(eventKey.String),
+			Value: value,
+			Type:  watch.EventPut,
+			Rev:   eventRev.Int64,
+			Ver:   version.Int64,
+		}
+		if deleted.Bool {
+			ev.Type = watch.EventDelete
+		}
+		events = append(events, ev)
+	}
+	return events, errors.EnsureStack(rows.Err())
+}
@@ -0,0 +1,164 @@
+package collection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/storage/gc"
+	"github.com/pachyderm/pachyderm/src/server/pkg/uuid"
+	"github.com/pachyderm/pachyderm/src/server/pkg/watch"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/jinzhu/gorm"
+)
+
+func getSQLDB(t *testing.T) *gorm.DB {
+	db, err := gc.NewLocalDB()
+	require.NoError(t, err)
+	require.NoError(t, SetupSQL(db))
+	return db
+}
+
+func TestSQLReadWrite(t *testing.T) {
+	db := getSQLDB(t)
+	jobInfos := NewSQLCollection(db, uuid.NewWithoutDashes(), []*Index{pipelineIndex}, &pps.JobInfo{}, nil, nil)
+
+	j1 := &pps.JobInfo{
+		Job:      client.NewJob("j1"),
+		Pipeline: client.NewPipeline("p1"),
+	}
+	j2 := &pps.JobInfo{
+		Job:      client.NewJob("j2"),
+		Pipeline: client.NewPipeline("p1"),
+	}
+	require.NoError(t, NewSQLSTM(context.Background(), db, func(stm STM) error {
+		jobInfos := jobInfos.ReadWrite(stm)
+		if err := jobInfos.Create(j1.Job.ID, j1); err != nil {
+			return err
+		}
+		return jobInfos.Create(j2.Job.ID, j2)
+	}))
+	err := NewSQLSTM(context.Background(), db, func(stm STM) error {
+		return jobInfos.ReadWrite(stm).Create(j1.Job.ID, j1)
+	})
+	require.True(t, IsErrExists(err))
+
+	jobInfosReadonly := jobInfos.ReadOnly(context.Background())
+	job := &pps.JobInfo{}
+	require.NoError(t, jobInfosReadonly.Get(j1.Job.ID, job))
+	require.Equal(t, j1, job)
+	count, err := jobInfosReadonly.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	// Moving j2 to another pipeline updates the index
+	require.NoError(t, NewSQLSTM(context.Background(), db, func(stm STM) error {
+		return jobInfos.ReadWrite(stm).Update(j2.Job.ID, job, func() error {
+			job.Pipeline = client.NewPipeline("p2")
+			return nil
+		})
+	}))
+	var ids []string
+	require.NoError(t, jobInfosReadonly.GetByIndex(pipelineIndex, client.NewPipeline("p1"), job, DefaultOptions, func(id string) error {
+		ids = append(ids, id)
+		return nil
+	}))
+	require.Equal(t, []string{j1.Job.ID}, ids)
+
+	// Lists are sorted by create revision, newest first
+	ids = nil
+	require.NoError(t, jobInfosReadonly.List(job, DefaultOptions, func(id string) error {
+		ids = append(ids, id)
+		return nil
+	}))
+	require.Equal(t, []string{j2.Job.ID, j1.Job.ID}, ids)
+
+	require.NoError(t, NewSQLSTM(context.Background(), db, func(stm STM) error {
+		return jobInfos.ReadWrite(stm).Delete(j1.Job.ID)
+	}))
+	require.True(t, IsErrNotFound(jobInfosReadonly.Get(j1.Job.ID, job)))
+}
+
+func TestSQLDryrun(t *testing.T) {
+	db := getSQLDB(t)
+	jobInfos := NewSQLCollection(db, uuid.NewWithoutDashes(), nil, &pps.JobInfo{}, nil, nil)
+
+	job := &pps.JobInfo{
+		Job:      client.NewJob("j1"),
+		Pipeline: client.NewPipeline("p1"),
+	}
+	require.NoError(t, NewSQLDryrunSTM(context.Background(), db, func(stm STM) error {
+		return jobInfos.ReadWrite(stm).Put(job.Job.ID, job)
+	}))
+	err := jobInfos.ReadOnly(context.Background()).Get("j1", job)
+	require.True(t, IsErrNotFound(err))
+}
+
+func TestSQLWatch(t *testing.T) {
+	db := getSQLDB(t)
+	clxn := NewSQLCollection(db, uuid.NewWithoutDashes(), nil, &types.BoolValue{}, nil, nil)
+	require.NoError(t, NewSQLSTM(context.Background(), db, func(stm STM) error {
+		return clxn.ReadWrite(stm).Put("a", epsilon)
+	}))
+
+	watcher, err := clxn.ReadOnly(context.Background()).Watch()
+	require.NoError(t, err)
+	defer watcher.Close()
+	nextEvent := func() *watch.Event {
+		select {
+		case ev := <-watcher.Watch():
+			return ev
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+		return nil
+	}
+	ev := nextEvent()
+	require.Equal(t, watch.EventPut, ev.Type)
+	require.Equal(t, "a", string(ev.Key))
+
+	require.NoError(t, NewSQLSTM(context.Background(), db, func(stm STM) error {
+		return clxn.ReadWrite(stm).Put("b", epsilon)
+	}))
+	ev = nextEvent()
+	require.Equal(t, watch.EventPut, ev.Type)
+	require.Equal(t, "b", string(ev.Key))
+
+	require.NoError(t, NewSQLSTM(context.Background(), db, func(stm STM) error {
+		return clxn.ReadWrite(stm).Delete("a")
+	}))
+	ev = nextEvent()
+	require.Equal(t, watch.EventDelete, ev.Type)
+	require.Equal(t, "a", string(ev.Key))
+}
+
+func TestSQLTTL(t *testing.T) {
+	db := getSQLDB(t)
+	clxn := NewSQLCollection(db, uuid.NewWithoutDashes(), nil, &types.BoolValue{}, nil, nil)
+	const TTL = 2
+	require.NoError(t, NewSQLSTM(context.Background(), db, func(stm STM) error {
+		return clxn.ReadWrite(stm).PutTTL("key", epsilon, TTL)
+	}))
+	actualTTL, err := clxn.ReadOnly(context.Background()).TTL("key")
+	require.NoError(t, err)
+	require.True(t, actualTTL > 0 && actualTTL < TTL, "actualTTL was %v", actualTTL)
+
+	watcher, err := clxn.ReadOnly(context.Background()).WatchOne("key", watch.WithFilterPut())
+	require.NoError(t, err)
+	defer watcher.Close()
+	time.Sleep((TTL + 1) * time.Second)
+	require.True(t, IsErrNotFound(clxn.ReadOnly(context.Background()).Get("key", &types.BoolValue{})))
+
+	// Reaping the expired key generates a delete event
+	require.NoError(t, reapExpired(context.Background(), db))
+	select {
+	case ev := <-watcher.Watch():
+		require.Equal(t, watch.EventDelete, ev.Type)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for delete event")
+	}
+}
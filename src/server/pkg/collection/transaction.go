@@ -52,7 +52,11 @@ type STM interface {
 	SetSafePutCheck(key string, ptr uintptr)
 	// IsSafePut checks against the bit pattern for a key to see if it is safe to put.
 	IsSafePut(key string, ptr uintptr) bool
+}
 
+// etcdSTM is an STM that is run against etcd.
+type etcdSTM interface {
+	STM
 	// commit attempts to apply the txn's changes to the server.
 	commit() *v3.TxnResponse
 	reset()
@@ -89,7 +93,7 @@ type stmResponse struct {
 	err  error
 }
 
-func runSTM(s STM, apply func(STM) error, dryrun bool) (*v3.TxnResponse, error) {
+func runSTM(s etcdSTM, apply func(STM) error, dryrun bool) (*v3.TxnResponse, error) {
 	outc := make(chan stmResponse, 1)
 	go func() {
 		defer func() {
@@ -457,7 +461,7 @@ func respToValue(key string, resp *v3.GetResponse) (string, error) {
 // is because fetchTTL calls iface.fetch(), and the implementation of 'fetch' is
 // different for stm and stmSerializeable. Passing the interface ensures the
 // correct version of fetch() is called
-func (s *stm) fetchTTL(iface STM, key string) (int64, error) {
+func (s *stm) fetchTTL(iface etcdSTM, key string) (int64, error) {
 	// check wset cache
 	if wv, ok := s.wset[key]; ok {
 		return wv.ttl, nil
@@ -30,6 +30,9 @@ type Collection interface {
 	// Claim attempts to claim a key and run the passed in callback with
 	// the context for the claim.
 	Claim(ctx context.Context, key string, val proto.Message, f func(context.Context) error) error
+	// ClaimTTL is like Claim, but the claim expires if it isn't renewed for
+	// ttl seconds (e.g. because the claimer died).
+	ClaimTTL(ctx context.Context, key string, val proto.Message, ttl int64, f func(context.Context) error) error
 }
 
 // Index specifies a secondary index on a collection.
@@ -149,6 +149,9 @@ const (
 
 	// StorageV2EnvVar is the environment variable for enabling V2 storage.
 	StorageV2EnvVar = "STORAGE_V2"
+
+	// StorageCompressionEnvVar is the environment variable for the chunk compression algorithm.
+	StorageCompressionEnvVar = "STORAGE_COMPRESSION"
 )
 
 const (
@@ -165,6 +168,7 @@ const (
 type StorageOpts struct {
 	UploadConcurrencyLimit  int
 	PutFileConcurrencyLimit int
+	Compression             string
 }
 
 const (
@@ -299,6 +303,10 @@ type AssetOpts struct {
 	// WorkerServiceAccountName is the name of the service account that will be
 	// used in the worker pods for creating S3 gateways.
 	WorkerServiceAccountName string
+
+	// CollectionBackend is the database that pachd stores its collections in,
+	// either "etcd" or "postgres". Postgres is deployed if it's "postgres".
+	CollectionBackend string
 }
 
 // replicas lets us create a pointer to a non-zero int32 in-line. This is
@@ -548,6 +556,7 @@ func getStorageEnvVars(opts *AssetOpts) []v1.EnvVar {
 		{Name: UploadConcurrencyLimitEnvVar, Value: strconv.Itoa(opts.StorageOpts.UploadConcurrencyLimit)},
 		{Name: PutFileConcurrencyLimitEnvVar, Value: strconv.Itoa(opts.StorageOpts.PutFileConcurrencyLimit)},
 		{Name: StorageV2EnvVar, Value: strconv.FormatBool(opts.FeatureFlags.StorageV2)},
+		{Name: StorageCompressionEnvVar, Value: opts.StorageOpts.Compression},
 	}
 }
 
@@ -696,6 +705,7 @@ func PachdDeployment(opts *AssetOpts, objectStoreBackend backend, hostPath strin
 		{Name: "EXPOSE_OBJECT_API", Value: strconv.FormatBool(opts.ExposeObjectAPI)},
 		{Name: "CLUSTER_DEPLOYMENT_ID", Value: opts.ClusterDeploymentID},
 		{Name: RequireCriticalServersOnlyEnvVar, Value: strconv.FormatBool(opts.RequireCriticalServersOnly)},
+		{Name: "COLLECTION_BACKEND", Value: opts.CollectionBackend},
 		{
 			Name: "PACHD_POD_NAME",
 			ValueFrom: &v1.EnvVarSource{
@@ -847,6 +857,11 @@ func PachdService(opts *AssetOpts) *v1.Service {
 					Name:     "s3gateway-port",
 					NodePort: 30600,
 				},
+				{
+					Port:     601, // also set in cmd/pachd/main.go
+					Name:     "webdav-port",
+					NodePort: 30601,
+				},
 			},
 		},
 	}
@@ -1811,7 +1826,7 @@ func WriteAssets(encoder serde.Encoder, opts *AssetOpts, objectStoreBackend back
 		return err
 	}
 
-	if opts.StorageV2 {
+	if opts.StorageV2 || opts.CollectionBackend == "postgres" {
 		// In the dynamic route, we create a storage class which dynamically
 		// provisions volumes, and run postgres as a stateful set.
 		// In the static route, we create a single volume, a single volume
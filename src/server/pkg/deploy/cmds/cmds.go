@@ -313,9 +313,11 @@ func standardDeployCmds() []*cobra.Command {
 	var tlsCertKey string
 	var uploadConcurrencyLimit int
 	var putFileConcurrencyLimit int
+	var storageCompression string
 	var clusterDeploymentID string
 	var requireCriticalServersOnly bool
 	var workerServiceAccountName string
+	var collectionBackend string
 	appendGlobalFlags := func(cmd *cobra.Command) {
 		cmd.Flags().IntVar(&pachdShards, "shards", 16, "(rarely set) The maximum number of pachd nodes allowed in the cluster; increasing this number blindly can result in degraded performance.")
 		cmd.Flags().IntVar(&etcdNodes, "dynamic-etcd-nodes", 0, "Deploy etcd as a StatefulSet with the given number of pods.  The persistent volumes used by these pods are provisioned dynamically.  Note that StatefulSet is currently a beta kubernetes feature, which might be unavailable in older versions of kubernetes.")
@@ -339,9 +341,11 @@ func standardDeployCmds() []*cobra.Command {
 		cmd.Flags().BoolVar(&storageV2, "storage-v2", false, "Deploy Pachyderm using V2 storage (alpha)")
 		cmd.Flags().IntVar(&uploadConcurrencyLimit, "upload-concurrency-limit", assets.DefaultUploadConcurrencyLimit, "The maximum number of concurrent object storage uploads per Pachd instance.")
 		cmd.Flags().IntVar(&putFileConcurrencyLimit, "put-file-concurrency-limit", assets.DefaultPutFileConcurrencyLimit, "The maximum number of files to upload or fetch from remote sources (HTTP, blob storage) using PutFile concurrently.")
+		cmd.Flags().StringVar(&storageCompression, "storage-compression", "", "The algorithm to compress chunks with when using V2 storage. One of: none|gzip|snappy|zstd")
 		cmd.Flags().StringVar(&clusterDeploymentID, "cluster-deployment-id", "", "Set an ID for the cluster deployment. Defaults to a random value.")
 		cmd.Flags().BoolVar(&requireCriticalServersOnly, "require-critical-servers-only", assets.DefaultRequireCriticalServersOnly, "Only require the critical Pachd servers to startup and run without errors.")
 		cmd.Flags().StringVar(&workerServiceAccountName, "worker-service-account", assets.DefaultWorkerServiceAccountName, "The Kubernetes service account for workers to use when creating S3 gateways.")
+		cmd.Flags().StringVar(&collectionBackend, "collection-backend", "etcd", "The database to store Pachyderm's metadata collections in (alpha). One of: etcd|postgres")
 
 		// Flags for setting pachd resource requests. These should rarely be set --
 		// only if we get the defaults wrong, or users have an unusual access pattern
@@ -427,6 +431,7 @@ func standardDeployCmds() []*cobra.Command {
 			StorageOpts: assets.StorageOpts{
 				UploadConcurrencyLimit:  uploadConcurrencyLimit,
 				PutFileConcurrencyLimit: putFileConcurrencyLimit,
+				Compression:             storageCompression,
 			},
 			PachdShards:                uint64(pachdShards),
 			Version:                    version.PrettyPrintVersion(version.Version),
@@ -454,6 +459,7 @@ func standardDeployCmds() []*cobra.Command {
 			ClusterDeploymentID:        clusterDeploymentID,
 			RequireCriticalServersOnly: requireCriticalServersOnly,
 			WorkerServiceAccountName:   workerServiceAccountName,
+			CollectionBackend:          collectionBackend,
 		}
 		if tlsCertKey != "" {
 			// TODO(msteffen): If either the cert path or the key path contains a
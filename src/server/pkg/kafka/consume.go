@@ -0,0 +1,47 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+)
+
+// Consume reads batches of messages from r and passes each one to f, until
+// ctx is canceled or f returns an error. A batch is passed to f once it
+// holds batchSize messages, or batchInterval after its first message was
+// read, whichever comes first. The offsets of a batch are committed only
+// after f returns successfully, so if the consumer restarts, batches that
+// weren't fully processed are read again (i.e. delivery is at least once).
+func Consume(ctx context.Context, r Reader, batchSize int, batchInterval time.Duration, f func([]Message) error) error {
+	for {
+		msg, err := r.FetchMessage(ctx)
+		if err != nil {
+			return err
+		}
+		batch := []Message{msg}
+		if err := func() error {
+			batchCtx, cancel := context.WithTimeout(ctx, batchInterval)
+			defer cancel()
+			for len(batch) < batchSize {
+				msg, err := r.FetchMessage(batchCtx)
+				if err != nil {
+					if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+						return nil
+					}
+					return err
+				}
+				batch = append(batch, msg)
+			}
+			return nil
+		}(); err != nil {
+			return err
+		}
+		if err := f(batch); err != nil {
+			return err
+		}
+		if err := r.CommitMessages(ctx, batch...); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+)
+
+// recordBatchSize is the number of records published in each call to
+// WriteMessages.
+const recordBatchSize = 1000
+
+// Egress publishes the files in commit to the topic in u. Each message is
+// keyed by the path of the file it came from.
+func Egress(pachClient *client.APIClient, commit *pfs.Commit, u *URL, d Dialer) (retErr error) {
+	w := d.NewWriter(u)
+	defer func() {
+		if err := w.Close(); retErr == nil {
+			retErr = err
+		}
+	}()
+	return pachClient.Walk(commit.Repo.Name, commit.ID, "", func(fileInfo *pfs.FileInfo) error {
+		if fileInfo.FileType != pfs.FileType_FILE {
+			return nil
+		}
+		r, err := pachClient.GetFileReader(commit.Repo.Name, commit.ID, fileInfo.File.Path, 0, 0)
+		if err != nil {
+			return err
+		}
+		return Publish(pachClient.Ctx(), w, fileInfo.File.Path, r, u.Records)
+	})
+}
+
+// Publish publishes the contents of r to w, keyed by path. If records is
+// true, each line of r is published as a separate message, otherwise all of
+// r is published as a single message.
+func Publish(ctx context.Context, w Writer, path string, r io.Reader, records bool) error {
+	if !records {
+		value, err := ioutil.ReadAll(r)
+		if err != nil {
+			return errors.EnsureStack(err)
+		}
+		return w.WriteMessages(ctx, Message{Key: []byte(path), Value: value})
+	}
+	br := bufio.NewReader(r)
+	var msgs []Message
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return errors.EnsureStack(err)
+		}
+		if len(line) > 0 {
+			msgs = append(msgs, Message{
+				Key:   []byte(path),
+				Value: bytes.TrimSuffix(line, []byte{'\n'}),
+			})
+		}
+		if len(msgs) == recordBatchSize || (errors.Is(err, io.EOF) && len(msgs) > 0) {
+			if err := w.WriteMessages(ctx, msgs...); err != nil {
+				return err
+			}
+			msgs = nil
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Package kafka implements Pachyderm's built in Kafka connectors: egress,
+// which publishes the files of an output commit to a topic, and spouts, which
+// consume a topic into a series of commits.
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// Message is a Kafka message.
+type Message = kafka.Message
+
+// Reader reads messages from a topic as a member of a consumer group.
+type Reader interface {
+	// FetchMessage returns the next message, without committing its offset.
+	FetchMessage(ctx context.Context) (Message, error)
+	// CommitMessages commits the offsets of msgs for the reader's group.
+	CommitMessages(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// Writer publishes messages to a topic.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// Dialer creates readers and writers for the topics in a Kafka URL.
+type Dialer interface {
+	NewReader(u *URL) Reader
+	NewWriter(u *URL) Writer
+}
+
+type dialer struct{}
+
+// NewDialer returns a Dialer that connects to the brokers in each URL.
+func NewDialer() Dialer {
+	return dialer{}
+}
+
+func (dialer) NewReader(u *URL) Reader {
+	return &reader{kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  u.Brokers,
+		Topic:    u.Topic,
+		GroupID:  u.GroupID,
+		MinBytes: 1,
+		MaxBytes: 10e6,
+		MaxWait:  time.Second,
+	})}
+}
+
+func (dialer) NewWriter(u *URL) Writer {
+	return &writer{kafka.NewWriter(kafka.WriterConfig{
+		Brokers: u.Brokers,
+		Topic:   u.Topic,
+		// Messages with the same key (i.e. from the same file) go to the same
+		// partition, so that consumers see them in order.
+		Balancer: &kafka.Hash{},
+	})}
+}
+
+type reader struct {
+	r *kafka.Reader
+}
+
+func (r *reader) FetchMessage(ctx context.Context) (Message, error) {
+	msg, err := r.r.FetchMessage(ctx)
+	return msg, errors.EnsureStack(err)
+}
+
+func (r *reader) CommitMessages(ctx context.Context, msgs ...Message) error {
+	return errors.EnsureStack(r.r.CommitMessages(ctx, msgs...))
+}
+
+func (r *reader) Close() error {
+	return errors.EnsureStack(r.r.Close())
+}
+
+type writer struct {
+	w *kafka.Writer
+}
+
+func (w *writer) WriteMessages(ctx context.Context, msgs ...Message) error {
+	return errors.EnsureStack(w.w.WriteMessages(ctx, msgs...))
+}
+
+func (w *writer) Close() error {
+	return errors.EnsureStack(w.w.Close())
+}
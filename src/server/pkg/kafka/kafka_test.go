@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestParseURL(t *testing.T) {
+	u, err := ParseURL("kafka://a:9092,b:9092/events?group=g&records=true&batch_size=10&batch_interval=1m")
+	require.NoError(t, err)
+	require.Equal(t, &URL{
+		Brokers:       []string{"a:9092", "b:9092"},
+		Topic:         "events",
+		GroupID:       "g",
+		Records:       true,
+		BatchSize:     10,
+		BatchInterval: time.Minute,
+	}, u)
+
+	u, err = ParseURL("kafka://a:9092/events")
+	require.NoError(t, err)
+	require.Equal(t, DefaultGroupID, u.GroupID)
+	require.Equal(t, DefaultBatchSize, u.BatchSize)
+	require.Equal(t, DefaultBatchInterval, u.BatchInterval)
+
+	for _, bad := range []string{
+		"s3://bucket/events",
+		"kafka:///events",
+		"kafka://a:9092",
+		"kafka://a:9092/events/more",
+		"kafka://a:9092/events?batch_size=0",
+		"kafka://a:9092/events?batch_interval=soon",
+	} {
+		_, err := ParseURL(bad)
+		require.YesError(t, err, bad)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	d := NewLocalDialer()
+	u := &URL{Topic: "out"}
+	w := d.NewWriter(u)
+	require.NoError(t, Publish(context.Background(), w, "/file", strings.NewReader("a\nb\n"), false))
+	require.NoError(t, Publish(context.Background(), w, "/records", strings.NewReader("c\nd\ne"), true))
+
+	var values []string
+	for _, msg := range d.Messages("out") {
+		values = append(values, string(msg.Key)+":"+string(msg.Value))
+	}
+	require.Equal(t, []string{"/file:a\nb\n", "/records:c", "/records:d", "/records:e"}, values)
+}
+
+func TestConsume(t *testing.T) {
+	d := NewLocalDialer()
+	u := &URL{Topic: "in", GroupID: "g"}
+	w := d.NewWriter(u)
+	for _, v := range []string{"1", "2", "3", "4", "5"} {
+		require.NoError(t, w.WriteMessages(context.Background(), Message{Value: []byte(v)}))
+	}
+
+	// A failed batch isn't committed
+	errFailed := errors.New("failed")
+	err := Consume(context.Background(), d.NewReader(u), 2, time.Second, func([]Message) error {
+		return errFailed
+	})
+	require.True(t, errors.Is(err, errFailed))
+	require.Equal(t, int64(0), d.Committed("g", "in"))
+
+	// Batches are cut at the batch size, and partial batches are passed on
+	// after the batch interval
+	ctx, cancel := context.WithCancel(context.Background())
+	var batches [][]string
+	err = Consume(ctx, d.NewReader(u), 2, 100*time.Millisecond, func(msgs []Message) error {
+		var batch []string
+		for _, msg := range msgs {
+			batch = append(batch, string(msg.Value))
+		}
+		batches = append(batches, batch)
+		if len(batches) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	require.True(t, errors.Is(err, context.Canceled))
+	require.Equal(t, [][]string{{"1", "2"}, {"3", "4"}, {"5"}}, batches)
+	require.Equal(t, int64(5), d.Committed("g", "in"))
+}
@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+)
+
+const (
+	// Scheme is the URL scheme of Kafka egress and spout URLs.
+	Scheme = "kafka"
+
+	// DefaultGroupID is the consumer group used by spouts that don't specify
+	// one.
+	DefaultGroupID = "pachyderm"
+	// DefaultBatchSize is the maximum number of messages written to each
+	// spout commit if the URL doesn't specify one.
+	DefaultBatchSize = 1000
+	// DefaultBatchInterval is how long a spout waits for a batch to fill up
+	// before committing it, if the URL doesn't specify an interval.
+	DefaultBatchInterval = 10 * time.Second
+)
+
+// URL is a parsed Kafka URL, of the form:
+//
+//	kafka://broker1:9092,broker2:9092/topic?group=g&records=true
+//
+// The supported query parameters are:
+//
+//	group          - the consumer group of a spout (default "pachyderm")
+//	records        - for egress, publish each line of a file as a separate
+//	                 message, rather than each file as a message
+//	batch_size     - the maximum number of messages in each spout commit
+//	batch_interval - how long a spout waits for a batch to fill, e.g. "30s"
+type URL struct {
+	Brokers       []string
+	Topic         string
+	GroupID       string
+	Records       bool
+	BatchSize     int
+	BatchInterval time.Duration
+}
+
+// IsURL returns true if urlStr is a Kafka URL.
+func IsURL(urlStr string) bool {
+	return strings.HasPrefix(urlStr, Scheme+"://")
+}
+
+// ParseURL parses a Kafka URL.
+func ParseURL(urlStr string) (*URL, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing url %v", urlStr)
+	}
+	if u.Scheme != Scheme {
+		return nil, errors.Errorf("unrecognized kafka url scheme: %s", u.Scheme)
+	}
+	result := &URL{
+		Topic:         strings.Trim(u.Path, "/"),
+		GroupID:       DefaultGroupID,
+		BatchSize:     DefaultBatchSize,
+		BatchInterval: DefaultBatchInterval,
+	}
+	for _, broker := range strings.Split(u.Host, ",") {
+		if broker != "" {
+			result.Brokers = append(result.Brokers, broker)
+		}
+	}
+	if len(result.Brokers) == 0 {
+		return nil, errors.Errorf("kafka url %v must specify at least one broker", urlStr)
+	}
+	if result.Topic == "" || strings.Contains(result.Topic, "/") {
+		return nil, errors.Errorf("kafka url %v must specify a single topic", urlStr)
+	}
+	q := u.Query()
+	if group := q.Get("group"); group != "" {
+		result.GroupID = group
+	}
+	if records := q.Get("records"); records != "" {
+		if result.Records, err = strconv.ParseBool(records); err != nil {
+			return nil, errors.Wrapf(err, "invalid value for records in kafka url %v", urlStr)
+		}
+	}
+	if batchSize := q.Get("batch_size"); batchSize != "" {
+		if result.BatchSize, err = strconv.Atoi(batchSize); err != nil {
+			return nil, errors.Wrapf(err, "invalid value for batch_size in kafka url %v", urlStr)
+		}
+		if result.BatchSize <= 0 {
+			return nil, errors.Errorf("batch_size in kafka url %v must be positive", urlStr)
+		}
+	}
+	if batchInterval := q.Get("batch_interval"); batchInterval != "" {
+		if result.BatchInterval, err = time.ParseDuration(batchInterval); err != nil {
+			return nil, errors.Wrapf(err, "invalid value for batch_interval in kafka url %v", urlStr)
+		}
+		if result.BatchInterval <= 0 {
+			return nil, errors.Errorf("batch_interval in kafka url %v must be positive", urlStr)
+		}
+	}
+	return result, nil
+}
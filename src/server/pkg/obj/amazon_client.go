@@ -315,6 +315,46 @@ func (c *amazonClient) Walk(_ context.Context, name string, fn func(name string)
 	return fnErr
 }
 
+func (c *amazonClient) WalkInfo(_ context.Context, name string, fn func(info *ObjectInfo) error) error {
+	var fnErr error
+	var prefix *string
+
+	if c.advancedConfig.Reverse {
+		prefix = nil
+	} else {
+		prefix = &name
+	}
+
+	if err := c.s3.ListObjectsPages(
+		&s3.ListObjectsInput{
+			Bucket: aws.String(c.bucket),
+			Prefix: prefix,
+		},
+		func(listObjectsOutput *s3.ListObjectsOutput, lastPage bool) bool {
+			for _, object := range listObjectsOutput.Contents {
+				key := *object.Key
+				if c.advancedConfig.Reverse {
+					key = reverse(key)
+				}
+				if strings.HasPrefix(key, name) {
+					if err := fn(&ObjectInfo{
+						Name: key,
+						Size: aws.Int64Value(object.Size),
+						ETag: aws.StringValue(object.ETag),
+					}); err != nil {
+						fnErr = err
+						return false
+					}
+				}
+			}
+			return true
+		},
+	); err != nil {
+		return err
+	}
+	return fnErr
+}
+
 func (c *amazonClient) Reader(ctx context.Context, name string, offset uint64, size uint64) (io.ReadCloser, error) {
 	if c.advancedConfig.Reverse {
 		name = reverse(name)
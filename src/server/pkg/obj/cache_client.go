@@ -72,6 +72,10 @@ func (c *cacheClient) Walk(ctx context.Context, p string, cb func(p string) erro
 	return c.slow.Walk(ctx, p, cb)
 }
 
+func (c *cacheClient) WalkInfo(ctx context.Context, p string, cb func(info *ObjectInfo) error) error {
+	return c.slow.WalkInfo(ctx, p, cb)
+}
+
 func (c *cacheClient) IsIgnorable(err error) bool {
 	return c.fast.IsIgnorable(err) || c.slow.IsIgnorable(err)
 }
@@ -1,6 +1,8 @@
 package obj
 
 import (
+	"encoding/hex"
+	"fmt"
 	"io"
 	"strings"
 
@@ -53,6 +55,33 @@ func (c *googleClient) Walk(ctx context.Context, name string, fn func(name strin
 	return nil
 }
 
+func (c *googleClient) WalkInfo(ctx context.Context, name string, fn func(info *ObjectInfo) error) error {
+	objectIter := c.bucket.Objects(ctx, &storage.Query{Prefix: name})
+	for {
+		objectAttrs, err := objectIter.Next()
+		if err != nil {
+			if errors.Is(err, iterator.Done) {
+				break
+			}
+			return err
+		}
+		// Composite objects don't have an MD5 hash, but all objects have a
+		// CRC32C checksum
+		etag := fmt.Sprintf("crc32c:%08x", objectAttrs.CRC32C)
+		if len(objectAttrs.MD5) > 0 {
+			etag = hex.EncodeToString(objectAttrs.MD5)
+		}
+		if err := fn(&ObjectInfo{
+			Name: objectAttrs.Name,
+			Size: objectAttrs.Size,
+			ETag: etag,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *googleClient) Reader(ctx context.Context, name string, offset uint64, size uint64) (io.ReadCloser, error) {
 	var reader io.ReadCloser
 	var err error
@@ -2,6 +2,7 @@ package obj
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -101,6 +102,22 @@ func (c *localClient) Walk(_ context.Context, dir string, walkFn func(name strin
 	return errors.EnsureStack(err)
 }
 
+// WalkInfo uses the modification time and size of each file as its ETag, as
+// computing a hash of its content would require reading it
+func (c *localClient) WalkInfo(ctx context.Context, dir string, walkFn func(info *ObjectInfo) error) error {
+	return c.Walk(ctx, dir, func(name string) error {
+		fi, err := os.Stat(c.normPath(name))
+		if err != nil {
+			return errors.EnsureStack(err)
+		}
+		return walkFn(&ObjectInfo{
+			Name: name,
+			Size: fi.Size(),
+			ETag: fmt.Sprintf("%x-%x", fi.ModTime().UnixNano(), fi.Size()),
+		})
+	})
+}
+
 func (c *localClient) Exists(ctx context.Context, path string) bool {
 	_, err := os.Stat(c.normPath(path))
 	tracing.TagAnySpan(ctx, "err", err)
@@ -89,6 +89,34 @@ func (c *microsoftClient) Walk(_ context.Context, name string, f func(name strin
 	return nil
 }
 
+func (c *microsoftClient) WalkInfo(_ context.Context, name string, f func(info *ObjectInfo) error) error {
+	var marker string
+	for {
+		blobList, err := c.container.ListBlobs(storage.ListBlobsParameters{
+			Prefix: name,
+			Marker: marker,
+		})
+		if err != nil {
+			return err
+		}
+		for _, file := range blobList.Blobs {
+			if err := f(&ObjectInfo{
+				Name: file.Name,
+				Size: file.Properties.ContentLength,
+				ETag: file.Properties.Etag,
+			}); err != nil {
+				return err
+			}
+		}
+		// NextMarker is empty when all results have been returned
+		if blobList.NextMarker == "" {
+			break
+		}
+		marker = blobList.NextMarker
+	}
+	return nil
+}
+
 func (c *microsoftClient) Exists(ctx context.Context, name string) bool {
 	exists, err := c.container.GetBlobReference(name).Exists()
 	tracing.TagAnySpan(ctx, "exists", exists, "err", err)
@@ -107,6 +107,26 @@ func (c *minioClient) Walk(_ context.Context, name string, fn func(name string)
 	return nil
 }
 
+func (c *minioClient) WalkInfo(_ context.Context, name string, fn func(info *ObjectInfo) error) error {
+	recursive := true // Recursively walk by default.
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	for objInfo := range c.ListObjectsV2(c.bucket, name, recursive, doneCh) {
+		if objInfo.Err != nil {
+			return objInfo.Err
+		}
+		if err := fn(&ObjectInfo{
+			Name: objInfo.Key,
+			Size: objInfo.Size,
+			ETag: objInfo.ETag,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // limitReadCloser implements a closer compatible wrapper
 // for a size limited reader.
 type limitReadCloser struct {
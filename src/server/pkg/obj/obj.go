@@ -106,6 +106,12 @@ const (
 	LogOptionsEnvVar     = "OBJ_LOG_OPTS"
 )
 
+// Chunk encryption environment variables
+const (
+	EncryptionKeyEnvVar   = "STORAGE_ENCRYPTION_KEY"
+	EncryptionKeyIDEnvVar = "STORAGE_ENCRYPTION_KEY_ID"
+)
+
 const (
 	// DefaultRetries is the default number of retries for object storage requests.
 	DefaultRetries = 10
@@ -182,6 +188,17 @@ var EnvVarToSecretKey = []struct {
 	{Key: DisableSSLEnvVar, Value: "disable-ssl"},
 	{Key: NoVerifySSLEnvVar, Value: "no-verify-ssl"},
 	{Key: LogOptionsEnvVar, Value: "log-options"},
+	{Key: EncryptionKeyEnvVar, Value: "encryption-key"},
+	{Key: EncryptionKeyIDEnvVar, Value: "encryption-key-id"},
+}
+
+// ObjectInfo describes an object found by Client.WalkInfo.
+type ObjectInfo struct {
+	Name string
+	Size int64
+	// ETag is an opaque string that changes whenever the object's content
+	// does. It's only comparable between objects in the same object store.
+	ETag string
 }
 
 // Client is an interface to object storage.
@@ -201,6 +218,9 @@ type Client interface {
 	Delete(ctx context.Context, name string) error
 	// Walk calls `fn` with the names of objects which can be found under `prefix`.
 	Walk(ctx context.Context, prefix string, fn func(name string) error) error
+	// WalkInfo is like Walk, but calls `fn` with the size and ETag of each
+	// object as well as its name.
+	WalkInfo(ctx context.Context, prefix string, fn func(info *ObjectInfo) error) error
 	// Exsits checks if a given object already exists
 	Exists(ctx context.Context, name string) bool
 	// IsRetryable determines if an operation should be retried given an error
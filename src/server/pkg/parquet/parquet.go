@@ -0,0 +1,335 @@
+// Package parquet splits Parquet files by row group, so that PFS can store a
+// Parquet file as a set of smaller, self-contained Parquet files.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+)
+
+// Magic is the four bytes that start and end every Parquet file. It is the
+// Header of every split file.
+var Magic = []byte("PAR1")
+
+// Field ids in the Parquet footer (see parquet.thrift in the Parquet format
+// specification)
+const (
+	fileMetaDataNumRows   = 3
+	fileMetaDataRowGroups = 4
+
+	rowGroupColumns    = 1
+	rowGroupNumRows    = 3
+	rowGroupFileOffset = 5
+	rowGroupOrdinal    = 7
+
+	columnChunkFilePath          = 1
+	columnChunkFileOffset        = 2
+	columnChunkMetaData          = 3
+	columnChunkOffsetIndexOffset = 4
+	columnChunkOffsetIndexLength = 5
+	columnChunkColumnIndexOffset = 6
+	columnChunkColumnIndexLength = 7
+	columnChunkCryptoMetaData    = 8
+
+	columnMetaDataTotalCompressedSize  = 7
+	columnMetaDataDataPageOffset       = 9
+	columnMetaDataIndexPageOffset      = 10
+	columnMetaDataDictionaryPageOffset = 11
+	columnMetaDataBloomFilterOffset    = 14
+	columnMetaDataBloomFilterLength    = 15
+)
+
+// Reader splits a Parquet file into groups of row groups. Each group is
+// returned with a footer describing just its row groups, so that Magic
+// followed by a group is a valid Parquet file with the original schema.
+//
+// Parquet files can only be read from their footer, so Reader copies its
+// input to a temporary file, which is removed by Close.
+type Reader struct {
+	Header []byte
+
+	f                       *os.File
+	meta                    *tStruct
+	rowGroups               []*rowGroup
+	next                    int
+	done                    bool
+	targetRows, targetBytes int64
+}
+
+// rowGroup is a row group's metadata and location in the file
+type rowGroup struct {
+	meta       *tStruct
+	start, end int64
+	rows       int64
+}
+
+// NewReader creates a new Reader. Each group of row groups returned by Read
+// holds at least targetRows rows or targetBytes bytes (if either is set),
+// unless it's the last group. If neither is set, each row group is returned
+// separately.
+func NewReader(r io.Reader, targetRows, targetBytes int64) (_ *Reader, retErr error) {
+	f, err := ioutil.TempFile("", "pachyderm_parquet")
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	pr := &Reader{
+		Header:      Magic,
+		f:           f,
+		targetRows:  targetRows,
+		targetBytes: targetBytes,
+	}
+	defer func() {
+		if retErr != nil {
+			pr.Close()
+		}
+	}()
+	size, err := io.Copy(f, r)
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	if err := pr.readFooter(size); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// Close removes the Reader's temporary file.
+func (r *Reader) Close() error {
+	err := r.f.Close()
+	if rmErr := os.Remove(r.f.Name()); err == nil {
+		err = rmErr
+	}
+	return errors.EnsureStack(err)
+}
+
+func (r *Reader) readFooter(size int64) error {
+	if size < int64(2*len(Magic)+4) {
+		return errors.Errorf("invalid parquet file: too short")
+	}
+	head := make([]byte, len(Magic))
+	if _, err := r.f.ReadAt(head, 0); err != nil {
+		return errors.EnsureStack(err)
+	}
+	tail := make([]byte, 4+len(Magic))
+	if _, err := r.f.ReadAt(tail, size-int64(len(tail))); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if !bytes.Equal(head, Magic) || !bytes.Equal(tail[4:], Magic) {
+		// Encrypted files end in "PARE"
+		return errors.Errorf("invalid parquet file: missing magic bytes (encrypted files are not supported)")
+	}
+	footerLen := int64(binary.LittleEndian.Uint32(tail[:4]))
+	footerStart := size - int64(len(tail)) - footerLen
+	if footerStart < int64(len(Magic)) {
+		return errors.Errorf("invalid parquet file: footer length %d is too long", footerLen)
+	}
+	footer := make([]byte, footerLen)
+	if _, err := r.f.ReadAt(footer, footerStart); err != nil {
+		return errors.EnsureStack(err)
+	}
+	meta, err := decodeStruct(footer)
+	if err != nil {
+		return err
+	}
+	r.meta = meta
+	rowGroups, _ := meta.list(fileMetaDataRowGroups)
+	if rowGroups == nil {
+		return nil
+	}
+	for _, elem := range rowGroups.elems {
+		rg, err := parseRowGroup(elem, footerStart)
+		if err != nil {
+			return err
+		}
+		r.rowGroups = append(r.rowGroups, rg)
+	}
+	return nil
+}
+
+func parseRowGroup(elem value, footerStart int64) (*rowGroup, error) {
+	meta, ok := elem.(*tStruct)
+	if !ok {
+		return nil, errors.Errorf("invalid parquet footer: row group is not a struct")
+	}
+	rg := &rowGroup{meta: meta, start: -1}
+	rg.rows, _ = meta.int(rowGroupNumRows)
+	columns, _ := meta.list(rowGroupColumns)
+	if columns == nil || len(columns.elems) == 0 {
+		return nil, errors.Errorf("invalid parquet footer: row group has no columns")
+	}
+	for _, elem := range columns.elems {
+		chunk, ok := elem.(*tStruct)
+		if !ok {
+			return nil, errors.Errorf("invalid parquet footer: column chunk is not a struct")
+		}
+		if chunk.field(columnChunkFilePath) != nil {
+			return nil, errors.Errorf("parquet files with columns in other files are not supported")
+		}
+		if chunk.field(columnChunkCryptoMetaData) != nil {
+			return nil, errors.Errorf("encrypted parquet files are not supported")
+		}
+		colMeta, ok := chunk.child(columnChunkMetaData)
+		if !ok {
+			return nil, errors.Errorf("invalid parquet footer: column chunk has no metadata")
+		}
+		start, ok := colMeta.int(columnMetaDataDataPageOffset)
+		if !ok {
+			return nil, errors.Errorf("invalid parquet footer: column chunk has no data page offset")
+		}
+		for _, id := range []int16{columnMetaDataDictionaryPageOffset, columnMetaDataIndexPageOffset} {
+			if offset, ok := colMeta.int(id); ok && offset > 0 && offset < start {
+				start = offset
+			}
+		}
+		size, _ := colMeta.int(columnMetaDataTotalCompressedSize)
+		end := start + size
+		if start < int64(len(Magic)) || size < 0 || end > footerStart {
+			return nil, errors.Errorf("invalid parquet footer: column chunk at [%d, %d) is outside the file", start, end)
+		}
+		if rg.start < 0 || start < rg.start {
+			rg.start = start
+		}
+		if end > rg.end {
+			rg.end = end
+		}
+	}
+	return rg, nil
+}
+
+// Read returns the next group of row groups (and the footer describing
+// them), and the number of rows in it. It returns io.EOF after the last
+// group. If the file has no row groups, Read returns a single group
+// containing just the footer.
+func (r *Reader) Read() ([]byte, int64, error) {
+	if r.done {
+		return nil, 0, io.EOF
+	}
+	var group []*rowGroup
+	var rows, size int64
+	for r.next < len(r.rowGroups) {
+		rg := r.rowGroups[r.next]
+		group = append(group, rg)
+		rows += rg.rows
+		size += rg.end - rg.start
+		r.next++
+		if (r.targetRows == 0 && r.targetBytes == 0) ||
+			(r.targetRows != 0 && rows >= r.targetRows) ||
+			(r.targetBytes != 0 && size >= r.targetBytes) {
+			break
+		}
+	}
+	r.done = r.next >= len(r.rowGroups)
+	body, err := r.writeGroup(group, rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, rows, nil
+}
+
+// writeGroup copies the row groups in 'group' after the Magic header, and
+// appends a footer in which their offsets point to their new locations.
+func (r *Reader) writeGroup(group []*rowGroup, rows int64) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	rowGroups := &tList{elemType: typeStruct}
+	for i, rg := range group {
+		data := make([]byte, rg.end-rg.start)
+		if _, err := r.f.ReadAt(data, rg.start); err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		// The data is moved from rg.start to just after the magic bytes and
+		// the row groups that precede it in the group
+		shift := int64(len(Magic)+buf.Len()) - rg.start
+		buf.Write(data)
+		rowGroups.elems = append(rowGroups.elems, rg.relocate(shift, int64(i)))
+	}
+	meta := &tStruct{}
+	for _, f := range r.meta.fields {
+		f := *f
+		switch f.id {
+		case fileMetaDataNumRows:
+			f.val = rows
+		case fileMetaDataRowGroups:
+			f.val = rowGroups
+		}
+		meta.fields = append(meta.fields, &f)
+	}
+	footer := encodeStruct(meta)
+	buf.Write(footer)
+	var footerLen [4]byte
+	binary.LittleEndian.PutUint32(footerLen[:], uint32(len(footer)))
+	buf.Write(footerLen[:])
+	buf.Write(Magic)
+	return buf.Bytes(), nil
+}
+
+// relocate returns a copy of rg's metadata in which every offset has been
+// moved by 'shift' bytes, and whose ordinal is 'ordinal'. Page indexes and
+// bloom filters are stored outside of the row groups, so their offsets are
+// dropped.
+func (rg *rowGroup) relocate(shift, ordinal int64) *tStruct {
+	meta := copyStruct(rg.meta)
+	if offset, ok := meta.int(rowGroupFileOffset); ok {
+		meta.setInt(rowGroupFileOffset, clamp(offset, rg.start, rg.end)+shift)
+	}
+	meta.setInt(rowGroupOrdinal, ordinal)
+	columns, _ := meta.list(rowGroupColumns)
+	for _, elem := range columns.elems {
+		chunk := elem.(*tStruct)
+		chunk.remove(columnChunkOffsetIndexOffset, columnChunkOffsetIndexLength,
+			columnChunkColumnIndexOffset, columnChunkColumnIndexLength)
+		if offset, ok := chunk.int(columnChunkFileOffset); ok {
+			chunk.setInt(columnChunkFileOffset, clamp(offset, rg.start, rg.end)+shift)
+		}
+		colMeta, _ := chunk.child(columnChunkMetaData)
+		colMeta.remove(columnMetaDataBloomFilterOffset, columnMetaDataBloomFilterLength)
+		for _, id := range []int16{columnMetaDataDataPageOffset, columnMetaDataIndexPageOffset, columnMetaDataDictionaryPageOffset} {
+			if offset, ok := colMeta.int(id); ok && offset > 0 {
+				colMeta.setInt(id, offset+shift)
+			}
+		}
+	}
+	return meta
+}
+
+func clamp(i, min, max int64) int64 {
+	switch {
+	case i < min:
+		return min
+	case i > max:
+		return max
+	default:
+		return i
+	}
+}
+
+// copyStruct returns a deep copy of the structs and lists in s (scalar values
+// are shared, as they're never modified in place).
+func copyStruct(s *tStruct) *tStruct {
+	result := &tStruct{}
+	for _, f := range s.fields {
+		f := *f
+		f.val = copyValue(f.val)
+		result.fields = append(result.fields, &f)
+	}
+	return result
+}
+
+func copyValue(v value) value {
+	switch v := v.(type) {
+	case *tStruct:
+		return copyStruct(v)
+	case *tList:
+		l := &tList{elemType: v.elemType}
+		for _, elem := range v.elems {
+			l.elems = append(l.elems, copyValue(elem))
+		}
+		return l
+	default:
+		return v
+	}
+}
@@ -0,0 +1,147 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func intField(id int16, typ byte, i int64) *tField {
+	return &tField{id: id, typ: typ, val: i}
+}
+
+// testFile returns a parquet file with one column and a row group for each
+// element of 'rows'. Each row group's data is 10 bytes per row.
+func testFile(rows ...int64) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(Magic)
+	rowGroups := &tList{elemType: typeStruct}
+	var total int64
+	for i, n := range rows {
+		start := int64(buf.Len())
+		buf.Write(bytes.Repeat([]byte{byte('a' + i)}, int(10*n)))
+		colMeta := &tStruct{fields: []*tField{
+			intField(columnMetaDataTotalCompressedSize, typeI64, 10*n),
+			intField(columnMetaDataDataPageOffset, typeI64, start),
+			intField(columnMetaDataBloomFilterOffset, typeI64, 1),
+		}}
+		chunk := &tStruct{fields: []*tField{
+			intField(columnChunkFileOffset, typeI64, start+10*n),
+			{id: columnChunkMetaData, typ: typeStruct, val: colMeta},
+			intField(columnChunkOffsetIndexOffset, typeI64, 1),
+		}}
+		rowGroups.elems = append(rowGroups.elems, &tStruct{fields: []*tField{
+			{id: rowGroupColumns, typ: typeList, val: &tList{elemType: typeStruct, elems: []value{chunk}}},
+			intField(rowGroupNumRows, typeI64, n),
+			intField(rowGroupFileOffset, typeI64, start),
+			intField(rowGroupOrdinal, typeI16, int64(i)),
+		}})
+		total += n
+	}
+	footer := encodeStruct(&tStruct{fields: []*tField{
+		intField(1, typeI32, 1),                                   // version
+		{id: 2, typ: typeList, val: &tList{elemType: typeStruct}}, // schema
+		intField(fileMetaDataNumRows, typeI64, total),
+		{id: fileMetaDataRowGroups, typ: typeList, val: rowGroups},
+		{id: 6, typ: typeBinary, val: []byte("test")}, // created_by
+	}})
+	buf.Write(footer)
+	var footerLen [4]byte
+	binary.LittleEndian.PutUint32(footerLen[:], uint32(len(footer)))
+	buf.Write(footerLen[:])
+	buf.Write(Magic)
+	return buf.Bytes()
+}
+
+func split(t *testing.T, file []byte, targetRows, targetBytes int64) ([][]byte, []int64) {
+	r, err := NewReader(bytes.NewReader(file), targetRows, targetBytes)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, r.Close())
+	}()
+	require.Equal(t, Magic, r.Header)
+	var files [][]byte
+	var rows []int64
+	for {
+		body, n, err := r.Read()
+		if err == io.EOF {
+			return files, rows
+		}
+		require.NoError(t, err)
+		files = append(files, append(append([]byte{}, r.Header...), body...))
+		rows = append(rows, n)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	file := testFile(1, 2, 3)
+	files, rows := split(t, file, 0, 0)
+	require.Equal(t, []int64{1, 2, 3}, rows)
+	for i, f := range files {
+		// Each file is a valid parquet file holding just one row group
+		r, err := NewReader(bytes.NewReader(f), 0, 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(r.rowGroups))
+		rg := r.rowGroups[0]
+		require.Equal(t, int64(len(Magic)), rg.start)
+		require.Equal(t, rows[i], rg.rows)
+		require.Equal(t, bytes.Repeat([]byte{byte('a' + i)}, int(10*rows[i])), f[rg.start:rg.end])
+		numRows, _ := r.meta.int(fileMetaDataNumRows)
+		require.Equal(t, rows[i], numRows)
+		ordinal, _ := rg.meta.int(rowGroupOrdinal)
+		require.Equal(t, int64(0), ordinal)
+		fileOffset, _ := rg.meta.int(rowGroupFileOffset)
+		require.Equal(t, rg.start, fileOffset)
+		columns, _ := rg.meta.list(rowGroupColumns)
+		chunk := columns.elems[0].(*tStruct)
+		require.Nil(t, chunk.field(columnChunkOffsetIndexOffset))
+		colMeta, _ := chunk.child(columnChunkMetaData)
+		require.Nil(t, colMeta.field(columnMetaDataBloomFilterOffset))
+		createdBy := r.meta.field(6)
+		require.NotNil(t, createdBy)
+		require.Equal(t, []byte("test"), createdBy.val)
+		require.NoError(t, r.Close())
+	}
+}
+
+func TestSplitTargets(t *testing.T) {
+	file := testFile(1, 2, 3, 4)
+	_, rows := split(t, file, 3, 0)
+	require.Equal(t, []int64{3, 3, 4}, rows)
+	_, rows = split(t, file, 0, 50)
+	require.Equal(t, []int64{6, 4}, rows)
+
+	// Row groups are renumbered and relocated within each file
+	files, _ := split(t, file, 100, 0)
+	require.Equal(t, 1, len(files))
+	r, err := NewReader(bytes.NewReader(files[0]), 0, 0)
+	require.NoError(t, err)
+	defer r.Close()
+	require.Equal(t, 4, len(r.rowGroups))
+	for i, rg := range r.rowGroups {
+		ordinal, _ := rg.meta.int(rowGroupOrdinal)
+		require.Equal(t, int64(i), ordinal)
+		require.Equal(t, bytes.Repeat([]byte{byte('a' + i)}, int(10*rg.rows)), files[0][rg.start:rg.end])
+	}
+}
+
+func TestSplitEmpty(t *testing.T) {
+	files, rows := split(t, testFile(), 0, 0)
+	require.Equal(t, []int64{0}, rows)
+	require.Equal(t, testFile(), files[0])
+}
+
+func TestInvalidFile(t *testing.T) {
+	_, err := NewReader(bytes.NewReader([]byte("not a parquet file")), 0, 0)
+	require.YesError(t, err)
+	file := testFile(1)
+	_, err = NewReader(bytes.NewReader(file[1:]), 0, 0)
+	require.YesError(t, err)
+	// Corrupt the footer length
+	file[len(file)-5] = 0xff
+	_, err = NewReader(bytes.NewReader(file), 0, 0)
+	require.YesError(t, err)
+}
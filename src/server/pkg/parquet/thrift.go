@@ -0,0 +1,368 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+)
+
+// This file implements just enough of thrift's compact protocol to decode a
+// Parquet footer into a generic tree of values, edit it, and encode it again.
+// Fields that aren't edited are re-encoded exactly as they were read, so
+// fields from newer versions of the Parquet format are preserved.
+
+// thrift compact protocol types
+const (
+	typeStop      = 0
+	typeBoolTrue  = 1
+	typeBoolFalse = 2
+	typeByte      = 3
+	typeI16       = 4
+	typeI32       = 5
+	typeI64       = 6
+	typeDouble    = 7
+	typeBinary    = 8
+	typeList      = 9
+	typeSet       = 10
+	typeMap       = 11
+	typeStruct    = 12
+)
+
+// maxDepth limits the nesting of decoded values, so that a malicious footer
+// can't exhaust the stack
+const maxDepth = 64
+
+// A value is one of: bool (struct fields only), byte (also used for bools
+// inside containers), int64 (i16, i32 and i64), float64, []byte, *tList (lists
+// and sets), *tMap or *tStruct.
+type value interface{}
+
+type tField struct {
+	id  int16
+	typ byte
+	val value
+}
+
+type tStruct struct {
+	fields []*tField
+}
+
+type tList struct {
+	elemType byte
+	elems    []value
+}
+
+type tMap struct {
+	keyType, valType byte
+	keys, vals       []value
+}
+
+// field returns the field of s with the given id, or nil.
+func (s *tStruct) field(id int16) *tField {
+	for _, f := range s.fields {
+		if f.id == id {
+			return f
+		}
+	}
+	return nil
+}
+
+// int returns the integer value of the field of s with the given id.
+func (s *tStruct) int(id int16) (int64, bool) {
+	if f := s.field(id); f != nil {
+		if i, ok := f.val.(int64); ok {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// setInt sets the integer field of s with the given id, which must already
+// exist.
+func (s *tStruct) setInt(id int16, i int64) {
+	if f := s.field(id); f != nil {
+		f.val = i
+	}
+}
+
+// remove removes the fields of s with the given ids.
+func (s *tStruct) remove(ids ...int16) {
+	fields := s.fields[:0]
+Fields:
+	for _, f := range s.fields {
+		for _, id := range ids {
+			if f.id == id {
+				continue Fields
+			}
+		}
+		fields = append(fields, f)
+	}
+	s.fields = fields
+}
+
+// list returns the list field of s with the given id.
+func (s *tStruct) list(id int16) (*tList, bool) {
+	if f := s.field(id); f != nil {
+		if l, ok := f.val.(*tList); ok {
+			return l, true
+		}
+	}
+	return nil, false
+}
+
+// child returns the struct field of s with the given id.
+func (s *tStruct) child(id int16) (*tStruct, bool) {
+	if f := s.field(id); f != nil {
+		if c, ok := f.val.(*tStruct); ok {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+type decoder struct {
+	r     *bytes.Reader
+	depth int
+}
+
+func decodeStruct(data []byte) (*tStruct, error) {
+	d := &decoder{r: bytes.NewReader(data)}
+	return d.readStruct()
+}
+
+func (d *decoder) readStruct() (*tStruct, error) {
+	if d.depth++; d.depth > maxDepth {
+		return nil, errors.Errorf("invalid parquet footer: nested too deeply")
+	}
+	defer func() { d.depth-- }()
+	s := &tStruct{}
+	var lastID int16
+	for {
+		b, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == typeStop {
+			return s, nil
+		}
+		f := &tField{typ: b & 0x0f}
+		if delta := int16(b >> 4); delta != 0 {
+			f.id = lastID + delta
+		} else {
+			id, err := d.readVarint()
+			if err != nil {
+				return nil, err
+			}
+			f.id = int16(id)
+		}
+		lastID = f.id
+		switch f.typ {
+		case typeBoolTrue, typeBoolFalse:
+			// the value of a bool field is encoded in its type
+		default:
+			if f.val, err = d.readValue(f.typ); err != nil {
+				return nil, err
+			}
+		}
+		s.fields = append(s.fields, f)
+	}
+}
+
+func (d *decoder) readValue(typ byte) (value, error) {
+	switch typ {
+	case typeBoolTrue, typeBoolFalse, typeByte:
+		return d.readByte()
+	case typeI16, typeI32, typeI64:
+		return d.readVarint()
+	case typeDouble:
+		var buf [8]byte
+		if _, err := io.ReadFull(d.r, buf[:]); err != nil {
+			return nil, truncated(err)
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf[:])), nil
+	case typeBinary:
+		n, err := d.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(d.r.Len()) {
+			return nil, truncated(io.ErrUnexpectedEOF)
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(d.r, buf); err != nil {
+			return nil, truncated(err)
+		}
+		return buf, nil
+	case typeList, typeSet:
+		b, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		l := &tList{elemType: b & 0x0f}
+		n := uint64(b >> 4)
+		if n == 15 {
+			if n, err = d.readUvarint(); err != nil {
+				return nil, err
+			}
+		}
+		if n > uint64(d.r.Len()) {
+			return nil, truncated(io.ErrUnexpectedEOF)
+		}
+		for i := uint64(0); i < n; i++ {
+			elem, err := d.readElem(l.elemType)
+			if err != nil {
+				return nil, err
+			}
+			l.elems = append(l.elems, elem)
+		}
+		return l, nil
+	case typeMap:
+		n, err := d.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		m := &tMap{}
+		if n == 0 {
+			return m, nil
+		}
+		if n > uint64(d.r.Len()) {
+			return nil, truncated(io.ErrUnexpectedEOF)
+		}
+		b, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		m.keyType, m.valType = b>>4, b&0x0f
+		for i := uint64(0); i < n; i++ {
+			k, err := d.readElem(m.keyType)
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.readElem(m.valType)
+			if err != nil {
+				return nil, err
+			}
+			m.keys, m.vals = append(m.keys, k), append(m.vals, v)
+		}
+		return m, nil
+	case typeStruct:
+		return d.readStruct()
+	default:
+		return nil, errors.Errorf("invalid parquet footer: unknown thrift type %d", typ)
+	}
+}
+
+func (d *decoder) readElem(typ byte) (value, error) {
+	if d.depth++; d.depth > maxDepth {
+		return nil, errors.Errorf("invalid parquet footer: nested too deeply")
+	}
+	defer func() { d.depth-- }()
+	return d.readValue(typ)
+}
+
+func (d *decoder) readByte() (byte, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, truncated(err)
+	}
+	return b, nil
+}
+
+func (d *decoder) readUvarint() (uint64, error) {
+	u, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return 0, truncated(err)
+	}
+	return u, nil
+}
+
+func (d *decoder) readVarint() (int64, error) {
+	i, err := binary.ReadVarint(d.r)
+	if err != nil {
+		return 0, truncated(err)
+	}
+	return i, nil
+}
+
+func truncated(err error) error {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return errors.Wrapf(err, "invalid parquet footer")
+}
+
+func encodeStruct(s *tStruct) []byte {
+	buf := &bytes.Buffer{}
+	writeStruct(buf, s)
+	return buf.Bytes()
+}
+
+func writeStruct(buf *bytes.Buffer, s *tStruct) {
+	var lastID int16
+	for _, f := range s.fields {
+		if delta := f.id - lastID; delta > 0 && delta <= 15 {
+			buf.WriteByte(byte(delta)<<4 | f.typ)
+		} else {
+			buf.WriteByte(f.typ)
+			writeVarint(buf, int64(f.id))
+		}
+		lastID = f.id
+		if f.typ != typeBoolTrue && f.typ != typeBoolFalse {
+			writeValue(buf, f.typ, f.val)
+		}
+	}
+	buf.WriteByte(typeStop)
+}
+
+func writeValue(buf *bytes.Buffer, typ byte, v value) {
+	switch typ {
+	case typeBoolTrue, typeBoolFalse, typeByte:
+		buf.WriteByte(v.(byte))
+	case typeI16, typeI32, typeI64:
+		writeVarint(buf, v.(int64))
+	case typeDouble:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(v.(float64)))
+		buf.Write(b[:])
+	case typeBinary:
+		writeUvarint(buf, uint64(len(v.([]byte))))
+		buf.Write(v.([]byte))
+	case typeList, typeSet:
+		l := v.(*tList)
+		if len(l.elems) < 15 {
+			buf.WriteByte(byte(len(l.elems))<<4 | l.elemType)
+		} else {
+			buf.WriteByte(0xf0 | l.elemType)
+			writeUvarint(buf, uint64(len(l.elems)))
+		}
+		for _, elem := range l.elems {
+			writeValue(buf, l.elemType, elem)
+		}
+	case typeMap:
+		m := v.(*tMap)
+		writeUvarint(buf, uint64(len(m.keys)))
+		if len(m.keys) == 0 {
+			return
+		}
+		buf.WriteByte(m.keyType<<4 | m.valType)
+		for i := range m.keys {
+			writeValue(buf, m.keyType, m.keys[i])
+			writeValue(buf, m.valType, m.vals[i])
+		}
+	case typeStruct:
+		writeStruct(buf, v.(*tStruct))
+	}
+}
+
+func writeUvarint(buf *bytes.Buffer, u uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], u)])
+}
+
+func writeVarint(buf *bytes.Buffer, i int64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutVarint(b[:], i)])
+}
@@ -4,7 +4,6 @@ package pfsdb
 import (
 	"path"
 
-	etcd "github.com/coreos/etcd/clientv3"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
@@ -27,9 +26,8 @@ var (
 )
 
 // Repos returns a collection of repos
-func Repos(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
-	return col.NewCollection(
-		etcdClient,
+func Repos(backend col.Backend, etcdPrefix string) col.Collection {
+	return backend.NewCollection(
 		path.Join(etcdPrefix, reposPrefix),
 		nil,
 		&pfs.RepoInfo{},
@@ -39,9 +37,8 @@ func Repos(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
 }
 
 // PutFileRecords returns a collection of putFileRecords
-func PutFileRecords(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
-	return col.NewCollection(
-		etcdClient,
+func PutFileRecords(backend col.Backend, etcdPrefix string) col.Collection {
+	return backend.NewCollection(
 		path.Join(etcdPrefix, putFileRecordsPrefix),
 		nil,
 		&pfs.PutFileRecords{},
@@ -51,9 +48,8 @@ func PutFileRecords(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
 }
 
 // Commits returns a collection of commits
-func Commits(etcdClient *etcd.Client, etcdPrefix string, repo string) col.Collection {
-	return col.NewCollection(
-		etcdClient,
+func Commits(backend col.Backend, etcdPrefix string, repo string) col.Collection {
+	return backend.NewCollection(
 		path.Join(etcdPrefix, commitsPrefix, repo),
 		[]*col.Index{ProvenanceIndex},
 		&pfs.CommitInfo{},
@@ -63,9 +59,8 @@ func Commits(etcdClient *etcd.Client, etcdPrefix string, repo string) col.Collec
 }
 
 // Branches returns a collection of branches
-func Branches(etcdClient *etcd.Client, etcdPrefix string, repo string) col.Collection {
-	return col.NewCollection(
-		etcdClient,
+func Branches(backend col.Backend, etcdPrefix string, repo string) col.Collection {
+	return backend.NewCollection(
 		path.Join(etcdPrefix, branchesPrefix, repo),
 		nil,
 		&pfs.BranchInfo{},
@@ -80,9 +75,8 @@ func Branches(etcdClient *etcd.Client, etcdPrefix string, repo string) col.Colle
 }
 
 // OpenCommits returns a collection of open commits
-func OpenCommits(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
-	return col.NewCollection(
-		etcdClient,
+func OpenCommits(backend col.Backend, etcdPrefix string) col.Collection {
+	return backend.NewCollection(
 		path.Join(etcdPrefix, openCommitsPrefix),
 		nil,
 		&pfs.Commit{},
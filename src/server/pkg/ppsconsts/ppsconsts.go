@@ -15,10 +15,22 @@ const (
 	// SpecFile is the file in every SpecRepo commit containing the PipelineInfo
 	SpecFile = "spec"
 
+	// TemplateFile is the file in a SpecRepo commit containing the template
+	// that the pipeline spec was rendered from, if any
+	TemplateFile = "template"
+
+	// TemplateArgsFile is the file in a SpecRepo commit containing the
+	// (JSON-encoded) args that the pipeline's template was rendered with
+	TemplateArgsFile = "args"
+
 	// PPSTokenKey is a key (in etcd) that maps to PPS's auth token.
 	// This is the token that PPS uses to authorize spec writes.
 	PPSTokenKey = "master_token"
 
 	// SpoutMarkerBranch is the branch that spouts use for keeping track of spout marker files
 	SpoutMarkerBranch = "marker"
+
+	// QuarantineBranch is the branch, in the output repo of a pipeline with
+	// QuarantineFailedDatums set, containing records of its quarantined datums
+	QuarantineBranch = "quarantine"
 )
@@ -4,8 +4,6 @@ package ppsdb
 import (
 	"path"
 
-	etcd "github.com/coreos/etcd/clientv3"
-
 	"github.com/pachyderm/pachyderm/src/client/pps"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 )
@@ -28,9 +26,8 @@ var (
 )
 
 // Pipelines returns a Collection of pipelines
-func Pipelines(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
-	return col.NewCollection(
-		etcdClient,
+func Pipelines(backend col.Backend, etcdPrefix string) col.Collection {
+	return backend.NewCollection(
 		path.Join(etcdPrefix, pipelinesPrefix),
 		nil,
 		&pps.EtcdPipelineInfo{},
@@ -40,9 +37,8 @@ func Pipelines(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
 }
 
 // Jobs returns a Collection of jobs
-func Jobs(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
-	return col.NewCollection(
-		etcdClient,
+func Jobs(backend col.Backend, etcdPrefix string) col.Collection {
+	return backend.NewCollection(
 		path.Join(etcdPrefix, jobsPrefix),
 		[]*col.Index{JobsPipelineIndex, JobsOutputIndex},
 		&pps.EtcdJobInfo{},
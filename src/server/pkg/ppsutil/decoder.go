@@ -19,18 +19,26 @@ import (
 // It's used by 'create pipeline' and 'update pipeline'
 type PipelineManifestReader struct {
 	decoder serde.Decoder
+	// template is set if the manifest was rendered from a template, and is
+	// attached to every request read from it
+	template *ppsclient.PipelineTemplate
 }
 
 // NewPipelineManifestReader creates a new manifest reader from a path.
-func NewPipelineManifestReader(path string) (result *PipelineManifestReader, retErr error) {
-	var pipelineBytes []byte
+func NewPipelineManifestReader(path string) (*PipelineManifestReader, error) {
+	pipelineBytes, err := readPipelineBytes(path)
+	if err != nil {
+		return nil, err
+	}
+	return newPipelineManifestReader(pipelineBytes), nil
+}
+
+// readPipelineBytes reads the file at 'path', which may be a URL, a local file
+// or "-" (for stdin)
+func readPipelineBytes(path string) (result []byte, retErr error) {
 	if path == "-" {
 		fmt.Print("Reading from stdin.\n")
-		var err error
-		pipelineBytes, err = ioutil.ReadAll(os.Stdin)
-		if err != nil {
-			return nil, err
-		}
+		return ioutil.ReadAll(os.Stdin)
 	} else if url, err := url.Parse(path); err == nil && url.Scheme != "" {
 		resp, err := http.Get(url.String())
 		if err != nil {
@@ -41,17 +49,12 @@ func NewPipelineManifestReader(path string) (result *PipelineManifestReader, ret
 				retErr = err
 			}
 		}()
-		pipelineBytes, err = ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		var err error
-		pipelineBytes, err = ioutil.ReadFile(path)
-		if err != nil {
-			return nil, err
-		}
+		return ioutil.ReadAll(resp.Body)
 	}
+	return ioutil.ReadFile(path)
+}
+
+func newPipelineManifestReader(pipelineBytes []byte) *PipelineManifestReader {
 	// TODO(msteffen): if we can get the yaml decoder to handle leading tabs, as
 	// in pps/cmds/cmds_test.go, then we can get rid of this
 	idx := bytes.IndexFunc(pipelineBytes, func(r rune) bool {
@@ -60,11 +63,11 @@ func NewPipelineManifestReader(path string) (result *PipelineManifestReader, ret
 	if idx >= 0 && pipelineBytes[idx] == '{' {
 		return &PipelineManifestReader{
 			decoder: serde.NewJSONDecoder(bytes.NewReader(pipelineBytes)),
-		}, nil
+		}
 	}
 	return &PipelineManifestReader{
 		decoder: serde.NewYAMLDecoder(bytes.NewReader(pipelineBytes)),
-	}, nil
+	}
 }
 
 // NextCreatePipelineRequest gets the next request from the manifest reader.
@@ -109,6 +112,10 @@ func (r *PipelineManifestReader) NextCreatePipelineRequest() (*ppsclient.CreateP
 	case err != nil:
 		return nil, errors.Wrapf(err, "malformed pipeline spec")
 	default:
+		if r.template != nil {
+			template := *r.template
+			result.Template = &template
+		}
 		return &result, nil
 	}
 }
@@ -0,0 +1,109 @@
+package ppsutil
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsconsts"
+	workercommon "github.com/pachyderm/pachyderm/src/server/worker/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// QuarantinedDatumPath returns the path, in a pipeline's quarantine branch, of
+// the record of the datum with ID 'datumID'
+func QuarantinedDatumPath(datumID string) string {
+	return path.Join("/", datumID+".json")
+}
+
+// ListQuarantinedDatums returns the records in the quarantine branch of
+// 'pipelineName's output repo, keyed by datum ID. If the branch doesn't exist
+// (i.e. no datum has been quarantined yet), no records are returned.
+func ListQuarantinedDatums(pachClient *client.APIClient, pipelineName string) (map[string]*pps.QuarantinedDatum, error) {
+	result := make(map[string]*pps.QuarantinedDatum)
+	var paths []string
+	if err := pachClient.ListFileF(pipelineName, ppsconsts.QuarantineBranch, "/", 0, func(fi *pfs.FileInfo) error {
+		if fi.FileType == pfs.FileType_FILE {
+			paths = append(paths, fi.File.Path)
+		}
+		return nil
+	}); err != nil {
+		if errutil.IsNotFoundError(err) {
+			return result, nil
+		}
+		return nil, err
+	}
+	for _, p := range paths {
+		var buf bytes.Buffer
+		if err := pachClient.GetFile(pipelineName, ppsconsts.QuarantineBranch, p, 0, 0, &buf); err != nil {
+			return nil, err
+		}
+		record := &pps.QuarantinedDatum{}
+		if err := jsonpb.Unmarshal(&buf, record); err != nil {
+			return nil, errors.Wrapf(err, "malformed quarantine record %s", p)
+		}
+		result[record.ID] = record
+	}
+	return result, nil
+}
+
+// PutQuarantinedDatum writes 'record' to the open commit 'commitID' in
+// 'pipelineName's output repo, replacing the datum's previous record (if any)
+func PutQuarantinedDatum(pachClient *client.APIClient, pipelineName, commitID string, record *pps.QuarantinedDatum) error {
+	recordJSON, err := (&jsonpb.Marshaler{Indent: "  "}).MarshalToString(record)
+	if err != nil {
+		return err
+	}
+	_, err = pachClient.PutFileOverwrite(pipelineName, commitID, QuarantinedDatumPath(record.ID), strings.NewReader(recordJSON), 0)
+	return err
+}
+
+// ReleaseQuarantinedDatums marks the datums that were quarantined by the job
+// 'jobID' and match 'dataFilters' as released, so that they're reprocessed by
+// the next job of the pipeline 'pipelineName'
+func ReleaseQuarantinedDatums(pachClient *client.APIClient, pipelineName, jobID string, dataFilters []string) (retErr error) {
+	records, err := ListQuarantinedDatums(pachClient, pipelineName)
+	if err != nil {
+		return err
+	}
+	var released []*pps.QuarantinedDatum
+	for _, record := range records {
+		if record.Released || record.Job.ID != jobID || !workercommon.MatchDatum(dataFilters, record.Data) {
+			continue
+		}
+		record.Released = true
+		released = append(released, record)
+	}
+	if len(released) == 0 {
+		return errors.Errorf("job %s has no quarantined datums matching %v", jobID, dataFilters)
+	}
+	sort.Slice(released, func(i, j int) bool {
+		return released[i].ID < released[j].ID
+	})
+	commit, err := pachClient.StartCommit(pipelineName, ppsconsts.QuarantineBranch)
+	if err != nil {
+		return err
+	}
+	// Delete the commit if it can't be finished, so that it doesn't block the
+	// next update of the quarantine branch
+	defer func() {
+		if retErr != nil {
+			if err := pachClient.DeleteCommit(pipelineName, commit.ID); err != nil {
+				log.Errorf("could not delete unfinished quarantine commit %s: %v", commit.ID, err)
+			}
+		}
+	}()
+	for _, record := range released {
+		if err := PutQuarantinedDatum(pachClient, pipelineName, commit.ID, record); err != nil {
+			return err
+		}
+	}
+	return pachClient.FinishCommit(pipelineName, commit.ID)
+}
@@ -0,0 +1,138 @@
+package ppsutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/serde"
+)
+
+// templateExtensions are the file extensions of pipeline specs that are
+// rendered as templates even if no parameters are passed
+var templateExtensions = []string{".tmpl", ".tpl"}
+
+// IsPipelineTemplate returns true if the pipeline spec at 'path' should be
+// rendered as a template, based on its file extension
+func IsPipelineTemplate(path string) bool {
+	for _, ext := range templateExtensions {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewPipelineTemplateReader creates a new manifest reader from the templated
+// pipeline spec at 'path'. The template is rendered with Go's text/template
+// package, with the parameters in the values file at 'valuesPath' (JSON or
+// YAML, may be empty) overlaid with 'args' (each of the form "key=value").
+// Besides the standard template functions, templates may call:
+//   - include: renders another template (relative to the top-level one) with
+//     the given parameters, e.g. {{ include "input.tmpl" . }}
+//   - toJSON: encodes a value as JSON, e.g. {{ toJSON .cmd }}
+//
+// A template may render several pipeline specs (e.g. in a range loop), in
+// which case they're read from the manifest in order.
+func NewPipelineTemplateReader(path, valuesPath string, args []string) (*PipelineManifestReader, error) {
+	params := make(map[string]interface{})
+	if valuesPath != "" {
+		valuesBytes, err := readPipelineBytes(valuesPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := serde.DecodeYAML(valuesBytes, &params); err != nil {
+			return nil, errors.Wrapf(err, "malformed values file %s", valuesPath)
+		}
+	}
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf("malformed template arg %q, must be of the form key=value", arg)
+		}
+		params[kv[0]] = kv[1]
+	}
+	source, err := readPipelineBytes(path)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := renderPipelineTemplate(path, string(source), params)
+	if err != nil {
+		return nil, err
+	}
+	argsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	r := newPipelineManifestReader(rendered)
+	r.template = &ppsclient.PipelineTemplate{
+		Path:   path,
+		Source: string(source),
+		Args:   string(argsJSON),
+	}
+	return r, nil
+}
+
+// renderPipelineTemplate renders the template 'source', read from 'path', with
+// 'params'
+func renderPipelineTemplate(path, source string, params map[string]interface{}) ([]byte, error) {
+	// Track the includes being rendered, so that include cycles are an error
+	// rather than a stack overflow
+	rendering := map[string]bool{path: true}
+	var render func(name, source string, data interface{}) ([]byte, error)
+	render = func(name, source string, data interface{}) ([]byte, error) {
+		t, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+			"include": func(includePath string, data interface{}) (string, error) {
+				includePath = resolveIncludePath(path, includePath)
+				if rendering[includePath] {
+					return "", errors.Errorf("template %s includes itself", includePath)
+				}
+				includeSource, err := readPipelineBytes(includePath)
+				if err != nil {
+					return "", err
+				}
+				rendering[includePath] = true
+				defer delete(rendering, includePath)
+				result, err := render(includePath, string(includeSource), data)
+				return string(result), err
+			},
+			"toJSON": func(v interface{}) (string, error) {
+				result, err := json.Marshal(v)
+				return string(result), err
+			},
+		}).Parse(source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse pipeline template")
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return nil, errors.Wrapf(err, "could not render pipeline template")
+		}
+		return buf.Bytes(), nil
+	}
+	return render(path, source, params)
+}
+
+// resolveIncludePath resolves the path of a template included by the template
+// at 'path'. Includes are relative to the directory (or URL) of the top-level
+// template, or the working directory if it was read from stdin.
+func resolveIncludePath(path, includePath string) string {
+	if path == "-" || filepath.IsAbs(includePath) {
+		return includePath
+	}
+	if base, err := url.Parse(path); err == nil && base.Scheme != "" {
+		if ref, err := url.Parse(includePath); err == nil {
+			return base.ResolveReference(ref).String()
+		}
+		return includePath
+	}
+	if ref, err := url.Parse(includePath); err == nil && ref.Scheme != "" {
+		return includePath
+	}
+	return filepath.Join(filepath.Dir(path), includePath)
+}
@@ -30,7 +30,6 @@ import (
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ppsconsts"
 
-	etcd "github.com/coreos/etcd/clientv3"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	v1 "k8s.io/api/core/v1"
@@ -52,6 +51,12 @@ func PipelineRcName(name string, version uint64) string {
 	return fmt.Sprintf("pipeline-%s-v%d", strings.ToLower(name), version)
 }
 
+// WorkNamespace returns the namespace of the work package task queue that a
+// pipeline's workers use to distribute datums
+func WorkNamespace(pipelineInfo *pps.PipelineInfo) string {
+	return fmt.Sprintf("/pipeline-%s/v%d", pipelineInfo.Pipeline.Name, pipelineInfo.Version)
+}
+
 // GetRequestsResourceListFromPipeline returns a list of resources that the pipeline,
 // minimally requires.
 func GetRequestsResourceListFromPipeline(pipelineInfo *pps.PipelineInfo) (*v1.ResourceList, error) {
@@ -158,14 +163,14 @@ func GetPipelineInfo(pachClient *client.APIClient, name string, ptr *pps.EtcdPip
 }
 
 // FailPipeline updates the pipeline's state to failed and sets the failure reason
-func FailPipeline(ctx context.Context, etcdClient *etcd.Client, pipelinesCollection col.Collection, pipelineName string, reason string) error {
-	return SetPipelineState(ctx, etcdClient, pipelinesCollection, pipelineName,
+func FailPipeline(ctx context.Context, backend col.Backend, pipelinesCollection col.Collection, pipelineName string, reason string) error {
+	return SetPipelineState(ctx, backend, pipelinesCollection, pipelineName,
 		nil, pps.PipelineState_PIPELINE_FAILURE, reason)
 }
 
 // CrashingPipeline updates the pipeline's state to crashing and sets the reason
-func CrashingPipeline(ctx context.Context, etcdClient *etcd.Client, pipelinesCollection col.Collection, pipelineName string, reason string) error {
-	return SetPipelineState(ctx, etcdClient, pipelinesCollection, pipelineName,
+func CrashingPipeline(ctx context.Context, backend col.Backend, pipelinesCollection col.Collection, pipelineName string, reason string) error {
+	return SetPipelineState(ctx, backend, pipelinesCollection, pipelineName,
 		nil, pps.PipelineState_PIPELINE_CRASHING, reason)
 }
 
@@ -195,9 +200,9 @@ func (p PipelineTransitionError) Error() string {
 //
 // This function logs a lot for a library function, but it's mostly (maybe
 // exclusively?) called by the PPS master
-func SetPipelineState(ctx context.Context, etcdClient *etcd.Client, pipelinesCollection col.Collection, pipeline string, from []pps.PipelineState, to pps.PipelineState, reason string) (retErr error) {
+func SetPipelineState(ctx context.Context, backend col.Backend, pipelinesCollection col.Collection, pipeline string, from []pps.PipelineState, to pps.PipelineState, reason string) (retErr error) {
 	log.Infof("SetPipelineState attempting to move %s to %s", pipeline, to)
-	_, err := col.NewSTM(ctx, etcdClient, func(stm col.STM) error {
+	err := backend.NewSTM(ctx, func(stm col.STM) error {
 		pipelines := pipelinesCollection.ReadWrite(stm)
 		pipelinePtr := &pps.EtcdPipelineInfo{}
 		if err := pipelines.Get(pipeline, pipelinePtr); err != nil {
@@ -283,33 +288,34 @@ func JobInput(pipelineInfo *pps.PipelineInfo, outputCommitInfo *pfs.CommitInfo)
 // PipelineReqFromInfo converts a PipelineInfo into a CreatePipelineRequest.
 func PipelineReqFromInfo(pipelineInfo *pps.PipelineInfo) *pps.CreatePipelineRequest {
 	return &pps.CreatePipelineRequest{
-		Pipeline:              pipelineInfo.Pipeline,
-		Transform:             pipelineInfo.Transform,
-		ParallelismSpec:       pipelineInfo.ParallelismSpec,
-		HashtreeSpec:          pipelineInfo.HashtreeSpec,
-		Egress:                pipelineInfo.Egress,
-		OutputBranch:          pipelineInfo.OutputBranch,
-		ResourceRequests:      pipelineInfo.ResourceRequests,
-		ResourceLimits:        pipelineInfo.ResourceLimits,
-		SidecarResourceLimits: pipelineInfo.SidecarResourceLimits,
-		Input:                 pipelineInfo.Input,
-		Description:           pipelineInfo.Description,
-		CacheSize:             pipelineInfo.CacheSize,
-		EnableStats:           pipelineInfo.EnableStats,
-		MaxQueueSize:          pipelineInfo.MaxQueueSize,
-		Service:               pipelineInfo.Service,
-		ChunkSpec:             pipelineInfo.ChunkSpec,
-		DatumTimeout:          pipelineInfo.DatumTimeout,
-		JobTimeout:            pipelineInfo.JobTimeout,
-		Salt:                  pipelineInfo.Salt,
-		PodSpec:               pipelineInfo.PodSpec,
-		PodPatch:              pipelineInfo.PodPatch,
-		Spout:                 pipelineInfo.Spout,
-		SchedulingSpec:        pipelineInfo.SchedulingSpec,
-		DatumTries:            pipelineInfo.DatumTries,
-		Standby:               pipelineInfo.Standby,
-		S3Out:                 pipelineInfo.S3Out,
-		Metadata:              pipelineInfo.Metadata,
+		Pipeline:               pipelineInfo.Pipeline,
+		Transform:              pipelineInfo.Transform,
+		ParallelismSpec:        pipelineInfo.ParallelismSpec,
+		HashtreeSpec:           pipelineInfo.HashtreeSpec,
+		Egress:                 pipelineInfo.Egress,
+		OutputBranch:           pipelineInfo.OutputBranch,
+		ResourceRequests:       pipelineInfo.ResourceRequests,
+		ResourceLimits:         pipelineInfo.ResourceLimits,
+		SidecarResourceLimits:  pipelineInfo.SidecarResourceLimits,
+		Input:                  pipelineInfo.Input,
+		Description:            pipelineInfo.Description,
+		CacheSize:              pipelineInfo.CacheSize,
+		EnableStats:            pipelineInfo.EnableStats,
+		MaxQueueSize:           pipelineInfo.MaxQueueSize,
+		Service:                pipelineInfo.Service,
+		ChunkSpec:              pipelineInfo.ChunkSpec,
+		DatumTimeout:           pipelineInfo.DatumTimeout,
+		JobTimeout:             pipelineInfo.JobTimeout,
+		Salt:                   pipelineInfo.Salt,
+		PodSpec:                pipelineInfo.PodSpec,
+		PodPatch:               pipelineInfo.PodPatch,
+		Spout:                  pipelineInfo.Spout,
+		SchedulingSpec:         pipelineInfo.SchedulingSpec,
+		DatumTries:             pipelineInfo.DatumTries,
+		Standby:                pipelineInfo.Standby,
+		S3Out:                  pipelineInfo.S3Out,
+		QuarantineFailedDatums: pipelineInfo.QuarantineFailedDatums,
+		Metadata:               pipelineInfo.Metadata,
 	}
 }
 
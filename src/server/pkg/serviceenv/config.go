@@ -17,6 +17,7 @@ type GlobalConfiguration struct {
 	HTTPPort      uint16 `env:"HTTP_PORT,default=652"`
 	PeerPort      uint16 `env:"PEER_PORT,default=653"`
 	S3GatewayPort uint16 `env:"S3GATEWAY_PORT,default=600"`
+	WebDAVPort    uint16 `env:"WEBDAV_PORT,default=601"`
 	PPSEtcdPrefix string `env:"PPS_ETCD_PREFIX,default=pachyderm_pps"`
 	Namespace     string `env:"PACH_NAMESPACE,default=default"`
 	StorageRoot   string `env:"PACH_ROOT,default=/pach"`
@@ -26,6 +27,12 @@ type GlobalConfiguration struct {
 	SamlPort      uint16 `env:"SAML_PORT,default=654"`
 	OidcPort      uint16 `env:"OIDC_PORT,default=657"`
 
+	// CollectionBackend is the database that pachd and workers store their
+	// metadata collections in, either "etcd" or "postgres"
+	CollectionBackend string `env:"COLLECTION_BACKEND,default=etcd"`
+	PostgresHost      string `env:"POSTGRES_SERVICE_HOST"`
+	PostgresPort      string `env:"POSTGRES_SERVICE_PORT"`
+
 	// PPSSpecCommitID is only set for workers and sidecar pachd instances.
 	// Because both pachd and worker need to know the spec commit (the worker so
 	// that it can avoid jobs for other versions of the same pipelines and the
@@ -55,6 +62,8 @@ type PachdSpecificConfiguration struct {
 	Init                       bool   `env:"INIT,default=false"`
 	BlockCacheBytes            string `env:"BLOCK_CACHE_BYTES,default=1G"`
 	PFSCacheSize               string `env:"PFS_CACHE_SIZE,default=0"`
+	PFSRetentionInterval       string `env:"PFS_RETENTION_INTERVAL,default="`
+	WebDAVSessionTimeout       string `env:"WEBDAV_SESSION_TIMEOUT,default=10s"`
 	WorkerImage                string `env:"WORKER_IMAGE,default="`
 	WorkerSidecarImage         string `env:"WORKER_SIDECAR_IMAGE,default="`
 	WorkerImagePullPolicy      string `env:"WORKER_IMAGE_PULL_POLICY,default="`
@@ -85,6 +94,9 @@ type StorageConfiguration struct {
 	StorageCompactionMaxFanIn      int    `env:"STORAGE_COMPACTION_MAX_FANIN,default=50"`
 	StorageFileSetsMaxOpen         int    `env:"STORAGE_FILESETS_MAX_OPEN,default=50"`
 	StorageDiskCacheSize           int    `env:"STORAGE_DISK_CACHE_SIZE,default=100"`
+	StorageEncryptionKey           string `env:"STORAGE_ENCRYPTION_KEY"`
+	StorageEncryptionKeyID         string `env:"STORAGE_ENCRYPTION_KEY_ID,default=default"`
+	StorageCompression             string `env:"STORAGE_COMPRESSION"`
 }
 
 // WorkerFullConfiguration contains the full worker configuration.
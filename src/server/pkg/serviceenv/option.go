@@ -1,10 +1,13 @@
 package serviceenv
 
 import (
+	"encoding/base64"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
 	"github.com/pachyderm/pachyderm/src/server/pkg/storage/chunk"
 	"github.com/pachyderm/pachyderm/src/server/pkg/storage/fileset"
@@ -46,6 +49,21 @@ func (env *ServiceEnv) ChunkStorageOptions() ([]chunk.StorageOption, error) {
 		}
 		opts = append(opts, chunk.WithObjectCache(diskCache, env.StorageDiskCacheSize))
 	}
+	if env.StorageEncryptionKey != "" {
+		secret, err := base64.StdEncoding.DecodeString(env.StorageEncryptionKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decode %s", obj.EncryptionKeyEnvVar)
+		}
+		keys := chunk.Keys{env.StorageEncryptionKeyID: secret}
+		opts = append(opts, chunk.WithEncryption(keys, env.StorageEncryptionKeyID))
+	}
+	if env.StorageCompression != "" {
+		algo, ok := chunk.CompressionAlgo_value[strings.ToUpper(env.StorageCompression)]
+		if !ok {
+			return nil, errors.Errorf("unrecognized compression algorithm: %s", env.StorageCompression)
+		}
+		opts = append(opts, chunk.WithDefaultCompression(chunk.CompressionAlgo(algo)))
+	}
 	return opts, nil
 }
 
@@ -10,9 +10,12 @@ import (
 	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/storage/gc"
 
 	etcd "github.com/coreos/etcd/clientv3"
 	loki "github.com/grafana/loki/pkg/logcli/client"
+	"github.com/jinzhu/gorm"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"golang.org/x/sync/errgroup"
@@ -20,6 +23,10 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+// collectionDBMaxOpenConns is the size of the connection pool to the postgres
+// database that collections are stored in
+const collectionDBMaxOpenConns = 20
+
 // ServiceEnv is a struct containing connections to other services in the
 // cluster. In pachd, there is only one instance of this struct, but tests may
 // create more, if they want to create multiple pachyderm "clusters" served in
@@ -58,6 +65,15 @@ type ServiceEnv struct {
 	// of this environment, it doesn't require an initialization funcion, so
 	// there's no errgroup associated with it.
 	lokiClient *loki.Client
+
+	// collectionBackend is the backend (etcd or postgres, per
+	// CollectionBackend) that metadata collections are stored in, and
+	// collectionDB is its database if it's postgres
+	collectionBackend col.Backend
+	collectionDB      *gorm.DB
+	// collectionEg coordinates the initialization of collectionBackend (see
+	// pachdEg)
+	collectionEg errgroup.Group
 }
 
 // InitPachOnlyEnv initializes this service environment. This dials a GRPC
@@ -83,6 +99,7 @@ func InitServiceEnv(config *Configuration) *ServiceEnv {
 	env := InitPachOnlyEnv(config)
 	env.etcdAddress = fmt.Sprintf("http://%s", net.JoinHostPort(env.EtcdHost, env.EtcdPort))
 	env.etcdEg.Go(env.initEtcdClient)
+	env.collectionEg.Go(env.initCollectionBackend)
 	if env.LokiHost != "" && env.LokiPort != "" {
 		env.lokiClient = &loki.Client{
 			Address: fmt.Sprintf("http://%s", net.JoinHostPort(env.LokiHost, env.LokiPort)),
@@ -139,6 +156,40 @@ func (env *ServiceEnv) initEtcdClient() error {
 	}, backoff.RetryEvery(time.Second).For(5*time.Minute))
 }
 
+func (env *ServiceEnv) initCollectionBackend() error {
+	switch env.CollectionBackend {
+	case col.EtcdBackend, "":
+		if err := env.etcdEg.Wait(); err != nil {
+			return err
+		}
+		env.collectionBackend = col.NewEtcdBackend(env.etcdClient)
+		return nil
+	case col.PostgresBackend:
+		if env.PostgresHost == "" || env.PostgresPort == "" {
+			return errors.Errorf("postgres service not found (POSTGRES_SERVICE_HOST and POSTGRES_SERVICE_PORT must be set)")
+		}
+		return backoff.Retry(func() error {
+			db, err := gc.NewDB(env.PostgresHost, env.PostgresPort)
+			if err != nil {
+				return errors.Wrapf(err, "failed to connect to postgres")
+			}
+			// Every pachd and worker transaction goes through this database, so
+			// allow more connections than gc.NewDB's default
+			db.DB().SetMaxOpenConns(collectionDBMaxOpenConns)
+			db.DB().SetMaxIdleConns(collectionDBMaxOpenConns)
+			if err := col.SetupSQL(db); err != nil {
+				db.Close()
+				return errors.Wrapf(err, "failed to set up collection tables")
+			}
+			env.collectionDB = db
+			env.collectionBackend = col.NewSQLBackend(db)
+			return nil
+		}, backoff.RetryEvery(time.Second).For(5*time.Minute))
+	default:
+		return col.ValidateBackend(env.CollectionBackend)
+	}
+}
+
 func (env *ServiceEnv) initKubeClient() error {
 	return backoff.Retry(func() error {
 		// Get secure in-cluster config
@@ -195,6 +246,28 @@ func (env *ServiceEnv) GetEtcdClient() *etcd.Client {
 	return env.etcdClient
 }
 
+// GetCollectionBackend returns the backend that metadata collections (e.g.
+// PFS's repos and commits and PPS's pipelines and jobs) are stored in, and
+// that transactions on them must be run against.
+func (env *ServiceEnv) GetCollectionBackend() col.Backend {
+	if err := env.collectionEg.Wait(); err != nil {
+		panic(err) // If env can't connect, there's no sensible way to recover
+	}
+	if env.collectionBackend == nil {
+		panic("service env never connected to the collection backend")
+	}
+	return env.collectionBackend
+}
+
+// GetCollectionDB returns the postgres database that collections are stored
+// in, or nil if they're stored in etcd.
+func (env *ServiceEnv) GetCollectionDB() *gorm.DB {
+	if err := env.collectionEg.Wait(); err != nil {
+		panic(err) // If env can't connect, there's no sensible way to recover
+	}
+	return env.collectionDB
+}
+
 // GetKubeClient returns the already connected Kubernetes API client without
 // modification.
 func (env *ServiceEnv) GetKubeClient() *kube.Clientset {
@@ -22,6 +22,37 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+type CompressionAlgo int32
+
+const (
+	CompressionAlgo_NONE   CompressionAlgo = 0
+	CompressionAlgo_GZIP   CompressionAlgo = 1
+	CompressionAlgo_SNAPPY CompressionAlgo = 2
+	CompressionAlgo_ZSTD   CompressionAlgo = 3
+)
+
+var CompressionAlgo_name = map[int32]string{
+	0: "NONE",
+	1: "GZIP",
+	2: "SNAPPY",
+	3: "ZSTD",
+}
+
+var CompressionAlgo_value = map[string]int32{
+	"NONE":   0,
+	"GZIP":   1,
+	"SNAPPY": 2,
+	"ZSTD":   3,
+}
+
+func (x CompressionAlgo) String() string {
+	return proto.EnumName(CompressionAlgo_name, int32(x))
+}
+
+func (CompressionAlgo) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_80b36f82a9f02ff9, []int{0}
+}
+
 // DataRef is a reference to data within a chunk.
 type DataRef struct {
 	// The chunk the referenced data is located in.
@@ -146,9 +177,12 @@ func (m *Chunk) GetHash() string {
 }
 
 type ChunkInfo struct {
-	Chunk                *Chunk   `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk,omitempty"`
-	SizeBytes            int64    `protobuf:"varint,2,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
-	Edge                 bool     `protobuf:"varint,3,opt,name=edge,proto3" json:"edge,omitempty"`
+	Chunk     *Chunk `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	SizeBytes int64  `protobuf:"varint,2,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	Edge      bool   `protobuf:"varint,3,opt,name=edge,proto3" json:"edge,omitempty"`
+	// The ID of the key the chunk was encrypted with.
+	// This field is empty when the chunk is not encrypted.
+	KeyId                string   `protobuf:"bytes,4,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -208,7 +242,15 @@ func (m *ChunkInfo) GetEdge() bool {
 	return false
 }
 
+func (m *ChunkInfo) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
 func init() {
+	proto.RegisterEnum("chunk.CompressionAlgo", CompressionAlgo_name, CompressionAlgo_value)
 	proto.RegisterType((*DataRef)(nil), "chunk.DataRef")
 	proto.RegisterType((*Chunk)(nil), "chunk.Chunk")
 	proto.RegisterType((*ChunkInfo)(nil), "chunk.ChunkInfo")
@@ -219,24 +261,29 @@ func init() {
 }
 
 var fileDescriptor_80b36f82a9f02ff9 = []byte{
-	// 268 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x50, 0x3d, 0x4f, 0xc3, 0x30,
-	0x10, 0x95, 0xfb, 0x01, 0xe4, 0xda, 0x01, 0x79, 0x8a, 0x84, 0x88, 0x42, 0xc4, 0x90, 0x29, 0x96,
-	0x60, 0x64, 0x2b, 0x2c, 0x5d, 0x3d, 0xb2, 0x54, 0x89, 0x7b, 0x8e, 0xa3, 0x8a, 0x38, 0xb2, 0x5d,
-	0xa4, 0xf2, 0x2b, 0xf8, 0x59, 0x8c, 0xfc, 0x04, 0x94, 0x5f, 0x82, 0x62, 0x97, 0x52, 0x55, 0xea,
-	0x72, 0x7a, 0x7e, 0x77, 0xbe, 0xf7, 0xee, 0xc1, 0xbd, 0x45, 0xf3, 0x8e, 0x86, 0x75, 0x9b, 0x9a,
-	0x59, 0xa7, 0x4d, 0x59, 0x23, 0x13, 0x6a, 0xdb, 0x6e, 0x42, 0x2d, 0x3a, 0xa3, 0x9d, 0xa6, 0x53,
-	0xff, 0xc8, 0x3e, 0x09, 0x5c, 0xbe, 0x94, 0xae, 0xe4, 0x28, 0x29, 0x03, 0xf0, 0xe4, 0xaa, 0x69,
-	0xa5, 0x8e, 0x49, 0x4a, 0xf2, 0xd9, 0xc3, 0x75, 0x11, 0x3e, 0x3d, 0x0f, 0x75, 0xd9, 0x4a, 0xcd,
-	0x23, 0xf1, 0x07, 0x29, 0x85, 0x89, 0x2a, 0xad, 0x8a, 0x47, 0x29, 0xc9, 0x23, 0xee, 0x31, 0xbd,
-	0x83, 0xb9, 0x96, 0xd2, 0xa2, 0x5b, 0x55, 0x3b, 0x87, 0x36, 0x1e, 0xa7, 0x24, 0x1f, 0xf3, 0x59,
-	0xe0, 0x16, 0x03, 0x45, 0x6f, 0x01, 0x6c, 0xf3, 0x81, 0xfb, 0x81, 0x89, 0x1f, 0x88, 0x06, 0xc6,
-	0xb7, 0xb3, 0x1b, 0x98, 0x7a, 0xb5, 0xc3, 0x7a, 0xf2, 0xbf, 0x3e, 0xab, 0x20, 0x3a, 0x58, 0xa1,
-	0x19, 0x84, 0x2b, 0xf6, 0x5e, 0xe7, 0xc7, 0x5e, 0x79, 0x68, 0x9d, 0x88, 0x8d, 0x4e, 0xc4, 0x06,
-	0x0d, 0x5c, 0xd7, 0xe8, 0x6d, 0x5e, 0x71, 0x8f, 0x17, 0xcb, 0xaf, 0x3e, 0x21, 0xdf, 0x7d, 0x42,
-	0x7e, 0xfa, 0x84, 0xbc, 0x3e, 0xd5, 0x8d, 0x53, 0xdb, 0xaa, 0x10, 0xfa, 0x8d, 0x75, 0xa5, 0x50,
-	0xbb, 0x35, 0x9a, 0x63, 0x64, 0x8d, 0x60, 0xe7, 0x12, 0xaf, 0x2e, 0x7c, 0xd8, 0x8f, 0xbf, 0x01,
-	0x00, 0x00, 0xff, 0xff, 0xc9, 0x79, 0xcd, 0x39, 0x94, 0x01, 0x00, 0x00,
+	// 342 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x91, 0x4d, 0x4b, 0xfb, 0x40,
+	0x10, 0xc6, 0xff, 0xdb, 0x26, 0xf9, 0x37, 0xd3, 0x82, 0x61, 0x41, 0x28, 0x88, 0xa5, 0x06, 0x0f,
+	0xc5, 0x43, 0x03, 0x7a, 0xec, 0xa9, 0x2f, 0x22, 0xf1, 0x50, 0x4b, 0xea, 0xc5, 0x5e, 0x4a, 0x9a,
+	0x4c, 0x5e, 0x88, 0xcd, 0x86, 0xdd, 0x54, 0x88, 0x17, 0xbf, 0x82, 0x1f, 0xcb, 0xa3, 0x1f, 0x41,
+	0xfa, 0x49, 0x24, 0x9b, 0x5a, 0x4b, 0xc1, 0xcb, 0xf0, 0xe4, 0x99, 0xc9, 0x3c, 0xbf, 0x65, 0xe0,
+	0x52, 0x20, 0x7f, 0x41, 0x6e, 0x65, 0x49, 0x68, 0x89, 0x9c, 0x71, 0x37, 0x44, 0xcb, 0x8b, 0x36,
+	0x69, 0x52, 0xd5, 0x7e, 0xc6, 0x59, 0xce, 0xa8, 0x2a, 0x3f, 0xcc, 0x77, 0x02, 0xff, 0x27, 0x6e,
+	0xee, 0x3a, 0x18, 0x50, 0x0b, 0x40, 0x9a, 0xcb, 0x38, 0x0d, 0x58, 0x9b, 0x74, 0x49, 0xaf, 0x79,
+	0x6d, 0xf4, 0xab, 0x9f, 0xc6, 0x65, 0xb5, 0xd3, 0x80, 0x39, 0xba, 0xf7, 0x23, 0x29, 0x05, 0x25,
+	0x72, 0x45, 0xd4, 0xae, 0x75, 0x49, 0x4f, 0x77, 0xa4, 0xa6, 0x17, 0xd0, 0x62, 0x41, 0x20, 0x30,
+	0x5f, 0xae, 0x8a, 0x1c, 0x45, 0xbb, 0xde, 0x25, 0xbd, 0xba, 0xd3, 0xac, 0xbc, 0x51, 0x69, 0xd1,
+	0x73, 0x00, 0x11, 0xbf, 0xe2, 0x6e, 0x40, 0x91, 0x03, 0x7a, 0xe9, 0xc8, 0xb6, 0x79, 0x06, 0xaa,
+	0x4c, 0xdb, 0xaf, 0x27, 0xbf, 0xeb, 0xcd, 0x37, 0xd0, 0xf7, 0x28, 0xd4, 0x84, 0xea, 0x15, 0x3b,
+	0xd6, 0xd6, 0x21, 0xab, 0x53, 0xb5, 0x8e, 0xc2, 0x6a, 0x47, 0x61, 0x65, 0x06, 0xfa, 0x21, 0x4a,
+	0xcc, 0x86, 0x23, 0x35, 0x3d, 0x05, 0x2d, 0xc1, 0x62, 0x19, 0xfb, 0x92, 0x4d, 0x77, 0xd4, 0x04,
+	0x0b, 0xdb, 0xbf, 0x57, 0x1a, 0xaa, 0xa1, 0x5d, 0x0d, 0xe0, 0x64, 0xcc, 0xd6, 0x19, 0x47, 0x21,
+	0x62, 0x96, 0x0e, 0x9f, 0x43, 0x46, 0x1b, 0xa0, 0x4c, 0x1f, 0xa6, 0xb7, 0xc6, 0xbf, 0x52, 0xdd,
+	0x2d, 0xec, 0x99, 0x41, 0x28, 0x80, 0x36, 0x9f, 0x0e, 0x67, 0xb3, 0x27, 0xa3, 0x56, 0xba, 0x8b,
+	0xf9, 0xe3, 0xc4, 0xa8, 0x8f, 0xec, 0x8f, 0x6d, 0x87, 0x7c, 0x6e, 0x3b, 0xe4, 0x6b, 0xdb, 0x21,
+	0x8b, 0x41, 0x18, 0xe7, 0xd1, 0x66, 0xd5, 0xf7, 0xd8, 0xda, 0xca, 0x5c, 0x2f, 0x2a, 0x7c, 0xe4,
+	0x87, 0x4a, 0x70, 0xcf, 0xfa, 0xeb, 0x96, 0x2b, 0x4d, 0x9e, 0xf1, 0xe6, 0x7b, 0x00, 0x2f, 0xaa,
+	0x9e, 0x4a, 0xee, 0x01, 0x00, 0x00,
 }
 
 func (m *DataRef) Marshal() (dAtA []byte, err error) {
@@ -353,6 +400,13 @@ func (m *ChunkInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.KeyId) > 0 {
+		i -= len(m.KeyId)
+		copy(dAtA[i:], m.KeyId)
+		i = encodeVarintChunk(dAtA, i, uint64(len(m.KeyId)))
+		i--
+		dAtA[i] = 0x22
+	}
 	if m.Edge {
 		i--
 		if m.Edge {
@@ -452,6 +506,10 @@ func (m *ChunkInfo) Size() (n int) {
 	if m.Edge {
 		n += 2
 	}
+	l = len(m.KeyId)
+	if l > 0 {
+		n += 1 + l + sovChunk(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -814,6 +872,38 @@ func (m *ChunkInfo) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.Edge = bool(v != 0)
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field KeyId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChunk
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthChunk
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthChunk
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.KeyId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipChunk(dAtA[iNdEx:])
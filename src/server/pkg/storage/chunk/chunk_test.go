@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"path"
+	"strings"
 	"testing"
 
 	"github.com/chmduquesne/rollinghash/buzhash64"
@@ -147,7 +149,7 @@ func generateAnnotations(t test) []*testAnnotation {
 	return as
 }
 
-func writeAnnotations(t *testing.T, chunks *Storage, annotations []*testAnnotation, msg string) {
+func writeAnnotations(t *testing.T, chunks *Storage, annotations []*testAnnotation, msg string, opts ...WriterOption) {
 	t.Run("Write", func(t *testing.T) {
 		cb := func(annotations []*Annotation) error {
 			for _, a := range annotations {
@@ -159,7 +161,7 @@ func writeAnnotations(t *testing.T, chunks *Storage, annotations []*testAnnotati
 			}
 			return nil
 		}
-		w := chunks.NewWriter(context.Background(), uuid.NewWithoutDashes(), cb)
+		w := chunks.NewWriter(context.Background(), uuid.NewWithoutDashes(), cb, opts...)
 		for _, a := range annotations {
 			require.NoError(t, w.Annotate(&Annotation{
 				Data: a,
@@ -196,3 +198,136 @@ func readAnnotations(t *testing.T, chunks *Storage, annotations []*testAnnotatio
 		}
 	})
 }
+
+func TestEncryption(t *testing.T) {
+	keys := Keys{"test": []byte("secret")}
+	require.NoError(t, WithLocalStorage(func(objC obj.Client, chunks *Storage) error {
+		msg := random.SeedRand()
+		test := test{1 * units.KB, 10 * units.MB}
+		as := generateAnnotations(test)
+		writeAnnotations(t, chunks, as, msg)
+		readAnnotations(t, chunks, as, msg)
+		// Check that the chunks are encrypted in object storage.
+		var chunkCount int64
+		require.NoError(t, chunks.List(context.Background(), func(name string) error {
+			chunkCount++
+			objR, err := objC.Reader(context.Background(), name, 0, 0)
+			require.NoError(t, err, msg)
+			defer objR.Close()
+			buf := &bytes.Buffer{}
+			_, err = buf.ReadFrom(objR)
+			require.NoError(t, err, msg)
+			for _, a := range as {
+				require.False(t, bytes.Contains(buf.Bytes(), a.data), msg)
+			}
+			return nil
+		}), msg)
+		// Check that writing the same data again is deduplicated.
+		for _, a := range as {
+			a.dataRefs = nil
+		}
+		writeAnnotations(t, chunks, as, msg)
+		var finalChunkCount int64
+		require.NoError(t, chunks.List(context.Background(), func(_ string) error {
+			finalChunkCount++
+			return nil
+		}), msg)
+		require.Equal(t, chunkCount, finalChunkCount, msg)
+		// Check that the data can't be read without the key.
+		delete(keys, "test")
+		r := chunks.NewReader(context.Background(), as[0].dataRefs)
+		require.YesError(t, r.Get(&bytes.Buffer{}), msg)
+		return nil
+	}, WithEncryption(keys, "test")))
+}
+
+func TestCheck(t *testing.T) {
+	require.NoError(t, WithLocalStorage(func(objC obj.Client, chunks *Storage) error {
+		msg := random.SeedRand()
+		as := generateAnnotations(test{1 * units.KB, 1 * units.MB})
+		writeAnnotations(t, chunks, as, msg)
+		chunkInfos := make(map[string]*ChunkInfo)
+		for _, a := range as {
+			for _, dataRef := range a.dataRefs {
+				chunkInfos[dataRef.ChunkInfo.Chunk.Hash] = dataRef.ChunkInfo
+			}
+		}
+		require.True(t, len(chunkInfos) > 1, msg)
+		for _, chunkInfo := range chunkInfos {
+			require.NoError(t, chunks.Check(context.Background(), chunkInfo, false), msg)
+			require.NoError(t, chunks.Check(context.Background(), chunkInfo, true), msg)
+		}
+		// Corrupt one chunk and delete another
+		var corrupt, missing *ChunkInfo
+		for _, chunkInfo := range chunkInfos {
+			if corrupt == nil {
+				corrupt = chunkInfo
+			} else if missing == nil {
+				missing = chunkInfo
+			}
+		}
+		w, err := objC.Writer(context.Background(), path.Join(prefix, corrupt.Chunk.Hash))
+		require.NoError(t, err, msg)
+		_, err = w.Write([]byte("corrupt"))
+		require.NoError(t, err, msg)
+		require.NoError(t, w.Close(), msg)
+		require.NoError(t, chunks.Delete(context.Background(), missing.Chunk.Hash), msg)
+		// Corruption is only found when the chunk is verified
+		require.NoError(t, chunks.Check(context.Background(), corrupt, false), msg)
+		err = chunks.Check(context.Background(), corrupt, true)
+		require.YesError(t, err, msg)
+		require.True(t, strings.Contains(err.Error(), "corrupt"), msg)
+		for _, verify := range []bool{false, true} {
+			err = chunks.Check(context.Background(), missing, verify)
+			require.YesError(t, err, msg)
+			require.True(t, strings.Contains(err.Error(), "does not exist"), msg)
+		}
+		return nil
+	}))
+}
+
+func TestCompression(t *testing.T) {
+	require.NoError(t, WithLocalStorage(func(objC obj.Client, chunks *Storage) error {
+		msg := random.SeedRand()
+		test := test{1 * units.KB, 10 * units.MB}
+		// Write a set of annotations with each compression algorithm, then check
+		// that chunks compressed with different algorithms can be read side by side.
+		algos := []CompressionAlgo{CompressionAlgo_NONE, CompressionAlgo_GZIP, CompressionAlgo_SNAPPY, CompressionAlgo_ZSTD}
+		var as [][]*testAnnotation
+		for _, algo := range algos {
+			algoAs := generateAnnotations(test)
+			writeAnnotations(t, chunks, algoAs, msg, WithCompression(algo))
+			as = append(as, algoAs)
+		}
+		for _, algoAs := range as {
+			readAnnotations(t, chunks, algoAs, msg)
+		}
+		// Chunks are deduplicated by their uncompressed content, so writing the
+		// same data with another algorithm reuses the chunks that are stored.
+		countChunks := func() int {
+			var count int
+			require.NoError(t, chunks.List(context.Background(), func(string) error {
+				count++
+				return nil
+			}), msg)
+			return count
+		}
+		numChunks := countChunks()
+		for i, algoAs := range as {
+			var rewrittenAs []*testAnnotation
+			for _, a := range algoAs {
+				rewrittenAs = append(rewrittenAs, &testAnnotation{data: a.data})
+			}
+			writeAnnotations(t, chunks, rewrittenAs, msg, WithCompression(algos[(i+1)%len(algos)]))
+			for j, a := range rewrittenAs {
+				require.Equal(t, len(algoAs[j].dataRefs), len(a.dataRefs), msg)
+				for k, dataRef := range a.dataRefs {
+					require.Equal(t, algoAs[j].dataRefs[k].ChunkInfo.Chunk.Hash, dataRef.ChunkInfo.Chunk.Hash, msg)
+				}
+			}
+			readAnnotations(t, chunks, rewrittenAs, msg)
+		}
+		require.Equal(t, numChunks, countChunks(), msg)
+		return nil
+	}))
+}
@@ -0,0 +1,93 @@
+package chunk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+)
+
+// compressionMagic starts every stored chunk, followed by a byte for the
+// CompressionAlgo that the rest of the chunk is compressed with. Chunks are
+// deduplicated by the hash of their uncompressed content, so a chunk may have
+// been stored with a different algorithm than the writer that references it
+// uses, and readers get the algorithm from the chunk. Chunks without the magic
+// were stored before chunks could be compressed, and are uncompressed.
+var compressionMagic = []byte("\x00pachchunk")
+
+// The encoder and decoder are only used through EncodeAll and DecodeAll,
+// which are safe for concurrent use. Creating them without options can't fail.
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// compressChunk compresses 'data' with 'algo', and adds the header that
+// decompressChunk reads the algorithm from.
+func compressChunk(algo CompressionAlgo, data []byte) ([]byte, error) {
+	compressed, err := compress(algo, data)
+	if err != nil {
+		return nil, err
+	}
+	header := append(append([]byte{}, compressionMagic...), byte(algo))
+	return append(header, compressed...), nil
+}
+
+// decompressChunk decompresses a chunk written by compressChunk (or a chunk
+// stored before chunks could be compressed).
+func decompressChunk(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, compressionMagic) {
+		return data, nil
+	}
+	data = data[len(compressionMagic):]
+	if len(data) == 0 {
+		return nil, errors.New("chunk header is missing its compression algorithm")
+	}
+	return decompress(CompressionAlgo(data[0]), data[1:])
+}
+
+func compress(algo CompressionAlgo, data []byte) ([]byte, error) {
+	switch algo {
+	case CompressionAlgo_NONE:
+		return data, nil
+	case CompressionAlgo_GZIP:
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		if _, err := gw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := gw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionAlgo_SNAPPY:
+		return snappy.Encode(nil, data), nil
+	case CompressionAlgo_ZSTD:
+		return zstdEncoder.EncodeAll(data, nil), nil
+	default:
+		return nil, errors.Errorf("unrecognized compression algorithm: %v", algo)
+	}
+}
+
+func decompress(algo CompressionAlgo, data []byte) ([]byte, error) {
+	switch algo {
+	case CompressionAlgo_NONE:
+		return data, nil
+	case CompressionAlgo_GZIP:
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		return ioutil.ReadAll(gr)
+	case CompressionAlgo_SNAPPY:
+		return snappy.Decode(nil, data)
+	case CompressionAlgo_ZSTD:
+		return zstdDecoder.DecodeAll(data, nil)
+	default:
+		return nil, errors.Errorf("unrecognized compression algorithm: %v", algo)
+	}
+}
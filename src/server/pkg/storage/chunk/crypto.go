@@ -0,0 +1,87 @@
+package chunk
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/server/pkg/storage/hash"
+)
+
+// Keys maps key IDs to the secrets used for encrypting chunks.
+// Keys that are no longer used for writing should be kept around for as long as
+// chunks encrypted with them may be read.
+type Keys map[string][]byte
+
+// ErrKeyNotFound is returned when a chunk is encrypted with a key that is not
+// available to the storage.
+var ErrKeyNotFound = errors.Errorf("chunk encryption key not found")
+
+func (ks Keys) get(keyID string) ([]byte, error) {
+	secret, ok := ks[keyID]
+	if !ok {
+		return nil, errors.Wrapf(ErrKeyNotFound, "key id %q", keyID)
+	}
+	return secret, nil
+}
+
+// crypter encrypts and decrypts chunks with a secret.
+// The encryption is convergent: the chunk ID and the chunk key are both derived
+// from the secret and the chunk content, so identical content written with the
+// same secret produces identical ciphertext and is still deduplicated.
+// A reader that knows the secret can derive the chunk key from the chunk ID alone.
+type crypter struct {
+	secret []byte
+}
+
+func newCrypter(secret []byte) *crypter {
+	return &crypter{secret: secret}
+}
+
+// sum computes a keyed hash of the data, this is used in place of the content
+// hash so that object names and data references do not reveal the content.
+func (c *crypter) sum(data ...[]byte) string {
+	mac := hmac.New(sha512.New, c.secret)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return hash.EncodeHash(mac.Sum(nil))
+}
+
+func (c *crypter) aead(chunkID string) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte("chunk key:"))
+	mac.Write([]byte(chunkID))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypt encrypts the chunk with the given ID.
+// A zero nonce is safe here because each chunk key is only ever used to encrypt
+// the content it was derived from.
+func (c *crypter) encrypt(chunkID string, data []byte) ([]byte, error) {
+	aead, err := c.aead(chunkID)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, make([]byte, aead.NonceSize()), data, []byte(chunkID)), nil
+}
+
+// decrypt decrypts and authenticates the chunk with the given ID.
+func (c *crypter) decrypt(chunkID string, data []byte) ([]byte, error) {
+	aead, err := c.aead(chunkID)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, make([]byte, aead.NonceSize()), data, []byte(chunkID))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not decrypt chunk %v", chunkID)
+	}
+	return plaintext, nil
+}
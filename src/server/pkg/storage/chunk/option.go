@@ -42,6 +42,24 @@ func WithObjectCache(fastLayer obj.Client, size int) StorageOption {
 	}
 }
 
+// WithEncryption sets the keys that chunks are encrypted and decrypted with,
+// and the ID of the key that writers use by default.
+// An empty key ID disables encryption for writers, chunks that were encrypted
+// previously can still be read as long as their key is in the key set.
+func WithEncryption(keys Keys, keyID string) StorageOption {
+	return func(s *Storage) {
+		s.keys = keys
+		s.keyID = keyID
+	}
+}
+
+// WithDefaultCompression sets the compression algorithm that writers use by default.
+func WithDefaultCompression(algo CompressionAlgo) StorageOption {
+	return func(s *Storage) {
+		s.compression = algo
+	}
+}
+
 // WriterOption configures a chunk writer.
 type WriterOption func(w *Writer)
 
@@ -75,3 +93,20 @@ func WithChunkTTL(ttl time.Duration) WriterOption {
 		w.chunkTTL = ttl
 	}
 }
+
+// WithEncryptionKey sets the ID of the key that chunks written with this writer
+// are encrypted with (an empty key ID writes unencrypted chunks).
+// This can be used to encrypt data for different repos with different keys.
+func WithEncryptionKey(keyID string) WriterOption {
+	return func(w *Writer) {
+		w.keyID = keyID
+	}
+}
+
+// WithCompression sets the algorithm that chunks written with this writer are
+// compressed with.
+func WithCompression(algo CompressionAlgo) WriterOption {
+	return func(w *Writer) {
+		w.compression = algo
+	}
+}
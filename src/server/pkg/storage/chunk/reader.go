@@ -16,13 +16,15 @@ import (
 type Reader struct {
 	ctx      context.Context
 	objC     obj.Client
+	keys     Keys
 	dataRefs []*DataRef
 }
 
-func newReader(ctx context.Context, objC obj.Client, dataRefs []*DataRef) *Reader {
+func newReader(ctx context.Context, objC obj.Client, keys Keys, dataRefs []*DataRef) *Reader {
 	return &Reader{
 		ctx:      ctx,
 		objC:     objC,
+		keys:     keys,
 		dataRefs: dataRefs,
 	}
 }
@@ -31,7 +33,7 @@ func newReader(ctx context.Context, objC obj.Client, dataRefs []*DataRef) *Reade
 func (r *Reader) Iterate(cb func(*DataReader) error) error {
 	var seed *DataReader
 	for _, dataRef := range r.dataRefs {
-		dr := newDataReader(r.ctx, r.objC, dataRef, seed)
+		dr := newDataReader(r.ctx, r.objC, r.keys, dataRef, seed)
 		if err := cb(dr); err != nil {
 			if errors.Is(err, errutil.ErrBreak) {
 				return nil
@@ -57,16 +59,18 @@ func (r *Reader) Get(w io.Writer) error {
 type DataReader struct {
 	ctx        context.Context
 	objC       obj.Client
+	keys       Keys
 	dataRef    *DataRef
 	seed       *DataReader
 	getChunkMu sync.Mutex
 	chunk      []byte
 }
 
-func newDataReader(ctx context.Context, objC obj.Client, dataRef *DataRef, seed *DataReader) *DataReader {
+func newDataReader(ctx context.Context, objC obj.Client, keys Keys, dataRef *DataRef, seed *DataReader) *DataReader {
 	return &DataReader{
 		ctx:     ctx,
 		objC:    objC,
+		keys:    keys,
 		dataRef: dataRef,
 		seed:    seed,
 	}
@@ -111,6 +115,22 @@ func (dr *DataReader) getChunk() error {
 	if _, err := io.Copy(buf, objR); err != nil {
 		return err
 	}
-	dr.chunk = buf.Bytes()
+	chunk := buf.Bytes()
+	// Decrypt the chunk if it was encrypted.
+	if dr.dataRef.ChunkInfo.KeyId != "" {
+		secret, err := dr.keys.get(dr.dataRef.ChunkInfo.KeyId)
+		if err != nil {
+			return err
+		}
+		chunk, err = newCrypter(secret).decrypt(dr.dataRef.ChunkInfo.Chunk.Hash, chunk)
+		if err != nil {
+			return err
+		}
+	}
+	chunk, err = decompressChunk(chunk)
+	if err != nil {
+		return err
+	}
+	dr.chunk = chunk
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"path"
 	"time"
 
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
 	"github.com/pachyderm/pachyderm/src/server/pkg/storage/gc"
 )
@@ -16,8 +17,11 @@ const (
 
 // Storage is the abstraction that manages chunk storage.
 type Storage struct {
-	objClient obj.Client
-	gcClient  gc.Client
+	objClient   obj.Client
+	gcClient    gc.Client
+	keys        Keys
+	keyID       string
+	compression CompressionAlgo
 
 	defaultChunkTTL time.Duration
 }
@@ -37,15 +41,15 @@ func NewStorage(objClient obj.Client, opts ...StorageOption) *Storage {
 
 // NewReader creates a new Reader.
 func (s *Storage) NewReader(ctx context.Context, dataRefs []*DataRef) *Reader {
-	return newReader(ctx, s.objClient, dataRefs)
+	return newReader(ctx, s.objClient, s.keys, dataRefs)
 }
 
 // NewWriter creates a new Writer for a stream of bytes to be chunked.
 // Chunks are created based on the content, then hashed and deduplicated/uploaded to
 // object storage.
 func (s *Storage) NewWriter(ctx context.Context, tmpID string, cb WriterCallback, opts ...WriterOption) *Writer {
-	opts = append([]WriterOption{WithChunkTTL(defaultChunkTTL)}, opts...)
-	return newWriter(ctx, s.objClient, s.gcClient, tmpID, cb, opts...)
+	opts = append([]WriterOption{WithChunkTTL(defaultChunkTTL), WithEncryptionKey(s.keyID), WithCompression(s.compression)}, opts...)
+	return newWriter(ctx, s.objClient, s.gcClient, s.keys, tmpID, cb, opts...)
 }
 
 // List lists all of the chunks in object storage.
@@ -65,6 +69,39 @@ func (s *Storage) Delete(ctx context.Context, hash string) error {
 	return s.objClient.Delete(ctx, path.Join(prefix, hash))
 }
 
+// Check returns an error if the chunk referenced by 'chunkInfo' does not exist
+// in object storage. If 'verify' is set, the chunk is also read, and it is an
+// error if it can't be decrypted and decompressed, or if its content doesn't
+// match its hash.
+func (s *Storage) Check(ctx context.Context, chunkInfo *ChunkInfo, verify bool) error {
+	hash := chunkInfo.Chunk.Hash
+	if !verify {
+		if !s.objClient.Exists(ctx, path.Join(prefix, hash)) {
+			return errors.Errorf("chunk %s does not exist", hash)
+		}
+		return nil
+	}
+	dr := newDataReader(ctx, s.objClient, s.keys, Reference(&DataRef{ChunkInfo: chunkInfo}), nil)
+	if err := dr.getChunk(); err != nil {
+		if s.objClient.IsNotExist(err) {
+			return errors.Errorf("chunk %s does not exist", hash)
+		}
+		return errors.Wrapf(err, "chunk %s could not be read", hash)
+	}
+	var c *crypter
+	if chunkInfo.KeyId != "" {
+		secret, err := s.keys.get(chunkInfo.KeyId)
+		if err != nil {
+			return err
+		}
+		c = newCrypter(secret)
+	}
+	if actual := sum(c, dr.chunk); actual != hash {
+		return errors.Errorf("chunk %s is corrupt: its content hashes to %s", hash, actual)
+	}
+	return nil
+}
+
 // CreateSemanticReference creates a semantic reference to a chunk.
 func (s *Storage) CreateSemanticReference(ctx context.Context, name string, chunk *Chunk) error {
 	return s.gcClient.CreateReference(ctx, semanticReference(name, chunk.Hash))
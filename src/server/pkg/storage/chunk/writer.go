@@ -57,6 +57,10 @@ type chunkSize struct {
 type Writer struct {
 	objC                    obj.Client
 	gcC                     gc.Client
+	keys                    Keys
+	keyID                   string
+	crypter                 *crypter
+	compression             CompressionAlgo
 	chunkSize               *chunkSize
 	cb                      WriterCallback
 	ctx                     context.Context
@@ -76,11 +80,12 @@ type Writer struct {
 	first, last             bool
 }
 
-func newWriter(ctx context.Context, objC obj.Client, gcC gc.Client, tmpID string, cb WriterCallback, opts ...WriterOption) *Writer {
+func newWriter(ctx context.Context, objC obj.Client, gcC gc.Client, keys Keys, tmpID string, cb WriterCallback, opts ...WriterOption) *Writer {
 	cancelCtx, cancel := context.WithCancel(ctx)
 	w := &Writer{
 		objC: objC,
 		gcC:  gcC,
+		keys: keys,
 		chunkSize: &chunkSize{
 			min: defaultMinChunkSize,
 			max: defaultMaxChunkSize,
@@ -98,6 +103,15 @@ func newWriter(ctx context.Context, objC obj.Client, gcC gc.Client, tmpID string
 	for _, opt := range opts {
 		opt(w)
 	}
+	if w.keyID != "" {
+		secret, err := keys.get(w.keyID)
+		if err != nil {
+			w.err = err
+			cancel()
+		} else {
+			w.crypter = newCrypter(secret)
+		}
+	}
 	w.resetHash()
 	return w
 }
@@ -235,7 +249,7 @@ func copyAnnotation(a *Annotation) *Annotation {
 }
 
 func (w *Writer) processChunk(ctx context.Context, chunkBytes []byte, edge bool, annotations []*Annotation, serial func(func() error) error) error {
-	chunk := &Chunk{Hash: hash.EncodeHash(hash.Sum(chunkBytes))}
+	chunk := &Chunk{Hash: w.sum(chunkBytes)}
 	if err := w.maybeUpload(ctx, chunk, chunkBytes); err != nil {
 		return err
 	}
@@ -244,6 +258,7 @@ func (w *Writer) processChunk(ctx context.Context, chunkBytes []byte, edge bool,
 			Chunk:     chunk,
 			SizeBytes: int64(len(chunkBytes)),
 			Edge:      edge,
+			KeyId:     w.keyID,
 		},
 		SizeBytes: int64(len(chunkBytes)),
 	}
@@ -255,6 +270,22 @@ func (w *Writer) processChunk(ctx context.Context, chunkBytes []byte, edge bool,
 	})
 }
 
+func (w *Writer) sum(data ...[]byte) string {
+	return sum(w.crypter, data...)
+}
+
+// sum computes the hash of the data, keyed by 'c' if chunks are encrypted.
+func sum(c *crypter, data ...[]byte) string {
+	if c != nil {
+		return c.sum(data...)
+	}
+	h := hash.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return hash.EncodeHash(h.Sum(nil))
+}
+
 func (w *Writer) maybeUpload(ctx context.Context, chunk *Chunk, chunkBytes []byte) error {
 	// Skip the upload if no upload is configured.
 	if w.noUpload {
@@ -268,6 +299,16 @@ func (w *Writer) maybeUpload(ctx context.Context, chunk *Chunk, chunkBytes []byt
 	if w.objC.Exists(ctx, path) {
 		return nil
 	}
+	chunkBytes, err := compressChunk(w.compression, chunkBytes)
+	if err != nil {
+		return err
+	}
+	if w.crypter != nil {
+		chunkBytes, err = w.crypter.encrypt(chunk.Hash, chunkBytes)
+		if err != nil {
+			return err
+		}
+	}
 	objW, err := w.objC.Writer(ctx, path)
 	if err != nil {
 		return err
@@ -285,7 +326,7 @@ func (w *Writer) processAnnotations(ctx context.Context, chunkRef *DataRef, chun
 		if a.size == 0 {
 			continue
 		}
-		a.NextDataRef = newDataRef(chunkRef, chunkBytes, offset, a.size)
+		a.NextDataRef = w.newDataRef(chunkRef, chunkBytes, offset, a.size)
 		offset += a.size
 		// Skip references if no upload is configured.
 		if w.noUpload {
@@ -312,13 +353,13 @@ func (w *Writer) processAnnotations(ctx context.Context, chunkRef *DataRef, chun
 	return nil
 }
 
-func newDataRef(chunkRef *DataRef, chunkBytes []byte, offset, size int64) *DataRef {
+func (w *Writer) newDataRef(chunkRef *DataRef, chunkBytes []byte, offset, size int64) *DataRef {
 	dataRef := &DataRef{}
 	dataRef.ChunkInfo = chunkRef.ChunkInfo
 	if chunkRef.SizeBytes == size {
 		dataRef.Hash = chunkRef.Hash
 	} else {
-		dataRef.Hash = hash.EncodeHash(hash.Sum(chunkBytes[offset : offset+size]))
+		dataRef.Hash = w.sum(chunkBytes[offset : offset+size])
 	}
 	dataRef.OffsetBytes = offset
 	dataRef.SizeBytes = size
@@ -347,7 +388,8 @@ func (w *Writer) maybeBufferDataRef(dataRef *DataRef) error {
 		// - We are at a chunk split point.
 		// - The data ref does not reference an edge chunk.
 		// - It is the first data reference for the chunk.
-		if w.buf.Len() != 0 || dataRef.ChunkInfo.Edge || dataRef.OffsetBytes != 0 {
+		// - The data ref references a chunk encrypted with the writer's key.
+		if w.buf.Len() != 0 || dataRef.ChunkInfo.Edge || dataRef.OffsetBytes != 0 || dataRef.ChunkInfo.KeyId != w.keyID {
 			return w.flushDataRef(dataRef)
 		}
 	} else {
@@ -408,7 +450,7 @@ func (w *Writer) flushBuffer() error {
 
 func (w *Writer) flushDataRef(dataRef *DataRef) error {
 	buf := &bytes.Buffer{}
-	r := newDataReader(w.ctx, w.objC, dataRef, nil)
+	r := newDataReader(w.ctx, w.objC, w.keys, dataRef, nil)
 	if err := r.Get(buf); err != nil {
 		return err
 	}
@@ -332,6 +332,34 @@ func (s *Storage) WalkFileSet(ctx context.Context, prefix string, f func(string)
 	})
 }
 
+// WalkChunks calls cb with the path of each file in the file set at fileSet
+// (in every primitive file set under it, e.g. each level of a compacted file
+// set) and each chunk that the file's data is stored in. An error is returned
+// if any of the file sets' indexes can't be read.
+func (s *Storage) WalkChunks(ctx context.Context, fileSet string, cb func(string, *chunk.ChunkInfo) error) error {
+	fileSet = applyPrefix(fileSet)
+	return s.objC.Walk(ctx, fileSet, func(name string) error {
+		ir := index.NewReader(s.objC, s.chunks, name)
+		if err := ir.Iterate(ctx, func(idx *index.Index) error {
+			var prev string
+			for _, dataRef := range idx.FileOp.DataRefs {
+				// Consecutive data refs are often in the same chunk
+				if dataRef.ChunkInfo.Chunk.Hash == prev {
+					continue
+				}
+				prev = dataRef.ChunkInfo.Chunk.Hash
+				if err := cb(idx.Path, dataRef.ChunkInfo); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return errors.Wrapf(err, "error reading file set %s", removePrefix(name))
+		}
+		return nil
+	})
+}
+
 // SetTTL sets the time-to-live for the path p.
 // if no fileset is found SetTTL returns ErrNoFileSetFound
 func (s *Storage) SetTTL(ctx context.Context, p string, ttl time.Duration) (time.Time, error) {
@@ -401,6 +401,11 @@ type createRepoFunc func(context.Context, *pfs.CreateRepoRequest) (*types.Empty,
 type inspectRepoFunc func(context.Context, *pfs.InspectRepoRequest) (*pfs.RepoInfo, error)
 type listRepoFunc func(context.Context, *pfs.ListRepoRequest) (*pfs.ListRepoResponse, error)
 type deleteRepoFunc func(context.Context, *pfs.DeleteRepoRequest) (*types.Empty, error)
+type setRepoQuotaFunc func(context.Context, *pfs.SetRepoQuotaRequest) (*types.Empty, error)
+type setRetentionPolicyFunc func(context.Context, *pfs.SetRetentionPolicyRequest) (*types.Empty, error)
+type pruneCommitsFunc func(context.Context, *pfs.PruneCommitsRequest) (*pfs.PruneCommitsResponse, error)
+type setMetadataFunc func(context.Context, *pfs.SetMetadataRequest) (*types.Empty, error)
+type setBranchProtectionFunc func(context.Context, *pfs.SetBranchProtectionRequest) (*types.Empty, error)
 type startCommitFunc func(context.Context, *pfs.StartCommitRequest) (*pfs.Commit, error)
 type finishCommitFunc func(context.Context, *pfs.FinishCommitRequest) (*types.Empty, error)
 type inspectCommitFunc func(context.Context, *pfs.InspectCommitRequest) (*pfs.CommitInfo, error)
@@ -414,8 +419,10 @@ type createBranchFunc func(context.Context, *pfs.CreateBranchRequest) (*types.Em
 type inspectBranchFunc func(context.Context, *pfs.InspectBranchRequest) (*pfs.BranchInfo, error)
 type listBranchFunc func(context.Context, *pfs.ListBranchRequest) (*pfs.BranchInfos, error)
 type deleteBranchFunc func(context.Context, *pfs.DeleteBranchRequest) (*types.Empty, error)
+type mergeBranchFunc func(context.Context, *pfs.MergeBranchRequest) (*pfs.MergeBranchResponse, error)
 type putFileFunc func(pfs.API_PutFileServer) error
 type copyFileFunc func(context.Context, *pfs.CopyFileRequest) (*types.Empty, error)
+type syncFileFunc func(context.Context, *pfs.SyncFileRequest) (*pfs.SyncFileResponse, error)
 type getFileFunc func(*pfs.GetFileRequest, pfs.API_GetFileServer) error
 type inspectFileFunc func(context.Context, *pfs.InspectFileRequest) (*pfs.FileInfo, error)
 type listFileFunc func(context.Context, *pfs.ListFileRequest) (*pfs.FileInfos, error)
@@ -438,6 +445,11 @@ type mockCreateRepo struct{ handler createRepoFunc }
 type mockInspectRepo struct{ handler inspectRepoFunc }
 type mockListRepo struct{ handler listRepoFunc }
 type mockDeleteRepo struct{ handler deleteRepoFunc }
+type mockSetRepoQuota struct{ handler setRepoQuotaFunc }
+type mockSetRetentionPolicy struct{ handler setRetentionPolicyFunc }
+type mockPruneCommits struct{ handler pruneCommitsFunc }
+type mockSetMetadata struct{ handler setMetadataFunc }
+type mockSetBranchProtection struct{ handler setBranchProtectionFunc }
 type mockStartCommit struct{ handler startCommitFunc }
 type mockFinishCommit struct{ handler finishCommitFunc }
 type mockInspectCommit struct{ handler inspectCommitFunc }
@@ -451,8 +463,10 @@ type mockCreateBranch struct{ handler createBranchFunc }
 type mockInspectBranch struct{ handler inspectBranchFunc }
 type mockListBranch struct{ handler listBranchFunc }
 type mockDeleteBranch struct{ handler deleteBranchFunc }
+type mockMergeBranch struct{ handler mergeBranchFunc }
 type mockPutFile struct{ handler putFileFunc }
 type mockCopyFile struct{ handler copyFileFunc }
+type mockSyncFile struct{ handler syncFileFunc }
 type mockGetFile struct{ handler getFileFunc }
 type mockInspectFile struct{ handler inspectFileFunc }
 type mockListFile struct{ handler listFileFunc }
@@ -471,85 +485,99 @@ type mockClearCommitV2 struct{ handler clearCommitV2Func }
 type mockCreateTmpFileSet struct{ handler createTmpFileSetFunc }
 type mockRenewTmpFileSet struct{ handler renewTmpFileSetFunc }
 
-func (mock *mockCreateRepo) Use(cb createRepoFunc)             { mock.handler = cb }
-func (mock *mockInspectRepo) Use(cb inspectRepoFunc)           { mock.handler = cb }
-func (mock *mockListRepo) Use(cb listRepoFunc)                 { mock.handler = cb }
-func (mock *mockDeleteRepo) Use(cb deleteRepoFunc)             { mock.handler = cb }
-func (mock *mockStartCommit) Use(cb startCommitFunc)           { mock.handler = cb }
-func (mock *mockFinishCommit) Use(cb finishCommitFunc)         { mock.handler = cb }
-func (mock *mockInspectCommit) Use(cb inspectCommitFunc)       { mock.handler = cb }
-func (mock *mockListCommit) Use(cb listCommitFunc)             { mock.handler = cb }
-func (mock *mockListCommitStream) Use(cb listCommitStreamFunc) { mock.handler = cb }
-func (mock *mockDeleteCommit) Use(cb deleteCommitFunc)         { mock.handler = cb }
-func (mock *mockFlushCommit) Use(cb flushCommitFunc)           { mock.handler = cb }
-func (mock *mockSubscribeCommit) Use(cb subscribeCommitFunc)   { mock.handler = cb }
-func (mock *mockBuildCommit) Use(cb buildCommitFunc)           { mock.handler = cb }
-func (mock *mockCreateBranch) Use(cb createBranchFunc)         { mock.handler = cb }
-func (mock *mockInspectBranch) Use(cb inspectBranchFunc)       { mock.handler = cb }
-func (mock *mockListBranch) Use(cb listBranchFunc)             { mock.handler = cb }
-func (mock *mockDeleteBranch) Use(cb deleteBranchFunc)         { mock.handler = cb }
-func (mock *mockPutFile) Use(cb putFileFunc)                   { mock.handler = cb }
-func (mock *mockCopyFile) Use(cb copyFileFunc)                 { mock.handler = cb }
-func (mock *mockGetFile) Use(cb getFileFunc)                   { mock.handler = cb }
-func (mock *mockInspectFile) Use(cb inspectFileFunc)           { mock.handler = cb }
-func (mock *mockListFile) Use(cb listFileFunc)                 { mock.handler = cb }
-func (mock *mockListFileStream) Use(cb listFileStreamFunc)     { mock.handler = cb }
-func (mock *mockWalkFile) Use(cb walkFileFunc)                 { mock.handler = cb }
-func (mock *mockGlobFile) Use(cb globFileFunc)                 { mock.handler = cb }
-func (mock *mockGlobFileStream) Use(cb globFileStreamFunc)     { mock.handler = cb }
-func (mock *mockDiffFile) Use(cb diffFileFunc)                 { mock.handler = cb }
-func (mock *mockDeleteFile) Use(cb deleteFileFunc)             { mock.handler = cb }
-func (mock *mockDeleteAllPFS) Use(cb deleteAllPFSFunc)         { mock.handler = cb }
-func (mock *mockFsck) Use(cb fsckFunc)                         { mock.handler = cb }
-func (mock *mockFileOperationV2) Use(cb fileOperationFuncV2)   { mock.handler = cb }
-func (mock *mockGetTarV2) Use(cb getTarFuncV2)                 { mock.handler = cb }
-func (mock *mockDiffFileV2) Use(cb diffFileV2Func)             { mock.handler = cb }
-func (mock *mockClearCommitV2) Use(cb clearCommitV2Func)       { mock.handler = cb }
-func (mock *mockCreateTmpFileSet) Use(cb createTmpFileSetFunc) { mock.handler = cb }
-func (mock *mockRenewTmpFileSet) Use(cb renewTmpFileSetFunc)   { mock.handler = cb }
+func (mock *mockCreateRepo) Use(cb createRepoFunc)                   { mock.handler = cb }
+func (mock *mockInspectRepo) Use(cb inspectRepoFunc)                 { mock.handler = cb }
+func (mock *mockListRepo) Use(cb listRepoFunc)                       { mock.handler = cb }
+func (mock *mockDeleteRepo) Use(cb deleteRepoFunc)                   { mock.handler = cb }
+func (mock *mockSetRepoQuota) Use(cb setRepoQuotaFunc)               { mock.handler = cb }
+func (mock *mockSetRetentionPolicy) Use(cb setRetentionPolicyFunc)   { mock.handler = cb }
+func (mock *mockPruneCommits) Use(cb pruneCommitsFunc)               { mock.handler = cb }
+func (mock *mockSetMetadata) Use(cb setMetadataFunc)                 { mock.handler = cb }
+func (mock *mockSetBranchProtection) Use(cb setBranchProtectionFunc) { mock.handler = cb }
+func (mock *mockStartCommit) Use(cb startCommitFunc)                 { mock.handler = cb }
+func (mock *mockFinishCommit) Use(cb finishCommitFunc)               { mock.handler = cb }
+func (mock *mockInspectCommit) Use(cb inspectCommitFunc)             { mock.handler = cb }
+func (mock *mockListCommit) Use(cb listCommitFunc)                   { mock.handler = cb }
+func (mock *mockListCommitStream) Use(cb listCommitStreamFunc)       { mock.handler = cb }
+func (mock *mockDeleteCommit) Use(cb deleteCommitFunc)               { mock.handler = cb }
+func (mock *mockFlushCommit) Use(cb flushCommitFunc)                 { mock.handler = cb }
+func (mock *mockSubscribeCommit) Use(cb subscribeCommitFunc)         { mock.handler = cb }
+func (mock *mockBuildCommit) Use(cb buildCommitFunc)                 { mock.handler = cb }
+func (mock *mockCreateBranch) Use(cb createBranchFunc)               { mock.handler = cb }
+func (mock *mockInspectBranch) Use(cb inspectBranchFunc)             { mock.handler = cb }
+func (mock *mockListBranch) Use(cb listBranchFunc)                   { mock.handler = cb }
+func (mock *mockDeleteBranch) Use(cb deleteBranchFunc)               { mock.handler = cb }
+func (mock *mockMergeBranch) Use(cb mergeBranchFunc)                 { mock.handler = cb }
+func (mock *mockPutFile) Use(cb putFileFunc)                         { mock.handler = cb }
+func (mock *mockCopyFile) Use(cb copyFileFunc)                       { mock.handler = cb }
+func (mock *mockSyncFile) Use(cb syncFileFunc)                       { mock.handler = cb }
+func (mock *mockGetFile) Use(cb getFileFunc)                         { mock.handler = cb }
+func (mock *mockInspectFile) Use(cb inspectFileFunc)                 { mock.handler = cb }
+func (mock *mockListFile) Use(cb listFileFunc)                       { mock.handler = cb }
+func (mock *mockListFileStream) Use(cb listFileStreamFunc)           { mock.handler = cb }
+func (mock *mockWalkFile) Use(cb walkFileFunc)                       { mock.handler = cb }
+func (mock *mockGlobFile) Use(cb globFileFunc)                       { mock.handler = cb }
+func (mock *mockGlobFileStream) Use(cb globFileStreamFunc)           { mock.handler = cb }
+func (mock *mockDiffFile) Use(cb diffFileFunc)                       { mock.handler = cb }
+func (mock *mockDeleteFile) Use(cb deleteFileFunc)                   { mock.handler = cb }
+func (mock *mockDeleteAllPFS) Use(cb deleteAllPFSFunc)               { mock.handler = cb }
+func (mock *mockFsck) Use(cb fsckFunc)                               { mock.handler = cb }
+func (mock *mockFileOperationV2) Use(cb fileOperationFuncV2)         { mock.handler = cb }
+func (mock *mockGetTarV2) Use(cb getTarFuncV2)                       { mock.handler = cb }
+func (mock *mockDiffFileV2) Use(cb diffFileV2Func)                   { mock.handler = cb }
+func (mock *mockClearCommitV2) Use(cb clearCommitV2Func)             { mock.handler = cb }
+func (mock *mockCreateTmpFileSet) Use(cb createTmpFileSetFunc)       { mock.handler = cb }
+func (mock *mockRenewTmpFileSet) Use(cb renewTmpFileSetFunc)         { mock.handler = cb }
 
 type pfsServerAPI struct {
 	mock *mockPFSServer
 }
 
 type mockPFSServer struct {
-	api              pfsServerAPI
-	CreateRepo       mockCreateRepo
-	InspectRepo      mockInspectRepo
-	ListRepo         mockListRepo
-	DeleteRepo       mockDeleteRepo
-	StartCommit      mockStartCommit
-	FinishCommit     mockFinishCommit
-	InspectCommit    mockInspectCommit
-	ListCommit       mockListCommit
-	ListCommitStream mockListCommitStream
-	DeleteCommit     mockDeleteCommit
-	FlushCommit      mockFlushCommit
-	SubscribeCommit  mockSubscribeCommit
-	BuildCommit      mockBuildCommit
-	CreateBranch     mockCreateBranch
-	InspectBranch    mockInspectBranch
-	ListBranch       mockListBranch
-	DeleteBranch     mockDeleteBranch
-	PutFile          mockPutFile
-	CopyFile         mockCopyFile
-	GetFile          mockGetFile
-	InspectFile      mockInspectFile
-	ListFile         mockListFile
-	ListFileStream   mockListFileStream
-	WalkFile         mockWalkFile
-	GlobFile         mockGlobFile
-	GlobFileStream   mockGlobFileStream
-	DiffFile         mockDiffFile
-	DeleteFile       mockDeleteFile
-	DeleteAll        mockDeleteAllPFS
-	Fsck             mockFsck
-	FileOperationV2  mockFileOperationV2
-	GetTarV2         mockGetTarV2
-	DiffFileV2       mockDiffFileV2
-	ClearCommitV2    mockClearCommitV2
-	CreateTmpFileSet mockCreateTmpFileSet
-	RenewTmpFileSet  mockRenewTmpFileSet
+	api                 pfsServerAPI
+	CreateRepo          mockCreateRepo
+	InspectRepo         mockInspectRepo
+	ListRepo            mockListRepo
+	DeleteRepo          mockDeleteRepo
+	SetRepoQuota        mockSetRepoQuota
+	SetRetentionPolicy  mockSetRetentionPolicy
+	PruneCommits        mockPruneCommits
+	SetMetadata         mockSetMetadata
+	SetBranchProtection mockSetBranchProtection
+	StartCommit         mockStartCommit
+	FinishCommit        mockFinishCommit
+	InspectCommit       mockInspectCommit
+	ListCommit          mockListCommit
+	ListCommitStream    mockListCommitStream
+	DeleteCommit        mockDeleteCommit
+	FlushCommit         mockFlushCommit
+	SubscribeCommit     mockSubscribeCommit
+	BuildCommit         mockBuildCommit
+	CreateBranch        mockCreateBranch
+	InspectBranch       mockInspectBranch
+	ListBranch          mockListBranch
+	DeleteBranch        mockDeleteBranch
+	MergeBranch         mockMergeBranch
+	PutFile             mockPutFile
+	CopyFile            mockCopyFile
+	SyncFile            mockSyncFile
+	GetFile             mockGetFile
+	InspectFile         mockInspectFile
+	ListFile            mockListFile
+	ListFileStream      mockListFileStream
+	WalkFile            mockWalkFile
+	GlobFile            mockGlobFile
+	GlobFileStream      mockGlobFileStream
+	DiffFile            mockDiffFile
+	DeleteFile          mockDeleteFile
+	DeleteAll           mockDeleteAllPFS
+	Fsck                mockFsck
+	FileOperationV2     mockFileOperationV2
+	GetTarV2            mockGetTarV2
+	DiffFileV2          mockDiffFileV2
+	ClearCommitV2       mockClearCommitV2
+	CreateTmpFileSet    mockCreateTmpFileSet
+	RenewTmpFileSet     mockRenewTmpFileSet
 }
 
 func (api *pfsServerAPI) CreateRepo(ctx context.Context, req *pfs.CreateRepoRequest) (*types.Empty, error) {
@@ -576,6 +604,36 @@ func (api *pfsServerAPI) DeleteRepo(ctx context.Context, req *pfs.DeleteRepoRequ
 	}
 	return nil, errors.Errorf("unhandled pachd mock pfs.DeleteRepo")
 }
+func (api *pfsServerAPI) SetRepoQuota(ctx context.Context, req *pfs.SetRepoQuotaRequest) (*types.Empty, error) {
+	if api.mock.SetRepoQuota.handler != nil {
+		return api.mock.SetRepoQuota.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.SetRepoQuota")
+}
+func (api *pfsServerAPI) SetRetentionPolicy(ctx context.Context, req *pfs.SetRetentionPolicyRequest) (*types.Empty, error) {
+	if api.mock.SetRetentionPolicy.handler != nil {
+		return api.mock.SetRetentionPolicy.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.SetRetentionPolicy")
+}
+func (api *pfsServerAPI) PruneCommits(ctx context.Context, req *pfs.PruneCommitsRequest) (*pfs.PruneCommitsResponse, error) {
+	if api.mock.PruneCommits.handler != nil {
+		return api.mock.PruneCommits.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.PruneCommits")
+}
+func (api *pfsServerAPI) SetMetadata(ctx context.Context, req *pfs.SetMetadataRequest) (*types.Empty, error) {
+	if api.mock.SetMetadata.handler != nil {
+		return api.mock.SetMetadata.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.SetMetadata")
+}
+func (api *pfsServerAPI) SetBranchProtection(ctx context.Context, req *pfs.SetBranchProtectionRequest) (*types.Empty, error) {
+	if api.mock.SetBranchProtection.handler != nil {
+		return api.mock.SetBranchProtection.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.SetBranchProtection")
+}
 func (api *pfsServerAPI) StartCommit(ctx context.Context, req *pfs.StartCommitRequest) (*pfs.Commit, error) {
 	if api.mock.StartCommit.handler != nil {
 		return api.mock.StartCommit.handler(ctx, req)
@@ -654,6 +712,12 @@ func (api *pfsServerAPI) DeleteBranch(ctx context.Context, req *pfs.DeleteBranch
 	}
 	return nil, errors.Errorf("unhandled pachd mock pfs.DeleteBranch")
 }
+func (api *pfsServerAPI) MergeBranch(ctx context.Context, req *pfs.MergeBranchRequest) (*pfs.MergeBranchResponse, error) {
+	if api.mock.MergeBranch.handler != nil {
+		return api.mock.MergeBranch.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.MergeBranch")
+}
 func (api *pfsServerAPI) PutFile(serv pfs.API_PutFileServer) error {
 	if api.mock.PutFile.handler != nil {
 		return api.mock.PutFile.handler(serv)
@@ -666,6 +730,12 @@ func (api *pfsServerAPI) CopyFile(ctx context.Context, req *pfs.CopyFileRequest)
 	}
 	return nil, errors.Errorf("unhandled pachd mock pfs.CopyFile")
 }
+func (api *pfsServerAPI) SyncFile(ctx context.Context, req *pfs.SyncFileRequest) (*pfs.SyncFileResponse, error) {
+	if api.mock.SyncFile.handler != nil {
+		return api.mock.SyncFile.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.SyncFile")
+}
 func (api *pfsServerAPI) GetFile(req *pfs.GetFileRequest, serv pfs.API_GetFileServer) error {
 	if api.mock.GetFile.handler != nil {
 		return api.mock.GetFile.handler(req, serv)
@@ -784,8 +854,10 @@ type listDatumFunc func(context.Context, *pps.ListDatumRequest) (*pps.ListDatumR
 type listDatumStreamFunc func(*pps.ListDatumRequest, pps.API_ListDatumStreamServer) error
 type restartDatumFunc func(context.Context, *pps.RestartDatumRequest) (*types.Empty, error)
 type createPipelineFunc func(context.Context, *pps.CreatePipelineRequest) (*types.Empty, error)
+type planPipelineFunc func(context.Context, *pps.CreatePipelineRequest) (*pps.PipelinePlan, error)
 type inspectPipelineFunc func(context.Context, *pps.InspectPipelineRequest) (*pps.PipelineInfo, error)
 type listPipelineFunc func(context.Context, *pps.ListPipelineRequest) (*pps.PipelineInfos, error)
+type inspectDAGFunc func(context.Context, *pps.InspectDAGRequest) (*pps.DAGInfo, error)
 type deletePipelineFunc func(context.Context, *pps.DeletePipelineRequest) (*types.Empty, error)
 type startPipelineFunc func(context.Context, *pps.StartPipelineRequest) (*types.Empty, error)
 type stopPipelineFunc func(context.Context, *pps.StopPipelineRequest) (*types.Empty, error)
@@ -813,8 +885,10 @@ type mockListDatum struct{ handler listDatumFunc }
 type mockListDatumStream struct{ handler listDatumStreamFunc }
 type mockRestartDatum struct{ handler restartDatumFunc }
 type mockCreatePipeline struct{ handler createPipelineFunc }
+type mockPlanPipeline struct{ handler planPipelineFunc }
 type mockInspectPipeline struct{ handler inspectPipelineFunc }
 type mockListPipeline struct{ handler listPipelineFunc }
+type mockInspectDAG struct{ handler inspectDAGFunc }
 type mockDeletePipeline struct{ handler deletePipelineFunc }
 type mockStartPipeline struct{ handler startPipelineFunc }
 type mockStopPipeline struct{ handler stopPipelineFunc }
@@ -842,8 +916,10 @@ func (mock *mockListDatum) Use(cb listDatumFunc)             { mock.handler = cb
 func (mock *mockListDatumStream) Use(cb listDatumStreamFunc) { mock.handler = cb }
 func (mock *mockRestartDatum) Use(cb restartDatumFunc)       { mock.handler = cb }
 func (mock *mockCreatePipeline) Use(cb createPipelineFunc)   { mock.handler = cb }
+func (mock *mockPlanPipeline) Use(cb planPipelineFunc)       { mock.handler = cb }
 func (mock *mockInspectPipeline) Use(cb inspectPipelineFunc) { mock.handler = cb }
 func (mock *mockListPipeline) Use(cb listPipelineFunc)       { mock.handler = cb }
+func (mock *mockInspectDAG) Use(cb inspectDAGFunc)           { mock.handler = cb }
 func (mock *mockDeletePipeline) Use(cb deletePipelineFunc)   { mock.handler = cb }
 func (mock *mockStartPipeline) Use(cb startPipelineFunc)     { mock.handler = cb }
 func (mock *mockStopPipeline) Use(cb stopPipelineFunc)       { mock.handler = cb }
@@ -877,8 +953,10 @@ type mockPPSServer struct {
 	ListDatumStream mockListDatumStream
 	RestartDatum    mockRestartDatum
 	CreatePipeline  mockCreatePipeline
+	PlanPipeline    mockPlanPipeline
 	InspectPipeline mockInspectPipeline
 	ListPipeline    mockListPipeline
+	InspectDAG      mockInspectDAG
 	DeletePipeline  mockDeletePipeline
 	StartPipeline   mockStartPipeline
 	StopPipeline    mockStopPipeline
@@ -972,6 +1050,12 @@ func (api *ppsServerAPI) CreatePipeline(ctx context.Context, req *pps.CreatePipe
 	}
 	return nil, errors.Errorf("unhandled pachd mock pps.CreatePipeline")
 }
+func (api *ppsServerAPI) PlanPipeline(ctx context.Context, req *pps.CreatePipelineRequest) (*pps.PipelinePlan, error) {
+	if api.mock.PlanPipeline.handler != nil {
+		return api.mock.PlanPipeline.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.PlanPipeline")
+}
 func (api *ppsServerAPI) InspectPipeline(ctx context.Context, req *pps.InspectPipelineRequest) (*pps.PipelineInfo, error) {
 	if api.mock.InspectPipeline.handler != nil {
 		return api.mock.InspectPipeline.handler(ctx, req)
@@ -984,6 +1068,12 @@ func (api *ppsServerAPI) ListPipeline(ctx context.Context, req *pps.ListPipeline
 	}
 	return nil, errors.Errorf("unhandled pachd mock pps.ListPipeline")
 }
+func (api *ppsServerAPI) InspectDAG(ctx context.Context, req *pps.InspectDAGRequest) (*pps.DAGInfo, error) {
+	if api.mock.InspectDAG.handler != nil {
+		return api.mock.InspectDAG.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.InspectDAG")
+}
 func (api *ppsServerAPI) DeletePipeline(ctx context.Context, req *pps.DeletePipelineRequest) (*types.Empty, error) {
 	if api.mock.DeletePipeline.handler != nil {
 		return api.mock.DeletePipeline.handler(ctx, req)
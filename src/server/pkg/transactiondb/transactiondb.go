@@ -5,8 +5,6 @@ package transactiondb
 import (
 	"path"
 
-	etcd "github.com/coreos/etcd/clientv3"
-
 	"github.com/pachyderm/pachyderm/src/client/transaction"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 )
@@ -16,9 +14,8 @@ const (
 )
 
 // Transactions returns a collection of open transactions
-func Transactions(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
-	return col.NewCollection(
-		etcdClient,
+func Transactions(backend col.Backend, etcdPrefix string) col.Collection {
+	return backend.NewCollection(
 		path.Join(etcdPrefix, transactionsPrefix),
 		nil,
 		&transaction.TransactionInfo{},
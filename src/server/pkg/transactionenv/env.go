@@ -344,7 +344,7 @@ func (env *TransactionEnv) WithTransaction(ctx context.Context, cb func(Transact
 // WithWriteContext will call the given callback with a TransactionContext
 // which can be used to perform reads and writes on the current cluster state.
 func (env *TransactionEnv) WithWriteContext(ctx context.Context, cb func(*TransactionContext) error) error {
-	_, err := col.NewSTM(ctx, env.serviceEnv.GetEtcdClient(), func(stm col.STM) error {
+	return env.serviceEnv.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		pachClient := env.serviceEnv.GetPachClient(ctx)
 		txnCtx := &TransactionContext{
 			Client:        pachClient,
@@ -360,14 +360,13 @@ func (env *TransactionEnv) WithWriteContext(ctx context.Context, cb func(*Transa
 		}
 		return txnCtx.finish()
 	})
-	return err
 }
 
 // WithReadContext will call the given callback with a TransactionContext
 // which can be used to perform reads of the current cluster state. If the
 // transaction is used to perform any writes, they will be silently discarded.
 func (env *TransactionEnv) WithReadContext(ctx context.Context, cb func(*TransactionContext) error) error {
-	return col.NewDryrunSTM(ctx, env.serviceEnv.GetEtcdClient(), func(stm col.STM) error {
+	return env.serviceEnv.GetCollectionBackend().NewDryrunSTM(ctx, func(stm col.STM) error {
 		pachClient := env.serviceEnv.GetPachClient(ctx)
 		txnCtx := &TransactionContext{
 			Client:        pachClient,
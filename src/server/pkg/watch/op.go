@@ -6,19 +6,58 @@ import etcd "github.com/coreos/etcd/clientv3"
 type OpOption struct {
 	Get   etcd.OpOption
 	Watch etcd.OpOption
+	// apply records the option in a backend independent form, for watchers
+	// that aren't backed by etcd.
+	apply func(*Options)
+}
+
+// Options is the backend independent form of a list of OpOptions.
+type Options struct {
+	FilterPut    bool
+	FilterDelete bool
+	// SortTarget and SortOrder are only meaningful if Sort is set.
+	Sort       bool
+	SortTarget etcd.SortTarget
+	SortOrder  etcd.SortOrder
+}
+
+// ParseOptions converts a list of OpOptions into an Options.
+func ParseOptions(opts ...OpOption) *Options {
+	options := &Options{}
+	for _, opt := range opts {
+		if opt.apply != nil {
+			opt.apply(options)
+		}
+	}
+	return options
 }
 
 // WithFilterPut discards PUT events from the watcher.
 func WithFilterPut() OpOption {
-	return OpOption{Watch: etcd.WithFilterPut(), Get: nil}
+	return OpOption{
+		Watch: etcd.WithFilterPut(),
+		Get:   nil,
+		apply: func(o *Options) { o.FilterPut = true },
+	}
 }
 
 // WithSort specifies the sort to use for the watcher
 func WithSort(sortBy etcd.SortTarget, sortOrder etcd.SortOrder) OpOption {
-	return OpOption{Get: etcd.WithSort(sortBy, sortOrder), Watch: nil}
+	return OpOption{
+		Get:   etcd.WithSort(sortBy, sortOrder),
+		Watch: nil,
+		apply: func(o *Options) {
+			o.Sort = true
+			o.SortTarget = sortBy
+			o.SortOrder = sortOrder
+		},
+	}
 }
 
 // WithFilterDelete discards DELETE events from the watcher.
 func WithFilterDelete() OpOption {
-	return OpOption{Watch: etcd.WithFilterDelete()}
+	return OpOption{
+		Watch: etcd.WithFilterDelete(),
+		apply: func(o *Options) { o.FilterDelete = true },
+	}
 }
@@ -2,6 +2,7 @@ package work
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -25,6 +26,19 @@ type taskEntry struct {
 	ctx             context.Context
 	cancel          context.CancelFunc
 	subtaskFuncChan chan subtaskFunc
+	priority        int64
+	group           *taskGroup
+}
+
+// taskGroup is a set of tasks that share the task queue with other groups in
+// proportion to their weight. Groups are scheduled by stride scheduling, each
+// group has a pass that advances by the inverse of its weight whenever one of
+// its subtasks is run, and the group with the lowest pass runs next.
+type taskGroup struct {
+	name     string
+	weight   int64
+	pass     float64
+	numTasks int
 }
 
 // runSubtask sends a subtask to be run in the task queue.
@@ -53,19 +67,25 @@ func (te *taskEntry) runSubtaskBlock(subtask subtaskBlockFunc) error {
 // only the ordering of subtasks across tasks.
 type taskQueue struct {
 	tasks                  *ordered_map.OrderedMap
+	groups                 map[string]*taskGroup
 	mu                     sync.Mutex
 	tasksDeletedSinceRemap int
+	// virtualTime is the pass of the group that most recently ran a subtask.
+	// New groups start at it, so that a group can't save up credit while it
+	// has no tasks.
+	virtualTime float64
 }
 
 func newTaskQueue(ctx context.Context) *taskQueue {
 	tq := &taskQueue{
-		tasks: ordered_map.NewOrderedMap(),
+		tasks:  ordered_map.NewOrderedMap(),
+		groups: make(map[string]*taskGroup),
 	}
-	// The next subtask to process is determined by iterating through the ordered map and checking the
-	// subtask function channel for each task entry to see if the next subtask is ready to be processed.
-	// If a subtask function is received, then it is executed.
-	// After processing a subtask, the iteration starts from the beginning (new subtasks from earlier
-	// tasks should be processed first).
+	// The next subtask to process is determined by iterating through the task entries in scheduling
+	// order and checking the subtask function channel for each task entry to see if the next subtask
+	// is ready to be processed. If a subtask function is received, then it is executed.
+	// After processing a subtask, the iteration starts from the beginning (the scheduling order
+	// changes as subtasks are processed).
 	go func() {
 	NextSubtask:
 		for {
@@ -75,11 +95,11 @@ func newTaskQueue(ctx context.Context) *taskQueue {
 			default:
 			}
 			tq.mu.Lock()
-			iter := tq.tasks.IterFunc()
-			for kv, ok := iter(); ok; kv, ok = iter() {
-				te := kv.Value.(*taskEntry)
+			for _, te := range tq.schedule() {
 				select {
 				case f := <-te.subtaskFuncChan:
+					tq.virtualTime = te.group.pass
+					te.group.pass += 1 / float64(te.group.weight)
 					tq.mu.Unlock()
 					f(te.ctx)
 					continue NextSubtask
@@ -93,13 +113,33 @@ func newTaskQueue(ctx context.Context) *taskQueue {
 	return tq
 }
 
+// schedule returns the task entries in the order their subtasks should be
+// run: by priority, then by the pass of their group, then by creation time.
+func (tq *taskQueue) schedule() []*taskEntry {
+	var tes []*taskEntry
+	iter := tq.tasks.IterFunc()
+	for kv, ok := iter(); ok; kv, ok = iter() {
+		tes = append(tes, kv.Value.(*taskEntry))
+	}
+	sort.SliceStable(tes, func(i, j int) bool {
+		if tes[i].priority != tes[j].priority {
+			return tes[i].priority > tes[j].priority
+		}
+		return tes[i].group.pass < tes[j].group.pass
+	})
+	return tes
+}
+
 // runTask runs a new task in the task queue.
 // The task code should be contained within the passed in callback.
 // The callback will receive a taskEntry, which should be used for running subtasks in the task queue.
 // The task state will be cleaned up upon return of the callback.
-func (tq *taskQueue) runTask(ctx context.Context, taskID string, f func(*taskEntry)) error {
+// The task's scheduling options (priority, group and weight) determine when
+// its subtasks are run relative to the subtasks of other tasks.
+func (tq *taskQueue) runTask(ctx context.Context, task *Task, f func(*taskEntry)) error {
 	tq.mu.Lock()
 	defer tq.mu.Unlock()
+	taskID := task.ID
 	if _, ok := tq.tasks.Get(taskID); ok {
 		return errors.Errorf("errored creating task %v, which already exists", taskID)
 	}
@@ -108,6 +148,8 @@ func (tq *taskQueue) runTask(ctx context.Context, taskID string, f func(*taskEnt
 		ctx:             ctx,
 		cancel:          cancel,
 		subtaskFuncChan: make(chan subtaskFunc, 1),
+		priority:        task.Priority,
+		group:           tq.joinGroup(task),
 	}
 	tq.tasks.Set(taskID, te)
 	go func() {
@@ -140,7 +182,39 @@ func (tq *taskQueue) deleteTask(taskID string) {
 	if !ok {
 		return
 	}
-	tc.(*taskEntry).cancel()
+	te := tc.(*taskEntry)
+	te.cancel()
 	tq.tasks.Delete(taskID)
+	tq.leaveGroup(te.group)
 	tq.maybeRemap()
 }
+
+// joinGroup adds a task to its group, creating the group if necessary.
+func (tq *taskQueue) joinGroup(task *Task) *taskGroup {
+	name := task.Group
+	if name == "" {
+		name = task.ID
+	}
+	weight := task.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+	g, ok := tq.groups[name]
+	if !ok {
+		g = &taskGroup{
+			name: name,
+			pass: tq.virtualTime,
+		}
+		tq.groups[name] = g
+	}
+	g.weight = weight
+	g.numTasks++
+	return g
+}
+
+func (tq *taskQueue) leaveGroup(g *taskGroup) {
+	g.numTasks--
+	if g.numTasks == 0 {
+		delete(tq.groups, g.name)
+	}
+}
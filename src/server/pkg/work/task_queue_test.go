@@ -3,6 +3,7 @@ package work
 import (
 	"context"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -32,7 +33,7 @@ func TestTaskQueue(t *testing.T) {
 	}
 	for i := 0; i < numTasks; i++ {
 		i := i
-		require.NoError(t, tq.runTask(context.Background(), strconv.Itoa(i), func(taskEntry *taskEntry) {
+		require.NoError(t, tq.runTask(context.Background(), &Task{ID: strconv.Itoa(i)}, func(taskEntry *taskEntry) {
 			for j := 0; j < numSubtasks; j++ {
 				if i == 0 {
 					// The first task will create subtasks that sleep a bit to allow the the subtasks
@@ -63,3 +64,67 @@ func TestTaskQueue(t *testing.T) {
 		}
 	}
 }
+
+// runSubtasks runs the given tasks in a task queue that is blocked until all
+// of them have a subtask ready, and returns the order in which the subtasks
+// were run (by task ID).
+func runSubtasks(t *testing.T, tasks []*Task, numSubtasks int) []string {
+	tq := newTaskQueue(context.Background())
+	blocked, release := make(chan struct{}), make(chan struct{})
+	require.NoError(t, tq.runTask(context.Background(), &Task{ID: "blocker"}, func(te *taskEntry) {
+		require.NoError(t, te.runSubtaskBlock(func(_ context.Context) error {
+			close(blocked)
+			<-release
+			return nil
+		}))
+	}))
+	<-blocked
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		task := task
+		wg.Add(1)
+		require.NoError(t, tq.runTask(context.Background(), task, func(te *taskEntry) {
+			defer wg.Done()
+			done := make(chan struct{})
+			for i := 0; i < numSubtasks; i++ {
+				i := i
+				// The next subtask is queued while this one runs, the sleep
+				// ensures that it's ready before the next one is scheduled.
+				te.runSubtask(func(_ context.Context) {
+					mu.Lock()
+					order = append(order, task.ID)
+					mu.Unlock()
+					time.Sleep(10 * time.Millisecond)
+					if i == numSubtasks-1 {
+						close(done)
+					}
+				})
+			}
+			<-done
+		}))
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	return order
+}
+
+func TestTaskQueuePriority(t *testing.T) {
+	order := runSubtasks(t, []*Task{{ID: "low"}, {ID: "high", Priority: 1}}, 5)
+	require.Equal(t, []string{"high", "high", "high", "high", "high", "low", "low", "low", "low", "low"}, order)
+}
+
+func TestTaskQueueFairness(t *testing.T) {
+	// Without fair scheduling, the earlier task would run all of its
+	// subtasks first.
+	order := runSubtasks(t, []*Task{{ID: "a"}, {ID: "b"}}, 3)
+	require.Equal(t, []string{"a", "b", "a", "b", "a", "b"}, order)
+	// Tasks in the same group share the group's share.
+	order = runSubtasks(t, []*Task{{ID: "a1", Group: "a"}, {ID: "a2", Group: "a"}, {ID: "b"}}, 2)
+	require.Equal(t, []string{"a1", "b", "a1", "b", "a2", "a2"}, order)
+	// Groups share in proportion to their weights.
+	order = runSubtasks(t, []*Task{{ID: "a", Weight: 2}, {ID: "b"}}, 3)
+	require.Equal(t, []string{"a", "b", "a", "a", "b", "b"}, order)
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"path"
 	"sync/atomic"
+	"time"
 
 	etcd "github.com/coreos/etcd/clientv3"
 	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
@@ -23,8 +25,10 @@ const (
 )
 
 // TaskQueue manages a set of parallel tasks, and provides an interface for running tasks.
-// Priority of tasks (and therefore subtasks) is based on task creation time, so tasks created
-// earlier will be prioritized over tasks that were created later.
+// Subtasks of tasks with a higher priority are run first. Tasks with the same priority
+// share workers fairly between task groups (by default each task is its own group), and
+// tasks created earlier are prioritized over tasks that were created later when they are
+// otherwise equal.
 type TaskQueue struct {
 	*taskEtcd
 	taskQueue *taskQueue
@@ -71,12 +75,45 @@ func newCollection(etcdClient *etcd.Client, etcdPrefix string, template proto.Me
 	)
 }
 
+// TaskOption sets a scheduling option of a task.
+type TaskOption func(*Task)
+
+// WithPriority sets the priority of a task, subtasks of tasks with a higher
+// priority are run before subtasks of tasks with a lower priority.
+func WithPriority(priority int64) TaskOption {
+	return func(task *Task) {
+		task.Priority = priority
+	}
+}
+
+// WithGroup puts a task in a group. Groups of tasks with the same priority
+// share workers in proportion to their weights, regardless of how many
+// subtasks each group has.
+func WithGroup(group string, weight int64) TaskOption {
+	return func(task *Task) {
+		task.Group = group
+		task.Weight = weight
+	}
+}
+
+// WithClaimTimeout sets how long a worker can go without renewing its claim
+// on one of the task's subtasks (e.g. because it died) before the subtask is
+// re-queued for another worker.
+func WithClaimTimeout(timeout time.Duration) TaskOption {
+	return func(task *Task) {
+		task.ClaimTimeout = types.DurationProto(timeout)
+	}
+}
+
 // RunTask runs a task in the task queue.
 // The task code should be contained within the passed in callback.
 // The callback will receive a Master, which should be used for running subtasks in the task queue.
 // The task state will be cleaned up upon return of the callback.
-func (tq *TaskQueue) RunTask(ctx context.Context, f func(*Master)) (retErr error) {
+func (tq *TaskQueue) RunTask(ctx context.Context, f func(*Master), opts ...TaskOption) (retErr error) {
 	task := &Task{ID: uuid.NewWithoutDashes()}
+	for _, opt := range opts {
+		opt(task)
+	}
 	if _, err := col.NewSTM(ctx, tq.etcdClient, func(stm col.STM) error {
 		return tq.taskCol.ReadWrite(stm).Put(task.ID, task)
 	}); err != nil {
@@ -89,7 +126,7 @@ func (tq *TaskQueue) RunTask(ctx context.Context, f func(*Master)) (retErr error
 			}
 		}
 	}()
-	return tq.taskQueue.runTask(ctx, task.ID, func(te *taskEntry) {
+	return tq.taskQueue.runTask(ctx, task, func(te *taskEntry) {
 		defer func() {
 			if err := tq.deleteTask(task.ID); err != nil {
 				fmt.Printf("errored deleting task %v: %v\n", task.ID, err)
@@ -104,11 +141,11 @@ func (tq *TaskQueue) RunTask(ctx context.Context, f func(*Master)) (retErr error
 }
 
 // RunTaskBlock is similar to RunTask, but blocks on the callback.
-func (tq *TaskQueue) RunTaskBlock(ctx context.Context, f func(*Master) error) error {
+func (tq *TaskQueue) RunTaskBlock(ctx context.Context, f func(*Master) error, opts ...TaskOption) error {
 	errChan := make(chan error)
 	if err := tq.RunTask(ctx, func(master *Master) {
 		errChan <- f(master)
-	}); err != nil {
+	}, opts...); err != nil {
 		return err
 	}
 	return <-errChan
@@ -217,7 +254,10 @@ func (m *Master) createSubtask(subtask *Task) error {
 		subtask.ID = uuid.NewWithoutDashes()
 	}
 	subtaskKey := path.Join(m.taskID, subtask.ID)
-	subtaskInfo := &TaskInfo{Task: subtask}
+	subtaskInfo := &TaskInfo{
+		Task:    subtask,
+		Created: types.TimestampNow(),
+	}
 	if _, err := col.NewSTM(m.taskEntry.ctx, m.etcdClient, func(stm col.STM) error {
 		return m.subtaskCol.ReadWrite(stm).Put(subtaskKey, subtaskInfo)
 	}); err != nil {
@@ -251,6 +291,23 @@ func (tq *TaskQueue) deleteAllTasks() error {
 	return err
 }
 
+// NumPendingSubtasks returns the number of subtasks in a task namespace that
+// haven't finished yet, whether or not a worker has claimed them.
+func NumPendingSubtasks(ctx context.Context, etcdClient *etcd.Client, etcdPrefix string, taskNamespace string) (int64, error) {
+	subtaskCol := newTaskEtcd(etcdClient, etcdPrefix, taskNamespace).subtaskCol
+	var count int64
+	subtaskInfo := &TaskInfo{}
+	if err := subtaskCol.ReadOnly(ctx).List(subtaskInfo, col.DefaultOptions, func(string) error {
+		if subtaskInfo.State == State_RUNNING {
+			count++
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // Worker is a worker that will process subtasks in a task.
 // A worker watches the task collection for tasks to be created / deleted and appropriately
 // runs / deletes tasks in the internal task queue with a function that watches the
@@ -283,7 +340,8 @@ func (w *Worker) Run(ctx context.Context, processFunc ProcessFunc) error {
 			taskQueue.deleteTask(taskID)
 			return nil
 		}
-		return taskQueue.runTask(ctx, taskID, func(taskEntry *taskEntry) {
+		task.ID = taskID
+		return taskQueue.runTask(ctx, task, func(taskEntry *taskEntry) {
 			if err := w.taskFunc(task, taskEntry, processFunc); err != nil && !errors.Is(taskEntry.ctx.Err(), context.Canceled) {
 				fmt.Printf("errored in task callback: %v\n", err)
 			}
@@ -302,6 +360,11 @@ func (w *Worker) taskFunc(task *Task, taskEntry *taskEntry, processFunc ProcessF
 		return err
 	}
 	defer subtaskWatch.Close()
+	// queued is the set of running subtasks that have been queued by the
+	// subtask watch. A running subtask is updated when it's claimed (to record
+	// the attempt), which shouldn't queue it again, subtasks whose claim is
+	// lost are re-queued by the claim watch.
+	queued := make(map[string]bool)
 	for {
 		select {
 		case e := <-claimWatch.Watch():
@@ -315,23 +378,49 @@ func (w *Worker) taskFunc(task *Task, taskEntry *taskEntry, processFunc ProcessF
 			if err := e.Unmarshal(&subtaskKey, &Claim{}); err != nil {
 				return err
 			}
-			taskEntry.runSubtask(w.subtaskFunc(subtaskKey, processFunc))
+			taskEntry.runSubtask(w.subtaskFunc(task, subtaskKey, processFunc))
 		case e := <-subtaskWatch.Watch():
 			if e.Type == watch.EventError {
 				return e.Err
 			}
 			var subtaskKey string
-			if err := e.Unmarshal(&subtaskKey, &TaskInfo{}); err != nil {
+			subtaskInfo := &TaskInfo{}
+			if err := e.Unmarshal(&subtaskKey, subtaskInfo); err != nil {
 				return err
 			}
-			taskEntry.runSubtask(w.subtaskFunc(subtaskKey, processFunc))
+			// Finished subtasks don't need to be processed.
+			if subtaskInfo.State != State_RUNNING {
+				delete(queued, subtaskKey)
+				continue
+			}
+			if queued[subtaskKey] {
+				continue
+			}
+			queued[subtaskKey] = true
+			taskEntry.runSubtask(w.subtaskFunc(task, subtaskKey, processFunc))
 		case <-taskEntry.ctx.Done():
 			return taskEntry.ctx.Err()
 		}
 	}
 }
 
-func (w *Worker) subtaskFunc(subtaskKey string, processFunc ProcessFunc) subtaskFunc {
+// claim claims a subtask of task and runs f with the claim's context.
+func (w *Worker) claim(ctx context.Context, task *Task, subtaskKey string, f func(context.Context) error) error {
+	if task.ClaimTimeout == nil {
+		return w.claimCol.Claim(ctx, subtaskKey, &Claim{}, f)
+	}
+	timeout, err := types.DurationFromProto(task.ClaimTimeout)
+	if err != nil {
+		return err
+	}
+	ttl := int64(timeout.Seconds())
+	if ttl < 1 {
+		ttl = 1
+	}
+	return w.claimCol.ClaimTTL(ctx, subtaskKey, &Claim{}, ttl, f)
+}
+
+func (w *Worker) subtaskFunc(task *Task, subtaskKey string, processFunc ProcessFunc) subtaskFunc {
 	return func(ctx context.Context) {
 		if err := func() error {
 			// (bryce) this should be refactored to have the check and claim in the same stm.
@@ -346,8 +435,25 @@ func (w *Worker) subtaskFunc(subtaskKey string, processFunc ProcessFunc) subtask
 			if subtaskInfo.State != State_RUNNING {
 				return nil
 			}
-			return w.claimCol.Claim(ctx, subtaskKey, &Claim{}, func(claimCtx context.Context) (retErr error) {
+			return w.claim(ctx, task, subtaskKey, func(claimCtx context.Context) (retErr error) {
 				subtask := subtaskInfo.Task
+				// Record the attempt, and how long the subtask waited for its
+				// first attempt.
+				if _, err := col.NewSTM(claimCtx, w.etcdClient, func(stm col.STM) error {
+					return w.subtaskCol.ReadWrite(stm).Update(subtaskKey, subtaskInfo, func() error {
+						if subtaskInfo.Attempts == 0 && subtaskInfo.Created != nil {
+							created, err := types.TimestampFromProto(subtaskInfo.Created)
+							if err != nil {
+								return err
+							}
+							subtaskInfo.WaitTime = types.DurationProto(time.Since(created))
+						}
+						subtaskInfo.Attempts++
+						return nil
+					})
+				}); err != nil {
+					return err
+				}
 				defer func() {
 					// If the task context was canceled or the claim was lost, just return with no error.
 					if errors.Is(claimCtx.Err(), context.Canceled) {
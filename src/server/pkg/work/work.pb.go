@@ -53,11 +53,24 @@ func (State) EnumDescriptor() ([]byte, []int) {
 }
 
 type Task struct {
-	ID                   string     `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Data                 *types.Any `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
-	XXX_unrecognized     []byte     `json:"-"`
-	XXX_sizecache        int32      `json:"-"`
+	ID   string     `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Data *types.Any `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// The fields below are scheduling options for tasks, they're ignored for
+	// subtasks.
+	// Subtasks of tasks with a higher priority are run before subtasks of tasks
+	// with a lower priority.
+	Priority int64 `protobuf:"varint,3,opt,name=priority,proto3" json:"priority,omitempty"`
+	// Tasks with the same priority share workers fairly between groups, in
+	// proportion to the groups' weights. A task with no group is in a group of
+	// its own.
+	Group  string `protobuf:"bytes,4,opt,name=group,proto3" json:"group,omitempty"`
+	Weight int64  `protobuf:"varint,5,opt,name=weight,proto3" json:"weight,omitempty"`
+	// claim_timeout is how long a worker can go without renewing its claim on a
+	// subtask before the subtask is given to another worker.
+	ClaimTimeout         *types.Duration `protobuf:"bytes,6,opt,name=claim_timeout,json=claimTimeout,proto3" json:"claim_timeout,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
 }
 
 func (m *Task) Reset()         { *m = Task{} }
@@ -107,10 +120,44 @@ func (m *Task) GetData() *types.Any {
 	return nil
 }
 
+func (m *Task) GetPriority() int64 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *Task) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *Task) GetWeight() int64 {
+	if m != nil {
+		return m.Weight
+	}
+	return 0
+}
+
+func (m *Task) GetClaimTimeout() *types.Duration {
+	if m != nil {
+		return m.ClaimTimeout
+	}
+	return nil
+}
+
 type TaskInfo struct {
-	Task                 *Task    `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
-	State                State    `protobuf:"varint,2,opt,name=state,proto3,enum=work.State" json:"state,omitempty"`
-	Reason               string   `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Task   *Task  `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
+	State  State  `protobuf:"varint,2,opt,name=state,proto3,enum=work.State" json:"state,omitempty"`
+	Reason string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// created is when the subtask was created.
+	Created *types.Timestamp `protobuf:"bytes,4,opt,name=created,proto3" json:"created,omitempty"`
+	// wait_time is how long the subtask waited to be claimed for the first time.
+	WaitTime *types.Duration `protobuf:"bytes,5,opt,name=wait_time,json=waitTime,proto3" json:"wait_time,omitempty"`
+	// attempts is the number of times the subtask has been claimed.
+	Attempts             int64    `protobuf:"varint,6,opt,name=attempts,proto3" json:"attempts,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -170,6 +217,27 @@ func (m *TaskInfo) GetReason() string {
 	return ""
 }
 
+func (m *TaskInfo) GetCreated() *types.Timestamp {
+	if m != nil {
+		return m.Created
+	}
+	return nil
+}
+
+func (m *TaskInfo) GetWaitTime() *types.Duration {
+	if m != nil {
+		return m.WaitTime
+	}
+	return nil
+}
+
+func (m *TaskInfo) GetAttempts() int64 {
+	if m != nil {
+		return m.Attempts
+	}
+	return 0
+}
+
 type Claim struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -267,28 +335,37 @@ func init() {
 func init() { proto.RegisterFile("server/pkg/work/work.proto", fileDescriptor_58a68e4647f78187) }
 
 var fileDescriptor_58a68e4647f78187 = []byte{
-	// 332 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x91, 0xcb, 0x6a, 0xe3, 0x30,
-	0x14, 0x86, 0xc7, 0x1e, 0xe7, 0x76, 0x0c, 0x43, 0x10, 0x21, 0x64, 0xc2, 0xe0, 0x49, 0xbd, 0x32,
-	0x5d, 0xd8, 0xe0, 0xbe, 0x40, 0x73, 0x6b, 0x6b, 0x28, 0x59, 0xc8, 0xc9, 0xa6, 0x3b, 0xc5, 0x56,
-	0x1c, 0xe3, 0xc4, 0x32, 0x92, 0xd2, 0x92, 0x37, 0xec, 0xb2, 0x4f, 0x50, 0x8a, 0x9f, 0xa4, 0x48,
-	0x6e, 0x69, 0xe9, 0x46, 0xfc, 0x97, 0xc3, 0xc7, 0x91, 0x04, 0x63, 0x41, 0xf9, 0x23, 0xe5, 0x41,
-	0x55, 0x64, 0xc1, 0x13, 0xe3, 0x85, 0x3e, 0xfc, 0x8a, 0x33, 0xc9, 0x90, 0xa5, 0xf4, 0x78, 0x90,
-	0xb1, 0x8c, 0xe9, 0x20, 0x50, 0xaa, 0xe9, 0xc6, 0x7f, 0x33, 0xc6, 0xb2, 0x03, 0x0d, 0xb4, 0xdb,
-	0x9e, 0x76, 0x01, 0x29, 0xcf, 0x4d, 0xe5, 0xde, 0x81, 0xb5, 0x26, 0xa2, 0x40, 0x43, 0x30, 0xf3,
-	0x74, 0x64, 0x4c, 0x0c, 0xaf, 0x37, 0x6b, 0xd7, 0xaf, 0xff, 0xcd, 0x68, 0x81, 0xcd, 0x3c, 0x45,
-	0x1e, 0x58, 0x29, 0x91, 0x64, 0x64, 0x4e, 0x0c, 0xcf, 0x0e, 0x07, 0x7e, 0x43, 0xf2, 0x3f, 0x49,
-	0xfe, 0xb4, 0x3c, 0x63, 0x3d, 0xe1, 0x52, 0xe8, 0x2a, 0x52, 0x54, 0xee, 0x18, 0x72, 0xc0, 0x92,
-	0x44, 0x14, 0x9a, 0x67, 0x87, 0xe0, 0xeb, 0x3d, 0x55, 0x8b, 0x75, 0x8e, 0x2e, 0xa0, 0x25, 0x24,
-	0x91, 0x54, 0x63, 0xff, 0x84, 0x76, 0x33, 0x10, 0xab, 0x08, 0x37, 0x0d, 0x1a, 0x42, 0x9b, 0x53,
-	0x22, 0x58, 0x39, 0xfa, 0xad, 0x96, 0xc2, 0x1f, 0xce, 0xed, 0x40, 0x6b, 0x7e, 0x20, 0xf9, 0xd1,
-	0xf5, 0xa0, 0xbb, 0xa6, 0x42, 0x2e, 0x88, 0x24, 0xe8, 0x1f, 0xf4, 0x2a, 0xce, 0x12, 0x2a, 0x04,
-	0x6d, 0x2e, 0xd1, 0xc5, 0x5f, 0xc1, 0xa5, 0x0f, 0x2d, 0x8d, 0x46, 0x36, 0x74, 0xf0, 0x66, 0xb5,
-	0x8a, 0x56, 0xb7, 0xfd, 0x5f, 0xca, 0xc4, 0x9b, 0xf9, 0x7c, 0x19, 0xc7, 0x7d, 0x43, 0x99, 0x9b,
-	0x69, 0x74, 0xbf, 0xc1, 0xcb, 0xbe, 0x39, 0xbb, 0x7e, 0xae, 0x1d, 0xe3, 0xa5, 0x76, 0x8c, 0xb7,
-	0xda, 0x31, 0x1e, 0xc2, 0x2c, 0x97, 0xfb, 0xd3, 0xd6, 0x4f, 0xd8, 0x31, 0xa8, 0x48, 0xb2, 0x3f,
-	0xa7, 0x94, 0x7f, 0x57, 0x82, 0x27, 0xc1, 0x8f, 0x7f, 0xd9, 0xb6, 0xf5, 0xfb, 0x5c, 0xbd, 0x07,
-	0x00, 0x00, 0xff, 0xff, 0xec, 0xad, 0x45, 0x2a, 0xb1, 0x01, 0x00, 0x00,
+	// 475 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x52, 0xc1, 0x8e, 0xd3, 0x30,
+	0x10, 0xc5, 0x6d, 0xd2, 0xa6, 0x0e, 0xa0, 0xca, 0xaa, 0x56, 0xd9, 0x08, 0x65, 0x4b, 0x4f, 0x11,
+	0x87, 0x44, 0x0a, 0x88, 0x23, 0x62, 0xb7, 0x5d, 0x50, 0x25, 0xd4, 0x83, 0xdb, 0x5e, 0xb8, 0x20,
+	0x37, 0xf1, 0xa6, 0x56, 0x37, 0x71, 0x64, 0x3b, 0x54, 0xfd, 0x43, 0x8e, 0x88, 0x0f, 0x40, 0xa8,
+	0x3f, 0xc0, 0x2f, 0x20, 0x3b, 0xe9, 0x82, 0xba, 0x07, 0x2e, 0xd1, 0xbc, 0x79, 0x4f, 0x33, 0xf3,
+	0x9e, 0x03, 0x7d, 0x49, 0xc5, 0x57, 0x2a, 0xe2, 0x6a, 0x97, 0xc7, 0x7b, 0x2e, 0x76, 0xe6, 0x13,
+	0x55, 0x82, 0x2b, 0x8e, 0x2c, 0x5d, 0xfb, 0xa3, 0x9c, 0xe7, 0xdc, 0x34, 0x62, 0x5d, 0x35, 0x9c,
+	0x7f, 0x99, 0x73, 0x9e, 0xdf, 0xd3, 0xd8, 0xa0, 0x4d, 0x7d, 0x17, 0x93, 0xf2, 0xd0, 0x52, 0xc1,
+	0x39, 0x95, 0xd5, 0x82, 0x28, 0xc6, 0xcb, 0x96, 0xbf, 0x3a, 0xe7, 0x15, 0x2b, 0xa8, 0x54, 0xa4,
+	0xa8, 0x1a, 0xc1, 0xe4, 0x07, 0x80, 0xd6, 0x8a, 0xc8, 0x1d, 0xba, 0x80, 0x1d, 0x96, 0x79, 0x60,
+	0x0c, 0xc2, 0xc1, 0x4d, 0xef, 0xf8, 0xf3, 0xaa, 0x33, 0x9f, 0xe1, 0x0e, 0xcb, 0x50, 0x08, 0xad,
+	0x8c, 0x28, 0xe2, 0x75, 0xc6, 0x20, 0x74, 0x93, 0x51, 0xd4, 0x0c, 0x8c, 0x4e, 0x03, 0xa3, 0xeb,
+	0xf2, 0x80, 0x8d, 0x02, 0xf9, 0xd0, 0xa9, 0x04, 0xe3, 0x82, 0xa9, 0x83, 0xd7, 0x1d, 0x83, 0xb0,
+	0x8b, 0x1f, 0x30, 0x1a, 0x41, 0x3b, 0x17, 0xbc, 0xae, 0x3c, 0x4b, 0x2f, 0xc0, 0x0d, 0x40, 0x17,
+	0xb0, 0xb7, 0xa7, 0x2c, 0xdf, 0x2a, 0xcf, 0x36, 0xfa, 0x16, 0xa1, 0x77, 0xf0, 0x59, 0x7a, 0x4f,
+	0x58, 0xf1, 0x45, 0x5f, 0xcb, 0x6b, 0xe5, 0xf5, 0xcc, 0xf2, 0xcb, 0x47, 0xcb, 0x67, 0xad, 0x5b,
+	0xfc, 0xd4, 0xe8, 0x57, 0x8d, 0x7c, 0xf2, 0x1b, 0x40, 0x47, 0x9b, 0x9a, 0x97, 0x77, 0x1c, 0x05,
+	0xd0, 0x52, 0x44, 0xee, 0x8c, 0x35, 0x37, 0x81, 0x91, 0x09, 0x5d, 0xb3, 0xd8, 0xf4, 0xd1, 0x4b,
+	0x68, 0x4b, 0x45, 0x14, 0x35, 0x0e, 0x9f, 0x27, 0x6e, 0x23, 0x58, 0xea, 0x16, 0x6e, 0x18, 0x7d,
+	0xa7, 0xa0, 0x44, 0xf2, 0xd2, 0xf8, 0x1a, 0xe0, 0x16, 0xa1, 0x37, 0xb0, 0x9f, 0x0a, 0x4a, 0x14,
+	0xcd, 0x8c, 0x2f, 0x37, 0xf1, 0x1f, 0x5d, 0xb8, 0x3a, 0xe5, 0x8d, 0x4f, 0x52, 0xf4, 0x16, 0x0e,
+	0xf6, 0x84, 0x29, 0x63, 0xce, 0xb3, 0xff, 0xe7, 0xcc, 0xd1, 0x5a, 0x3d, 0x45, 0xe7, 0x4b, 0x94,
+	0xa2, 0x45, 0xa5, 0xa4, 0x09, 0xa4, 0x8b, 0x1f, 0xf0, 0xa4, 0x0f, 0xed, 0xa9, 0x4e, 0x60, 0x12,
+	0x42, 0x67, 0x45, 0xa5, 0x9a, 0xe9, 0x07, 0x79, 0x01, 0x07, 0x95, 0xe0, 0x29, 0x95, 0x92, 0x36,
+	0x2f, 0xeb, 0xe0, 0xbf, 0x8d, 0x57, 0x11, 0xb4, 0x8d, 0x49, 0xe4, 0xc2, 0x3e, 0x5e, 0x2f, 0x16,
+	0xf3, 0xc5, 0xc7, 0xe1, 0x13, 0x0d, 0x96, 0xeb, 0xe9, 0xf4, 0x76, 0xb9, 0x1c, 0x02, 0x0d, 0x3e,
+	0x5c, 0xcf, 0x3f, 0xad, 0xf1, 0xed, 0xb0, 0x73, 0xf3, 0xfe, 0xdb, 0x31, 0x00, 0xdf, 0x8f, 0x01,
+	0xf8, 0x75, 0x0c, 0xc0, 0xe7, 0x24, 0x67, 0x6a, 0x5b, 0x6f, 0xa2, 0x94, 0x17, 0x71, 0x45, 0xd2,
+	0xed, 0x21, 0xa3, 0xe2, 0xdf, 0x4a, 0x8a, 0x34, 0x3e, 0xfb, 0xdd, 0x37, 0x3d, 0xe3, 0xee, 0xf5,
+	0x9f, 0x01, 0x00, 0x2d, 0x29, 0x22, 0x7c, 0x08, 0x03, 0x00, 0x00,
 }
 
 func (m *Task) Marshal() (dAtA []byte, err error) {
@@ -315,6 +392,35 @@ func (m *Task) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.ClaimTimeout != nil {
+		{
+			size, err := m.ClaimTimeout.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWork(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.Weight != 0 {
+		i = encodeVarintWork(dAtA, i, uint64(m.Weight))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.Group) > 0 {
+		i -= len(m.Group)
+		copy(dAtA[i:], m.Group)
+		i = encodeVarintWork(dAtA, i, uint64(len(m.Group)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Priority != 0 {
+		i = encodeVarintWork(dAtA, i, uint64(m.Priority))
+		i--
+		dAtA[i] = 0x18
+	}
 	if m.Data != nil {
 		{
 			size, err := m.Data.MarshalToSizedBuffer(dAtA[:i])
@@ -361,6 +467,35 @@ func (m *TaskInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Attempts != 0 {
+		i = encodeVarintWork(dAtA, i, uint64(m.Attempts))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.WaitTime != nil {
+		{
+			size, err := m.WaitTime.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWork(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Created != nil {
+		{
+			size, err := m.Created.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWork(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
 	if len(m.Reason) > 0 {
 		i -= len(m.Reason)
 		copy(dAtA[i:], m.Reason)
@@ -477,6 +612,20 @@ func (m *Task) Size() (n int) {
 		l = m.Data.Size()
 		n += 1 + l + sovWork(uint64(l))
 	}
+	if m.Priority != 0 {
+		n += 1 + sovWork(uint64(m.Priority))
+	}
+	l = len(m.Group)
+	if l > 0 {
+		n += 1 + l + sovWork(uint64(l))
+	}
+	if m.Weight != 0 {
+		n += 1 + sovWork(uint64(m.Weight))
+	}
+	if m.ClaimTimeout != nil {
+		l = m.ClaimTimeout.Size()
+		n += 1 + l + sovWork(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -500,6 +649,17 @@ func (m *TaskInfo) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovWork(uint64(l))
 	}
+	if m.Created != nil {
+		l = m.Created.Size()
+		n += 1 + l + sovWork(uint64(l))
+	}
+	if m.WaitTime != nil {
+		l = m.WaitTime.Size()
+		n += 1 + l + sovWork(uint64(l))
+	}
+	if m.Attempts != 0 {
+		n += 1 + sovWork(uint64(m.Attempts))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -636,6 +796,112 @@ func (m *Task) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Priority", wireType)
+			}
+			m.Priority = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWork
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Priority |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Group", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWork
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWork
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWork
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Group = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Weight", wireType)
+			}
+			m.Weight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWork
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Weight |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClaimTimeout", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWork
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWork
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWork
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ClaimTimeout == nil {
+				m.ClaimTimeout = &types.Duration{}
+			}
+			if err := m.ClaimTimeout.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWork(dAtA[iNdEx:])
@@ -777,6 +1043,97 @@ func (m *TaskInfo) Unmarshal(dAtA []byte) error {
 			}
 			m.Reason = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Created", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWork
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWork
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWork
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Created == nil {
+				m.Created = &types.Timestamp{}
+			}
+			if err := m.Created.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WaitTime", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWork
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWork
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWork
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.WaitTime == nil {
+				m.WaitTime = &types.Duration{}
+			}
+			if err := m.WaitTime.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Attempts", wireType)
+			}
+			m.Attempts = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWork
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Attempts |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWork(dAtA[iNdEx:])
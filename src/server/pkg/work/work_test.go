@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -200,3 +201,48 @@ func TestRunZeroSubtasks(t *testing.T) {
 		})
 	}))
 }
+
+func TestSubtaskAttempts(t *testing.T) {
+	require.NoError(t, testetcd.WithEnv(func(env *testetcd.Env) error {
+		numSubtasks := 10
+		numWorkers := 5
+		// Setup workers, which count how many times each subtask is processed.
+		var mu sync.Mutex
+		processed := make(map[string]int)
+		workerCtx, workerCancel := context.WithCancel(context.Background())
+		defer workerCancel()
+		for i := 0; i < numWorkers; i++ {
+			w := NewWorker(env.EtcdClient, "", "")
+			go w.Run(workerCtx, func(_ context.Context, subtask *Task) error {
+				mu.Lock()
+				defer mu.Unlock()
+				processed[subtask.ID]++
+				return nil
+			})
+		}
+		tq, err := NewTaskQueue(context.Background(), env.EtcdClient, "", "")
+		if err != nil {
+			return err
+		}
+		var subtasks []*Task
+		for i := 0; i < numSubtasks; i++ {
+			subtasks = append(subtasks, &Task{ID: strconv.Itoa(i)})
+		}
+		if err := tq.RunTaskBlock(context.Background(), func(m *Master) error {
+			return m.RunSubtasks(subtasks, func(_ context.Context, subtaskInfo *TaskInfo) error {
+				require.Equal(t, State_SUCCESS, subtaskInfo.State)
+				require.Equal(t, int64(1), subtaskInfo.Attempts)
+				require.NotNil(t, subtaskInfo.WaitTime)
+				return nil
+			})
+		}, WithClaimTimeout(time.Second)); err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for i := 0; i < numSubtasks; i++ {
+			require.Equal(t, 1, processed[strconv.Itoa(i)])
+		}
+		return nil
+	}))
+}
@@ -10,7 +10,9 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	pachdclient "github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
@@ -27,6 +29,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/server/pkg/serde"
 	"github.com/pachyderm/pachyderm/src/server/pkg/tabwriter"
 	"github.com/pachyderm/pachyderm/src/server/pkg/uuid"
+	"github.com/pachyderm/pachyderm/src/server/pps/graph"
 	"github.com/pachyderm/pachyderm/src/server/pps/pretty"
 
 	prompt "github.com/c-bata/go-prompt"
@@ -106,16 +109,95 @@ If the job fails, the output commit will not be populated with data.`,
 	commands = append(commands, cmdutil.CreateDocsAlias(jobDocs, "job", " job$"))
 
 	var block bool
+	var resources bool
+	var resourcesPipeline string
+	var since string
+	var until string
 	inspectJob := &cobra.Command{
 		Use:   "{{alias}} <job>",
 		Short: "Return info about a job.",
-		Long:  "Return info about a job.",
-		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+		Long: `Return info about a job.
+
+With --resources, no job is given; instead the resources (CPU time, peak memory,
+disk I/O and scratch space) consumed by the user code of every job started in
+the given time range are totaled per pipeline.`,
+		Example: `
+# Return info about job aedfa12aedf
+$ {{alias}} aedfa12aedf
+
+# Return the resources used by each pipeline's jobs in the last week
+$ {{alias}} --resources --since 168h
+
+# Return the resources used by each pipeline's jobs in the week before last
+$ {{alias}} --resources --since 336h --until 168h
+
+# Return the resources used by pipeline "foo"'s jobs in the last day
+$ {{alias}} --resources -p foo`,
+		Run: cmdutil.RunBoundedArgs(0, 1, func(args []string) error {
+			if resources {
+				if len(args) > 0 {
+					return errors.New("cannot specify a job with --resources")
+				}
+			} else if len(args) == 0 {
+				return errors.New("must specify a job")
+			}
 			client, err := pachdclient.NewOnUserMachine("user")
 			if err != nil {
 				return err
 			}
 			defer client.Close()
+			if resources {
+				sinceDuration, err := time.ParseDuration(since)
+				if err != nil {
+					return errors.Wrapf(err, "error parsing since")
+				}
+				untilDuration, err := time.ParseDuration(until)
+				if err != nil {
+					return errors.Wrapf(err, "error parsing until")
+				}
+				now := time.Now()
+				start, end := now.Add(-sinceDuration), now.Add(-untilDuration)
+				if !start.Before(end) {
+					return errors.Errorf("--since (%s) must be longer than --until (%s)", since, until)
+				}
+				usages := make(map[string]*pretty.PipelineResourceUsage)
+				if err := client.ListJobF(resourcesPipeline, nil, nil, -1, false, func(ji *ppsclient.JobInfo) error {
+					started, err := types.TimestampFromProto(ji.Started)
+					if err != nil || started.Before(start) || started.After(end) {
+						return nil
+					}
+					usage, ok := usages[ji.Pipeline.Name]
+					if !ok {
+						usage = &pretty.PipelineResourceUsage{Pipeline: ji.Pipeline.Name}
+						usages[ji.Pipeline.Name] = usage
+					}
+					usage.Add(ji)
+					return nil
+				}); err != nil {
+					return err
+				}
+				var pipelines []string
+				for pipeline := range usages {
+					pipelines = append(pipelines, pipeline)
+				}
+				sort.Strings(pipelines)
+				if raw {
+					e := encoder(output)
+					for _, pipeline := range pipelines {
+						if err := e.Encode(usages[pipeline]); err != nil {
+							return err
+						}
+					}
+					return nil
+				} else if output != "" {
+					cmdutil.ErrorAndExit("cannot set --output (-o) without --raw")
+				}
+				writer := tabwriter.NewWriter(os.Stdout, pretty.ResourceUsageHeader)
+				for _, pipeline := range pipelines {
+					pretty.PrintResourceUsage(writer, usages[pipeline])
+				}
+				return writer.Flush()
+			}
 			jobInfo, err := client.InspectJob(args[0], block, true)
 			if err != nil {
 				cmdutil.ErrorAndExit("error from InspectJob: %s", err.Error())
@@ -136,6 +218,11 @@ If the job fails, the output commit will not be populated with data.`,
 		}),
 	}
 	inspectJob.Flags().BoolVarP(&block, "block", "b", false, "block until the job has either succeeded or failed")
+	inspectJob.Flags().BoolVar(&resources, "resources", false, "Return the resources used by each pipeline's jobs, rather than info about a single job.")
+	inspectJob.Flags().StringVarP(&resourcesPipeline, "pipeline", "p", "", "With --resources, limit to jobs made by pipeline.")
+	inspectJob.MarkFlagCustom("pipeline", "__pachctl_get_pipeline")
+	inspectJob.Flags().StringVar(&since, "since", "24h", "With --resources, only count jobs started within this duration of the current time.")
+	inspectJob.Flags().StringVar(&until, "until", "0s", "With --resources, only count jobs started at least this duration before the current time.")
 	inspectJob.Flags().AddFlagSet(outputFlags)
 	inspectJob.Flags().AddFlagSet(fullTimestampsFlags)
 	shell.RegisterCompletionFunc(inspectJob, shell.JobCompletion)
@@ -349,17 +436,33 @@ each datum.`,
 	}
 	commands = append(commands, cmdutil.CreateDocsAlias(datumDocs, "datum", " datum$"))
 
+	var quarantined bool
 	restartDatum := &cobra.Command{
 		Use:   "{{alias}} <job> <datum-path1>,<datum-path2>,...",
 		Short: "Restart a datum.",
-		Long:  "Restart a datum.",
-		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+		Long: "Restart a datum. With --quarantined, release datums that the job quarantined " +
+			"(rather than restarting datums that it's processing), so that the pipeline's next job " +
+			"reprocesses them. In that case the datum paths may be omitted, to release all of the " +
+			"datums that the job quarantined.",
+		Example: `
+# Restart the datum containing /foo/bar in job aedfa12aedf
+$ {{alias}} aedfa12aedf /foo/bar
+
+# Reprocess every datum that job aedfa12aedf quarantined
+$ {{alias}} --quarantined aedfa12aedf`,
+		Run: cmdutil.RunBoundedArgs(1, 2, func(args []string) error {
+			if len(args) < 2 && !quarantined {
+				return errors.New("must specify the datums to restart")
+			}
 			client, err := pachdclient.NewOnUserMachine("user")
 			if err != nil {
 				return err
 			}
 			defer client.Close()
-			datumFilter := strings.Split(args[1], ",")
+			var datumFilter []string
+			if len(args) == 2 {
+				datumFilter = strings.Split(args[1], ",")
+			}
 			for i := 0; i < len(datumFilter); {
 				if len(datumFilter[i]) == 0 {
 					if i+1 < len(datumFilter) {
@@ -370,9 +473,13 @@ each datum.`,
 					i++
 				}
 			}
+			if quarantined {
+				return client.RestartQuarantinedDatums(args[0], datumFilter)
+			}
 			return client.RestartDatum(args[0], datumFilter)
 		}),
 	}
+	restartDatum.Flags().BoolVar(&quarantined, "quarantined", false, "Release the datums that the job quarantined, so that the pipeline's next job reprocesses them.")
 	commands = append(commands, cmdutil.CreateAlias(restartDatum, "restart datum"))
 
 	var pageSize int64
@@ -604,11 +711,14 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	var registry string
 	var username string
 	var pipelinePath string
+	var valuesPath string
+	var templateArgs []string
+	var dryRun bool
 	createPipeline := &cobra.Command{
 		Short: "Create a new pipeline.",
-		Long:  "Create a new pipeline from a pipeline specification. For details on the format, see http://docs.pachyderm.io/en/latest/reference/pipeline_spec.html.",
+		Long:  "Create a new pipeline from a pipeline specification. For details on the format, see http://docs.pachyderm.io/en/latest/reference/pipeline_spec.html. The spec is rendered as a Go template (see https://golang.org/pkg/text/template) if it ends in .tmpl or .tpl, or --values or --arg is set; a template may include other templates with {{ include \"<file>\" . }} and render several pipelines.",
 		Run: cmdutil.RunFixedArgs(0, func(args []string) (retErr error) {
-			return pipelineHelper(false, build, pushImages, registry, username, pipelinePath, false)
+			return pipelineHelper(false, build, pushImages, registry, username, pipelinePath, valuesPath, templateArgs, dryRun, false)
 		}),
 	}
 	createPipeline.Flags().StringVarP(&pipelinePath, "file", "f", "-", "The JSON file containing the pipeline, it can be a url or local file. - reads from stdin.")
@@ -616,14 +726,17 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	createPipeline.Flags().BoolVarP(&pushImages, "push-images", "p", false, "If true, push local docker images into the docker registry.")
 	createPipeline.Flags().StringVarP(&registry, "registry", "r", "index.docker.io", "The registry to push images to.")
 	createPipeline.Flags().StringVarP(&username, "username", "u", "", "The username to push images as.")
+	createPipeline.Flags().StringVar(&valuesPath, "values", "", "A JSON or YAML file of parameters to render the pipeline spec with, as a template.")
+	createPipeline.Flags().StringArrayVar(&templateArgs, "arg", nil, "A parameter to render the pipeline spec with, as a template, of the form key=value. Takes precedence over --values. May be repeated.")
+	createPipeline.Flags().BoolVar(&dryRun, "dry-run", false, "If true, validate the pipeline spec and print the changes it would make (to the spec, the datums that would be processed and the downstream pipelines affected) without making them.")
 	commands = append(commands, cmdutil.CreateAlias(createPipeline, "create pipeline"))
 
 	var reprocess bool
 	updatePipeline := &cobra.Command{
 		Short: "Update an existing Pachyderm pipeline.",
-		Long:  "Update a Pachyderm pipeline with a new pipeline specification. For details on the format, see http://docs.pachyderm.io/en/latest/reference/pipeline_spec.html.",
+		Long:  "Update a Pachyderm pipeline with a new pipeline specification. For details on the format, see http://docs.pachyderm.io/en/latest/reference/pipeline_spec.html. The spec is rendered as a Go template (see https://golang.org/pkg/text/template) if it ends in .tmpl or .tpl, or --values or --arg is set; a template may include other templates with {{ include \"<file>\" . }} and render several pipelines.",
 		Run: cmdutil.RunFixedArgs(0, func(args []string) (retErr error) {
-			return pipelineHelper(reprocess, build, pushImages, registry, username, pipelinePath, true)
+			return pipelineHelper(reprocess, build, pushImages, registry, username, pipelinePath, valuesPath, templateArgs, dryRun, true)
 		}),
 	}
 	updatePipeline.Flags().StringVarP(&pipelinePath, "file", "f", "-", "The JSON file containing the pipeline, it can be a url or local file. - reads from stdin.")
@@ -631,6 +744,9 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	updatePipeline.Flags().BoolVarP(&pushImages, "push-images", "p", false, "If true, push local docker images into the docker registry.")
 	updatePipeline.Flags().StringVarP(&registry, "registry", "r", "index.docker.io", "The registry to push images to.")
 	updatePipeline.Flags().StringVarP(&username, "username", "u", "", "The username to push images as.")
+	updatePipeline.Flags().StringVar(&valuesPath, "values", "", "A JSON or YAML file of parameters to render the pipeline spec with, as a template.")
+	updatePipeline.Flags().StringArrayVar(&templateArgs, "arg", nil, "A parameter to render the pipeline spec with, as a template, of the form key=value. Takes precedence over --values. May be repeated.")
+	updatePipeline.Flags().BoolVar(&dryRun, "dry-run", false, "If true, validate the pipeline spec and print the changes it would make (to the spec, the datums that would be processed and the downstream pipelines affected) without making them.")
 	updatePipeline.Flags().BoolVar(&reprocess, "reprocess", false, "If true, reprocess datums that were already processed by previous version of the pipeline.")
 	commands = append(commands, cmdutil.CreateAlias(updatePipeline, "update pipeline"))
 
@@ -1086,6 +1202,44 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	}
 	commands = append(commands, cmdutil.CreateAlias(listSecret, "list secret"))
 
+	var dagFormat string
+	var overlay bool
+	var upstream string
+	var downstream string
+	drawDAG := &cobra.Command{
+		Short: "Draw the DAG of repos and pipelines.",
+		Long: "Draw the DAG of repos and pipelines (derived from pipeline inputs and branch provenance) " +
+			"as a Graphviz DOT digraph, a Mermaid flowchart or JSON.",
+		Example: `
+# Render the DAG as an image with Graphviz
+$ {{alias}} | dot -Tpng > dag.png
+
+# Print the pipelines and repos that pipeline "foo" depends on, with the
+# pipelines' states and the datum counts of their most recent jobs
+$ {{alias}} --upstream foo --overlay
+
+# Print the pipelines downstream of repo "bar" as a Mermaid flowchart
+$ {{alias}} --downstream bar --format mermaid`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			client, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			dagInfo, err := client.InspectDAG(overlay, upstream, downstream)
+			if err != nil {
+				return err
+			}
+			return graph.New(dagInfo).Write(os.Stdout, dagFormat)
+		}),
+	}
+	drawDAG.Flags().StringVar(&dagFormat, "format", graph.FormatDOT, "The format to draw the DAG in, one of \"dot\", \"mermaid\" or \"json\".")
+	drawDAG.Flags().BoolVar(&overlay, "overlay", false, "If true, include each pipeline's state, and the state and datum counts of its most recent job.")
+	drawDAG.Flags().StringVar(&upstream, "upstream", "", "Only draw this pipeline or repo and the pipelines and repos it depends on.")
+	drawDAG.Flags().StringVar(&downstream, "downstream", "", "Only draw this pipeline or repo and the pipelines that depend on it.")
+	shell.RegisterCompletionFunc(drawDAG, shell.PipelineCompletion)
+	commands = append(commands, cmdutil.CreateAlias(drawDAG, "draw dag"))
+
 	var memory string
 	garbageCollect := &cobra.Command{
 		Short: "Garbage collect unused data.",
@@ -1131,12 +1285,21 @@ you can increase the amount of memory used for the bloom filters with the
 	return commands
 }
 
-func pipelineHelper(reprocess bool, build bool, pushImages bool, registry, username, pipelinePath string, update bool) error {
+func pipelineHelper(reprocess bool, build bool, pushImages bool, registry, username, pipelinePath, valuesPath string, templateArgs []string, dryRun bool, update bool) error {
 	if build && pushImages {
 		logrus.Warning("`--push-images` is redundant, as it's already enabled with `--build`")
 	}
+	if dryRun && (build || pushImages) {
+		return errors.New("--dry-run cannot be used with --build or --push-images")
+	}
 
-	pipelineReader, err := ppsutil.NewPipelineManifestReader(pipelinePath)
+	var pipelineReader *ppsutil.PipelineManifestReader
+	var err error
+	if valuesPath != "" || len(templateArgs) > 0 || ppsutil.IsPipelineTemplate(pipelinePath) {
+		pipelineReader, err = ppsutil.NewPipelineTemplateReader(pipelinePath, valuesPath, templateArgs)
+	} else {
+		pipelineReader, err = ppsutil.NewPipelineManifestReader(pipelinePath)
+	}
 	if err != nil {
 		return err
 	}
@@ -1174,6 +1337,18 @@ func pipelineHelper(reprocess bool, build bool, pushImages bool, registry, usern
 			request.Reprocess = reprocess
 		}
 
+		if dryRun {
+			if request.Transform != nil && request.Transform.Build != nil {
+				return errors.New("--dry-run is not supported for build step-enabled pipelines")
+			}
+			plan, err := pc.PpsAPIClient.PlanPipeline(pc.Ctx(), request)
+			if err != nil {
+				return grpcutil.ScrubGRPC(err)
+			}
+			pretty.PrintPipelinePlan(os.Stdout, plan)
+			continue
+		}
+
 		isLocal := true
 		url, err := url.Parse(pipelinePath)
 		if pipelinePath != "-" && err == nil && url.Scheme != "" {
@@ -27,7 +27,9 @@ package cmds
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/pachyderm/pachyderm/src/client/pkg/require"
@@ -619,6 +621,152 @@ func TestEditPipeline(t *testing.T) {
 		`).Run())
 }
 
+func TestUpdatePipelineDryRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	require.NoError(t, tu.BashCmd(`
+		yes | pachctl delete all
+		pachctl create repo data
+		echo foo | pachctl put file data@master:/foo
+		pachctl create pipeline <<EOF
+		  pipeline:
+		    name: first
+		  input:
+		    pfs:
+		      glob: /*
+		      repo: data
+		  transform:
+		    cmd: [ /bin/bash ]
+		    stdin:
+		      - "cp /pfs/data/* /pfs/out"
+		EOF
+		pachctl create pipeline <<EOF
+		  pipeline:
+		    name: second
+		  input:
+		    pfs:
+		      glob: /*
+		      repo: first
+		  transform:
+		    cmd: [ /bin/bash ]
+		    stdin:
+		      - "cp /pfs/first/* /pfs/out"
+		EOF
+		pachctl flush commit data@master
+		`).Run())
+
+	// Only the pipeline's cmd changes
+	require.NoError(t, tu.BashCmd(`
+		pachctl update pipeline --dry-run <<EOF \
+		  | match 'Pipeline: first \(update\)' \
+		  | match 'transform:' \
+		  | match '/bin/sh' \
+		  | match 'Datums: 1 total, 0 to process, 1 to skip' \
+		  | match 'Downstream Pipelines: second'
+		  pipeline:
+		    name: first
+		  input:
+		    pfs:
+		      glob: /*
+		      repo: data
+		  transform:
+		    cmd: [ /bin/sh ]
+		    stdin:
+		      - "cp /pfs/data/* /pfs/out"
+		EOF
+		`).Run())
+	require.NoError(t, tu.BashCmd(`
+		pachctl update pipeline --dry-run --reprocess <<EOF \
+		  | match 'Datums: 1 total, 1 to process, 0 to skip' \
+		  | match 'Reprocess: all datums'
+		  pipeline:
+		    name: first
+		  input:
+		    pfs:
+		      glob: /*
+		      repo: data
+		  transform:
+		    cmd: [ /bin/sh ]
+		    stdin:
+		      - "cp /pfs/data/* /pfs/out"
+		EOF
+		`).Run())
+	// The pipeline wasn't updated
+	require.NoError(t, tu.BashCmd(`
+		pachctl inspect pipeline first | match -v '/bin/sh'
+		`).Run())
+}
+
+// TestTemplatedPipelines tests that pipeline specs may be rendered from a
+// template, and that the template is recorded in the pipelines' specs
+func TestTemplatedPipelines(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	dir, err := ioutil.TempDir("", "pachyderm-test-templated-pipelines")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// tu.BashCmd renders its commands as templates too, so the files are
+	// written here rather than in the script
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pipelines.json.tmpl"), []byte(`
+		{{ range .repos }}
+		{
+		  "pipeline": {"name": "{{ $.prefix }}-{{ . }}"},
+		  "input": {"pfs": {"glob": "/*", "repo": "{{ . }}"}},
+		  "transform": {{ include "transform.json.tmpl" $ }}
+		}
+		{{ end }}
+		`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "transform.json.tmpl"), []byte(`
+		{"cmd": ["/bin/bash"], "stdin": [{{ toJSON .cmd }}]}
+		`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "values.yaml"), []byte(`
+repos: [left, right]
+cmd: "cp /pfs/*/* /pfs/out"
+prefix: ignored
+`), 0644))
+
+	require.NoError(t, tu.BashCmd(`
+		yes | pachctl delete all
+		pachctl create repo left
+		pachctl create repo right
+		pachctl create pipeline -f {{.dir}}/pipelines.json.tmpl --values {{.dir}}/values.yaml --arg prefix=copy
+		pachctl list pipeline \
+		  | match copy-left \
+		  | match copy-right
+		pachctl inspect pipeline copy-left \
+		  | match 'Template: {{.dir}}/pipelines.json.tmpl' \
+		  | match 'Template Args: .*"prefix":"copy"'
+		pachctl get file __spec__@copy-right:/template | match 'range .repos'
+		pachctl get file __spec__@copy-right:/args | match '"repos":\["left","right"\]'
+
+		echo foo | pachctl put file left@master:/foo
+		pachctl flush commit left@master
+		pachctl get file copy-left@master:/foo | match foo
+		`,
+		"dir", dir,
+	).Run())
+
+	// Updating a pipeline with a plain spec removes its template
+	require.NoError(t, tu.BashCmd(`
+		pachctl update pipeline <<EOF
+		  pipeline:
+		    name: copy-left
+		  input:
+		    pfs:
+		      glob: /*
+		      repo: left
+		  transform:
+		    cmd: [ /bin/bash ]
+		    stdin:
+		      - "cp /pfs/left/* /pfs/out"
+		EOF
+		pachctl inspect pipeline copy-left | match -v 'Template:'
+		pachctl list file __spec__@copy-left | match -v template
+		`).Run())
+}
+
 func TestPipelineBuildLifecyclePython(t *testing.T) {
 	if os.Getenv("RUN_BAD_TESTS") == "" {
 		t.Skip("Skipping because RUN_BAD_TESTS was empty")
@@ -0,0 +1,343 @@
+// Package graph exports the graph of repos and pipelines in a Pachyderm
+// cluster (as seen in the dashboard), in formats that other tools can render.
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dag"
+	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsconsts"
+)
+
+// The formats that a Graph can be written in
+const (
+	// FormatDOT is the Graphviz DOT language
+	FormatDOT = "dot"
+	// FormatMermaid is a Mermaid flowchart
+	FormatMermaid = "mermaid"
+	// FormatJSON is a JSON encoding of the Graph struct
+	FormatJSON = "json"
+)
+
+// NodeType is the type of a Node
+type NodeType string
+
+// The types of Nodes
+const (
+	// RepoNode is a repo that isn't a pipeline's output repo
+	RepoNode NodeType = "repo"
+	// PipelineNode is a pipeline, along with its output repo
+	PipelineNode NodeType = "pipeline"
+)
+
+// Node is a repo or a pipeline in a Graph
+type Node struct {
+	Name string   `json:"name"`
+	Type NodeType `json:"type"`
+
+	// The fields below are only set for pipelines, if the DAG was inspected
+	// with an overlay
+	State        string       `json:"state,omitempty"`
+	LastJobState string       `json:"last_job_state,omitempty"`
+	Datums       *DatumCounts `json:"datums,omitempty"`
+}
+
+// DatumCounts are the datum counts of a pipeline's most recent job
+type DatumCounts struct {
+	Total     int64 `json:"total"`
+	Processed int64 `json:"processed"`
+	Skipped   int64 `json:"skipped"`
+	Failed    int64 `json:"failed"`
+	Recovered int64 `json:"recovered"`
+}
+
+// Edge is an edge in a Graph, from a repo or pipeline to a repo or pipeline
+// whose output is provenant on it
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is a graph of repos and pipelines. Nodes are sorted by name, and edges
+// by their nodes' names.
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
+}
+
+// New returns the Graph of 'dagInfo' (as returned by the InspectDAG RPC), for
+// writing.
+func New(dagInfo *pps.DAGInfo) *Graph {
+	g := &Graph{}
+	for _, dagNode := range dagInfo.Nodes {
+		node := &Node{Name: dagNode.Name, Type: RepoNode}
+		if dagNode.Pipeline {
+			node.Type = PipelineNode
+		}
+		if overlay := dagNode.Overlay; overlay != nil {
+			node.State = pipelineState(overlay.State)
+			if job := overlay.LastJob; job != nil {
+				node.LastJobState = jobState(job.State)
+				node.Datums = &DatumCounts{
+					Total:     job.DataTotal,
+					Processed: job.DataProcessed,
+					Skipped:   job.DataSkipped,
+					Failed:    job.DataFailed,
+					Recovered: job.DataRecovered,
+				}
+			}
+		}
+		g.Nodes = append(g.Nodes, node)
+	}
+	for _, dagEdge := range dagInfo.Edges {
+		g.Edges = append(g.Edges, &Edge{From: dagEdge.From, To: dagEdge.To})
+	}
+	return g
+}
+
+// InspectDAG returns the DAG of repos and pipelines in the cluster that
+// 'pachClient' is connected to, which implements the InspectDAG RPC. Edges
+// come from pipeline inputs and branch provenance.
+func InspectDAG(pachClient *client.APIClient, request *pps.InspectDAGRequest) (*pps.DAGInfo, error) {
+	repoInfos, err := pachClient.ListRepo()
+	if err != nil {
+		return nil, err
+	}
+	pipelineInfos, err := pachClient.ListPipeline()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]*pps.DAGNode)
+	for _, repoInfo := range repoInfos {
+		nodes[repoInfo.Repo.Name] = &pps.DAGNode{Name: repoInfo.Repo.Name}
+	}
+	parents := make(map[string]map[string]bool)
+	addEdge := func(from, to string) {
+		if from == to || from == ppsconsts.SpecRepo {
+			return
+		}
+		for _, name := range []string{from, to} {
+			if _, ok := nodes[name]; !ok {
+				nodes[name] = &pps.DAGNode{Name: name}
+			}
+		}
+		if parents[to] == nil {
+			parents[to] = make(map[string]bool)
+		}
+		parents[to][from] = true
+	}
+	for _, pipelineInfo := range pipelineInfos {
+		name := pipelineInfo.Pipeline.Name
+		node := &pps.DAGNode{Name: name, Pipeline: true}
+		nodes[name] = node
+		pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
+			switch {
+			case input.Pfs != nil:
+				addEdge(input.Pfs.Repo, name)
+			case input.Cron != nil:
+				addEdge(input.Cron.Repo, name)
+			case input.Git != nil:
+				addEdge(input.Git.Name, name)
+			}
+		})
+		if request.Overlay {
+			if node.Overlay, err = overlay(pachClient, pipelineInfo); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, repoInfo := range repoInfos {
+		branchInfos, err := pachClient.ListBranch(repoInfo.Repo.Name)
+		if err != nil {
+			if auth.IsErrNotAuthorized(err) {
+				// The caller can't read the repo's branches, so only its
+				// pipeline inputs are included
+				continue
+			}
+			return nil, err
+		}
+		for _, branchInfo := range branchInfos {
+			for _, branch := range branchInfo.DirectProvenance {
+				addEdge(branch.Repo.Name, repoInfo.Repo.Name)
+			}
+		}
+	}
+
+	// Limit the DAG to the requested subgraphs
+	included := make(map[string]bool)
+	if request.Upstream != "" || request.Downstream != "" {
+		d := dag.NewDAG(nil)
+		for name := range nodes {
+			var nodeParents []string
+			for parent := range parents[name] {
+				nodeParents = append(nodeParents, parent)
+			}
+			d.NewNode(name, nodeParents)
+		}
+		if request.Upstream != "" {
+			if _, ok := nodes[request.Upstream]; !ok {
+				return nil, errors.Errorf("no repo or pipeline named %q", request.Upstream)
+			}
+			for _, name := range d.Ancestors(request.Upstream, nil) {
+				included[name] = true
+			}
+		}
+		if request.Downstream != "" {
+			if _, ok := nodes[request.Downstream]; !ok {
+				return nil, errors.Errorf("no repo or pipeline named %q", request.Downstream)
+			}
+			for _, name := range d.Descendants(request.Downstream, nil) {
+				included[name] = true
+			}
+		}
+	} else {
+		for name := range nodes {
+			included[name] = true
+		}
+	}
+
+	result := &pps.DAGInfo{}
+	for name, node := range nodes {
+		if included[name] {
+			result.Nodes = append(result.Nodes, node)
+		}
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool {
+		return result.Nodes[i].Name < result.Nodes[j].Name
+	})
+	for to, froms := range parents {
+		for from := range froms {
+			if included[from] && included[to] {
+				result.Edges = append(result.Edges, &pps.DAGEdge{From: from, To: to})
+			}
+		}
+	}
+	sort.Slice(result.Edges, func(i, j int) bool {
+		if result.Edges[i].From != result.Edges[j].From {
+			return result.Edges[i].From < result.Edges[j].From
+		}
+		return result.Edges[i].To < result.Edges[j].To
+	})
+	return result, nil
+}
+
+// overlay returns the state of the pipeline 'pipelineInfo', and the state and
+// datum counts of its most recent job
+func overlay(pachClient *client.APIClient, pipelineInfo *pps.PipelineInfo) (*pps.DAGOverlay, error) {
+	result := &pps.DAGOverlay{State: pipelineInfo.State}
+	if err := pachClient.ListJobF(pipelineInfo.Pipeline.Name, nil, nil, 0, false, func(jobInfo *pps.JobInfo) error {
+		// Jobs are listed from the most recent
+		result.LastJob = &pps.DAGJob{
+			State:         jobInfo.State,
+			DataTotal:     jobInfo.DataTotal,
+			DataProcessed: jobInfo.DataProcessed,
+			DataSkipped:   jobInfo.DataSkipped,
+			DataFailed:    jobInfo.DataFailed,
+			DataRecovered: jobInfo.DataRecovered,
+		}
+		return errutil.ErrBreak
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func pipelineState(state pps.PipelineState) string {
+	return strings.ToLower(strings.TrimPrefix(state.String(), "PIPELINE_"))
+}
+
+func jobState(state pps.JobState) string {
+	return strings.ToLower(strings.TrimPrefix(state.String(), "JOB_"))
+}
+
+// Write writes 'g' to 'w' in 'format', which is one of FormatDOT,
+// FormatMermaid and FormatJSON
+func (g *Graph) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatDOT:
+		return g.WriteDOT(w)
+	case FormatMermaid:
+		return g.WriteMermaid(w)
+	case FormatJSON:
+		return g.WriteJSON(w)
+	}
+	return errors.Errorf("unrecognized graph format %q, must be one of %q, %q or %q", format, FormatDOT, FormatMermaid, FormatJSON)
+}
+
+// WriteDOT writes 'g' to 'w' as a Graphviz digraph, in which repos are
+// cylinders and pipelines are boxes
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph pachyderm {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, node := range g.Nodes {
+		shape := "cylinder"
+		if node.Type == PipelineNode {
+			shape = "box"
+		}
+		fmt.Fprintf(&b, "  %s [shape=%s, label=%s];\n", strconv.Quote(node.Name), shape, strconv.Quote(strings.Join(node.labelLines(), "\n")))
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", strconv.Quote(edge.From), strconv.Quote(edge.To))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes 'g' to 'w' as a Mermaid flowchart, in which repos are
+// cylinders and pipelines are boxes
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	// Repo names may not be valid Mermaid IDs, so nodes are numbered instead
+	ids := make(map[string]string)
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for i, node := range g.Nodes {
+		ids[node.Name] = fmt.Sprintf("n%d", i)
+		label := strings.ReplaceAll(strings.Join(node.labelLines(), "<br/>"), `"`, "#quot;")
+		if node.Type == PipelineNode {
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[node.Name], label)
+		} else {
+			fmt.Fprintf(&b, "  %s[(\"%s\")]\n", ids[node.Name], label)
+		}
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes 'g' to 'w' as JSON
+func (g *Graph) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(g)
+}
+
+// labelLines returns the lines of the label of 'node': its name, followed by
+// its overlay (if any)
+func (node *Node) labelLines() []string {
+	lines := []string{node.Name}
+	if node.State != "" {
+		lines = append(lines, "state: "+node.State)
+	}
+	if node.LastJobState != "" {
+		lines = append(lines, "last job: "+node.LastJobState)
+	}
+	if node.Datums != nil {
+		lines = append(lines, fmt.Sprintf("datums: %d processed, %d skipped, %d failed, %d recovered / %d total",
+			node.Datums.Processed, node.Datums.Skipped, node.Datums.Failed, node.Datums.Recovered, node.Datums.Total))
+	}
+	return lines
+}
@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/testpachd"
+
+	"golang.org/x/net/context"
+)
+
+// withGraphEnv runs 'cb' in a testpachd env containing two pipelines ("edges"
+// and "montage", which are mocked, as testpachd doesn't run PPS) and a repo
+// ("derived") that's only connected to the others through branch provenance
+func withGraphEnv(t *testing.T, cb func(env *testpachd.RealEnv)) {
+	require.NoError(t, testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		c := env.PachClient
+		for _, repo := range []string{"images", "labels", "edges", "montage", "derived"} {
+			require.NoError(t, c.CreateRepo(repo))
+		}
+		for _, repo := range []string{"images", "labels"} {
+			_, err := c.PutFile(repo, "master", "/file", strings.NewReader("foo"))
+			require.NoError(t, err)
+		}
+		require.NoError(t, c.CreateBranch("edges", "master", "", []*pfs.Branch{
+			client.NewBranch("images", "master"),
+		}))
+		require.NoError(t, c.CreateBranch("montage", "master", "", []*pfs.Branch{
+			client.NewBranch("images", "master"),
+			client.NewBranch("edges", "master"),
+		}))
+		require.NoError(t, c.CreateBranch("derived", "master", "", []*pfs.Branch{
+			client.NewBranch("labels", "master"),
+		}))
+
+		env.MockPachd.PPS.ListPipeline.Use(func(context.Context, *pps.ListPipelineRequest) (*pps.PipelineInfos, error) {
+			return &pps.PipelineInfos{PipelineInfo: []*pps.PipelineInfo{
+				{
+					Pipeline: client.NewPipeline("edges"),
+					Input:    client.NewPFSInput("images", "/*"),
+					State:    pps.PipelineState_PIPELINE_STANDBY,
+				},
+				{
+					Pipeline: client.NewPipeline("montage"),
+					Input: client.NewCrossInput(
+						client.NewPFSInput("images", "/"),
+						client.NewPFSInput("edges", "/"),
+					),
+					State: pps.PipelineState_PIPELINE_RUNNING,
+				},
+			}}, nil
+		})
+		env.MockPachd.PPS.ListJobStream.Use(func(req *pps.ListJobRequest, serv pps.API_ListJobStreamServer) error {
+			if req.Pipeline.Name != "montage" {
+				return nil
+			}
+			for _, jobInfo := range []*pps.JobInfo{
+				{State: pps.JobState_JOB_FAILURE, DataTotal: 3, DataProcessed: 1, DataSkipped: 1, DataFailed: 1},
+				{State: pps.JobState_JOB_SUCCESS, DataTotal: 2, DataProcessed: 2},
+			} {
+				if err := serv.Send(jobInfo); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		cb(env)
+		return nil
+	}))
+}
+
+func nodeNames(g *Graph) []string {
+	var result []string
+	for _, node := range g.Nodes {
+		result = append(result, node.Name)
+	}
+	return result
+}
+
+// inspectDAG returns the Graph of the DAG returned by InspectDAG
+func inspectDAG(pachClient *client.APIClient, request *pps.InspectDAGRequest) (*Graph, error) {
+	dagInfo, err := InspectDAG(pachClient, request)
+	if err != nil {
+		return nil, err
+	}
+	return New(dagInfo), nil
+}
+
+func TestInspectDAG(t *testing.T) {
+	t.Parallel()
+	withGraphEnv(t, func(env *testpachd.RealEnv) {
+		g, err := inspectDAG(env.PachClient, &pps.InspectDAGRequest{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"derived", "edges", "images", "labels", "montage"}, nodeNames(g))
+		require.Equal(t, RepoNode, g.Nodes[0].Type)
+		require.Equal(t, PipelineNode, g.Nodes[1].Type)
+		require.Equal(t, PipelineNode, g.Nodes[4].Type)
+		require.Equal(t, []*Edge{
+			{From: "edges", To: "montage"},
+			{From: "images", To: "edges"},
+			{From: "images", To: "montage"},
+			{From: "labels", To: "derived"},
+		}, g.Edges)
+		// Overlays are only added if requested
+		require.Equal(t, "", g.Nodes[4].State)
+		require.True(t, g.Nodes[4].Datums == nil)
+	})
+}
+
+func TestInspectDAGSubgraph(t *testing.T) {
+	t.Parallel()
+	withGraphEnv(t, func(env *testpachd.RealEnv) {
+		g, err := inspectDAG(env.PachClient, &pps.InspectDAGRequest{Upstream: "montage"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"edges", "images", "montage"}, nodeNames(g))
+		require.Equal(t, 3, len(g.Edges))
+
+		g, err = inspectDAG(env.PachClient, &pps.InspectDAGRequest{Downstream: "edges"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"edges", "montage"}, nodeNames(g))
+		require.Equal(t, []*Edge{{From: "edges", To: "montage"}}, g.Edges)
+
+		g, err = inspectDAG(env.PachClient, &pps.InspectDAGRequest{Upstream: "edges", Downstream: "labels"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"derived", "edges", "images", "labels"}, nodeNames(g))
+
+		_, err = inspectDAG(env.PachClient, &pps.InspectDAGRequest{Upstream: "nonexistent"})
+		require.YesError(t, err)
+	})
+}
+
+func TestOverlayAndFormats(t *testing.T) {
+	t.Parallel()
+	withGraphEnv(t, func(env *testpachd.RealEnv) {
+		g, err := inspectDAG(env.PachClient, &pps.InspectDAGRequest{Overlay: true, Downstream: "edges"})
+		require.NoError(t, err)
+		edges, montage := g.Nodes[0], g.Nodes[1]
+		require.Equal(t, "standby", edges.State)
+		require.Equal(t, "", edges.LastJobState)
+		require.True(t, edges.Datums == nil)
+		require.Equal(t, "running", montage.State)
+		require.Equal(t, "failure", montage.LastJobState)
+		require.Equal(t, &DatumCounts{Total: 3, Processed: 1, Skipped: 1, Failed: 1}, montage.Datums)
+
+		var buf bytes.Buffer
+		require.NoError(t, g.Write(&buf, FormatDOT))
+		require.Equal(t, `digraph pachyderm {
+  rankdir=LR;
+  "edges" [shape=box, label="edges\nstate: standby"];
+  "montage" [shape=box, label="montage\nstate: running\nlast job: failure\ndatums: 1 processed, 1 skipped, 1 failed, 0 recovered / 3 total"];
+  "edges" -> "montage";
+}
+`, buf.String())
+
+		buf.Reset()
+		require.NoError(t, g.Write(&buf, FormatMermaid))
+		require.Equal(t, `graph LR
+  n0["edges<br/>state: standby"]
+  n1["montage<br/>state: running<br/>last job: failure<br/>datums: 1 processed, 1 skipped, 1 failed, 0 recovered / 3 total"]
+  n0 --> n1
+`, buf.String())
+
+		buf.Reset()
+		require.NoError(t, g.Write(&buf, FormatJSON))
+		var decoded Graph
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		require.Equal(t, g, &decoded)
+
+		require.YesError(t, g.Write(&buf, "png"))
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"strings"
 	"text/template"
+	"time"
 
 	units "github.com/docker/go-units"
 	"github.com/fatih/color"
@@ -29,6 +30,8 @@ const (
 	DatumHeader = "ID\tFILES\tSTATUS\tTIME\t\n"
 	// SecretHeader is the header for secrets
 	SecretHeader = "NAME\tTYPE\tCREATED\t\n"
+	// ResourceUsageHeader is the header for per-pipeline resource usage
+	ResourceUsageHeader = "PIPELINE\tJOBS\tDATUMS\tCPU TIME\tPEAK MEMORY\tDISK READ\tDISK WRITTEN\tSCRATCH\t\n"
 	// jobReasonLen is the amount of the job reason that we print
 	jobReasonLen = 25
 )
@@ -66,6 +69,53 @@ func PrintJobInfo(w io.Writer, jobInfo *ppsclient.JobInfo, fullTimestamps bool)
 	fmt.Fprintln(w)
 }
 
+// PipelineResourceUsage is the total resource usage of a pipeline's jobs, as
+// shown by 'pachctl inspect job --resources'
+type PipelineResourceUsage struct {
+	Pipeline string `json:"pipeline"`
+	Jobs     int    `json:"jobs"`
+	// Datums is the number of datums that ran user code (i.e. weren't skipped)
+	Datums int64 `json:"datums"`
+	// CPUTime, DiskReadBytes, DiskWriteBytes and ScratchBytes are summed over
+	// the pipeline's jobs, and PeakMemoryBytes is the maximum over all of them
+	CPUTime         time.Duration `json:"cpu_time_ns"`
+	PeakMemoryBytes uint64        `json:"peak_memory_bytes"`
+	DiskReadBytes   uint64        `json:"disk_read_bytes"`
+	DiskWriteBytes  uint64        `json:"disk_write_bytes"`
+	ScratchBytes    uint64        `json:"scratch_bytes"`
+}
+
+// Add adds the resources consumed by the job 'jobInfo' to 'u'
+func (u *PipelineResourceUsage) Add(jobInfo *ppsclient.JobInfo) {
+	u.Jobs++
+	u.Datums += jobInfo.DataProcessed + jobInfo.DataFailed + jobInfo.DataRecovered + jobInfo.DataQuarantined
+	if jobInfo.Stats == nil {
+		return
+	}
+	if cpuTime, err := types.DurationFromProto(jobInfo.Stats.CpuTime); err == nil {
+		u.CPUTime += cpuTime
+	}
+	if jobInfo.Stats.PeakMemoryBytes > u.PeakMemoryBytes {
+		u.PeakMemoryBytes = jobInfo.Stats.PeakMemoryBytes
+	}
+	u.DiskReadBytes += jobInfo.Stats.DiskReadBytes
+	u.DiskWriteBytes += jobInfo.Stats.DiskWriteBytes
+	u.ScratchBytes += jobInfo.Stats.ScratchBytes
+}
+
+// PrintResourceUsage pretty-prints the resource usage of a pipeline's jobs.
+func PrintResourceUsage(w io.Writer, usage *PipelineResourceUsage) {
+	fmt.Fprintf(w, "%s\t", usage.Pipeline)
+	fmt.Fprintf(w, "%d\t", usage.Jobs)
+	fmt.Fprintf(w, "%d\t", usage.Datums)
+	fmt.Fprintf(w, "%s\t", usage.CPUTime.Round(time.Millisecond))
+	fmt.Fprintf(w, "%s\t", pretty.Size(usage.PeakMemoryBytes))
+	fmt.Fprintf(w, "%s\t", pretty.Size(usage.DiskReadBytes))
+	fmt.Fprintf(w, "%s\t", pretty.Size(usage.DiskWriteBytes))
+	fmt.Fprintf(w, "%s\t", pretty.Size(usage.ScratchBytes))
+	fmt.Fprintln(w)
+}
+
 // PrintPipelineInfo pretty-prints pipeline info.
 func PrintPipelineInfo(w io.Writer, pipelineInfo *ppsclient.PipelineInfo, fullTimestamps bool) {
 	if pipelineInfo.Transform == nil {
@@ -140,13 +190,19 @@ Reason: {{.Reason}}
 Processed: {{.DataProcessed}}
 Failed: {{.DataFailed}}
 Skipped: {{.DataSkipped}}
-Recovered: {{.DataRecovered}}
+Recovered: {{.DataRecovered}}{{if .DataQuarantined}}
+Quarantined: {{.DataQuarantined}}{{end}}
 Total: {{.DataTotal}}
 Data Downloaded: {{prettySize .Stats.DownloadBytes}}
 Data Uploaded: {{prettySize .Stats.UploadBytes}}
 Download Time: {{prettyDuration .Stats.DownloadTime}}
 Process Time: {{prettyDuration .Stats.ProcessTime}}
 Upload Time: {{prettyDuration .Stats.UploadTime}}
+CPU Time: {{prettyDuration .Stats.CpuTime}}
+Peak Memory: {{prettySize .Stats.PeakMemoryBytes}}
+Disk Read: {{prettySize .Stats.DiskReadBytes}}
+Disk Written: {{prettySize .Stats.DiskWriteBytes}}
+Scratch Space: {{prettySize .Stats.ScratchBytes}}
 Datum Timeout: {{.DatumTimeout}}
 Job Timeout: {{.JobTimeout}}
 Worker Status:
@@ -202,7 +258,9 @@ func NewPrintablePipelineInfo(pi *ppsclient.PipelineInfo) *PrintablePipelineInfo
 func PrintDetailedPipelineInfo(w io.Writer, pipelineInfo *PrintablePipelineInfo) error {
 	template, err := template.New("PipelineInfo").Funcs(funcMap).Parse(
 		`Name: {{.Pipeline.Name}}{{if .Description}}
-Description: {{.Description}}{{end}}{{if .FullTimestamps }}
+Description: {{.Description}}{{end}}{{if .Template}}
+Template: {{.Template.Path}}
+Template Args: {{.Template.Args}}{{end}}{{if .FullTimestamps }}
 Created: {{.CreatedAt}}{{ else }}
 Created: {{prettyAgo .CreatedAt}} {{end}}
 State: {{pipelineState .State}}
@@ -224,7 +282,8 @@ Job Timeout: {{.JobTimeout}}
 Input:
 {{pipelineInput .PipelineInfo}}
 {{ if .GithookURL }}Githook URL: {{.GithookURL}} {{end}}
-Output Branch: {{.OutputBranch}}
+Output Branch: {{.OutputBranch}}{{if .QuarantineFailedDatums}}
+Quarantine Branch: quarantine{{end}}
 Transform:
 {{prettyTransform .Transform}}
 {{ if .Egress }}Egress: {{.Egress.URL}} {{end}}
@@ -242,6 +301,47 @@ Job Counts:
 	return nil
 }
 
+// PrintPipelinePlan pretty-prints the plan for creating or updating a pipeline.
+func PrintPipelinePlan(w io.Writer, plan *ppsclient.PipelinePlan) {
+	if plan.Exists {
+		fmt.Fprintf(w, "Pipeline: %s (update)\n", plan.Pipeline.Name)
+		fmt.Fprintln(w, "Changes:")
+		if len(plan.Diffs) == 0 {
+			fmt.Fprintln(w, "  none")
+		}
+		for _, diff := range plan.Diffs {
+			fmt.Fprintf(w, "  %s:\n    - %s\n    + %s\n", diff.Field, planValue(diff.OldValue), planValue(diff.NewValue))
+		}
+	} else {
+		fmt.Fprintf(w, "Pipeline: %s (create)\n", plan.Pipeline.Name)
+	}
+	if plan.SkippedUnknown {
+		fmt.Fprintf(w, "Datums: %d total, unknown number to skip\n", plan.DatumsTotal)
+	} else {
+		fmt.Fprintf(w, "Datums: %d total, %d to process, %d to skip\n", plan.DatumsTotal, plan.DatumsToProcess, plan.DatumsToSkip)
+	}
+	if plan.Reprocess {
+		fmt.Fprintf(w, "Reprocess: all datums (%s)\n", plan.ReprocessReason)
+	}
+	if plan.Exists {
+		var downstream []string
+		for _, pipeline := range plan.Downstream {
+			downstream = append(downstream, pipeline.Name)
+		}
+		if len(downstream) == 0 {
+			downstream = []string{"none"}
+		}
+		fmt.Fprintf(w, "Downstream Pipelines: %s\n", strings.Join(downstream, ", "))
+	}
+}
+
+func planValue(value string) string {
+	if value == "" {
+		return "<unset>"
+	}
+	return value
+}
+
 // PrintDatumInfo pretty-prints file info.
 // If recurse is false and directory size is 0, display "-" instead
 // If fast is true and file size is 0, display "-" instead
@@ -306,6 +406,22 @@ func PrintDetailedDatumInfo(w io.Writer, datumInfo *ppsclient.DatumInfo) {
 	}
 	fmt.Fprintf(w, "Upload Time\t%s\n", uploadTime)
 
+	// CPU time is missing from the stats of datums processed by older workers
+	cpuTime := "-"
+	if datumInfo.Stats.CpuTime != nil {
+		cpu, err := types.DurationFromProto(datumInfo.Stats.CpuTime)
+		if err != nil {
+			cpuTime = err.Error()
+		} else {
+			cpuTime = cpu.String()
+		}
+	}
+	fmt.Fprintf(w, "CPU Time\t%s\n", cpuTime)
+	fmt.Fprintf(w, "Peak Memory\t%s\n", pretty.Size(datumInfo.Stats.PeakMemoryBytes))
+	fmt.Fprintf(w, "Disk Read\t%s\n", pretty.Size(datumInfo.Stats.DiskReadBytes))
+	fmt.Fprintf(w, "Disk Written\t%s\n", pretty.Size(datumInfo.Stats.DiskWriteBytes))
+	fmt.Fprintf(w, "Scratch Space\t%s\n", pretty.Size(datumInfo.Stats.ScratchBytes))
+
 	fmt.Fprintf(w, "PFS State:\n")
 	tw := ansiterm.NewTabWriter(w, 10, 1, 3, ' ', 0)
 	PrintFileHeader(tw)
@@ -0,0 +1,52 @@
+package pretty
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+func TestPipelineResourceUsageAdd(t *testing.T) {
+	usage := &PipelineResourceUsage{Pipeline: "foo"}
+	usage.Add(&ppsclient.JobInfo{
+		DataProcessed: 3,
+		DataSkipped:   5,
+		DataFailed:    1,
+		Stats: &ppsclient.ProcessStats{
+			CpuTime:         types.DurationProto(2 * time.Second),
+			PeakMemoryBytes: 100,
+			DiskReadBytes:   10,
+			DiskWriteBytes:  20,
+			ScratchBytes:    30,
+		},
+	})
+	usage.Add(&ppsclient.JobInfo{
+		DataRecovered:   2,
+		DataQuarantined: 1,
+		Stats: &ppsclient.ProcessStats{
+			CpuTime:         types.DurationProto(time.Second),
+			PeakMemoryBytes: 50,
+			DiskReadBytes:   1,
+			DiskWriteBytes:  2,
+			ScratchBytes:    3,
+		},
+	})
+	// Jobs without stats (e.g. ones that never ran) are counted, but add no
+	// resources
+	usage.Add(&ppsclient.JobInfo{DataProcessed: 1})
+
+	require.Equal(t, &PipelineResourceUsage{
+		Pipeline:        "foo",
+		Jobs:            3,
+		Datums:          8,
+		CPUTime:         3 * time.Second,
+		PeakMemoryBytes: 100,
+		DiskReadBytes:   11,
+		DiskWriteBytes:  22,
+		ScratchBytes:    33,
+	}, usage)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	"github.com/pachyderm/pachyderm/src/server/pkg/kafka"
 	"github.com/pachyderm/pachyderm/src/server/pkg/log"
 	"github.com/pachyderm/pachyderm/src/server/pkg/lokiutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
@@ -569,6 +570,7 @@ func (a *apiServer) UpdateJobStateInTransaction(txnCtx *txnenv.TransactionContex
 	jobPtr.DataSkipped = request.DataSkipped
 	jobPtr.DataFailed = request.DataFailed
 	jobPtr.DataRecovered = request.DataRecovered
+	jobPtr.DataQuarantined = request.DataQuarantined
 	jobPtr.DataTotal = request.DataTotal
 	jobPtr.Stats = request.Stats
 
@@ -589,7 +591,7 @@ func (a *apiServer) CreateJob(ctx context.Context, request *pps.CreateJobRequest
 	if request.Stats == nil {
 		request.Stats = &pps.ProcessStats{}
 	}
-	_, err = col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	err = a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		jobPtr := &pps.EtcdJobInfo{
 			Job:           job,
 			OutputCommit:  request.OutputCommit,
@@ -843,22 +845,23 @@ func (a *apiServer) listJob(pachClient *client.APIClient, pipeline *pps.Pipeline
 
 func (a *apiServer) jobInfoFromPtr(pachClient *client.APIClient, jobPtr *pps.EtcdJobInfo, full bool) (*pps.JobInfo, error) {
 	result := &pps.JobInfo{
-		Job:           jobPtr.Job,
-		Pipeline:      jobPtr.Pipeline,
-		OutputRepo:    &pfs.Repo{Name: jobPtr.Pipeline.Name},
-		OutputCommit:  jobPtr.OutputCommit,
-		Restart:       jobPtr.Restart,
-		DataProcessed: jobPtr.DataProcessed,
-		DataSkipped:   jobPtr.DataSkipped,
-		DataTotal:     jobPtr.DataTotal,
-		DataFailed:    jobPtr.DataFailed,
-		DataRecovered: jobPtr.DataRecovered,
-		Stats:         jobPtr.Stats,
-		StatsCommit:   jobPtr.StatsCommit,
-		State:         jobPtr.State,
-		Reason:        jobPtr.Reason,
-		Started:       jobPtr.Started,
-		Finished:      jobPtr.Finished,
+		Job:             jobPtr.Job,
+		Pipeline:        jobPtr.Pipeline,
+		OutputRepo:      &pfs.Repo{Name: jobPtr.Pipeline.Name},
+		OutputCommit:    jobPtr.OutputCommit,
+		Restart:         jobPtr.Restart,
+		DataProcessed:   jobPtr.DataProcessed,
+		DataSkipped:     jobPtr.DataSkipped,
+		DataTotal:       jobPtr.DataTotal,
+		DataFailed:      jobPtr.DataFailed,
+		DataRecovered:   jobPtr.DataRecovered,
+		DataQuarantined: jobPtr.DataQuarantined,
+		Stats:           jobPtr.Stats,
+		StatsCommit:     jobPtr.StatsCommit,
+		State:           jobPtr.State,
+		Reason:          jobPtr.Reason,
+		Started:         jobPtr.Started,
+		Finished:        jobPtr.Finished,
 	}
 	commitInfo, err := pachClient.InspectCommit(jobPtr.OutputCommit.Repo.Name, jobPtr.OutputCommit.ID)
 	if err != nil {
@@ -1016,7 +1019,7 @@ func (a *apiServer) DeleteJob(ctx context.Context, request *pps.DeleteJobRequest
 	if err := a.stopJob(ctx, pachClient, request.Job); err != nil {
 		return nil, err
 	}
-	_, err = col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+	err = a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 		return a.jobs.ReadWrite(stm).Delete(request.Job.ID)
 	})
 	if err != nil {
@@ -1076,6 +1079,12 @@ func (a *apiServer) RestartDatum(ctx context.Context, request *pps.RestartDatumR
 	if err != nil {
 		return nil, err
 	}
+	if request.Quarantined {
+		if err := ppsutil.ReleaseQuarantinedDatums(pachClient, jobInfo.Pipeline.Name, jobInfo.Job.ID, request.DataFilters); err != nil {
+			return nil, err
+		}
+		return &types.Empty{}, nil
+	}
 	workerPoolID := ppsutil.PipelineRcName(jobInfo.Pipeline.Name, jobInfo.PipelineVersion)
 	if err := workerserver.Cancel(ctx, workerPoolID, a.env.GetEtcdClient(), a.etcdPrefix, a.workerGrpcPort, request.Job.ID, request.DataFilters); err != nil {
 		return nil, err
@@ -1816,6 +1825,17 @@ func (a *apiServer) validatePipelineRequest(request *pps.CreatePipelineRequest)
 	if request.S3Out && request.EnableStats {
 		return errors.New("stats are not supported for pipelines that output via Pachyderm's S3 gateway")
 	}
+	if request.QuarantineFailedDatums {
+		if request.Service != nil || request.Spout != nil {
+			return errors.New("quarantining failed datums is not supported in spouts or services")
+		}
+		if request.S3Out {
+			return errors.New("quarantining failed datums is not supported for pipelines that output via Pachyderm's S3 gateway")
+		}
+		if request.OutputBranch == ppsconsts.QuarantineBranch {
+			return errors.Errorf("the output branch of a pipeline that quarantines failed datums cannot be %q", ppsconsts.QuarantineBranch)
+		}
+	}
 	if request.Transform == nil {
 		return errors.Errorf("pipeline must specify a transform")
 	}
@@ -1858,6 +1878,11 @@ func (a *apiServer) validatePipeline(pachClient *client.APIClient, pipelineInfo
 		if pipelineInfo.Service != nil && pipelineInfo.ParallelismSpec.Constant != 1 {
 			return errors.New("services can only be run with a constant parallelism of 1")
 		}
+		if pipelineInfo.ParallelismSpec.Autoscaling != nil {
+			if err := validateAutoscaling(pipelineInfo.ParallelismSpec); err != nil {
+				return err
+			}
+		}
 	}
 	if pipelineInfo.HashtreeSpec != nil {
 		if pipelineInfo.HashtreeSpec.Constant == 0 {
@@ -1899,6 +1924,11 @@ func (a *apiServer) validatePipeline(pachClient *client.APIClient, pipelineInfo
 			return errors.Errorf("the following service type %s is not allowed", pipelineInfo.Service.Type)
 		}
 	}
+	if pipelineInfo.Egress != nil && kafka.IsURL(pipelineInfo.Egress.URL) {
+		if _, err := kafka.ParseURL(pipelineInfo.Egress.URL); err != nil {
+			return errors.Wrapf(err, "invalid egress URL")
+		}
+	}
 	if pipelineInfo.Spout != nil {
 		if pipelineInfo.EnableStats {
 			return errors.Errorf("spouts are not allowed to have a stats branch")
@@ -1912,6 +1942,14 @@ func (a *apiServer) validatePipeline(pachClient *client.APIClient, pipelineInfo
 		if pipelineInfo.Spout.Service == nil && pipelineInfo.Input != nil {
 			return errors.Errorf("spout pipelines (without a service) must not have an input")
 		}
+		if pipelineInfo.Spout.URL != "" {
+			if _, err := kafka.ParseURL(pipelineInfo.Spout.URL); err != nil {
+				return errors.Wrapf(err, "invalid spout URL")
+			}
+			if pipelineInfo.Spout.Service != nil || pipelineInfo.Spout.Marker != "" || pipelineInfo.Spout.Overwrite {
+				return errors.Errorf("kafka spouts can't have a service, marker or overwrite")
+			}
+		}
 	}
 	return nil
 }
@@ -2013,7 +2051,7 @@ func (a *apiServer) sudo(pachClient *client.APIClient, f func(*client.APIClient)
 		b.MaxElapsedTime = 60 * time.Second
 		b.MaxInterval = 5 * time.Second
 		if err := backoff.Retry(func() error {
-			superUserTokenCol := col.NewCollection(a.env.GetEtcdClient(), ppsconsts.PPSTokenKey, nil, &types.StringValue{}, nil, nil).ReadOnly(pachClient.Ctx())
+			superUserTokenCol := a.env.GetCollectionBackend().NewCollection(ppsconsts.PPSTokenKey, nil, &types.StringValue{}, nil, nil).ReadOnly(pachClient.Ctx())
 			var result types.StringValue
 			if err := superUserTokenCol.Get("", &result); err != nil {
 				return err
@@ -2048,23 +2086,61 @@ func (a *apiServer) sudoTransaction(txnCtx *txnenv.TransactionContext, f func(*t
 // with 'pipelineInfo' in SpecRepo (in PFS). It's called in both the case where
 // a user is updating a pipeline and the case where a user is creating a new
 // pipeline.
+//
+// If the pipeline was rendered from a template, the template's source and args
+// are written to the commit alongside 'pipelineInfo' (which only keeps the
+// template's path and args). If 'pipelineInfo' has a template without a
+// source (e.g. because it was read from an existing spec commit), the
+// template files in the spec branch are left as they are, and if it has no
+// template, they're deleted.
 func (a *apiServer) makePipelineInfoCommit(pachClient *client.APIClient, pipelineInfo *pps.PipelineInfo) (result *pfs.Commit, retErr error) {
 	pipelineName := pipelineInfo.Pipeline.Name
 	var commit *pfs.Commit
-	if err := a.sudo(pachClient, func(superUserClient *client.APIClient) error {
-		data, err := pipelineInfo.Marshal()
+	if err := a.sudo(pachClient, func(superUserClient *client.APIClient) (retErr error) {
+		specInfo := pipelineInfo
+		if pipelineInfo.Template != nil && pipelineInfo.Template.Source != "" {
+			// The template source is stored in its own file
+			specInfoCopy, templateCopy := *pipelineInfo, *pipelineInfo.Template
+			templateCopy.Source = ""
+			specInfoCopy.Template = &templateCopy
+			specInfo = &specInfoCopy
+		}
+		data, err := specInfo.Marshal()
 		if err != nil {
 			return errors.Wrapf(err, "could not marshal PipelineInfo")
 		}
-		if _, err = superUserClient.PutFileOverwrite(ppsconsts.SpecRepo, pipelineName, ppsconsts.SpecFile, bytes.NewReader(data), 0); err != nil {
+		commit, err = superUserClient.StartCommit(ppsconsts.SpecRepo, pipelineName)
+		if err != nil {
 			return err
 		}
-		branchInfo, err := superUserClient.InspectBranch(ppsconsts.SpecRepo, pipelineName)
-		if err != nil {
+		// Delete the spec commit if it can't be finished, an open commit on the
+		// pipeline's spec branch would block all later changes to the pipeline
+		defer func() {
+			if retErr != nil {
+				if err := superUserClient.DeleteCommit(ppsconsts.SpecRepo, commit.ID); err != nil {
+					logrus.Errorf("could not delete unfinished spec commit %s: %v", commit.ID, err)
+				}
+			}
+		}()
+		if _, err = superUserClient.PutFileOverwrite(ppsconsts.SpecRepo, commit.ID, ppsconsts.SpecFile, bytes.NewReader(data), 0); err != nil {
 			return err
 		}
-		commit = branchInfo.Head
-		return nil
+		switch {
+		case pipelineInfo.Template == nil:
+			for _, file := range []string{ppsconsts.TemplateFile, ppsconsts.TemplateArgsFile} {
+				if err := superUserClient.DeleteFile(ppsconsts.SpecRepo, commit.ID, file); err != nil {
+					return err
+				}
+			}
+		case pipelineInfo.Template.Source != "":
+			if _, err = superUserClient.PutFileOverwrite(ppsconsts.SpecRepo, commit.ID, ppsconsts.TemplateFile, strings.NewReader(pipelineInfo.Template.Source), 0); err != nil {
+				return err
+			}
+			if _, err = superUserClient.PutFileOverwrite(ppsconsts.SpecRepo, commit.ID, ppsconsts.TemplateArgsFile, strings.NewReader(pipelineInfo.Template.Args), 0); err != nil {
+				return err
+			}
+		}
+		return superUserClient.FinishCommit(ppsconsts.SpecRepo, commit.ID)
 	}); err != nil {
 		return nil, err
 	}
@@ -2194,6 +2270,10 @@ func (a *apiServer) fixPipelineInputRepoACLsInTransaction(txnCtx *txnenv.Transac
 // that can be stored in EtcdPipelineInfo.Parallelism
 func getExpectedNumWorkers(kc *kube.Clientset, pipelineInfo *pps.PipelineInfo) (int, error) {
 	switch pspec := pipelineInfo.ParallelismSpec; {
+	case pspec.GetAutoscaling() != nil:
+		// Autoscaling pipelines shard work for their maximum number of workers,
+		// so that all of them have something to do when the pipeline scales up
+		return int(pspec.Autoscaling.MaxWorkers), nil
 	case pspec == nil, pspec.Constant == 0 && pspec.Coefficient == 0:
 		return 1, nil
 	case pspec.Constant > 0 && pspec.Coefficient == 0:
@@ -2216,6 +2296,45 @@ func getExpectedNumWorkers(kc *kube.Clientset, pipelineInfo *pps.PipelineInfo) (
 	}
 }
 
+// pipelineInfoFromRequest returns the (first version of the) PipelineInfo that
+// 'request' describes, without any defaults set
+func pipelineInfoFromRequest(request *pps.CreatePipelineRequest) *pps.PipelineInfo {
+	return &pps.PipelineInfo{
+		Pipeline:               request.Pipeline,
+		Version:                1,
+		Transform:              request.Transform,
+		TFJob:                  request.TFJob,
+		ParallelismSpec:        request.ParallelismSpec,
+		HashtreeSpec:           request.HashtreeSpec,
+		Input:                  request.Input,
+		OutputBranch:           request.OutputBranch,
+		Egress:                 request.Egress,
+		CreatedAt:              now(),
+		ResourceRequests:       request.ResourceRequests,
+		ResourceLimits:         request.ResourceLimits,
+		SidecarResourceLimits:  request.SidecarResourceLimits,
+		Description:            request.Description,
+		CacheSize:              request.CacheSize,
+		EnableStats:            request.EnableStats,
+		Salt:                   request.Salt,
+		MaxQueueSize:           request.MaxQueueSize,
+		Service:                request.Service,
+		Spout:                  request.Spout,
+		ChunkSpec:              request.ChunkSpec,
+		DatumTimeout:           request.DatumTimeout,
+		JobTimeout:             request.JobTimeout,
+		Standby:                request.Standby,
+		DatumTries:             request.DatumTries,
+		SchedulingSpec:         request.SchedulingSpec,
+		PodSpec:                request.PodSpec,
+		PodPatch:               request.PodPatch,
+		S3Out:                  request.S3Out,
+		QuarantineFailedDatums: request.QuarantineFailedDatums,
+		Metadata:               request.Metadata,
+		Template:               request.Template,
+	}
+}
+
 // CreatePipeline implements the protobuf pps.CreatePipeline RPC
 //
 // Implementation note:
@@ -2265,38 +2384,7 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 	if request.Salt == "" || request.Reprocess {
 		request.Salt = uuid.NewWithoutDashes()
 	}
-	pipelineInfo := &pps.PipelineInfo{
-		Pipeline:              request.Pipeline,
-		Version:               1,
-		Transform:             request.Transform,
-		TFJob:                 request.TFJob,
-		ParallelismSpec:       request.ParallelismSpec,
-		HashtreeSpec:          request.HashtreeSpec,
-		Input:                 request.Input,
-		OutputBranch:          request.OutputBranch,
-		Egress:                request.Egress,
-		CreatedAt:             now(),
-		ResourceRequests:      request.ResourceRequests,
-		ResourceLimits:        request.ResourceLimits,
-		SidecarResourceLimits: request.SidecarResourceLimits,
-		Description:           request.Description,
-		CacheSize:             request.CacheSize,
-		EnableStats:           request.EnableStats,
-		Salt:                  request.Salt,
-		MaxQueueSize:          request.MaxQueueSize,
-		Service:               request.Service,
-		Spout:                 request.Spout,
-		ChunkSpec:             request.ChunkSpec,
-		DatumTimeout:          request.DatumTimeout,
-		JobTimeout:            request.JobTimeout,
-		Standby:               request.Standby,
-		DatumTries:            request.DatumTries,
-		SchedulingSpec:        request.SchedulingSpec,
-		PodSpec:               request.PodSpec,
-		PodPatch:              request.PodPatch,
-		S3Out:                 request.S3Out,
-		Metadata:              request.Metadata,
-	}
+	pipelineInfo := pipelineInfoFromRequest(request)
 	if err := setPipelineDefaults(pipelineInfo); err != nil {
 		return nil, err
 	}
@@ -2391,7 +2479,7 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 			pipelinePtr     pps.EtcdPipelineInfo
 			oldPipelineInfo *pps.PipelineInfo
 		)
-		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 			// Read existing PipelineInfo from PFS output repo
 			return a.pipelines.ReadWrite(stm).Update(pipelineName, &pipelinePtr, func() error {
 				var err error
@@ -2434,6 +2522,8 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 				pipelinePtr.Reason = ""
 				// Update pipeline parallelism
 				pipelinePtr.Parallelism = uint64(parallelism)
+				// The autoscaler starts over with the new spec
+				pipelinePtr.AutoscaledParallelism = 0
 
 				// Generate new pipeline auth token (added due to & add pipeline to the ACLs of input/output
 				// repos
@@ -2565,7 +2655,7 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 		}
 
 		// Put a pointer to the new PipelineInfo commit into etcd
-		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 			err := a.pipelines.ReadWrite(stm).Create(pipelineName, pipelinePtr)
 			if isAlreadyExistsErr(err) {
 				if err := a.sudo(pachClient, func(superUserClient *client.APIClient) error {
@@ -2780,7 +2870,7 @@ func (a *apiServer) inspectPipelineInTransaction(txnCtx *txnenv.TransactionConte
 		logrus.Errorf("failed to get worker status with err: %s", err.Error())
 	} else {
 		pipelineInfo.WorkersAvailable = int64(len(workerStatus))
-		pipelineInfo.WorkersRequested = int64(pipelinePtr.Parallelism)
+		pipelineInfo.WorkersRequested = int64(currentParallelism(&pipelinePtr, pipelineInfo))
 	}
 	return pipelineInfo, nil
 }
@@ -3069,7 +3159,7 @@ func (a *apiServer) deletePipeline(pachClient *client.APIClient, request *pps.De
 	})
 	// Delete EtcdPipelineInfo
 	eg.Go(func() error {
-		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		if err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 			return a.pipelines.ReadWrite(stm).Delete(request.Pipeline.Name)
 		}); err != nil {
 			return errors.Wrapf(err, "collection.Delete")
@@ -3848,7 +3938,7 @@ func (a *apiServer) ActivateAuth(ctx context.Context, req *pps.ActivateAuthReque
 				if err != nil {
 					return errors.Wrapf(grpcutil.ScrubGRPC(err), "could not generate pipeline auth token")
 				}
-				_, err = col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+				err = a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 					var pipelinePtr pps.EtcdPipelineInfo
 					if err := a.pipelines.ReadWrite(stm).Update(pipelineName, &pipelinePtr, func() error {
 						pipelinePtr.AuthToken = tokenResp.Token
@@ -3908,7 +3998,7 @@ func isNotFoundErr(err error) bool {
 }
 
 func (a *apiServer) updatePipelineSpecCommit(pachClient *client.APIClient, pipelineName string, commit *pfs.Commit) error {
-	_, err := col.NewSTM(pachClient.Ctx(), a.env.GetEtcdClient(), func(stm col.STM) error {
+	err := a.env.GetCollectionBackend().NewSTM(pachClient.Ctx(), func(stm col.STM) error {
 		pipelines := a.pipelines.ReadWrite(stm)
 		pipelinePtr := &pps.EtcdPipelineInfo{}
 		if err := pipelines.Get(pipelineName, pipelinePtr); err != nil {
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsdb"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/work"
+)
+
+const (
+	// autoscalingInterval is how often the autoscaler checks a pipeline's
+	// backlog
+	autoscalingInterval = 10 * time.Second
+
+	defaultScaleUpCooldown   = 30 * time.Second
+	defaultScaleDownCooldown = 5 * time.Minute
+)
+
+// validateAutoscaling checks the autoscaling options in a parallelism spec
+func validateAutoscaling(pspec *pps.ParallelismSpec) error {
+	as := pspec.Autoscaling
+	if pspec.Constant != 0 || pspec.Coefficient != 0 {
+		return errors.New("contradictory parallelism strategies: autoscaling " +
+			"cannot be combined with ParallelismSpec.Constant or ParallelismSpec.Coefficient")
+	}
+	if as.MinWorkers == 0 {
+		return errors.New("Autoscaling.MinWorkers must be at least 1 (use standby to scale a pipeline to 0 workers)")
+	}
+	if as.MaxWorkers < as.MinWorkers {
+		return errors.Errorf("Autoscaling.MaxWorkers (%d) cannot be less than Autoscaling.MinWorkers (%d)",
+			as.MaxWorkers, as.MinWorkers)
+	}
+	for _, d := range []*types.Duration{as.ScaleUpCooldown, as.ScaleDownCooldown} {
+		if d == nil {
+			continue
+		}
+		if cooldown, err := types.DurationFromProto(d); err != nil {
+			return err
+		} else if cooldown < 0 {
+			return errors.New("autoscaling cooldowns cannot be negative")
+		}
+	}
+	return nil
+}
+
+// currentParallelism returns the number of workers that 'ptr's pipeline
+// should be running right now (if it's running at all). For autoscaling
+// pipelines this is the autoscaler's most recent choice, and for other
+// pipelines it's the fixed parallelism computed by getExpectedNumWorkers.
+func currentParallelism(ptr *pps.EtcdPipelineInfo, pipelineInfo *pps.PipelineInfo) uint64 {
+	as := pipelineInfo.GetParallelismSpec().GetAutoscaling()
+	if as == nil {
+		return ptr.Parallelism
+	}
+	if ptr.AutoscaledParallelism == 0 {
+		return as.MinWorkers
+	}
+	return ptr.AutoscaledParallelism
+}
+
+// desiredWorkers returns the number of workers that a pipeline's backlog
+// calls for, within the bounds of 'as'.
+func desiredWorkers(as *pps.Autoscaling, pendingDatums, pendingSubtasks int64) uint64 {
+	datumsPerWorker := int64(as.DatumsPerWorker)
+	if datumsPerWorker == 0 {
+		datumsPerWorker = 1
+	}
+	desired := (pendingDatums + datumsPerWorker - 1) / datumsPerWorker
+	if pendingSubtasks > desired {
+		desired = pendingSubtasks
+	}
+	switch {
+	case desired < int64(as.MinWorkers):
+		return as.MinWorkers
+	case desired > int64(as.MaxWorkers):
+		return as.MaxWorkers
+	default:
+		return uint64(desired)
+	}
+}
+
+// autoscaler decides when to resize an autoscaling pipeline. Workers are
+// added once 'upCooldown' has passed since the pipeline was last resized,
+// and removed once the backlog has called for fewer workers than are running
+// for 'downCooldown', so that brief lulls between jobs don't cause churn.
+type autoscaler struct {
+	as                       *pps.Autoscaling
+	upCooldown, downCooldown time.Duration
+
+	workers    uint64
+	lastResize time.Time
+	// lastBusy is the last time the backlog called for at least as many
+	// workers as are running
+	lastBusy time.Time
+}
+
+func newAutoscaler(as *pps.Autoscaling, workers uint64, now time.Time) *autoscaler {
+	s := &autoscaler{
+		as:           as,
+		upCooldown:   defaultScaleUpCooldown,
+		downCooldown: defaultScaleDownCooldown,
+		workers:      workers,
+		lastResize:   now,
+		lastBusy:     now,
+	}
+	if d, err := types.DurationFromProto(as.ScaleUpCooldown); as.ScaleUpCooldown != nil && err == nil {
+		s.upCooldown = d
+	}
+	if d, err := types.DurationFromProto(as.ScaleDownCooldown); as.ScaleDownCooldown != nil && err == nil {
+		s.downCooldown = d
+	}
+	return s
+}
+
+// next returns the number of workers the pipeline should have, given its
+// current backlog.
+func (s *autoscaler) next(now time.Time, pendingDatums, pendingSubtasks int64) uint64 {
+	desired := desiredWorkers(s.as, pendingDatums, pendingSubtasks)
+	if desired >= s.workers {
+		s.lastBusy = now
+	}
+	switch {
+	case desired > s.workers && now.Sub(s.lastResize) >= s.upCooldown,
+		desired < s.workers && now.Sub(s.lastBusy) >= s.downCooldown:
+		s.workers = desired
+		s.lastResize = now
+	}
+	return s.workers
+}
+
+// pipelineBacklog returns the number of datums that 'pipelineInfo's
+// unfinished jobs haven't processed yet, and the number of subtasks in its
+// task queue that haven't finished.
+func (a *apiServer) pipelineBacklog(ctx context.Context, pipelineInfo *pps.PipelineInfo) (int64, int64, error) {
+	var pendingDatums int64
+	jobPtr := &pps.EtcdJobInfo{}
+	if err := a.jobs.ReadOnly(ctx).GetByIndex(ppsdb.JobsPipelineIndex, pipelineInfo.Pipeline, jobPtr, col.DefaultOptions, func(string) error {
+		if ppsutil.IsTerminal(jobPtr.State) {
+			return nil
+		}
+		done := jobPtr.DataProcessed + jobPtr.DataSkipped + jobPtr.DataFailed + jobPtr.DataRecovered + jobPtr.DataQuarantined
+		if jobPtr.DataTotal > done {
+			pendingDatums += jobPtr.DataTotal - done
+		}
+		return nil
+	}); err != nil {
+		return 0, 0, err
+	}
+	pendingSubtasks, err := work.NumPendingSubtasks(ctx, a.env.GetEtcdClient(), a.etcdPrefix, ppsutil.WorkNamespace(pipelineInfo))
+	if err != nil {
+		return 0, 0, err
+	}
+	return pendingDatums, pendingSubtasks, nil
+}
+
+// setAutoscaledParallelism records the autoscaler's choice of the number of
+// workers for 'pipeline', which causes the pipeline controller to resize its
+// RC.
+func (a *apiServer) setAutoscaledParallelism(ctx context.Context, pipeline string, workers uint64) error {
+	err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
+		pipelines := a.pipelines.ReadWrite(stm)
+		pipelinePtr := &pps.EtcdPipelineInfo{}
+		return pipelines.Update(pipeline, pipelinePtr, func() error {
+			pipelinePtr.AutoscaledParallelism = workers
+			return nil
+		})
+	})
+	return err
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+func TestDesiredWorkers(t *testing.T) {
+	as := &pps.Autoscaling{MinWorkers: 2, MaxWorkers: 200}
+	require.Equal(t, uint64(2), desiredWorkers(as, 0, 0))
+	require.Equal(t, uint64(10), desiredWorkers(as, 10, 3))
+	require.Equal(t, uint64(12), desiredWorkers(as, 10, 12))
+	require.Equal(t, uint64(200), desiredWorkers(as, 100000, 0))
+
+	as.DatumsPerWorker = 10
+	require.Equal(t, uint64(11), desiredWorkers(as, 101, 0))
+}
+
+func TestAutoscalerCooldowns(t *testing.T) {
+	as := &pps.Autoscaling{
+		MinWorkers:        2,
+		MaxWorkers:        200,
+		ScaleUpCooldown:   types.DurationProto(time.Minute),
+		ScaleDownCooldown: types.DurationProto(10 * time.Minute),
+	}
+	start := time.Now()
+	scaler := newAutoscaler(as, 2, start)
+
+	// The pipeline doesn't grow until the scale up cooldown has passed
+	require.Equal(t, uint64(2), scaler.next(start.Add(30*time.Second), 1000, 0))
+	require.Equal(t, uint64(200), scaler.next(start.Add(time.Minute), 1000, 0))
+
+	// The backlog must stay small for the scale down cooldown before the
+	// pipeline shrinks
+	require.Equal(t, uint64(200), scaler.next(start.Add(2*time.Minute), 0, 0))
+	require.Equal(t, uint64(200), scaler.next(start.Add(5*time.Minute), 1000, 0))
+	require.Equal(t, uint64(200), scaler.next(start.Add(14*time.Minute), 0, 0))
+	require.Equal(t, uint64(2), scaler.next(start.Add(15*time.Minute), 0, 0))
+}
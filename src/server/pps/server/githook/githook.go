@@ -1,7 +1,6 @@
 // Package githook adds support for git-based sources in pipeline specs. It
 // does so by exposing an HTTP server that listens for webhook requests. This
-// works with github's webhook API, and anything else API-compatible with
-// their push events.
+// works with the push events of github, gitlab, gitea and bitbucket.
 package githook
 
 // TODO(ys): remove githook server in pachyderm 2.0
@@ -10,9 +9,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"math"
+	"io/ioutil"
 	"net/http"
 	"path"
+	"strings"
 
 	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
@@ -21,10 +21,11 @@ import (
 	"github.com/pachyderm/pachyderm/src/server/pkg/ppsdb"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
 
-	etcd "github.com/coreos/etcd/clientv3"
 	logrus "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"gopkg.in/go-playground/webhooks.v5/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube "k8s.io/client-go/kubernetes"
 )
 
 // GitHookPort specifies the port the server will listen on
@@ -33,9 +34,10 @@ const apiVersion = "v1"
 
 // gitHookServer serves GetFile requests over HTTP
 type gitHookServer struct {
-	hook       *github.Webhook
 	client     *client.APIClient
-	etcdClient *etcd.Client
+	backend    col.Backend
+	kubeClient *kube.Clientset
+	namespace  string
 	pipelines  col.Collection
 }
 
@@ -60,29 +62,17 @@ func URLFromDomain(domain string) string {
 }
 
 // RunGitHookServer starts the webhook server
-func RunGitHookServer(address string, etcdAddress string, etcdPrefix string) error {
+func RunGitHookServer(address string, backend col.Backend, etcdPrefix string, kubeClient *kube.Clientset, namespace string) error {
 	c, err := client.NewFromAddress(address)
 	if err != nil {
 		return err
 	}
-	etcdClient, err := etcd.New(etcd.Config{
-		Endpoints:          []string{etcdAddress},
-		DialOptions:        client.DefaultDialOptions(),
-		MaxCallSendMsgSize: math.MaxInt32,
-		MaxCallRecvMsgSize: math.MaxInt32,
-	})
-	if err != nil {
-		return err
-	}
-	hook, err := github.New()
-	if err != nil {
-		return err
-	}
 	s := &gitHookServer{
-		hook,
 		c,
-		etcdClient,
-		ppsdb.Pipelines(etcdClient, etcdPrefix),
+		backend,
+		kubeClient,
+		namespace,
+		ppsdb.Pipelines(backend, etcdPrefix),
 	}
 	return http.ListenAndServe(fmt.Sprintf(":%d", GitHookPort), s)
 }
@@ -98,83 +88,126 @@ func matchingBranch(inputBranch string, payloadBranch string) bool {
 }
 
 func (s *gitHookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	payload, err := s.hook.Parse(r, github.PushEvent)
+	if r.Method != http.MethodPost {
+		http.Error(w, "webhooks must be sent with POST", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		// `ErrEventNotFound` implies github sent an event we didn't ask for
-		if !errors.Is(err, github.ErrEventNotFound) {
-			logrus.Errorf("error parsing github hook: %v", err)
+		logrus.Errorf("error reading git hook: %v", err)
+		return
+	}
+	provider, pl, err := parsePush(r.Header, body)
+	if err != nil {
+		// `errNotPush` implies the provider sent an event we didn't ask for
+		if !errors.Is(err, errNotPush) {
+			logrus.Errorf("error parsing %v hook: %v", providerName(provider), err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 		}
 		return
 	}
 
-	pl, ok := payload.(github.PushPayload)
-	if !ok {
-		logrus.Errorf("github webhook failed to cast payload, this is likely a bug")
+	if err = s.handlePush(provider, r.Header, body, pl); err != nil {
+		logrus.Errorf("%v webhook failed to handle push for repo (%v) on branch (%v) with error %v", providerName(provider), pl.Repository.Name, path.Base(pl.Ref), err)
 	}
+}
 
-	if err = s.handlePush(pl); err != nil {
-		logrus.Errorf("github webhook failed to handle push for repo (%v) on branch (%v) with error %v", pl.Repository.Name, path.Base(pl.Ref), err)
-	}
+func providerName(provider pps.GitProvider) string {
+	return strings.ToLower(provider.String())
+}
+
+// gitInputMatch is a git input which is triggered by a push, along with the
+// pipeline that it belongs to.
+type gitInputMatch struct {
+	pipelineInfo *pps.PipelineInfo
+	input        *pps.GitInput
 }
 
-func (s *gitHookServer) findMatchingPipelineInputs(payload github.PushPayload) (pipelines []*pps.PipelineInfo, inputs []*pps.GitInput, err error) {
+func (s *gitHookServer) findMatchingPipelineInputs(provider pps.GitProvider, payload *github.PushPayload) ([]gitInputMatch, error) {
 	payloadBranch := path.Base(payload.Ref)
-	pipelines, err = s.client.ListPipeline()
+	pipelines, err := s.client.ListPipeline()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
+	var matches []gitInputMatch
 	for _, pipelineInfo := range pipelines {
 		pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
 			if input.Git != nil {
-				if input.Git.URL == payload.Repository.CloneURL && matchingBranch(input.Git.Branch, payloadBranch) {
-					inputs = append(inputs, input.Git)
+				if input.Git.Provider == provider && input.Git.URL == payload.Repository.CloneURL && matchingBranch(input.Git.Branch, payloadBranch) {
+					matches = append(matches, gitInputMatch{pipelineInfo, input.Git})
 				}
 			}
 		})
 	}
-	if len(inputs) == 0 {
-		return nil, nil, errors.Errorf("no pipeline inputs corresponding to git URL (%v) on branch (%v) found, perhaps the git input is not set yet on a pipeline", payload.Repository.CloneURL, payloadBranch)
+	if len(matches) == 0 {
+		return nil, errors.Errorf("no pipeline inputs corresponding to git URL (%v) on branch (%v) found, perhaps the git input is not set yet on a pipeline", payload.Repository.CloneURL, payloadBranch)
 	}
-	return pipelines, inputs, nil
+	return matches, nil
 }
 
-func (s *gitHookServer) handlePush(pl github.PushPayload) (retErr error) {
-	logrus.Infof("received github push payload for repo (%v) on branch (%v)", pl.Repository.Name, path.Base(pl.Ref))
+// getSecret returns the data of the kubernetes secret for a git input, or nil
+// if the input has no secret.
+func (s *gitHookServer) getSecret(input *pps.GitInput) (map[string][]byte, error) {
+	if input.Secret == "" {
+		return nil, nil
+	}
+	secret, err := s.kubeClient.CoreV1().Secrets(s.namespace).Get(input.Secret, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting secret (%v) for git input (%v)", input.Secret, input.Name)
+	}
+	return secret.Data, nil
+}
+
+func (s *gitHookServer) handlePush(provider pps.GitProvider, header http.Header, body []byte, pl *github.PushPayload) (retErr error) {
+	logrus.Infof("received %v push payload for repo (%v) on branch (%v)", providerName(provider), pl.Repository.Name, path.Base(pl.Ref))
 
 	raw, err := json.Marshal(pl)
 	if err != nil {
 		return errors.Wrapf(err, "error marshalling payload (%v)", pl)
 	}
-	pipelines, gitInputs, err := s.findMatchingPipelineInputs(pl)
+	matches, err := s.findMatchingPipelineInputs(provider, pl)
 	if err != nil {
 		return err
 	}
-	if pl.Repository.Private {
-		for _, pipelineInfo := range pipelines {
-			if err := ppsutil.FailPipeline(context.Background(), s.etcdClient, s.pipelines, pipelineInfo.Pipeline.Name, fmt.Sprintf("unable to clone private github repo (%v)", pl.Repository.CloneURL)); err != nil {
+	triggeredRepos := make(map[string]bool)
+	for _, match := range matches {
+		input := match.input
+		secret, err := s.getSecret(input)
+		if err != nil {
+			logrus.Errorf("%v webhook failed to handle push to repo (%v): %v", providerName(provider), input.Name, err)
+			retErr = err
+			continue
+		}
+		if webhookSecret, ok := secret[client.GitWebhookSecretKey]; ok {
+			if err := verifyPush(provider, header, body, webhookSecret); err != nil {
+				logrus.Errorf("%v webhook for repo (%v) failed verification: %v", providerName(provider), input.Name, err)
+				retErr = err
+				continue
+			}
+		}
+		if _, ok := secret[client.GitPasswordKey]; pl.Repository.Private && !ok {
+			pipelineName := match.pipelineInfo.Pipeline.Name
+			if err := ppsutil.FailPipeline(context.Background(), s.backend, s.pipelines, pipelineName, fmt.Sprintf("unable to clone private %v repo (%v) without credentials, set %q in the git input's secret", providerName(provider), pl.Repository.CloneURL, client.GitPasswordKey)); err != nil {
 				// err will be handled but first we want to
 				// try and fail all relevant pipelines
-				logrus.Errorf("error marking pipeline %v as failed %v", pipelineInfo.Pipeline.Name, err)
+				logrus.Errorf("error marking pipeline %v as failed %v", pipelineName, err)
 				retErr = err
 			}
+			continue
 		}
-		return retErr
-	}
-	triggeredRepos := make(map[string]bool)
-	for _, input := range gitInputs {
 		if triggeredRepos[input.Name] {
 			// This input is used on multiple pipelines, and we've already
 			// committed to this input repo
 			continue
 		}
 		if err := s.commitPayload(input.Name, input.Branch, raw); err != nil {
-			logrus.Errorf("github webhook failed to commit payload to repo (%v) push with error: %v\n", input.Name, err)
+			logrus.Errorf("%v webhook failed to commit payload to repo (%v) push with error: %v\n", providerName(provider), input.Name, err)
 			retErr = err
 			continue
 		}
 		triggeredRepos[input.Name] = true
 	}
-	return nil
+	return retErr
 }
 
 func (s *gitHookServer) commitPayload(repoName string, branchName string, rawPayload []byte) (retErr error) {
@@ -0,0 +1,201 @@
+package githook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"net/http"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+
+	"gopkg.in/go-playground/webhooks.v5/github"
+)
+
+// errNotPush is returned by parsePush for webhook events that are not pushes
+// (e.g. the ping event sent when a webhook is created), these are ignored.
+var errNotPush = errors.New("webhook event is not a push")
+
+// gitlabPublic is the visibility level of public gitlab projects, projects
+// with a lower visibility level require credentials to clone.
+const gitlabPublic = 20
+
+type gitlabPushPayload struct {
+	Ref     string `json:"ref"`
+	After   string `json:"after"`
+	Project struct {
+		Name            string `json:"name"`
+		PathWithNS      string `json:"path_with_namespace"`
+		GitHTTPURL      string `json:"git_http_url"`
+		VisibilityLevel int64  `json:"visibility_level"`
+	} `json:"project"`
+}
+
+type giteaPushPayload struct {
+	Ref        string `json:"ref"`
+	After      string `json:"after"`
+	Repository struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+		Private  bool   `json:"private"`
+		CloneURL string `json:"clone_url"`
+	} `json:"repository"`
+}
+
+type bitbucketPushPayload struct {
+	Push struct {
+		Changes []struct {
+			New *struct {
+				Type   string `json:"type"`
+				Name   string `json:"name"`
+				Target struct {
+					Hash string `json:"hash"`
+				} `json:"target"`
+			} `json:"new"`
+		} `json:"changes"`
+	} `json:"push"`
+	Repository struct {
+		Name      string `json:"name"`
+		FullName  string `json:"full_name"`
+		IsPrivate bool   `json:"is_private"`
+		Links     struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	} `json:"repository"`
+}
+
+// detectProvider determines which git host sent a webhook from its headers.
+// Gitea also sets the github event header, so it must be checked first.
+func detectProvider(header http.Header) (pps.GitProvider, string, error) {
+	switch {
+	case header.Get("X-Gitea-Event") != "":
+		return pps.GitProvider_GITEA, header.Get("X-Gitea-Event"), nil
+	case header.Get("X-Gitlab-Event") != "":
+		return pps.GitProvider_GITLAB, header.Get("X-Gitlab-Event"), nil
+	case header.Get("X-Event-Key") != "":
+		return pps.GitProvider_BITBUCKET, header.Get("X-Event-Key"), nil
+	case header.Get("X-GitHub-Event") != "":
+		return pps.GitProvider_GITHUB, header.Get("X-GitHub-Event"), nil
+	}
+	return 0, "", errors.New("could not determine the git provider of the webhook")
+}
+
+// parsePush parses a push webhook from any supported provider. The push is
+// converted to a github push payload, which is the format that is committed
+// to the input repo and read by the workers.
+func parsePush(header http.Header, body []byte) (pps.GitProvider, *github.PushPayload, error) {
+	provider, event, err := detectProvider(header)
+	if err != nil {
+		return 0, nil, err
+	}
+	payload := &github.PushPayload{}
+	switch provider {
+	case pps.GitProvider_GITHUB:
+		if event != "push" {
+			return provider, nil, errNotPush
+		}
+		if err := json.Unmarshal(body, payload); err != nil {
+			return provider, nil, errors.EnsureStack(err)
+		}
+	case pps.GitProvider_GITLAB:
+		if event != "Push Hook" {
+			return provider, nil, errNotPush
+		}
+		var pl gitlabPushPayload
+		if err := json.Unmarshal(body, &pl); err != nil {
+			return provider, nil, errors.EnsureStack(err)
+		}
+		payload.Ref = pl.Ref
+		payload.After = pl.After
+		payload.Repository.Name = pl.Project.Name
+		payload.Repository.FullName = pl.Project.PathWithNS
+		payload.Repository.CloneURL = pl.Project.GitHTTPURL
+		payload.Repository.Private = pl.Project.VisibilityLevel < gitlabPublic
+	case pps.GitProvider_GITEA:
+		if event != "push" {
+			return provider, nil, errNotPush
+		}
+		var pl giteaPushPayload
+		if err := json.Unmarshal(body, &pl); err != nil {
+			return provider, nil, errors.EnsureStack(err)
+		}
+		payload.Ref = pl.Ref
+		payload.After = pl.After
+		payload.Repository.Name = pl.Repository.Name
+		payload.Repository.FullName = pl.Repository.FullName
+		payload.Repository.CloneURL = pl.Repository.CloneURL
+		payload.Repository.Private = pl.Repository.Private
+	case pps.GitProvider_BITBUCKET:
+		if event != "repo:push" {
+			return provider, nil, errNotPush
+		}
+		var pl bitbucketPushPayload
+		if err := json.Unmarshal(body, &pl); err != nil {
+			return provider, nil, errors.EnsureStack(err)
+		}
+		// A push may update several refs, only the last branch update is
+		// used. Deleted branches have no new state.
+		for _, change := range pl.Push.Changes {
+			if change.New != nil && change.New.Type == "branch" {
+				payload.Ref = "refs/heads/" + change.New.Name
+				payload.After = change.New.Target.Hash
+			}
+		}
+		if payload.Ref == "" {
+			return provider, nil, errNotPush
+		}
+		payload.Repository.Name = pl.Repository.Name
+		payload.Repository.FullName = pl.Repository.FullName
+		payload.Repository.CloneURL = pl.Repository.Links.HTML.Href + ".git"
+		payload.Repository.Private = pl.Repository.IsPrivate
+	}
+	return provider, payload, nil
+}
+
+// verifyPush checks that a webhook was sent by a party that knows secret.
+// Gitlab sends the secret itself as a token, the other providers sign the
+// body with an HMAC.
+func verifyPush(provider pps.GitProvider, header http.Header, body []byte, secret []byte) error {
+	switch provider {
+	case pps.GitProvider_GITLAB:
+		if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), secret) != 1 {
+			return errors.New("gitlab webhook token does not match")
+		}
+		return nil
+	case pps.GitProvider_GITEA:
+		return verifyHMAC(sha256.New, header.Get("X-Gitea-Signature"), body, secret)
+	case pps.GitProvider_GITHUB, pps.GitProvider_BITBUCKET:
+		if sig := header.Get("X-Hub-Signature-256"); sig != "" {
+			return verifyHMAC(sha256.New, strings.TrimPrefix(sig, "sha256="), body, secret)
+		}
+		sig := header.Get("X-Hub-Signature")
+		if strings.HasPrefix(sig, "sha256=") {
+			return verifyHMAC(sha256.New, strings.TrimPrefix(sig, "sha256="), body, secret)
+		}
+		return verifyHMAC(sha1.New, strings.TrimPrefix(sig, "sha1="), body, secret)
+	}
+	return errors.Errorf("unrecognized git provider: %v", provider)
+}
+
+func verifyHMAC(h func() hash.Hash, signature string, body []byte, secret []byte) error {
+	if signature == "" {
+		return errors.New("webhook is not signed")
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return errors.Wrapf(err, "malformed webhook signature")
+	}
+	mac := hmac.New(h, secret)
+	mac.Write(body)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return errors.New("webhook signature does not match")
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package githook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+func header(kvs ...string) http.Header {
+	h := http.Header{}
+	for i := 0; i < len(kvs); i += 2 {
+		h.Set(kvs[i], kvs[i+1])
+	}
+	return h
+}
+
+func TestParsePush(t *testing.T) {
+	for _, test := range []struct {
+		header   http.Header
+		body     string
+		provider pps.GitProvider
+		cloneURL string
+		private  bool
+	}{
+		{
+			header:   header("X-GitHub-Event", "push"),
+			body:     `{"ref": "refs/heads/master", "after": "abc", "repository": {"name": "foo", "clone_url": "https://github.com/org/foo.git", "private": true}}`,
+			provider: pps.GitProvider_GITHUB,
+			cloneURL: "https://github.com/org/foo.git",
+			private:  true,
+		},
+		{
+			header:   header("X-Gitlab-Event", "Push Hook"),
+			body:     `{"ref": "refs/heads/master", "after": "abc", "project": {"name": "foo", "git_http_url": "https://gitlab.example.com/org/foo.git", "visibility_level": 0}}`,
+			provider: pps.GitProvider_GITLAB,
+			cloneURL: "https://gitlab.example.com/org/foo.git",
+			private:  true,
+		},
+		{
+			header:   header("X-GitHub-Event", "push", "X-Gitea-Event", "push"),
+			body:     `{"ref": "refs/heads/master", "after": "abc", "repository": {"name": "foo", "clone_url": "https://gitea.example.com/org/foo.git", "private": false, "created_at": "2020-01-01T00:00:00Z"}}`,
+			provider: pps.GitProvider_GITEA,
+			cloneURL: "https://gitea.example.com/org/foo.git",
+		},
+		{
+			header:   header("X-Event-Key", "repo:push"),
+			body:     `{"push": {"changes": [{"new": {"type": "branch", "name": "master", "target": {"hash": "abc"}}}]}, "repository": {"name": "foo", "is_private": true, "links": {"html": {"href": "https://bitbucket.org/org/foo"}}}}`,
+			provider: pps.GitProvider_BITBUCKET,
+			cloneURL: "https://bitbucket.org/org/foo.git",
+			private:  true,
+		},
+	} {
+		provider, pl, err := parsePush(test.header, []byte(test.body))
+		require.NoError(t, err)
+		require.Equal(t, test.provider, provider)
+		require.Equal(t, "refs/heads/master", pl.Ref)
+		require.Equal(t, "abc", pl.After)
+		require.Equal(t, "foo", pl.Repository.Name)
+		require.Equal(t, test.cloneURL, pl.Repository.CloneURL)
+		require.Equal(t, test.private, pl.Repository.Private)
+	}
+}
+
+func TestParseNonPush(t *testing.T) {
+	_, _, err := parsePush(header("X-GitHub-Event", "ping"), []byte(`{}`))
+	require.True(t, errors.Is(err, errNotPush))
+	_, _, err = parsePush(header("X-Gitlab-Event", "Tag Push Hook"), []byte(`{}`))
+	require.True(t, errors.Is(err, errNotPush))
+	// Branch deletions have no new state
+	_, _, err = parsePush(header("X-Event-Key", "repo:push"), []byte(`{"push": {"changes": [{"new": null}]}}`))
+	require.True(t, errors.Is(err, errNotPush))
+	_, _, err = parsePush(http.Header{}, []byte(`{}`))
+	require.YesError(t, err)
+}
+
+func TestVerifyPush(t *testing.T) {
+	body := []byte(`{"ref": "refs/heads/master"}`)
+	secret := []byte("secret")
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	sig := hex.EncodeToString(mac.Sum(nil))
+
+	require.NoError(t, verifyPush(pps.GitProvider_GITHUB, header("X-Hub-Signature-256", "sha256="+sig), body, secret))
+	require.NoError(t, verifyPush(pps.GitProvider_BITBUCKET, header("X-Hub-Signature", "sha256="+sig), body, secret))
+	require.NoError(t, verifyPush(pps.GitProvider_GITEA, header("X-Gitea-Signature", sig), body, secret))
+	require.NoError(t, verifyPush(pps.GitProvider_GITLAB, header("X-Gitlab-Token", "secret"), body, secret))
+
+	require.YesError(t, verifyPush(pps.GitProvider_GITHUB, header(), body, secret))
+	require.YesError(t, verifyPush(pps.GitProvider_GITHUB, header("X-Hub-Signature-256", "sha256="+sig), body, []byte("wrong")))
+	require.YesError(t, verifyPush(pps.GitProvider_GITEA, header("X-Gitea-Signature", sig), []byte("tampered"), secret))
+	require.YesError(t, verifyPush(pps.GitProvider_GITLAB, header("X-Gitlab-Token", "wrong"), body, secret))
+}
@@ -0,0 +1,19 @@
+package server
+
+import (
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pps/graph"
+
+	"golang.org/x/net/context"
+)
+
+// InspectDAG implements the protobuf pps.InspectDAG RPC. The DAG is read with
+// the caller's credentials, so the provenance of repos that they can't read is
+// left out.
+func (a *apiServer) InspectDAG(ctx context.Context, request *pps.InspectDAGRequest) (response *pps.DAGInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return graph.InspectDAG(a.env.GetPachClient(ctx), request)
+}
@@ -235,7 +235,7 @@ func (a *apiServer) setPipelineState(ctx context.Context, pipeline string, state
 		tracing.TagAnySpan(span, "err", retErr)
 		tracing.FinishAnySpan(span)
 	}()
-	return ppsutil.SetPipelineState(ctx, a.env.GetEtcdClient(), a.pipelines,
+	return ppsutil.SetPipelineState(ctx, a.env.GetCollectionBackend(), a.pipelines,
 		pipeline, nil, state, reason)
 }
 
@@ -249,6 +249,6 @@ func (a *apiServer) transitionPipelineState(ctx context.Context, pipeline string
 		tracing.TagAnySpan(span, "err", retErr)
 		tracing.FinishAnySpan(span)
 	}()
-	return ppsutil.SetPipelineState(ctx, a.env.GetEtcdClient(), a.pipelines,
+	return ppsutil.SetPipelineState(ctx, a.env.GetCollectionBackend(), a.pipelines,
 		pipeline, from, to, reason)
 }
@@ -73,7 +73,8 @@ func startMonitorThread(parentPachClient *client.APIClient, name string, f func(
 // Every running pipeline with standby == true or a cron input has a
 // corresponding goroutine running monitorPipeline() that puts the pipeline in
 // and out of standby in response to new output commits appearing in that
-// pipeline's output repo.
+// pipeline's output repo. For autoscaling pipelines, monitorPipeline also
+// resizes the pipeline according to its backlog.
 func (a *apiServer) startMonitor(ppsMasterClient *client.APIClient, pipelineInfo *pps.PipelineInfo) {
 	pipeline := pipelineInfo.Pipeline.Name
 	a.monitorCancelsMu.Lock()
@@ -189,6 +190,14 @@ func (a *apiServer) monitorPipeline(pachClient *client.APIClient, pipelineInfo *
 			})
 		}
 	})
+	if as := pipelineInfo.GetParallelismSpec().GetAutoscaling(); as != nil {
+		eg.Go(func() error {
+			return backoff.RetryNotify(func() error {
+				return a.autoscalePipeline(pachClient, pipelineInfo, as)
+			}, backoff.NewInfiniteBackOff(),
+				backoff.NotifyCtx(pachClient.Ctx(), "autoscaling for "+pipeline))
+		})
+	}
 	if pipelineInfo.Standby {
 		// Capacity 1 gives us a bit of buffer so we don't needlessly go into
 		// standby when SubscribeCommit takes too long to return.
@@ -339,6 +348,49 @@ func (a *apiServer) monitorPipeline(pachClient *client.APIClient, pipelineInfo *
 	}
 }
 
+// autoscalePipeline periodically compares an autoscaling pipeline's backlog
+// to its number of workers, and records a new number of workers in etcd
+// (which the pipeline controller applies to the pipeline's RC) when the
+// autoscaler calls for one. It's a helper function called by monitorPipeline.
+func (a *apiServer) autoscalePipeline(pachClient *client.APIClient, pipelineInfo *pps.PipelineInfo, as *pps.Autoscaling) error {
+	pipeline := pipelineInfo.Pipeline.Name
+	ctx := pachClient.Ctx()
+	pipelinePtr := &pps.EtcdPipelineInfo{}
+	if err := a.pipelines.ReadOnly(ctx).Get(pipeline, pipelinePtr); err != nil {
+		return err
+	}
+	scaler := newAutoscaler(as, currentParallelism(pipelinePtr, pipelineInfo), time.Now())
+	ticker := time.NewTicker(autoscalingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := a.pipelines.ReadOnly(ctx).Get(pipeline, pipelinePtr); err != nil {
+			return err
+		}
+		// Pipelines in standby (or paused) have no workers to resize
+		if pipelinePtr.State != pps.PipelineState_PIPELINE_RUNNING &&
+			pipelinePtr.State != pps.PipelineState_PIPELINE_CRASHING {
+			continue
+		}
+		pendingDatums, pendingSubtasks, err := a.pipelineBacklog(ctx, pipelineInfo)
+		if err != nil {
+			return err
+		}
+		current := currentParallelism(pipelinePtr, pipelineInfo)
+		if workers := scaler.next(time.Now(), pendingDatums, pendingSubtasks); workers != current {
+			log.Infof("PPS master: autoscaling %q from %d to %d workers (%d pending datums, %d pending subtasks)",
+				pipeline, current, workers, pendingDatums, pendingSubtasks)
+			if err := a.setAutoscaledParallelism(ctx, pipeline, workers); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // allWorkersUp is a helper used by monitorCrashingPipeline
 func (a *apiServer) allWorkersUp(ctx context.Context, parallelism64 uint64, pipelineInfo *pps.PipelineInfo) (bool, error) {
 	parallelism := int(parallelism64)
@@ -444,7 +444,7 @@ func (op *pipelineOp) startPipelineMonitor() {
 }
 
 func (op *pipelineOp) startCrashingPipelineMonitor() {
-	op.apiServer.startCrashingMonitor(op.masterClient, op.ptr.Parallelism, op.pipelineInfo)
+	op.apiServer.startCrashingMonitor(op.masterClient, currentParallelism(op.ptr, op.pipelineInfo), op.pipelineInfo)
 }
 
 func (op *pipelineOp) stopPipelineMonitor() {
@@ -583,7 +583,7 @@ func (op *pipelineOp) scaleUpPipeline() (retErr error) {
 	}()
 
 	// compute target pipeline parallelism
-	parallelism := int(op.ptr.Parallelism)
+	parallelism := int(currentParallelism(op.ptr, op.pipelineInfo))
 	if parallelism == 0 {
 		log.Errorf("PPS master: error getting number of workers (defaulting to 1 worker)")
 		parallelism = 1
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/limit"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
+	workercommon "github.com/pachyderm/pachyderm/src/server/worker/common"
+	"github.com/pachyderm/pachyderm/src/server/worker/datum"
+
+	"golang.org/x/net/context"
+	"golang.org/x/sync/errgroup"
+)
+
+// planParallelism is the number of datums that PlanPipeline checks at once
+const planParallelism = 100
+
+// planIgnoredFields are the fields of a pipeline spec that PlanPipeline doesn't
+// report as diffs. The salt is reported through PipelinePlan.Reprocess instead.
+var planIgnoredFields = map[string]bool{
+	"salt": true,
+}
+
+// PlanPipeline implements the protobuf pps.PlanPipeline RPC. It goes through
+// the same validation as CreatePipeline, but rather than creating or updating
+// the pipeline, it describes what doing so would change.
+func (a *apiServer) PlanPipeline(ctx context.Context, request *pps.CreatePipelineRequest) (response *pps.PipelinePlan, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	// Validate request
+	if err := a.validatePipelineRequest(request); err != nil {
+		return nil, err
+	}
+	pachClient := a.env.GetPachClient(ctx)
+	ctx = pachClient.Ctx() // GetPachClient propagates auth info to inner ctx
+
+	// Copy the request, so that setting defaults doesn't modify it
+	pipelineInfo := pipelineInfoFromRequest(proto.Clone(request).(*pps.CreatePipelineRequest))
+	if err := setPipelineDefaults(pipelineInfo); err != nil {
+		return nil, err
+	}
+	if err := a.validatePipeline(pachClient, pipelineInfo); err != nil {
+		return nil, err
+	}
+	operation := pipelineOpCreate
+	if request.Update {
+		operation = pipelineOpUpdate
+	}
+	if err := a.authorizePipelineOp(pachClient, operation, pipelineInfo.Input, pipelineInfo.Pipeline.Name); err != nil {
+		return nil, err
+	}
+	pipelineName := pipelineInfo.Pipeline.Name
+	pps.SortInput(pipelineInfo.Input) // Makes datum hashes comparable
+
+	response = &pps.PipelinePlan{Pipeline: pipelineInfo.Pipeline}
+	var oldPipelineInfo *pps.PipelineInfo
+	pipelinePtr := &pps.EtcdPipelineInfo{}
+	if err := a.pipelines.ReadOnly(ctx).Get(pipelineName, pipelinePtr); err != nil {
+		if !col.IsErrNotFound(err) {
+			return nil, err
+		}
+	} else {
+		if !request.Update {
+			return nil, newErrPipelineExists(pipelineName)
+		}
+		oldPipelineInfo, err = ppsutil.GetPipelineInfo(pachClient, pipelineName, pipelinePtr)
+		if err != nil {
+			return nil, err
+		}
+		if oldPipelineInfo.EnableStats && !pipelineInfo.EnableStats {
+			return nil, newErrPipelineUpdate(pipelineName, "cannot disable stats")
+		}
+		response.Exists = true
+	}
+
+	// Work out whether previously processed datums can be skipped. As in
+	// CreatePipeline, they can't be if the pipeline is new or is being
+	// reprocessed, as it gets a new salt.
+	if oldPipelineInfo != nil {
+		if request.Reprocess {
+			response.Reprocess = true
+			response.ReprocessReason = "reprocessing was requested"
+			pipelineInfo.Salt = ""
+		} else {
+			pipelineInfo.Salt = oldPipelineInfo.Salt
+		}
+		diffs, err := pipelineSpecDiffs(oldPipelineInfo, pipelineInfo)
+		if err != nil {
+			return nil, err
+		}
+		response.Diffs = diffs
+		downstream, err := a.downstreamPipelines(pachClient, oldPipelineInfo)
+		if err != nil {
+			return nil, err
+		}
+		response.Downstream = downstream
+	}
+	if err := a.planDatums(pachClient, pipelineInfo, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// planDatums counts the datums in the current input of 'pipelineInfo', and the
+// number that would be skipped by its first job, in 'plan'. Datums are only
+// skipped if 'pipelineInfo' has a salt. In storage v2, the number of skipped
+// datums is reported as unknown.
+func (a *apiServer) planDatums(pachClient *client.APIClient, pipelineInfo *pps.PipelineInfo, plan *pps.PipelinePlan) error {
+	if pipelineInfo.Input == nil {
+		return nil // spouts have no datums
+	}
+	// Like ListDatum with an input, compute datums from the input branches'
+	// heads. Unlike ListDatum, inputs whose branches don't have a head yet
+	// (e.g. cron inputs of new pipelines) just have no datums.
+	input := proto.Clone(pipelineInfo.Input).(*pps.Input)
+	var visitErr error
+	pps.VisitInput(input, func(input *pps.Input) {
+		if visitErr != nil {
+			return
+		}
+		var repo, branch string
+		var commit *string
+		switch {
+		case input.Pfs != nil:
+			repo, branch, commit = input.Pfs.Repo, input.Pfs.Branch, &input.Pfs.Commit
+		case input.Cron != nil:
+			repo, branch, commit = input.Cron.Repo, "master", &input.Cron.Commit
+		case input.Git != nil:
+			repo, branch, commit = input.Git.Name, input.Git.Branch, &input.Git.Commit
+		default:
+			return
+		}
+		ci, err := pachClient.InspectCommit(repo, branch)
+		if err != nil {
+			if !isNotFoundErr(err) {
+				visitErr = err
+			}
+			return
+		}
+		*commit = ci.Commit.ID
+	})
+	if visitErr != nil {
+		return visitErr
+	}
+	dit, err := datum.NewIterator(pachClient, input)
+	if err != nil {
+		return err
+	}
+	plan.DatumsTotal = int64(dit.Len())
+	if pipelineInfo.Salt == "" {
+		plan.DatumsToProcess = plan.DatumsTotal
+		return nil
+	}
+	if a.env.StorageV2 {
+		// Only storage v1 records processed datums as the tags checked below
+		plan.SkippedUnknown = true
+		return nil
+	}
+
+	// Datums are skipped if a previous job with the same salt output a tag for
+	// them (see processDatum in the worker)
+	skipped := make([]bool, dit.Len())
+	var eg errgroup.Group
+	limiter := limit.New(planParallelism)
+	for i := 0; i < dit.Len(); i++ {
+		i := i
+		tag := workercommon.HashDatum(pipelineInfo.Pipeline.Name, pipelineInfo.Salt, dit.DatumN(i))
+		limiter.Acquire()
+		eg.Go(func() error {
+			defer limiter.Release()
+			if _, err := pachClient.InspectTag(pachClient.Ctx(), client.NewTag(tag)); err != nil {
+				if isNotFoundErr(err) {
+					return nil
+				}
+				return err
+			}
+			skipped[i] = true
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	for _, s := range skipped {
+		if s {
+			plan.DatumsToSkip++
+		}
+	}
+	plan.DatumsToProcess = plan.DatumsTotal - plan.DatumsToSkip
+	return nil
+}
+
+// downstreamPipelines returns the pipelines whose output is provenant on the
+// output of 'pipelineInfo', sorted by name
+func (a *apiServer) downstreamPipelines(pachClient *client.APIClient, pipelineInfo *pps.PipelineInfo) ([]*pps.Pipeline, error) {
+	branchInfo, err := pachClient.InspectBranch(pipelineInfo.Pipeline.Name, pipelineInfo.OutputBranch)
+	if err != nil {
+		return nil, err
+	}
+	// Every branch with provenance is the output (or stats) branch of a
+	// pipeline, named after its repo
+	names := make(map[string]bool)
+	for _, branch := range branchInfo.Subvenance {
+		if branch.Repo.Name != pipelineInfo.Pipeline.Name {
+			names[branch.Repo.Name] = true
+		}
+	}
+	var result []*pps.Pipeline
+	for name := range names {
+		result = append(result, client.NewPipeline(name))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// pipelineSpecDiffs returns the fields of the pipeline spec that differ between
+// 'oldInfo' and 'newInfo', sorted by name. Fields that aren't part of the spec
+// (e.g. the pipeline's state, version and creation time) are ignored.
+func pipelineSpecDiffs(oldInfo, newInfo *pps.PipelineInfo) ([]*pps.PipelineFieldDiff, error) {
+	oldFields, err := pipelineSpecFields(oldInfo)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := pipelineSpecFields(newInfo)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]bool)
+	for field := range oldFields {
+		fields[field] = true
+	}
+	for field := range newFields {
+		fields[field] = true
+	}
+	var result []*pps.PipelineFieldDiff
+	for field := range fields {
+		if planIgnoredFields[field] || bytes.Equal(oldFields[field], newFields[field]) {
+			continue
+		}
+		result = append(result, &pps.PipelineFieldDiff{
+			Field:    field,
+			OldValue: string(oldFields[field]),
+			NewValue: string(newFields[field]),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Field < result[j].Field
+	})
+	return result, nil
+}
+
+// pipelineSpecFields returns the JSON-encoded fields of the pipeline spec that
+// 'pipelineInfo' was created from, keyed by their names in the spec
+func pipelineSpecFields(pipelineInfo *pps.PipelineInfo) (map[string]json.RawMessage, error) {
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{OrigName: true}).Marshal(&buf, ppsutil.PipelineReqFromInfo(pipelineInfo)); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
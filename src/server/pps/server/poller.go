@@ -122,7 +122,7 @@ func (a *apiServer) pollPipelines(pachClient *client.APIClient) {
 		// generate an etcd event for 'pipeline' by reading it & writing it back
 		log.Debugf("PPS master: polling pipeline %q", pipeline)
 		var curPI pps.EtcdPipelineInfo
-		_, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		err := a.env.GetCollectionBackend().NewSTM(ctx, func(stm col.STM) error {
 			return a.pipelines.ReadWrite(stm).Update(pipeline, &curPI,
 				func() error { /* no modification, just r+w */ return nil })
 		})
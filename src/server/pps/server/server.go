@@ -55,8 +55,8 @@ func NewAPIServer(
 		noExposeDockerSocket:   noExposeDockerSocket,
 		reporter:               reporter,
 		workerUsesRoot:         workerUsesRoot,
-		pipelines:              ppsdb.Pipelines(env.GetEtcdClient(), etcdPrefix),
-		jobs:                   ppsdb.Jobs(env.GetEtcdClient(), etcdPrefix),
+		pipelines:              ppsdb.Pipelines(env.GetCollectionBackend(), etcdPrefix),
+		jobs:                   ppsdb.Jobs(env.GetCollectionBackend(), etcdPrefix),
 		monitorCancels:         make(map[string]func()),
 		crashingMonitorCancels: make(map[string]func()),
 		workerGrpcPort:         workerGrpcPort,
@@ -93,8 +93,8 @@ func NewSidecarAPIServer(
 		reporter:       reporter,
 		namespace:      namespace,
 		workerUsesRoot: true,
-		pipelines:      ppsdb.Pipelines(env.GetEtcdClient(), etcdPrefix),
-		jobs:           ppsdb.Jobs(env.GetEtcdClient(), etcdPrefix),
+		pipelines:      ppsdb.Pipelines(env.GetCollectionBackend(), etcdPrefix),
+		jobs:           ppsdb.Jobs(env.GetCollectionBackend(), etcdPrefix),
 		workerGrpcPort: workerGrpcPort,
 		httpPort:       httpPort,
 		peerPort:       peerPort,
@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"os"
+	"path"
 	"strconv"
 
 	jsonpatch "github.com/evanphx/json-patch"
@@ -191,6 +192,12 @@ func (a *apiServer) workerPodSpec(options *workerOptions) (v1.PodSpec, error) {
 		sidecarEnv = append(sidecarEnv, v1.EnvVar{Name: "LOKI_LOGGING", Value: "true"})
 		workerEnv = append(workerEnv, v1.EnvVar{Name: "LOKI_LOGGING", Value: "true"})
 	}
+	// Workers and sidecars must read and write metadata in the same collection
+	// backend as pachd
+	if a.env.CollectionBackend != "" {
+		sidecarEnv = append(sidecarEnv, v1.EnvVar{Name: "COLLECTION_BACKEND", Value: a.env.CollectionBackend})
+		workerEnv = append(workerEnv, v1.EnvVar{Name: "COLLECTION_BACKEND", Value: a.env.CollectionBackend})
+	}
 
 	// This only happens in local deployment.  We want the workers to be
 	// able to read from/write to the hostpath volume as well.
@@ -409,9 +416,13 @@ func getStorageEnvVars() ([]v1.EnvVar, error) {
 	if !ok {
 		return nil, errors.Errorf("%s not found", assets.UploadConcurrencyLimitEnvVar)
 	}
-	return []v1.EnvVar{
+	envVars := []v1.EnvVar{
 		{Name: assets.UploadConcurrencyLimitEnvVar, Value: uploadConcurrencyLimit},
-	}, nil
+	}
+	if compression, ok := os.LookupEnv(assets.StorageCompressionEnvVar); ok {
+		envVars = append(envVars, v1.EnvVar{Name: assets.StorageCompressionEnvVar, Value: compression})
+	}
+	return envVars, nil
 }
 
 // We don't want to expose pipeline auth tokens, so we hash it. This will be
@@ -508,6 +519,32 @@ func (a *apiServer) getWorkerOptions(ptr *pps.EtcdPipelineInfo, pipelineInfo *pp
 		}
 	}
 
+	// Mount the secrets of git inputs, which hold the credentials used to
+	// clone private repos
+	gitSecrets := make(map[string]bool)
+	pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
+		if input.Git == nil || input.Git.Secret == "" {
+			return
+		}
+		volumeName := "git-secret-" + input.Git.Secret
+		if !gitSecrets[input.Git.Secret] {
+			volumes = append(volumes, v1.Volume{
+				Name: volumeName,
+				VolumeSource: v1.VolumeSource{
+					Secret: &v1.SecretVolumeSource{
+						SecretName: input.Git.Secret,
+					},
+				},
+			})
+			gitSecrets[input.Git.Secret] = true
+		}
+		volumeMounts = append(volumeMounts, v1.VolumeMount{
+			Name:      volumeName,
+			MountPath: path.Join(client.PPSGitSecretPrefix, input.Git.Name),
+			ReadOnly:  true,
+		})
+	})
+
 	volumes = append(volumes, v1.Volume{
 		Name: "pach-bin",
 		VolumeSource: v1.VolumeSource{
@@ -94,7 +94,7 @@ func (a *apiServer) DeleteAll(ctx context.Context, request *transaction.DeleteAl
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	_, err := col.NewSTM(ctx, a.driver.etcdClient, func(stm col.STM) error {
+	err := a.driver.backend.NewSTM(ctx, func(stm col.STM) error {
 		return a.driver.deleteAll(ctx, stm, nil)
 	})
 	if err != nil {
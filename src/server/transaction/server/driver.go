@@ -15,7 +15,6 @@ import (
 	txnenv "github.com/pachyderm/pachyderm/src/server/pkg/transactionenv"
 	"github.com/pachyderm/pachyderm/src/server/pkg/uuid"
 
-	etcd "github.com/coreos/etcd/clientv3"
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 )
@@ -25,9 +24,9 @@ type driver struct {
 	// make calls within the same transaction without serializing through RPCs
 	txnEnv *txnenv.TransactionEnv
 
-	// etcdClient and prefix write repo and other metadata to etcd
-	etcdClient *etcd.Client
-	prefix     string
+	// backend and prefix write transactions to the collection backend
+	backend col.Backend
+	prefix  string
 
 	// collections
 	transactions col.Collection
@@ -38,12 +37,12 @@ func newDriver(
 	txnEnv *txnenv.TransactionEnv,
 	etcdPrefix string,
 ) (*driver, error) {
-	etcdClient := env.GetEtcdClient()
+	backend := env.GetCollectionBackend()
 	d := &driver{
 		txnEnv:       txnEnv,
-		etcdClient:   etcdClient,
+		backend:      backend,
 		prefix:       etcdPrefix,
-		transactions: transactiondb.Transactions(etcdClient, etcdPrefix),
+		transactions: transactiondb.Transactions(backend, etcdPrefix),
 	}
 	return d, nil
 }
@@ -90,7 +89,7 @@ func (d *driver) startTransaction(ctx context.Context) (*transaction.Transaction
 		Started:  now(),
 	}
 
-	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+	err := d.backend.NewSTM(ctx, func(stm col.STM) error {
 		return d.transactions.ReadWrite(stm).Put(
 			info.Transaction.ID,
 			info,
@@ -112,7 +111,7 @@ func (d *driver) inspectTransaction(ctx context.Context, txn *transaction.Transa
 }
 
 func (d *driver) deleteTransaction(ctx context.Context, txn *transaction.Transaction) error {
-	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+	err := d.backend.NewSTM(ctx, func(stm col.STM) error {
 		return d.transactions.ReadWrite(stm).Delete(txn.ID)
 	})
 	return err
@@ -282,7 +281,7 @@ func (d *driver) appendTransaction(
 		}
 
 		info = &transaction.TransactionInfo{}
-		_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		err = d.backend.NewSTM(ctx, func(stm col.STM) error {
 			// Update the existing transaction with the new requests/responses
 			return d.transactions.ReadWrite(stm).Update(txn.ID, info, func() error {
 				if len(info.Requests) != numRequests || len(info.Responses) != numResponses {
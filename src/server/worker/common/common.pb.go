@@ -8,6 +8,7 @@ import (
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
 	pfs "github.com/pachyderm/pachyderm/src/client/pfs"
+	pps "github.com/pachyderm/pachyderm/src/client/pps"
 	io "io"
 	math "math"
 	math_bits "math/bits"
@@ -25,19 +26,21 @@ var _ = math.Inf
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
 type Input struct {
-	FileInfo             *pfs.FileInfo `protobuf:"bytes,1,opt,name=file_info,json=fileInfo,proto3" json:"file_info,omitempty"`
-	ParentCommit         *pfs.Commit   `protobuf:"bytes,5,opt,name=parent_commit,json=parentCommit,proto3" json:"parent_commit,omitempty"`
-	Name                 string        `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	JoinOn               string        `protobuf:"bytes,8,opt,name=join_on,json=joinOn,proto3" json:"join_on,omitempty"`
-	GroupBy              string        `protobuf:"bytes,10,opt,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
-	Lazy                 bool          `protobuf:"varint,3,opt,name=lazy,proto3" json:"lazy,omitempty"`
-	Branch               string        `protobuf:"bytes,4,opt,name=branch,proto3" json:"branch,omitempty"`
-	GitURL               string        `protobuf:"bytes,6,opt,name=git_url,json=gitUrl,proto3" json:"git_url,omitempty"`
-	EmptyFiles           bool          `protobuf:"varint,7,opt,name=empty_files,json=emptyFiles,proto3" json:"empty_files,omitempty"`
-	S3                   bool          `protobuf:"varint,9,opt,name=s3,proto3" json:"s3,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
-	XXX_unrecognized     []byte        `json:"-"`
-	XXX_sizecache        int32         `json:"-"`
+	FileInfo             *pfs.FileInfo   `protobuf:"bytes,1,opt,name=file_info,json=fileInfo,proto3" json:"file_info,omitempty"`
+	ParentCommit         *pfs.Commit     `protobuf:"bytes,5,opt,name=parent_commit,json=parentCommit,proto3" json:"parent_commit,omitempty"`
+	Name                 string          `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	JoinOn               string          `protobuf:"bytes,8,opt,name=join_on,json=joinOn,proto3" json:"join_on,omitempty"`
+	GroupBy              string          `protobuf:"bytes,10,opt,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	Lazy                 bool            `protobuf:"varint,3,opt,name=lazy,proto3" json:"lazy,omitempty"`
+	Branch               string          `protobuf:"bytes,4,opt,name=branch,proto3" json:"branch,omitempty"`
+	GitURL               string          `protobuf:"bytes,6,opt,name=git_url,json=gitUrl,proto3" json:"git_url,omitempty"`
+	EmptyFiles           bool            `protobuf:"varint,7,opt,name=empty_files,json=emptyFiles,proto3" json:"empty_files,omitempty"`
+	S3                   bool            `protobuf:"varint,9,opt,name=s3,proto3" json:"s3,omitempty"`
+	GitProvider          pps.GitProvider `protobuf:"varint,11,opt,name=git_provider,json=gitProvider,proto3,enum=pps.GitProvider" json:"git_provider,omitempty"`
+	GitSecret            string          `protobuf:"bytes,12,opt,name=git_secret,json=gitSecret,proto3" json:"git_secret,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
 }
 
 func (m *Input) Reset()         { *m = Input{} }
@@ -143,6 +146,20 @@ func (m *Input) GetS3() bool {
 	return false
 }
 
+func (m *Input) GetGitProvider() pps.GitProvider {
+	if m != nil {
+		return m.GitProvider
+	}
+	return pps.GitProvider_GITHUB
+}
+
+func (m *Input) GetGitSecret() string {
+	if m != nil {
+		return m.GitSecret
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Input)(nil), "common.Input")
 }
@@ -150,29 +167,33 @@ func init() {
 func init() { proto.RegisterFile("server/worker/common/common.proto", fileDescriptor_91fb6c79ddd9db74) }
 
 var fileDescriptor_91fb6c79ddd9db74 = []byte{
-	// 352 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0xb1, 0x4e, 0xeb, 0x30,
-	0x14, 0x86, 0x95, 0xdc, 0x36, 0x49, 0x4f, 0x6f, 0xef, 0x60, 0x55, 0x17, 0xd3, 0xa1, 0x2d, 0xb0,
-	0x54, 0x0c, 0x0d, 0xa2, 0x03, 0x7b, 0x11, 0xa0, 0x4a, 0x48, 0x48, 0x91, 0xba, 0xb0, 0x44, 0x49,
-	0x70, 0x52, 0x43, 0x62, 0x47, 0xb6, 0x03, 0x0a, 0x2f, 0xc5, 0x6b, 0x30, 0xf2, 0x04, 0x08, 0xe5,
-	0x49, 0x90, 0xed, 0x0e, 0x0c, 0x0c, 0x96, 0xbf, 0xff, 0x3f, 0xc7, 0xbf, 0x75, 0x6c, 0x38, 0x92,
-	0x44, 0x3c, 0x13, 0x11, 0xbe, 0x70, 0xf1, 0x44, 0x44, 0x98, 0xf1, 0xaa, 0xe2, 0x6c, 0xbf, 0x2d,
-	0x6b, 0xc1, 0x15, 0x47, 0x9e, 0x55, 0x93, 0x71, 0x56, 0x52, 0xc2, 0x54, 0x58, 0xe7, 0x52, 0x2f,
-	0x5b, 0x9d, 0x8c, 0x0b, 0x5e, 0x70, 0x83, 0xa1, 0x26, 0xeb, 0x1e, 0xbf, 0xb9, 0xd0, 0xdf, 0xb0,
-	0xba, 0x51, 0xe8, 0x14, 0x06, 0x39, 0x2d, 0x49, 0x4c, 0x59, 0xce, 0xb1, 0x33, 0x77, 0x16, 0xc3,
-	0xf3, 0xd1, 0x52, 0x1f, 0xbf, 0xa6, 0x25, 0xd9, 0xb0, 0x9c, 0x47, 0x41, 0xbe, 0x27, 0x74, 0x06,
-	0xa3, 0x3a, 0x11, 0x84, 0xa9, 0x58, 0x5f, 0x49, 0x15, 0xee, 0x9b, 0xfe, 0xa1, 0xe9, 0xbf, 0x34,
-	0x56, 0xf4, 0xd7, 0x76, 0x58, 0x85, 0x10, 0xf4, 0x58, 0x52, 0x11, 0xec, 0xce, 0x9d, 0xc5, 0x20,
-	0x32, 0x8c, 0x0e, 0xc0, 0x7f, 0xe4, 0x94, 0xc5, 0x9c, 0xe1, 0xc0, 0xd8, 0x9e, 0x96, 0x77, 0x0c,
-	0x1d, 0x42, 0x50, 0x08, 0xde, 0xd4, 0x71, 0xda, 0x62, 0x30, 0x15, 0xdf, 0xe8, 0x75, 0xab, 0x73,
-	0xca, 0xe4, 0xb5, 0xc5, 0x7f, 0xe6, 0xce, 0x22, 0x88, 0x0c, 0xa3, 0xff, 0xe0, 0xa5, 0x22, 0x61,
-	0xd9, 0x0e, 0xf7, 0x6c, 0x8c, 0x55, 0xe8, 0x04, 0xfc, 0x82, 0xaa, 0xb8, 0x11, 0x25, 0xf6, 0x74,
-	0x61, 0x0d, 0xdd, 0xe7, 0xcc, 0xbb, 0xa1, 0x6a, 0x1b, 0xdd, 0x46, 0x5e, 0x41, 0xd5, 0x56, 0x94,
-	0x68, 0x06, 0x43, 0x52, 0xd5, 0xaa, 0x8d, 0xf5, 0x70, 0x12, 0xfb, 0x26, 0x17, 0x8c, 0xa5, 0x07,
-	0x97, 0xe8, 0x1f, 0xb8, 0x72, 0x85, 0x07, 0xc6, 0x77, 0xe5, 0x6a, 0x7d, 0xf5, 0xde, 0x4d, 0x9d,
-	0x8f, 0x6e, 0xea, 0x7c, 0x75, 0x53, 0xe7, 0xfe, 0xa2, 0xa0, 0x6a, 0xd7, 0xa4, 0xcb, 0x8c, 0x57,
-	0x61, 0x9d, 0x64, 0xbb, 0xf6, 0x81, 0x88, 0x9f, 0x24, 0x45, 0x16, 0xfe, 0xf6, 0x73, 0xa9, 0x67,
-	0xde, 0x7f, 0xf5, 0x1d, 0x00, 0x00, 0xff, 0xff, 0xe7, 0x9e, 0xf6, 0x08, 0xd8, 0x01, 0x00, 0x00,
+	// 402 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x92, 0x41, 0x8b, 0xd4, 0x30,
+	0x14, 0xc7, 0xc9, 0xec, 0x6e, 0xa7, 0x7d, 0x9d, 0x5d, 0x24, 0x88, 0x46, 0xc1, 0xd9, 0xaa, 0x97,
+	0xe2, 0xa1, 0x95, 0xed, 0xc1, 0xfb, 0x88, 0x2e, 0x0b, 0x82, 0x52, 0xd9, 0x8b, 0x97, 0xd2, 0x76,
+	0xd3, 0x4c, 0xb4, 0x4d, 0x42, 0x92, 0xae, 0xd4, 0xab, 0x5f, 0xce, 0xa3, 0x9f, 0x40, 0xa4, 0x9f,
+	0x44, 0x92, 0x8e, 0xac, 0x07, 0x0f, 0xa1, 0xff, 0xff, 0xef, 0xbd, 0xfe, 0x79, 0xaf, 0x0d, 0x3c,
+	0x35, 0x54, 0xdf, 0x52, 0x9d, 0x7f, 0x95, 0xfa, 0x0b, 0xd5, 0x79, 0x2b, 0x87, 0x41, 0x8a, 0xc3,
+	0x23, 0x53, 0x5a, 0x5a, 0x89, 0x83, 0xc5, 0x3d, 0xbe, 0xdf, 0xf6, 0x9c, 0x0a, 0x9b, 0xab, 0xce,
+	0xb8, 0xb3, 0x54, 0xef, 0xa8, 0x32, 0xee, 0xfc, 0xa5, 0x4c, 0x32, 0xe9, 0x65, 0xee, 0xd4, 0x42,
+	0x9f, 0x7d, 0x3f, 0x82, 0x93, 0x2b, 0xa1, 0x46, 0x8b, 0x5f, 0x40, 0xd4, 0xf1, 0x9e, 0x56, 0x5c,
+	0x74, 0x92, 0xa0, 0x04, 0xa5, 0xf1, 0xc5, 0x69, 0xe6, 0x42, 0xdf, 0xf2, 0x9e, 0x5e, 0x89, 0x4e,
+	0x96, 0x61, 0x77, 0x50, 0xf8, 0x25, 0x9c, 0xaa, 0x5a, 0x53, 0x61, 0x2b, 0x37, 0x08, 0xb7, 0xe4,
+	0xc4, 0xf7, 0xc7, 0xbe, 0xff, 0xb5, 0x47, 0xe5, 0x66, 0xe9, 0x58, 0x1c, 0xc6, 0x70, 0x2c, 0xea,
+	0x81, 0x92, 0x55, 0x82, 0xd2, 0xa8, 0xf4, 0x1a, 0x3f, 0x84, 0xf5, 0x67, 0xc9, 0x45, 0x25, 0x05,
+	0x09, 0x3d, 0x0e, 0x9c, 0x7d, 0x2f, 0xf0, 0x23, 0x08, 0x99, 0x96, 0xa3, 0xaa, 0x9a, 0x89, 0x80,
+	0xaf, 0xac, 0xbd, 0xdf, 0x4d, 0x2e, 0xa7, 0xaf, 0xbf, 0x4d, 0xe4, 0x28, 0x41, 0x69, 0x58, 0x7a,
+	0x8d, 0x1f, 0x40, 0xd0, 0xe8, 0x5a, 0xb4, 0x7b, 0x72, 0xbc, 0xc4, 0x2c, 0x0e, 0x3f, 0x87, 0x35,
+	0xe3, 0xb6, 0x1a, 0x75, 0x4f, 0x02, 0x57, 0xd8, 0xc1, 0xfc, 0xeb, 0x3c, 0xb8, 0xe4, 0xf6, 0xba,
+	0x7c, 0x57, 0x06, 0x8c, 0xdb, 0x6b, 0xdd, 0xe3, 0x73, 0x88, 0xe9, 0xa0, 0xec, 0x54, 0xb9, 0xe5,
+	0x0c, 0x59, 0xfb, 0x5c, 0xf0, 0xc8, 0x2d, 0x6e, 0xf0, 0x19, 0xac, 0x4c, 0x41, 0x22, 0xcf, 0x57,
+	0xa6, 0xc0, 0x05, 0x6c, 0x5c, 0xaa, 0xd2, 0xf2, 0x96, 0xdf, 0x50, 0x4d, 0xe2, 0x04, 0xa5, 0x67,
+	0x17, 0xf7, 0x32, 0xf7, 0xa5, 0x2f, 0xb9, 0xfd, 0x70, 0xe0, 0x65, 0xcc, 0xee, 0x0c, 0x7e, 0x02,
+	0xe0, 0x5e, 0x32, 0xb4, 0xd5, 0xd4, 0x92, 0x8d, 0x1f, 0x33, 0x62, 0xdc, 0x7e, 0xf4, 0x60, 0xf7,
+	0xe6, 0xc7, 0xbc, 0x45, 0x3f, 0xe7, 0x2d, 0xfa, 0x3d, 0x6f, 0xd1, 0xa7, 0x57, 0x8c, 0xdb, 0xfd,
+	0xd8, 0x64, 0xad, 0x1c, 0x72, 0x55, 0xb7, 0xfb, 0xe9, 0x86, 0xea, 0x7f, 0x95, 0xd1, 0x6d, 0xfe,
+	0xbf, 0x3b, 0xd2, 0x04, 0xfe, 0x9f, 0x16, 0x7f, 0x06, 0x00, 0xdf, 0x23, 0xfa, 0xb0, 0x42, 0x02,
+	0x00, 0x00,
 }
 
 func (m *Input) Marshal() (dAtA []byte, err error) {
@@ -199,6 +220,18 @@ func (m *Input) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.GitSecret) > 0 {
+		i -= len(m.GitSecret)
+		copy(dAtA[i:], m.GitSecret)
+		i = encodeVarintCommon(dAtA, i, uint64(len(m.GitSecret)))
+		i--
+		dAtA[i] = 0x62
+	}
+	if m.GitProvider != 0 {
+		i = encodeVarintCommon(dAtA, i, uint64(m.GitProvider))
+		i--
+		dAtA[i] = 0x58
+	}
 	if len(m.GroupBy) > 0 {
 		i -= len(m.GroupBy)
 		copy(dAtA[i:], m.GroupBy)
@@ -345,6 +378,13 @@ func (m *Input) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovCommon(uint64(l))
 	}
+	if m.GitProvider != 0 {
+		n += 1 + sovCommon(uint64(m.GitProvider))
+	}
+	l = len(m.GitSecret)
+	if l > 0 {
+		n += 1 + l + sovCommon(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -678,6 +718,57 @@ func (m *Input) Unmarshal(dAtA []byte) error {
 			}
 			m.GroupBy = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GitProvider", wireType)
+			}
+			m.GitProvider = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.GitProvider |= pps.GitProvider(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GitSecret", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthCommon
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommon
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.GitSecret = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCommon(dAtA[iNdEx:])
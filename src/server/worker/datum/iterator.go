@@ -490,10 +490,12 @@ func newGitIterator(pachClient *client.APIClient, input *pps.GitInput) (Iterator
 	result.inputs = append(
 		result.inputs,
 		&common.Input{
-			FileInfo: fileInfo,
-			Name:     input.Name,
-			Branch:   input.Branch,
-			GitURL:   input.URL,
+			FileInfo:    fileInfo,
+			Name:        input.Name,
+			Branch:      input.Branch,
+			GitURL:      input.URL,
+			GitProvider: input.Provider,
+			GitSecret:   input.Secret,
 		},
 	)
 	return result, nil
@@ -26,6 +26,8 @@ import (
 	"gopkg.in/go-playground/webhooks.v5/github"
 	"gopkg.in/src-d/go-git.v4"
 	gitPlumbing "gopkg.in/src-d/go-git.v4/plumbing"
+	gitTransport "gopkg.in/src-d/go-git.v4/plumbing/transport"
+	gitHTTP "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
 
 	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/enterprise"
@@ -37,6 +39,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/exec"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	"github.com/pachyderm/pachyderm/src/server/pkg/kafka"
 	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ppsconsts"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ppsdb"
@@ -59,10 +62,6 @@ var (
 	errSpecialFile = errors.New("cannot upload special file")
 )
 
-func workNamespace(pipelineInfo *pps.PipelineInfo) string {
-	return fmt.Sprintf("/pipeline-%s/v%d", pipelineInfo.Pipeline.Name, pipelineInfo.Version)
-}
-
 // Driver provides an interface for common functions needed by worker code, and
 // captures the relevant objects necessary to provide these functions so that
 // users do not need to keep track of as many variables.  In addition, this
@@ -135,7 +134,7 @@ type Driver interface {
 
 	// TODO: figure out how to not expose this - currently only used for a few
 	// operations in the map spawner
-	NewSTM(func(col.STM) error) (*etcd.TxnResponse, error)
+	NewSTM(func(col.STM) error) error
 
 	// These caches are used for storing and merging hashtrees from jobs until the
 	// job is complete
@@ -154,6 +153,7 @@ type driver struct {
 	pipelineInfo    *pps.PipelineInfo
 	pachClient      *client.APIClient
 	etcdClient      *etcd.Client
+	backend         col.Backend
 	etcdPrefix      string
 	activeDataMutex *sync.Mutex
 
@@ -197,6 +197,7 @@ func NewDriver(
 	pipelineInfo *pps.PipelineInfo,
 	pachClient *client.APIClient,
 	etcdClient *etcd.Client,
+	backend col.Backend,
 	etcdPrefix string,
 	hashtreePath string,
 	rootPath string,
@@ -235,10 +236,11 @@ func NewDriver(
 		pipelineInfo:     pipelineInfo,
 		pachClient:       pachClient,
 		etcdClient:       etcdClient,
+		backend:          backend,
 		etcdPrefix:       etcdPrefix,
 		activeDataMutex:  &sync.Mutex{},
-		jobs:             ppsdb.Jobs(etcdClient, etcdPrefix),
-		pipelines:        ppsdb.Pipelines(etcdClient, etcdPrefix),
+		jobs:             ppsdb.Jobs(backend, etcdPrefix),
+		pipelines:        ppsdb.Pipelines(backend, etcdPrefix),
 		numShards:        numShards,
 		rootDir:          rootPath,
 		inputDir:         pfsPath,
@@ -372,11 +374,11 @@ func (d *driver) Pipelines() col.Collection {
 }
 
 func (d *driver) NewTaskWorker() *work.Worker {
-	return work.NewWorker(d.etcdClient, d.etcdPrefix, workNamespace(d.pipelineInfo))
+	return work.NewWorker(d.etcdClient, d.etcdPrefix, ppsutil.WorkNamespace(d.pipelineInfo))
 }
 
 func (d *driver) NewTaskQueue() (*work.TaskQueue, error) {
-	return work.NewTaskQueue(d.PachClient().Ctx(), d.etcdClient, d.etcdPrefix, workNamespace(d.pipelineInfo))
+	return work.NewTaskQueue(d.PachClient().Ctx(), d.etcdClient, d.etcdPrefix, ppsutil.WorkNamespace(d.pipelineInfo))
 }
 
 func (d *driver) ExpectedNumWorkers() (int64, error) {
@@ -451,8 +453,8 @@ func (d *driver) WithDatumCache(cb func(*hashtree.MergeCache, *hashtree.MergeCac
 	return withDatumCache(d.hashtreeDir, cb)
 }
 
-func (d *driver) NewSTM(cb func(col.STM) error) (*etcd.TxnResponse, error) {
-	return col.NewSTM(d.pachClient.Ctx(), d.etcdClient, cb)
+func (d *driver) NewSTM(cb func(col.STM) error) error {
+	return d.backend.NewSTM(d.pachClient.Ctx(), cb)
 }
 
 func (d *driver) WithData(
@@ -499,7 +501,11 @@ func (d *driver) WithData(
 		})
 	}
 
-	if err := cb(dir, stats); err != nil {
+	err = cb(dir, stats)
+	// Measure the scratch space before it's removed, whether or not the user
+	// code succeeded, as failed datums consume disk too
+	d.reportScratchStats(dir, stats, logger)
+	if err != nil {
 		return stats, err
 	}
 
@@ -657,6 +663,11 @@ func (d *driver) downloadGitData(scratchPath string, input *common.Input) error
 		return errors.New("git hook payload does not specify the commit SHA")
 	}
 
+	auth, err := gitAuth(input)
+	if err != nil {
+		return err
+	}
+
 	// Clone checks out a reference, not a SHA. Github does not support fetching
 	// an individual SHA.
 	remoteURL := payload.Repository.CloneURL
@@ -666,6 +677,7 @@ func (d *driver) downloadGitData(scratchPath string, input *common.Input) error
 		false,
 		&git.CloneOptions{
 			URL:           remoteURL,
+			Auth:          auth,
 			SingleBranch:  true,
 			ReferenceName: gitPlumbing.ReferenceName(payload.Ref),
 		},
@@ -697,6 +709,42 @@ func (d *driver) downloadGitData(scratchPath string, input *common.Input) error
 	return nil
 }
 
+// gitAuth returns the credentials used to clone a git input, which are read
+// from the input's secret. It returns nil if the input has no credentials.
+func gitAuth(input *common.Input) (gitTransport.AuthMethod, error) {
+	if input.GitSecret == "" {
+		return nil, nil
+	}
+	secretDir := filepath.Join(client.PPSGitSecretPrefix, input.Name)
+	password, err := ioutil.ReadFile(filepath.Join(secretDir, client.GitPasswordKey))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.EnsureStack(err)
+	}
+	username, err := ioutil.ReadFile(filepath.Join(secretDir, client.GitUsernameKey))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.EnsureStack(err)
+	}
+	if len(username) == 0 {
+		// Access tokens are sent as the password, the username that goes with
+		// them depends on the provider.
+		switch input.GitProvider {
+		case pps.GitProvider_GITLAB:
+			username = []byte("oauth2")
+		case pps.GitProvider_BITBUCKET:
+			username = []byte("x-token-auth")
+		default:
+			username = []byte("git")
+		}
+	}
+	return &gitHTTP.BasicAuth{
+		Username: strings.TrimSpace(string(username)),
+		Password: strings.TrimSpace(string(password)),
+	}, nil
+}
+
 // Run user code and return the combined output of stdout and stderr.
 func (d *driver) RunUserCode(
 	logger logs.TaggedLogger,
@@ -751,6 +799,7 @@ func (d *driver) RunUserCode(
 	if err != nil {
 		return errors.EnsureStack(err)
 	}
+	d.reportResourceUsageStats(state, procStats, logger)
 	if common.IsDone(ctx) {
 		if err = ctx.Err(); err != nil {
 			return errors.EnsureStack(err)
@@ -817,6 +866,7 @@ func (d *driver) RunUserErrorHandlingCode(logger logs.TaggedLogger, environ []st
 	if err != nil {
 		return errors.EnsureStack(err)
 	}
+	d.reportResourceUsageStats(state, procStats, logger)
 	if common.IsDone(ctx) {
 		if err = ctx.Err(); err != nil {
 			return errors.EnsureStack(err)
@@ -850,7 +900,7 @@ func (d *driver) RunUserErrorHandlingCode(logger logs.TaggedLogger, environ []st
 }
 
 func (d *driver) UpdateJobState(jobID string, state pps.JobState, reason string) error {
-	_, err := d.NewSTM(func(stm col.STM) error {
+	err := d.NewSTM(func(stm col.STM) error {
 		jobPtr := &pps.EtcdJobInfo{}
 		if err := d.Jobs().ReadWrite(stm).Get(jobID, jobPtr); err != nil {
 			return errors.EnsureStack(err)
@@ -948,6 +998,78 @@ func (d *driver) reportDeferredUserCodeStats(
 	}
 }
 
+// reportResourceUsageStats adds the resources consumed by the finished user
+// process described by 'state' to 'procStats'. It may be called several times
+// for one datum (e.g. for both the user code and the error handling code), so
+// CPU time and disk I/O are summed and peak memory is the maximum.
+func (d *driver) reportResourceUsageStats(
+	state *os.ProcessState,
+	procStats *pps.ProcessStats,
+	logger logs.TaggedLogger,
+) {
+	cpuTime := state.UserTime() + state.SystemTime()
+	peakMemory, diskRead, diskWrite := processResourceUsage(state)
+
+	totalCPUTime := cpuTime
+	if procStats.CpuTime != nil {
+		if prevCPUTime, err := types.DurationFromProto(procStats.CpuTime); err == nil {
+			totalCPUTime += prevCPUTime
+		}
+	}
+	procStats.CpuTime = types.DurationProto(totalCPUTime)
+	if peakMemory > procStats.PeakMemoryBytes {
+		procStats.PeakMemoryBytes = peakMemory
+	}
+	procStats.DiskReadBytes += diskRead
+	procStats.DiskWriteBytes += diskWrite
+
+	if d.exportStats {
+		d.updateCounter(stats.DatumCPUSecondsCount, logger, "", func(counter prometheus.Counter) {
+			counter.Add(cpuTime.Seconds())
+		})
+		d.updateHistogram(stats.DatumPeakMemory, logger, "", func(hist prometheus.Observer) {
+			hist.Observe(float64(peakMemory))
+		})
+		d.updateCounter(stats.DatumDiskReadBytesCount, logger, "", func(counter prometheus.Counter) {
+			counter.Add(float64(diskRead))
+		})
+		d.updateCounter(stats.DatumDiskWriteBytesCount, logger, "", func(counter prometheus.Counter) {
+			counter.Add(float64(diskWrite))
+		})
+	}
+}
+
+// reportScratchStats records the size of the datum's scratch space 'dir',
+// which holds its downloaded inputs and its outputs. Symlinks and lazily
+// downloaded (not yet read) inputs don't occupy disk, so only regular files
+// are counted.
+func (d *driver) reportScratchStats(
+	dir string,
+	procStats *pps.ProcessStats,
+	logger logs.TaggedLogger,
+) {
+	var size uint64
+	if err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += uint64(info.Size())
+		}
+		return nil
+	}); err != nil {
+		logger.Logf("failed to measure scratch space %s: %v", dir, err)
+		return
+	}
+	procStats.ScratchBytes = size
+
+	if d.exportStats {
+		d.updateHistogram(stats.DatumScratchSize, logger, "", func(hist prometheus.Observer) {
+			hist.Observe(float64(size))
+		})
+	}
+}
+
 func (d *driver) ReportUploadStats(
 	start time.Time,
 	procStats *pps.ProcessStats,
@@ -1289,6 +1411,13 @@ func (d *driver) Egress(commit *pfs.Commit, egressURL string) error {
 	pachClient := d.PachClient().WithCtx(d.PachClient().Ctx())
 	pachClient.SetMaxConcurrentStreams(100)
 
+	if kafka.IsURL(egressURL) {
+		url, err := kafka.ParseURL(egressURL)
+		if err != nil {
+			return err
+		}
+		return kafka.Egress(pachClient, commit, url, kafka.NewDialer())
+	}
 	url, err := obj.ParseURL(egressURL)
 	if err != nil {
 		return err
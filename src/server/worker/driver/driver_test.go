@@ -24,6 +24,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	"github.com/pachyderm/pachyderm/src/client/pkg/require"
 	"github.com/pachyderm/pachyderm/src/client/pps"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/testpachd"
 	tu "github.com/pachyderm/pachyderm/src/server/pkg/testutil"
 	"github.com/pachyderm/pachyderm/src/server/worker/common"
@@ -70,6 +71,7 @@ func withTestEnv(cb func(*testEnv)) error {
 			testPipelineInfo(),
 			env.PachClient,
 			env.EtcdClient,
+			col.NewEtcdBackend(env.EtcdClient),
 			tu.UniqueString("driverTest"),
 			filepath.Clean(filepath.Join(env.Directory, "hashtrees")),
 			filepath.Clean(filepath.Join(env.Directory, "pfs")),
@@ -27,6 +27,18 @@ func makeCmdCredentials(uid uint32, gid uint32) *syscall.SysProcAttr {
 	}
 }
 
+// processResourceUsage returns the peak memory and disk I/O of a finished
+// process (and the children it waited for), in bytes, as reported by rusage
+func processResourceUsage(state *os.ProcessState) (peakMemory, diskRead, diskWrite uint64) {
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || rusage == nil {
+		return 0, 0, 0
+	}
+	// On linux, ru_maxrss is in kilobytes and ru_inblock/ru_oublock count
+	// 512-byte blocks
+	return uint64(rusage.Maxrss) * 1024, uint64(rusage.Inblock) * 512, uint64(rusage.Oublock) * 512
+}
+
 // WithActiveData is implemented differently in unix vs windows because of how
 // symlinks work on windows. Here, we create symlinks to the scratch space
 // directory, then clean up before returning.
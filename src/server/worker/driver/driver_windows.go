@@ -19,6 +19,12 @@ func makeCmdCredentials(uid uint32, gid uint32) *syscall.SysProcAttr {
 	return nil
 }
 
+// rusage is not available on windows, so only CPU time (which is read from
+// the process state directly) is recorded there
+func processResourceUsage(state *os.ProcessState) (peakMemory, diskRead, diskWrite uint64) {
+	return 0, 0, 0
+}
+
 // Note: this function only exists for tests, the real system uses a fifo for
 // this (which does not exist in the normal filesystem on Windows)
 func createSpoutFifo(path string) error {
@@ -0,0 +1,69 @@
+package logs
+
+import (
+	"sync"
+
+	"github.com/pachyderm/pachyderm/src/server/worker/common"
+)
+
+// LogCapture keeps the most recent output of user code written to a logger
+// returned by WithCapture
+type LogCapture struct {
+	mu   sync.Mutex
+	buf  []byte
+	size int
+}
+
+// NewLogCapture constructs a LogCapture that keeps at most 'size' bytes
+func NewLogCapture(size int) *LogCapture {
+	return &LogCapture{size: size}
+}
+
+func (c *LogCapture) write(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buf = append(c.buf, p...)
+	if len(c.buf) > c.size {
+		c.buf = append(c.buf[:0], c.buf[len(c.buf)-c.size:]...)
+	}
+}
+
+// String returns the captured output
+func (c *LogCapture) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return string(c.buf)
+}
+
+type capturingLogger struct {
+	TaggedLogger
+	capture *LogCapture
+	user    bool
+}
+
+// WithCapture wraps 'logger' so that the output of user code (i.e. whatever is
+// written to the loggers returned by its WithUserCode method) is also kept in
+// 'capture'. User code's stdout and stderr are written concurrently, so they
+// may be interleaved.
+func WithCapture(logger TaggedLogger, capture *LogCapture) TaggedLogger {
+	return &capturingLogger{TaggedLogger: logger, capture: capture}
+}
+
+func (logger *capturingLogger) Write(p []byte) (int, error) {
+	if logger.user {
+		logger.capture.write(p)
+	}
+	return logger.TaggedLogger.Write(p)
+}
+
+func (logger *capturingLogger) WithJob(jobID string) TaggedLogger {
+	return &capturingLogger{TaggedLogger: logger.TaggedLogger.WithJob(jobID), capture: logger.capture, user: logger.user}
+}
+
+func (logger *capturingLogger) WithData(data []*common.Input) TaggedLogger {
+	return &capturingLogger{TaggedLogger: logger.TaggedLogger.WithData(data), capture: logger.capture, user: logger.user}
+}
+
+func (logger *capturingLogger) WithUserCode() TaggedLogger {
+	return &capturingLogger{TaggedLogger: logger.TaggedLogger.WithUserCode(), capture: logger.capture, user: true}
+}
@@ -0,0 +1,74 @@
+package spout
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	"github.com/pachyderm/pachyderm/src/server/pkg/kafka"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsconsts"
+	"github.com/pachyderm/pachyderm/src/server/worker/driver"
+	"github.com/pachyderm/pachyderm/src/server/worker/logs"
+)
+
+// runKafka consumes the topic in the spout's URL into the pipeline's output
+// branch, writing one commit per batch of messages. Offsets are committed to
+// Kafka once the commit holding their messages has been finished.
+func runKafka(driver driver.Driver, logger logs.TaggedLogger, d kafka.Dialer) (retErr error) {
+	pachClient := driver.PachClient()
+	pipelineInfo := driver.PipelineInfo()
+	u, err := kafka.ParseURL(pipelineInfo.Spout.URL)
+	if err != nil {
+		return err
+	}
+	r := d.NewReader(u)
+	defer func() {
+		if err := r.Close(); retErr == nil {
+			retErr = err
+		}
+	}()
+	logger.Logf("consuming kafka topic %q as group %q", u.Topic, u.GroupID)
+	return kafka.Consume(pachClient.Ctx(), r, u.BatchSize, u.BatchInterval, func(msgs []kafka.Message) error {
+		return writeKafkaCommit(pachClient, pipelineInfo, logger, msgs)
+	})
+}
+
+// writeKafkaCommit writes msgs to a new commit in the spout's output branch,
+// retrying until it succeeds or the spout is canceled. Each message is
+// written to a file named <topic>-<partition>-<offset>.
+func writeKafkaCommit(pachClient *client.APIClient, pipelineInfo *pps.PipelineInfo, logger logs.TaggedLogger, msgs []kafka.Message) error {
+	repo := pipelineInfo.Pipeline.Name
+	return backoff.RetryUntilCancel(pachClient.Ctx(), func() (retErr error) {
+		commit, err := pachClient.PfsAPIClient.StartCommit(pachClient.Ctx(), &pfs.StartCommitRequest{
+			Parent:     client.NewCommit(repo, ""),
+			Branch:     pipelineInfo.OutputBranch,
+			Provenance: []*pfs.CommitProvenance{client.NewCommitProvenance(ppsconsts.SpecRepo, repo, pipelineInfo.SpecCommit.ID)},
+		})
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if retErr != nil {
+				pachClient.DeleteCommit(repo, commit.ID)
+				return
+			}
+			if err := pachClient.FinishCommit(repo, commit.ID); retErr == nil {
+				retErr = err
+			}
+		}()
+		for _, msg := range msgs {
+			name := fmt.Sprintf("%s-%d-%d", msg.Topic, msg.Partition, msg.Offset)
+			if _, err := pachClient.PutFile(repo, commit.ID, name, bytes.NewReader(msg.Value)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, backoff.NewInfiniteBackOff(), func(err error, d time.Duration) error {
+		logger.Logf("error writing kafka messages to a commit: %+v, retrying in: %+v", err, d)
+		return nil
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/kafka"
 	"github.com/pachyderm/pachyderm/src/server/worker/common"
 	"github.com/pachyderm/pachyderm/src/server/worker/driver"
 	"github.com/pachyderm/pachyderm/src/server/worker/logs"
@@ -28,6 +29,10 @@ func Run(driver driver.Driver, logger logs.TaggedLogger) error {
 		return pachClient.DeleteCommit(pipelineInfo.Pipeline.Name, c.Commit.ID)
 	})
 
+	if pipelineInfo.Spout.URL != "" {
+		return runKafka(driver, logger, kafka.NewDialer())
+	}
+
 	// TODO: do something with stats?
 	_, err := driver.WithData(nil, nil, logger, func(dir string, stats *pps.ProcessStats) error {
 		inputs := []*common.Input{} // Spouts take no inputs
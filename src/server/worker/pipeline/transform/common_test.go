@@ -5,7 +5,6 @@ import (
 	"path/filepath"
 	"time"
 
-	etcd "github.com/coreos/etcd/clientv3"
 	"github.com/gogo/protobuf/types"
 
 	"github.com/pachyderm/pachyderm/src/client"
@@ -123,7 +122,7 @@ func (td *testDriver) UploadOutput(dir string, tag string, logger logs.TaggedLog
 func (td *testDriver) ReportUploadStats(t time.Time, stats *pps.ProcessStats, logger logs.TaggedLogger) {
 	td.inner.ReportUploadStats(t, stats, logger)
 }
-func (td *testDriver) NewSTM(cb func(col.STM) error) (*etcd.TxnResponse, error) {
+func (td *testDriver) NewSTM(cb func(col.STM) error) error {
 	return td.inner.NewSTM(cb)
 }
 func (td *testDriver) ChunkCaches() cache.WorkerCache {
@@ -150,6 +149,7 @@ func withTestEnv(pipelineInfo *pps.PipelineInfo, cb func(*testEnv) error) error
 			pipelineInfo,
 			realEnv.PachClient,
 			realEnv.EtcdClient,
+			col.NewEtcdBackend(realEnv.EtcdClient),
 			"/pachyderm_test",
 			filepath.Join(workerDir, "hashtrees"),
 			workerDir,
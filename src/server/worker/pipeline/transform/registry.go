@@ -9,6 +9,7 @@ import (
 	"path"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gogo/protobuf/types"
@@ -18,12 +19,14 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/limit"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
 	"github.com/pachyderm/pachyderm/src/client/pkg/pbutil"
 	"github.com/pachyderm/pachyderm/src/client/pps"
 	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsconsts"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/uuid"
 	"github.com/pachyderm/pachyderm/src/server/pkg/work"
@@ -35,6 +38,35 @@ import (
 	"github.com/pachyderm/pachyderm/src/server/worker/pipeline/transform/chain"
 )
 
+const (
+	// chunkClaimTimeout is how long a worker can go without renewing its claim
+	// on a chunk of a job's datums before the chunk is given to another worker.
+	// It's shorter than the task queue's default, so that the datums of a
+	// worker that dies are re-processed promptly.
+	chunkClaimTimeout = 10 * time.Second
+	// smallJobDatums is the largest number of datums that a job can have and
+	// still be scheduled as a small job (see jobTaskOptions).
+	smallJobDatums = 100
+)
+
+// jobTaskOptions returns the scheduling options of the task that processes the
+// datums of the job 'jobInfo'. Small jobs are prioritized over large ones, so
+// that a large job (such as one that reprocesses all of a pipeline's input)
+// doesn't hold up the pipeline's small, frequent jobs. Jobs are grouped by the
+// version of the pipeline that created them, so that the jobs of an updated
+// pipeline share the workers fairly with the jobs still running for its
+// previous version, however many of each there are.
+func jobTaskOptions(jobInfo *pps.JobInfo) []work.TaskOption {
+	opts := []work.TaskOption{
+		work.WithClaimTimeout(chunkClaimTimeout),
+		work.WithGroup(fmt.Sprintf("version-%d", jobInfo.PipelineVersion), 1),
+	}
+	if jobInfo.DataTotal <= smallJobDatums {
+		opts = append(opts, work.WithPriority(1))
+	}
+	return opts
+}
+
 func jobArtifactPrefix(jobID string) string {
 	return path.Join("artifacts", fmt.Sprintf("job-%s", jobID))
 }
@@ -51,6 +83,10 @@ func jobArtifactRecoveredObject(jobID string) string {
 	return path.Join(jobArtifactPrefix(jobID), "recovered")
 }
 
+func jobArtifactQuarantinedObject(jobID string) string {
+	return path.Join(jobArtifactPrefix(jobID), "quarantined")
+}
+
 type pendingJob struct {
 	driver          driver.Driver
 	commitInfo      *pfs.CommitInfo
@@ -65,6 +101,12 @@ type pendingJob struct {
 	// from object storage.
 	chunkHashtrees []*HashtreeInfo
 	statsHashtrees []*HashtreeInfo
+
+	// These are filled in when the RUNNING phase starts, if the pipeline
+	// quarantines failed datums. Datums that are still quarantined by an
+	// earlier job are skipped.
+	quarantined        map[string]bool
+	quarantinedSkipped int64
 }
 
 type registry struct {
@@ -251,6 +293,10 @@ func (reg *registry) succeedJob(
 
 	// Use the registry's driver so that the job's supervision goroutine cannot cancel us
 	if err := finishJob(reg.driver.PipelineInfo(), reg.driver.PachClient(), pj.ji, newState, "", datums, trees, size, statsTrees, statsSize); err != nil {
+		if pfsserver.IsRepoQuotaExceededErr(err) {
+			// The output can't be committed, so retrying won't help
+			return reg.failJob(pj, fmt.Sprintf("output exceeds the output repo's quota: %v", grpcutil.ScrubGRPC(err)), nil, 0)
+		}
 		return err
 	}
 
@@ -314,16 +360,17 @@ func (reg *registry) cleanJobArtifacts(job *pps.Job) error {
 
 func writeJobInfo(pachClient *client.APIClient, jobInfo *pps.JobInfo) error {
 	_, err := pachClient.PpsAPIClient.UpdateJobState(pachClient.Ctx(), &pps.UpdateJobStateRequest{
-		Job:           jobInfo.Job,
-		State:         jobInfo.State,
-		Reason:        jobInfo.Reason,
-		Restart:       jobInfo.Restart,
-		DataProcessed: jobInfo.DataProcessed,
-		DataSkipped:   jobInfo.DataSkipped,
-		DataTotal:     jobInfo.DataTotal,
-		DataFailed:    jobInfo.DataFailed,
-		DataRecovered: jobInfo.DataRecovered,
-		Stats:         jobInfo.Stats,
+		Job:             jobInfo.Job,
+		State:           jobInfo.State,
+		Reason:          jobInfo.Reason,
+		Restart:         jobInfo.Restart,
+		DataProcessed:   jobInfo.DataProcessed,
+		DataSkipped:     jobInfo.DataSkipped,
+		DataTotal:       jobInfo.DataTotal,
+		DataFailed:      jobInfo.DataFailed,
+		DataRecovered:   jobInfo.DataRecovered,
+		DataQuarantined: jobInfo.DataQuarantined,
+		Stats:           jobInfo.Stats,
 	})
 	return err
 }
@@ -449,6 +496,11 @@ func (reg *registry) sendDatumTasks(ctx context.Context, pj *pendingJob, numDatu
 			return errors.New("job datum iterator returned nil inputs")
 		}
 
+		if pj.quarantined[common.HashDatum(driver.PipelineInfo().Pipeline.Name, driver.PipelineInfo().Salt, inputs)] {
+			atomic.AddInt64(&pj.quarantinedSkipped, 1)
+			continue
+		}
+
 		datums = append(datums, &DatumInputs{Inputs: inputs, Index: index})
 
 		// If we have enough input bytes, finish the task
@@ -803,7 +855,7 @@ func (reg *registry) startJob(commitInfo *pfs.CommitInfo, statsCommit *pfs.Commi
 				return nil
 			})
 			pj.logger.Logf("master done running processJobs")
-		}); err != nil {
+		}, jobTaskOptions(pj.ji)...); err != nil {
 			return err
 		}
 
@@ -855,7 +907,7 @@ func (reg *registry) superviseJob(pj *pendingJob) error {
 			}
 
 			// Output commit was deleted. Delete job as well
-			if _, err := pj.driver.NewSTM(func(stm col.STM) error {
+			if err := pj.driver.NewSTM(func(stm col.STM) error {
 				// Delete the job if no other worker has deleted it yet
 				jobPtr := &pps.EtcdJobInfo{}
 				if err := pj.driver.Jobs().ReadWrite(stm).Get(pj.ji.Job.ID, jobPtr); err != nil {
@@ -942,6 +994,10 @@ func (pj *pendingJob) Iterator() (datum.Iterator, error) {
 }
 
 func (reg *registry) processJobRunning(pj *pendingJob) error {
+	if err := reg.loadQuarantine(pj); err != nil {
+		return err
+	}
+
 	pj.logger.Logf("processJobRunning creating task channel")
 	subtasks := make(chan *work.Task, 10)
 
@@ -972,6 +1028,7 @@ func (reg *registry) processJobRunning(pj *pendingJob) error {
 	chunkHashtrees := []*HashtreeInfo{}
 	statsHashtrees := []*HashtreeInfo{}
 	recoveredObjects := []string{}
+	quarantinedObjects := []string{}
 
 	// Run subtasks until we are done
 	eg.Go(func() error {
@@ -1002,6 +1059,9 @@ func (reg *registry) processJobRunning(pj *pendingJob) error {
 					if data.RecoveredDatumsObject != "" {
 						recoveredObjects = append(recoveredObjects, data.RecoveredDatumsObject)
 					}
+					if data.QuarantinedDatumsObject != "" {
+						quarantinedObjects = append(quarantinedObjects, data.QuarantinedDatumsObject)
+					}
 					// propagate the stats to etcd
 					pj.saveJobStats(stats)
 					return pj.writeJobInfo()
@@ -1015,6 +1075,8 @@ func (reg *registry) processJobRunning(pj *pendingJob) error {
 		// If these was no failed datum, we can reattempt later
 		return errors.Wrap(err, "process datum error")
 	}
+	stats.DatumsQuarantined += atomic.LoadInt64(&pj.quarantinedSkipped)
+	pj.saveJobStats(stats)
 
 	if stats.FailedDatumID != "" {
 		// A datum failed, but we still may need to merge stats - discard chunk hashtrees
@@ -1038,6 +1100,9 @@ func (reg *registry) processJobRunning(pj *pendingJob) error {
 	if err := pj.storeRecoveredDatums(recoveredObjects); err != nil {
 		return err
 	}
+	if err := pj.storeQuarantinedDatums(quarantinedObjects); err != nil {
+		return err
+	}
 
 	pj.logger.Logf("processJobRunning updating task to merging, total stats: %v", stats)
 	pj.ji.State = pps.JobState_JOB_MERGING
@@ -1051,12 +1116,13 @@ func (pj *pendingJob) saveJobStats(stats *DatumStats) {
 	pj.ji.DataProcessed = stats.DatumsProcessed
 	pj.ji.DataFailed = stats.DatumsFailed
 	pj.ji.DataRecovered = stats.DatumsRecovered
+	pj.ji.DataQuarantined = stats.DatumsQuarantined
 	pj.ji.DataTotal = int64(pj.jdit.MaxLen())
 	pj.ji.Stats = stats.ProcessStats
 }
 
 func (pj *pendingJob) finalizeJobStats() {
-	pj.ji.DataSkipped = int64(pj.jdit.MaxLen()) - pj.ji.DataProcessed - pj.ji.DataFailed - pj.ji.DataRecovered
+	pj.ji.DataSkipped = int64(pj.jdit.MaxLen()) - pj.ji.DataProcessed - pj.ji.DataFailed - pj.ji.DataRecovered - pj.ji.DataQuarantined
 }
 
 func (pj *pendingJob) storeHashtreeInfos(chunks []*HashtreeInfo, stats []*HashtreeInfo) (retErr error) {
@@ -1191,6 +1257,172 @@ func (pj *pendingJob) loadRecoveredDatums() (chain.DatumSet, error) {
 	return datumSet, nil
 }
 
+// loadQuarantine loads the hashes of the datums that are still quarantined (in
+// the pipeline's quarantine branch) into 'pj', so that they're skipped
+func (reg *registry) loadQuarantine(pj *pendingJob) error {
+	pj.quarantined = nil
+	atomic.StoreInt64(&pj.quarantinedSkipped, 0)
+	if !pj.driver.PipelineInfo().QuarantineFailedDatums {
+		return nil
+	}
+	records, err := ppsutil.ListQuarantinedDatums(pj.driver.PachClient(), pj.driver.PipelineInfo().Pipeline.Name)
+	if err != nil {
+		return err
+	}
+	pj.quarantined = make(map[string]bool)
+	for _, record := range records {
+		if !record.Released {
+			pj.quarantined[record.Hash] = true
+		}
+	}
+	return nil
+}
+
+func (pj *pendingJob) storeQuarantinedDatums(quarantinedObjects []string) (retErr error) {
+	if len(quarantinedObjects) == 0 {
+		return nil
+	}
+
+	objects := &QuarantinedDatumObjects{Objects: quarantinedObjects}
+	writer, err := pj.driver.PachClient().DirectObjWriter(jobArtifactQuarantinedObject(pj.ji.Job.ID))
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	defer func() {
+		if err := writer.Close(); err != nil && retErr == nil {
+			retErr = errors.EnsureStack(err)
+		}
+	}()
+
+	pbw := pbutil.NewWriter(writer)
+	_, err = pbw.Write(objects)
+	return err
+}
+
+func (pj *pendingJob) loadQuarantinedDatums() ([]*pps.QuarantinedDatum, error) {
+	reader, err := pj.driver.PachClient().DirectObjReader(jobArtifactQuarantinedObject(pj.ji.Job.ID))
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+
+	quarantinedObjects := &QuarantinedDatumObjects{}
+	protoReader := pbutil.NewReader(reader)
+	if err := protoReader.Read(quarantinedObjects); err != nil {
+		reader.Close()
+		if errutil.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := reader.Close(); err != nil {
+		return nil, err
+	}
+
+	var result []*pps.QuarantinedDatum
+	for _, object := range quarantinedObjects.Objects {
+		reader, err := pj.driver.PachClient().DirectObjReader(object)
+		if err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+
+		quarantinedDatums := &QuarantinedDatums{}
+		protoReader := pbutil.NewReader(reader)
+		if err := protoReader.Read(quarantinedDatums); err != nil {
+			reader.Close()
+			return nil, err
+		}
+
+		if err := reader.Close(); err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+
+		result = append(result, quarantinedDatums.Datums...)
+	}
+
+	return result, nil
+}
+
+// updateQuarantine writes the records of the datums that 'pj' quarantined to
+// the pipeline's quarantine branch, and removes the records of released datums
+// that have since been processed successfully. It returns the hashes of the
+// datums that remain quarantined.
+func (reg *registry) updateQuarantine(pj *pendingJob) (_ chain.DatumSet, retErr error) {
+	pachClient := reg.driver.PachClient()
+	pipelineName := reg.driver.PipelineInfo().Pipeline.Name
+	quarantinedDatums, err := pj.loadQuarantinedDatums()
+	if err != nil {
+		return nil, err
+	}
+	records, err := ppsutil.ListQuarantinedDatums(pachClient, pipelineName)
+	if err != nil {
+		return nil, err
+	}
+
+	var puts []*pps.QuarantinedDatum
+	var deletes []string
+	for _, record := range quarantinedDatums {
+		if oldRecord, ok := records[record.ID]; ok {
+			if oldRecord.Job.ID == pj.ji.Job.ID {
+				continue // written by an earlier attempt to finish this job
+			}
+			record.Retries = oldRecord.Retries + 1
+		}
+		records[record.ID] = record
+		puts = append(puts, record)
+	}
+	for id, record := range records {
+		if !record.Released {
+			continue
+		}
+		// The output of successfully processed datums is tagged with their hash
+		if _, err := pachClient.InspectTag(pachClient.Ctx(), client.NewTag(record.Hash)); err != nil {
+			if errutil.IsNotFoundError(err) {
+				continue
+			}
+			return nil, err
+		}
+		delete(records, id)
+		deletes = append(deletes, id)
+	}
+
+	if len(puts) > 0 || len(deletes) > 0 {
+		pj.logger.Logf("quarantining %d datums, and unquarantining %d released datums", len(puts), len(deletes))
+		commit, err := pachClient.StartCommit(pipelineName, ppsconsts.QuarantineBranch)
+		if err != nil {
+			return nil, err
+		}
+		// Delete the commit if it can't be finished, so that it doesn't block
+		// the next attempt to update the quarantine branch
+		defer func() {
+			if retErr != nil {
+				if err := pachClient.DeleteCommit(pipelineName, commit.ID); err != nil {
+					pj.logger.Logf("could not delete unfinished quarantine commit %s: %v", commit.ID, err)
+				}
+			}
+		}()
+		for _, record := range puts {
+			if err := ppsutil.PutQuarantinedDatum(pachClient, pipelineName, commit.ID, record); err != nil {
+				return nil, err
+			}
+		}
+		for _, id := range deletes {
+			if err := pachClient.DeleteFile(pipelineName, commit.ID, ppsutil.QuarantinedDatumPath(id)); err != nil {
+				return nil, err
+			}
+		}
+		if err := pachClient.FinishCommit(pipelineName, commit.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make(chain.DatumSet)
+	for _, record := range records {
+		result[record.Hash]++
+	}
+	return result, nil
+}
+
 func (reg *registry) makeMergeSubtasks(pj *pendingJob, commitInfo *pfs.CommitInfo, stats bool) ([]*work.Task, error) {
 	hashtrees := pj.chunkHashtrees
 	if stats {
@@ -1339,6 +1571,18 @@ func (reg *registry) storeJobDatums(pj *pendingJob) (*pfs.Object, error) {
 		return nil, err
 	}
 
+	// Quarantined datums, like recovered datums, weren't processed
+	// successfully, so they're left out of the job's datums
+	if reg.driver.PipelineInfo().QuarantineFailedDatums {
+		quarantinedDatums, err := reg.updateQuarantine(pj)
+		if err != nil {
+			return nil, err
+		}
+		for hash, count := range quarantinedDatums {
+			recoveredDatums[hash] += count
+		}
+	}
+
 	if err := reg.jobChain.RecoveredDatums(pj, recoveredDatums); err != nil {
 		return nil, err
 	}
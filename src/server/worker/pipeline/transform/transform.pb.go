@@ -283,6 +283,103 @@ func (m *RecoveredDatumObjects) GetObjects() []string {
 	return nil
 }
 
+// QuarantinedDatums is the message contained in the objects generated by
+// workers for the datums that they quarantined (see
+// pps.CreatePipelineRequest.quarantine_failed_datums)
+type QuarantinedDatums struct {
+	Datums               []*pps.QuarantinedDatum `protobuf:"bytes,1,rep,name=datums,proto3" json:"datums,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *QuarantinedDatums) Reset()         { *m = QuarantinedDatums{} }
+func (m *QuarantinedDatums) String() string { return proto.CompactTextString(m) }
+func (*QuarantinedDatums) ProtoMessage()    {}
+func (*QuarantinedDatums) Descriptor() ([]byte, []int) {
+	return fileDescriptor_21583a759eb7fa97, []int{5}
+}
+func (m *QuarantinedDatums) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QuarantinedDatums) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QuarantinedDatums.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QuarantinedDatums) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuarantinedDatums.Merge(m, src)
+}
+func (m *QuarantinedDatums) XXX_Size() int {
+	return m.Size()
+}
+func (m *QuarantinedDatums) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuarantinedDatums.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuarantinedDatums proto.InternalMessageInfo
+
+func (m *QuarantinedDatums) GetDatums() []*pps.QuarantinedDatum {
+	if m != nil {
+		return m.Datums
+	}
+	return nil
+}
+
+type QuarantinedDatumObjects struct {
+	Objects              []string `protobuf:"bytes,1,rep,name=objects,proto3" json:"objects,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QuarantinedDatumObjects) Reset()         { *m = QuarantinedDatumObjects{} }
+func (m *QuarantinedDatumObjects) String() string { return proto.CompactTextString(m) }
+func (*QuarantinedDatumObjects) ProtoMessage()    {}
+func (*QuarantinedDatumObjects) Descriptor() ([]byte, []int) {
+	return fileDescriptor_21583a759eb7fa97, []int{6}
+}
+func (m *QuarantinedDatumObjects) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QuarantinedDatumObjects) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QuarantinedDatumObjects.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QuarantinedDatumObjects) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuarantinedDatumObjects.Merge(m, src)
+}
+func (m *QuarantinedDatumObjects) XXX_Size() int {
+	return m.Size()
+}
+func (m *QuarantinedDatumObjects) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuarantinedDatumObjects.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuarantinedDatumObjects proto.InternalMessageInfo
+
+func (m *QuarantinedDatumObjects) GetObjects() []string {
+	if m != nil {
+		return m.Objects
+	}
+	return nil
+}
+
 type HashtreeInfo struct {
 	// Address used for fetching a cached version directly from the worker
 	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
@@ -300,7 +397,7 @@ func (m *HashtreeInfo) Reset()         { *m = HashtreeInfo{} }
 func (m *HashtreeInfo) String() string { return proto.CompactTextString(m) }
 func (*HashtreeInfo) ProtoMessage()    {}
 func (*HashtreeInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_21583a759eb7fa97, []int{5}
+	return fileDescriptor_21583a759eb7fa97, []int{7}
 }
 func (m *HashtreeInfo) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -356,6 +453,7 @@ type DatumStats struct {
 	DatumsSkipped        int64             `protobuf:"varint,3,opt,name=datums_skipped,json=datumsSkipped,proto3" json:"datums_skipped,omitempty"`
 	DatumsFailed         int64             `protobuf:"varint,5,opt,name=datums_failed,json=datumsFailed,proto3" json:"datums_failed,omitempty"`
 	DatumsRecovered      int64             `protobuf:"varint,6,opt,name=datums_recovered,json=datumsRecovered,proto3" json:"datums_recovered,omitempty"`
+	DatumsQuarantined    int64             `protobuf:"varint,9,opt,name=datums_quarantined,json=datumsQuarantined,proto3" json:"datums_quarantined,omitempty"`
 	FailedDatumID        string            `protobuf:"bytes,8,opt,name=failed_datum_id,json=failedDatumId,proto3" json:"failed_datum_id,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
 	XXX_unrecognized     []byte            `json:"-"`
@@ -366,7 +464,7 @@ func (m *DatumStats) Reset()         { *m = DatumStats{} }
 func (m *DatumStats) String() string { return proto.CompactTextString(m) }
 func (*DatumStats) ProtoMessage()    {}
 func (*DatumStats) Descriptor() ([]byte, []int) {
-	return fileDescriptor_21583a759eb7fa97, []int{6}
+	return fileDescriptor_21583a759eb7fa97, []int{8}
 }
 func (m *DatumStats) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -430,6 +528,13 @@ func (m *DatumStats) GetDatumsRecovered() int64 {
 	return 0
 }
 
+func (m *DatumStats) GetDatumsQuarantined() int64 {
+	if m != nil {
+		return m.DatumsQuarantined
+	}
+	return 0
+}
+
 func (m *DatumStats) GetFailedDatumID() string {
 	if m != nil {
 		return m.FailedDatumID
@@ -443,20 +548,21 @@ type DatumData struct {
 	DatumsObject string      `protobuf:"bytes,8,opt,name=datums_object,json=datumsObject,proto3" json:"datums_object,omitempty"`
 	OutputCommit *pfs.Commit `protobuf:"bytes,3,opt,name=output_commit,json=outputCommit,proto3" json:"output_commit,omitempty"`
 	// Outputs
-	Stats                 *DatumStats   `protobuf:"bytes,4,opt,name=stats,proto3" json:"stats,omitempty"`
-	ChunkHashtree         *HashtreeInfo `protobuf:"bytes,5,opt,name=chunk_hashtree,json=chunkHashtree,proto3" json:"chunk_hashtree,omitempty"`
-	StatsHashtree         *HashtreeInfo `protobuf:"bytes,6,opt,name=stats_hashtree,json=statsHashtree,proto3" json:"stats_hashtree,omitempty"`
-	RecoveredDatumsObject string        `protobuf:"bytes,7,opt,name=recovered_datums_object,json=recoveredDatumsObject,proto3" json:"recovered_datums_object,omitempty"`
-	XXX_NoUnkeyedLiteral  struct{}      `json:"-"`
-	XXX_unrecognized      []byte        `json:"-"`
-	XXX_sizecache         int32         `json:"-"`
+	Stats                   *DatumStats   `protobuf:"bytes,4,opt,name=stats,proto3" json:"stats,omitempty"`
+	ChunkHashtree           *HashtreeInfo `protobuf:"bytes,5,opt,name=chunk_hashtree,json=chunkHashtree,proto3" json:"chunk_hashtree,omitempty"`
+	StatsHashtree           *HashtreeInfo `protobuf:"bytes,6,opt,name=stats_hashtree,json=statsHashtree,proto3" json:"stats_hashtree,omitempty"`
+	RecoveredDatumsObject   string        `protobuf:"bytes,7,opt,name=recovered_datums_object,json=recoveredDatumsObject,proto3" json:"recovered_datums_object,omitempty"`
+	QuarantinedDatumsObject string        `protobuf:"bytes,9,opt,name=quarantined_datums_object,json=quarantinedDatumsObject,proto3" json:"quarantined_datums_object,omitempty"`
+	XXX_NoUnkeyedLiteral    struct{}      `json:"-"`
+	XXX_unrecognized        []byte        `json:"-"`
+	XXX_sizecache           int32         `json:"-"`
 }
 
 func (m *DatumData) Reset()         { *m = DatumData{} }
 func (m *DatumData) String() string { return proto.CompactTextString(m) }
 func (*DatumData) ProtoMessage()    {}
 func (*DatumData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_21583a759eb7fa97, []int{7}
+	return fileDescriptor_21583a759eb7fa97, []int{9}
 }
 func (m *DatumData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -534,6 +640,13 @@ func (m *DatumData) GetRecoveredDatumsObject() string {
 	return ""
 }
 
+func (m *DatumData) GetQuarantinedDatumsObject() string {
+	if m != nil {
+		return m.QuarantinedDatumsObject
+	}
+	return ""
+}
+
 type MergeData struct {
 	// Inputs
 	JobID     string          `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
@@ -553,7 +666,7 @@ func (m *MergeData) Reset()         { *m = MergeData{} }
 func (m *MergeData) String() string { return proto.CompactTextString(m) }
 func (*MergeData) ProtoMessage()    {}
 func (*MergeData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_21583a759eb7fa97, []int{8}
+	return fileDescriptor_21583a759eb7fa97, []int{10}
 }
 func (m *MergeData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -637,6 +750,8 @@ func init() {
 	proto.RegisterType((*HashtreeObjects)(nil), "pachyderm.worker.pipeline.transform.HashtreeObjects")
 	proto.RegisterType((*RecoveredDatums)(nil), "pachyderm.worker.pipeline.transform.RecoveredDatums")
 	proto.RegisterType((*RecoveredDatumObjects)(nil), "pachyderm.worker.pipeline.transform.RecoveredDatumObjects")
+	proto.RegisterType((*QuarantinedDatums)(nil), "pachyderm.worker.pipeline.transform.QuarantinedDatums")
+	proto.RegisterType((*QuarantinedDatumObjects)(nil), "pachyderm.worker.pipeline.transform.QuarantinedDatumObjects")
 	proto.RegisterType((*HashtreeInfo)(nil), "pachyderm.worker.pipeline.transform.HashtreeInfo")
 	proto.RegisterType((*DatumStats)(nil), "pachyderm.worker.pipeline.transform.DatumStats")
 	proto.RegisterType((*DatumData)(nil), "pachyderm.worker.pipeline.transform.DatumData")
@@ -648,55 +763,59 @@ func init() {
 }
 
 var fileDescriptor_21583a759eb7fa97 = []byte{
-	// 760 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x55, 0xd1, 0x4e, 0xdb, 0x4a,
-	0x10, 0x55, 0x12, 0x62, 0xf0, 0x26, 0x26, 0x97, 0x15, 0xdc, 0x1b, 0x71, 0x25, 0xc2, 0x35, 0x42,
-	0x02, 0xe9, 0xca, 0x86, 0x5c, 0x09, 0xe9, 0xbe, 0x42, 0x5a, 0x11, 0xd4, 0x0a, 0xea, 0xbc, 0x54,
-	0xed, 0x83, 0xe5, 0xd8, 0x9b, 0xd8, 0x84, 0x78, 0xad, 0x5d, 0x87, 0xb6, 0xfc, 0x43, 0x3f, 0xa2,
-	0x5f, 0xd1, 0x5f, 0xe8, 0x63, 0xbf, 0x00, 0x55, 0xf9, 0x92, 0x6a, 0x67, 0xd6, 0xc1, 0xe9, 0x4b,
-	0x23, 0x1e, 0x2c, 0xef, 0x9c, 0x3d, 0x73, 0x66, 0x76, 0x66, 0xc7, 0x26, 0x27, 0x92, 0x89, 0x7b,
-	0x26, 0xdc, 0x0f, 0x5c, 0x4c, 0x98, 0x70, 0xb3, 0x24, 0x63, 0x77, 0x49, 0xca, 0xdc, 0x5c, 0x04,
-	0xa9, 0x1c, 0x71, 0x31, 0x7d, 0x5a, 0x39, 0x99, 0xe0, 0x39, 0xa7, 0x07, 0x59, 0x10, 0xc6, 0x9f,
-	0x22, 0x26, 0xa6, 0x0e, 0x3a, 0x39, 0x85, 0x93, 0xb3, 0xa0, 0xee, 0x6e, 0x8f, 0xf9, 0x98, 0x03,
-	0xdf, 0x55, 0x2b, 0x74, 0xdd, 0xdd, 0x0e, 0xef, 0x12, 0x96, 0xe6, 0x6e, 0x36, 0x92, 0xea, 0xf9,
-	0x15, 0xcd, 0xa4, 0x7a, 0x34, 0xfa, 0xcf, 0x72, 0x62, 0x21, 0x9f, 0x4e, 0x79, 0xaa, 0x5f, 0x48,
-	0xb1, 0xaf, 0x48, 0xa3, 0x17, 0xe4, 0xb3, 0x69, 0x3f, 0xcd, 0x66, 0xb9, 0xa4, 0x87, 0xc4, 0x48,
-	0x60, 0xd5, 0xae, 0xec, 0xd7, 0x8e, 0x1a, 0x5d, 0xcb, 0xd1, 0x6c, 0xd8, 0xf7, 0xf4, 0x26, 0xdd,
-	0x26, 0xf5, 0x24, 0x8d, 0xd8, 0xc7, 0x76, 0x75, 0xbf, 0x72, 0x54, 0xf3, 0xd0, 0xb0, 0xdf, 0x93,
-	0x56, 0x49, 0xeb, 0x55, 0x22, 0x73, 0x7a, 0x49, 0x8c, 0x48, 0x41, 0x85, 0xde, 0x89, 0xb3, 0xc2,
-	0xc9, 0x9d, 0x92, 0x8a, 0xa7, 0xfd, 0x95, 0xf8, 0x65, 0x20, 0xe3, 0x5c, 0x30, 0x76, 0x3d, 0xbc,
-	0x65, 0x61, 0x2e, 0xe9, 0x01, 0xb1, 0xc2, 0x78, 0x96, 0x4e, 0x7c, 0x8e, 0x00, 0xc4, 0x30, 0xbd,
-	0x26, 0x80, 0x25, 0x92, 0xcc, 0x83, 0x5c, 0x2e, 0x48, 0x55, 0x24, 0x01, 0xa8, 0x49, 0xf6, 0x31,
-	0x69, 0x79, 0x2c, 0xe4, 0xf7, 0x4c, 0xb0, 0x08, 0x82, 0x4b, 0xfa, 0x27, 0x31, 0xe2, 0x40, 0xc6,
-	0xac, 0x50, 0xd5, 0x96, 0x7d, 0x4a, 0x76, 0x96, 0xa9, 0x45, 0xa0, 0x36, 0x59, 0x5f, 0xce, 0xa3,
-	0x30, 0xed, 0x94, 0x34, 0x8b, 0xd4, 0xfb, 0xe9, 0x88, 0x2b, 0x66, 0x10, 0x45, 0x82, 0x49, 0xc5,
-	0xac, 0x28, 0xa6, 0x36, 0xe9, 0xbf, 0x84, 0xc8, 0xd9, 0x30, 0x0f, 0xe4, 0xc4, 0x4f, 0x22, 0x28,
-	0xae, 0x79, 0x6e, 0xcd, 0x1f, 0x3b, 0xe6, 0x00, 0xd1, 0x7e, 0xcf, 0x33, 0x35, 0xa1, 0x1f, 0xa9,
-	0x14, 0x31, 0x44, 0xbb, 0x06, 0x32, 0xda, 0xb2, 0xbf, 0x54, 0x09, 0x81, 0xd4, 0x06, 0xea, 0x8c,
-	0xf4, 0x8c, 0x58, 0x99, 0xe0, 0x21, 0x93, 0xd2, 0x87, 0x43, 0x43, 0xd0, 0x46, 0x77, 0xcb, 0x51,
-	0x17, 0xe5, 0x06, 0x77, 0x80, 0xe9, 0x35, 0xb3, 0x92, 0x45, 0x8f, 0xc9, 0x1f, 0x58, 0x7b, 0x5f,
-	0xc3, 0x2c, 0xd2, 0xfd, 0x6e, 0x21, 0x7e, 0x53, 0xc0, 0xf4, 0x90, 0x6c, 0x6a, 0xaa, 0x9c, 0x24,
-	0x59, 0xc6, 0x22, 0xc8, 0xa8, 0xe6, 0x59, 0x88, 0x0e, 0x10, 0x54, 0xbd, 0xd0, 0xb4, 0x51, 0x90,
-	0xdc, 0xb1, 0xa8, 0x5d, 0x07, 0x56, 0x13, 0xc1, 0x97, 0x80, 0x95, 0xc2, 0x8a, 0xa2, 0xce, 0x6d,
-	0xa3, 0x1c, 0x76, 0x51, 0x7e, 0xfa, 0x3f, 0x69, 0xa1, 0x90, 0x0f, 0x3b, 0xaa, 0x66, 0x1b, 0x50,
-	0xb3, 0xad, 0xf9, 0x63, 0xc7, 0x42, 0x3d, 0xbc, 0x4b, 0x3d, 0xcf, 0x1a, 0x95, 0xcc, 0xc8, 0xfe,
-	0x5a, 0x23, 0x26, 0xac, 0x7b, 0x41, 0x1e, 0xd0, 0x7d, 0x62, 0xdc, 0xf2, 0xa1, 0xf2, 0x87, 0x86,
-	0x9c, 0x9b, 0xf3, 0xc7, 0x4e, 0xfd, 0x8a, 0x0f, 0xfb, 0x3d, 0xaf, 0x7e, 0xcb, 0x87, 0xfd, 0x72,
-	0xea, 0xba, 0xe4, 0x10, 0xa8, 0x48, 0x1d, 0xef, 0x00, 0x3d, 0x21, 0x16, 0x9f, 0xe5, 0xd9, 0x2c,
-	0xf7, 0xd5, 0xd4, 0x24, 0xd8, 0x97, 0x46, 0xb7, 0xe1, 0xa8, 0x41, 0xbd, 0x00, 0xc8, 0x6b, 0x22,
-	0x03, 0x2d, 0xfa, 0x82, 0xd4, 0xb1, 0x27, 0x6b, 0xc0, 0x74, 0x57, 0x1f, 0x0f, 0xec, 0x18, 0x7a,
-	0xd3, 0xb7, 0x64, 0x13, 0x27, 0x21, 0xd6, 0xf7, 0x0c, 0x2a, 0xdb, 0xe8, 0x9e, 0xae, 0xa4, 0x57,
-	0xbe, 0x9c, 0x1e, 0x8e, 0x54, 0x01, 0x29, 0x65, 0x1c, 0x9f, 0x85, 0xb2, 0xf1, 0x6c, 0x65, 0x10,
-	0x5a, 0x28, 0x9f, 0x91, 0xbf, 0x16, 0x0d, 0xf6, 0x97, 0x6b, 0xbb, 0x0e, 0xb5, 0xdd, 0x11, 0xcb,
-	0x23, 0x89, 0x45, 0xb6, 0x3f, 0x57, 0x89, 0xf9, 0x9a, 0x89, 0x31, 0x5b, 0xb1, 0x73, 0xd7, 0xc4,
-	0x2c, 0x72, 0xc7, 0xe1, 0x7f, 0x56, 0xf2, 0x4f, 0x1a, 0xf4, 0x80, 0x18, 0x59, 0x20, 0x58, 0xba,
-	0xdc, 0x5e, 0xcc, 0xce, 0xd3, 0x5b, 0xea, 0x0b, 0x29, 0xe3, 0x40, 0x44, 0xd0, 0xd8, 0x9a, 0x87,
-	0x06, 0xa0, 0xd0, 0x6e, 0xd5, 0x9e, 0x8d, 0xa2, 0x7b, 0x1d, 0xb2, 0x56, 0xaa, 0xec, 0x92, 0x1c,
-	0x6c, 0xd0, 0xbf, 0x89, 0xa9, 0xde, 0xbe, 0x4c, 0x1e, 0x18, 0x14, 0x67, 0xcd, 0xdb, 0x50, 0xc0,
-	0x20, 0x79, 0x60, 0xe7, 0x6f, 0xbe, 0xcd, 0xf7, 0x2a, 0xdf, 0xe7, 0x7b, 0x95, 0x1f, 0xf3, 0xbd,
-	0xca, 0xbb, 0x8b, 0x71, 0x92, 0xc7, 0xb3, 0xa1, 0xfa, 0x6c, 0xbb, 0x8b, 0x43, 0x96, 0x56, 0x52,
-	0x84, 0xee, 0xef, 0x7e, 0x57, 0x43, 0x03, 0xfe, 0x0d, 0xff, 0xfd, 0x0c, 0x00, 0x00, 0xff, 0xff,
-	0x6f, 0x96, 0xe4, 0xe7, 0xd9, 0x06, 0x00, 0x00,
+	// 821 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x55, 0xc1, 0x6e, 0xdb, 0x46,
+	0x10, 0x85, 0x2c, 0x8b, 0x31, 0x47, 0x62, 0x5c, 0x2f, 0xec, 0x5a, 0x4d, 0x01, 0xdb, 0xa5, 0x11,
+	0x20, 0x01, 0x1a, 0xd2, 0x71, 0x80, 0x00, 0xed, 0xd1, 0x51, 0x8b, 0x28, 0x68, 0x91, 0x84, 0xbe,
+	0x14, 0xed, 0x81, 0xa0, 0xc8, 0x95, 0x49, 0xdb, 0xe2, 0x32, 0xbb, 0xcb, 0xb4, 0xcd, 0x3f, 0xf4,
+	0xbf, 0x7a, 0xcc, 0x17, 0x18, 0x85, 0x7e, 0xa1, 0x3f, 0x10, 0xec, 0xcc, 0x92, 0xa6, 0x74, 0x89,
+	0x91, 0x83, 0xa0, 0x9d, 0xb7, 0x6f, 0x66, 0x76, 0xe6, 0xcd, 0x72, 0xe1, 0x44, 0x71, 0xf9, 0x9e,
+	0xcb, 0xf0, 0x4f, 0x21, 0xaf, 0xb8, 0x0c, 0xab, 0xa2, 0xe2, 0xd7, 0x45, 0xc9, 0x43, 0x2d, 0x93,
+	0x52, 0xcd, 0x85, 0x5c, 0xdc, 0xae, 0x82, 0x4a, 0x0a, 0x2d, 0xd8, 0x71, 0x95, 0xa4, 0xf9, 0xdf,
+	0x19, 0x97, 0x8b, 0x80, 0x9c, 0x82, 0xc6, 0x29, 0x68, 0xa9, 0x0f, 0x76, 0x2f, 0xc4, 0x85, 0x40,
+	0x7e, 0x68, 0x56, 0xe4, 0xfa, 0x60, 0x37, 0xbd, 0x2e, 0x78, 0xa9, 0xc3, 0x6a, 0xae, 0xcc, 0x6f,
+	0x1d, 0xad, 0x94, 0xf9, 0x59, 0xf4, 0xbb, 0xd5, 0x83, 0xa5, 0x62, 0xb1, 0x10, 0xa5, 0xfd, 0x23,
+	0x8a, 0xff, 0x0a, 0x86, 0x93, 0x44, 0xd7, 0x8b, 0x69, 0x59, 0xd5, 0x5a, 0xb1, 0x87, 0xe0, 0x14,
+	0xb8, 0x1a, 0xf7, 0x8e, 0xfa, 0x8f, 0x86, 0xa7, 0x5e, 0x60, 0xd9, 0xb8, 0x1f, 0xd9, 0x4d, 0xb6,
+	0x0b, 0x83, 0xa2, 0xcc, 0xf8, 0x5f, 0xe3, 0x8d, 0xa3, 0xde, 0xa3, 0x7e, 0x44, 0x86, 0xff, 0x07,
+	0x6c, 0x77, 0x62, 0xfd, 0x52, 0x28, 0xcd, 0x5e, 0x82, 0x93, 0x19, 0xa8, 0x89, 0x77, 0x12, 0xdc,
+	0xa1, 0xf2, 0xa0, 0x13, 0x25, 0xb2, 0xfe, 0x26, 0xf8, 0xcb, 0x44, 0xe5, 0x5a, 0x72, 0xfe, 0x7a,
+	0x76, 0xc9, 0x53, 0xad, 0xd8, 0x31, 0x78, 0x69, 0x5e, 0x97, 0x57, 0xb1, 0x20, 0x00, 0x73, 0xb8,
+	0xd1, 0x08, 0xc1, 0x0e, 0x49, 0xe9, 0x44, 0xab, 0x96, 0xb4, 0x41, 0x24, 0x04, 0x2d, 0xc9, 0x7f,
+	0x0c, 0xdb, 0x11, 0x4f, 0xc5, 0x7b, 0x2e, 0x79, 0x86, 0xc9, 0x15, 0xfb, 0x1a, 0x9c, 0x3c, 0x51,
+	0x39, 0x6f, 0xa2, 0x5a, 0xcb, 0x7f, 0x0a, 0x7b, 0xab, 0xd4, 0x26, 0xd1, 0x18, 0xee, 0xad, 0x9e,
+	0xa3, 0x31, 0xfd, 0x33, 0xd8, 0x79, 0x5b, 0x27, 0x32, 0x29, 0x75, 0x51, 0xb6, 0xf1, 0x9f, 0xac,
+	0x75, 0x66, 0x2f, 0x30, 0xba, 0xad, 0xf3, 0xda, 0xf2, 0x9f, 0xc1, 0xfe, 0xfa, 0xde, 0xe7, 0x13,
+	0x97, 0x30, 0x6a, 0x7a, 0x36, 0x2d, 0xe7, 0xc2, 0x30, 0x93, 0x2c, 0x93, 0x5c, 0x19, 0x66, 0xcf,
+	0x30, 0xad, 0xc9, 0xbe, 0x07, 0x50, 0xf5, 0x4c, 0x27, 0xea, 0x2a, 0x2e, 0x32, 0x54, 0xd5, 0x3d,
+	0xf3, 0x96, 0x37, 0x87, 0xee, 0x39, 0xa1, 0xd3, 0x49, 0xe4, 0x5a, 0xc2, 0x34, 0x33, 0xbd, 0xa1,
+	0x14, 0xe3, 0x3e, 0x86, 0xb1, 0x96, 0xff, 0x71, 0x03, 0x00, 0x8f, 0x76, 0x6e, 0x9a, 0xcb, 0x9e,
+	0x83, 0x57, 0x49, 0x91, 0x72, 0xa5, 0x62, 0xec, 0x36, 0x26, 0x1d, 0x9e, 0xee, 0x60, 0xa5, 0x6f,
+	0x68, 0x07, 0x99, 0xd1, 0xa8, 0xea, 0x58, 0xec, 0x31, 0x7c, 0x45, 0x55, 0xc7, 0x16, 0xe6, 0x99,
+	0x1d, 0xb4, 0x6d, 0xc2, 0xdf, 0x34, 0x30, 0x7b, 0x08, 0xf7, 0x2d, 0x55, 0x5d, 0x15, 0x55, 0xc5,
+	0x33, 0x3c, 0x51, 0x3f, 0xf2, 0x08, 0x3d, 0x27, 0xd0, 0x0c, 0x81, 0xa5, 0xcd, 0x93, 0xe2, 0x9a,
+	0x67, 0xe3, 0x01, 0xb2, 0x46, 0x04, 0xfe, 0x8c, 0x58, 0x27, 0xad, 0x6c, 0x04, 0x1e, 0x3b, 0xdd,
+	0xb4, 0xad, 0xee, 0xec, 0x09, 0x30, 0x4b, 0x7d, 0x77, 0x2b, 0xca, 0xd8, 0x45, 0xf2, 0x0e, 0xed,
+	0x74, 0xd4, 0x62, 0x3f, 0xc0, 0x36, 0xe5, 0x8d, 0x71, 0xcf, 0xb4, 0x78, 0x0b, 0x5b, 0xbc, 0xb3,
+	0xbc, 0x39, 0xf4, 0x28, 0x3d, 0xcd, 0xfc, 0x24, 0xf2, 0xe6, 0x1d, 0x33, 0xf3, 0xff, 0xef, 0x83,
+	0x8b, 0xeb, 0x49, 0xa2, 0x13, 0x76, 0x04, 0xce, 0xa5, 0x98, 0x19, 0x7f, 0xd4, 0xef, 0xcc, 0x5d,
+	0xde, 0x1c, 0x0e, 0x5e, 0x89, 0xd9, 0x74, 0x12, 0x0d, 0x2e, 0xc5, 0x6c, 0xda, 0xad, 0xd4, 0x2a,
+	0x84, 0x89, 0x9a, 0x4a, 0x69, 0x64, 0xd8, 0x09, 0x78, 0xa2, 0xd6, 0x55, 0xad, 0x63, 0x73, 0xbb,
+	0x0b, 0x92, 0x71, 0x78, 0x3a, 0x0c, 0xcc, 0x07, 0xe5, 0x05, 0x42, 0xd1, 0x88, 0x18, 0x64, 0xb1,
+	0x9f, 0x60, 0x40, 0x12, 0x6e, 0x22, 0x33, 0xbc, 0xfb, 0x35, 0x26, 0x81, 0xc9, 0x9b, 0xfd, 0x06,
+	0xf7, 0xe9, 0xc6, 0xe6, 0x76, 0x2c, 0x51, 0x88, 0xe1, 0xe9, 0xd3, 0x3b, 0xc5, 0xeb, 0xce, 0x72,
+	0x44, 0x57, 0xbf, 0x81, 0x4c, 0x64, 0xba, 0xe6, 0x6d, 0x64, 0xe7, 0x8b, 0x23, 0x63, 0xa0, 0x36,
+	0xf2, 0x73, 0xd8, 0x6f, 0xe7, 0x21, 0x5e, 0xed, 0xed, 0x3d, 0xec, 0xed, 0x9e, 0x5c, 0xfd, 0x74,
+	0xd8, 0x26, 0xff, 0x08, 0xdf, 0x74, 0x86, 0x63, 0xcd, 0xd3, 0x45, 0xcf, 0xfd, 0x77, 0xeb, 0x9f,
+	0x05, 0xf2, 0xf5, 0xff, 0xd9, 0x00, 0xf7, 0x57, 0x2e, 0x2f, 0xf8, 0x1d, 0x55, 0x7f, 0x0d, 0x6e,
+	0x53, 0x37, 0x7d, 0xe0, 0xbe, 0xa8, 0xf0, 0xdb, 0x18, 0xec, 0x18, 0x9c, 0x2a, 0x91, 0xbc, 0x5c,
+	0x1d, 0x0d, 0x3a, 0x5d, 0x64, 0xb7, 0xcc, 0x2b, 0xa0, 0xf2, 0x44, 0x66, 0x38, 0x14, 0xfd, 0x88,
+	0x0c, 0x44, 0x71, 0x54, 0x8c, 0xb4, 0x5b, 0x8d, 0xf2, 0x87, 0xb0, 0xd9, 0x51, 0x65, 0x25, 0x1c,
+	0x6e, 0xb0, 0x6f, 0xc1, 0x35, 0xff, 0xb1, 0x2a, 0x3e, 0x70, 0x6c, 0xec, 0x66, 0xb4, 0x65, 0x80,
+	0xf3, 0xe2, 0x03, 0x3f, 0x7b, 0xfb, 0xef, 0xf2, 0xa0, 0xf7, 0x71, 0x79, 0xd0, 0xfb, 0x6f, 0x79,
+	0xd0, 0xfb, 0xfd, 0xc5, 0x45, 0xa1, 0xf3, 0x7a, 0x66, 0x9e, 0xa6, 0xb0, 0x2d, 0xb2, 0xb3, 0x52,
+	0x32, 0x0d, 0x3f, 0xf7, 0x24, 0xcf, 0x1c, 0x7c, 0xff, 0x9e, 0x7d, 0x1a, 0x00, 0x4c, 0xfb, 0x4e,
+	0x37, 0xbd, 0x07, 0x00, 0x00,
 }
 
 func (m *DatumInputs) Marshal() (dAtA []byte, err error) {
@@ -903,6 +1022,83 @@ func (m *RecoveredDatumObjects) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *QuarantinedDatums) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QuarantinedDatums) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QuarantinedDatums) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Datums) > 0 {
+		for iNdEx := len(m.Datums) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Datums[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTransform(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *QuarantinedDatumObjects) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QuarantinedDatumObjects) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QuarantinedDatumObjects) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Objects) > 0 {
+		for iNdEx := len(m.Objects) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Objects[iNdEx])
+			copy(dAtA[i:], m.Objects[iNdEx])
+			i = encodeVarintTransform(dAtA, i, uint64(len(m.Objects[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *HashtreeInfo) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -975,6 +1171,11 @@ func (m *DatumStats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.DatumsQuarantined != 0 {
+		i = encodeVarintTransform(dAtA, i, uint64(m.DatumsQuarantined))
+		i--
+		dAtA[i] = 0x48
+	}
 	if len(m.FailedDatumID) > 0 {
 		i -= len(m.FailedDatumID)
 		copy(dAtA[i:], m.FailedDatumID)
@@ -1041,6 +1242,13 @@ func (m *DatumData) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.QuarantinedDatumsObject) > 0 {
+		i -= len(m.QuarantinedDatumsObject)
+		copy(dAtA[i:], m.QuarantinedDatumsObject)
+		i = encodeVarintTransform(dAtA, i, uint64(len(m.QuarantinedDatumsObject)))
+		i--
+		dAtA[i] = 0x4a
+	}
 	if len(m.DatumsObject) > 0 {
 		i -= len(m.DatumsObject)
 		copy(dAtA[i:], m.DatumsObject)
@@ -1315,6 +1523,42 @@ func (m *RecoveredDatumObjects) Size() (n int) {
 	return n
 }
 
+func (m *QuarantinedDatums) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Datums) > 0 {
+		for _, e := range m.Datums {
+			l = e.Size()
+			n += 1 + l + sovTransform(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *QuarantinedDatumObjects) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Objects) > 0 {
+		for _, s := range m.Objects {
+			l = len(s)
+			n += 1 + l + sovTransform(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func (m *HashtreeInfo) Size() (n int) {
 	if m == nil {
 		return 0
@@ -1365,6 +1609,9 @@ func (m *DatumStats) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovTransform(uint64(l))
 	}
+	if m.DatumsQuarantined != 0 {
+		n += 1 + sovTransform(uint64(m.DatumsQuarantined))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1405,6 +1652,10 @@ func (m *DatumData) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovTransform(uint64(l))
 	}
+	l = len(m.QuarantinedDatumsObject)
+	if l > 0 {
+		n += 1 + l + sovTransform(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1941,6 +2192,180 @@ func (m *RecoveredDatumObjects) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *QuarantinedDatums) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTransform
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QuarantinedDatums: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QuarantinedDatums: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Datums", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTransform
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTransform
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTransform
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Datums = append(m.Datums, &pps.QuarantinedDatum{})
+			if err := m.Datums[len(m.Datums)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTransform(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthTransform
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthTransform
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QuarantinedDatumObjects) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTransform
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QuarantinedDatumObjects: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QuarantinedDatumObjects: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Objects", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTransform
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTransform
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTransform
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Objects = append(m.Objects, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTransform(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthTransform
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthTransform
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *HashtreeInfo) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -2264,6 +2689,25 @@ func (m *DatumStats) Unmarshal(dAtA []byte) error {
 			}
 			m.FailedDatumID = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DatumsQuarantined", wireType)
+			}
+			m.DatumsQuarantined = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTransform
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DatumsQuarantined |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTransform(dAtA[iNdEx:])
@@ -2558,6 +3002,38 @@ func (m *DatumData) Unmarshal(dAtA []byte) error {
 			}
 			m.DatumsObject = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QuarantinedDatumsObject", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTransform
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTransform
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTransform
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.QuarantinedDatumsObject = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTransform(dAtA[iNdEx:])
@@ -91,7 +91,7 @@ func withWorkerSpawnerPair(pipelineInfo *pps.PipelineInfo, cb func(env *testEnv)
 		}
 
 		// Put the pipeline info into etcd (which is read by the master)
-		if _, err = env.driver.NewSTM(func(stm col.STM) error {
+		if err = env.driver.NewSTM(func(stm col.STM) error {
 			etcdPipelineInfo := &pps.EtcdPipelineInfo{
 				State:       pps.PipelineState_PIPELINE_STARTING,
 				SpecCommit:  pipelineInfo.SpecCommit,
@@ -233,6 +233,14 @@ func mockBasicJob(t *testing.T, env *testEnv, pi *pps.PipelineInfo) (context.Con
 
 		etcdJobInfo.State = request.State
 		etcdJobInfo.Reason = request.Reason
+		etcdJobInfo.Restart = request.Restart
+		etcdJobInfo.DataProcessed = request.DataProcessed
+		etcdJobInfo.DataSkipped = request.DataSkipped
+		etcdJobInfo.DataFailed = request.DataFailed
+		etcdJobInfo.DataRecovered = request.DataRecovered
+		etcdJobInfo.DataQuarantined = request.DataQuarantined
+		etcdJobInfo.DataTotal = request.DataTotal
+		etcdJobInfo.Stats = request.Stats
 
 		// If setting the job to a terminal state, we are done
 		if ppsutil.IsTerminal(request.State) {
@@ -336,6 +344,131 @@ func TestJobFailedDatum(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestJobQuarantinedDatum(t *testing.T) {
+	pi := defaultPipelineInfo()
+	pi.Transform.Cmd = []string{"bash", "-c", "if [ -e inputRepo/bad ]; then echo oops; exit 1; fi; cp inputRepo/* out"}
+	pi.QuarantineFailedDatums = true
+	err := withWorkerSpawnerPair(pi, func(env *testEnv) error {
+		ctx, etcdJobInfo := mockBasicJob(t, env, pi)
+		triggerJob(t, env, pi, []*inputFile{newInput("good", "foobar"), newInput("bad", "barfoo")})
+		ctx = withTimeout(ctx, 10*time.Second)
+		<-ctx.Done()
+		require.Equal(t, pps.JobState_JOB_SUCCESS, etcdJobInfo.State)
+		require.Equal(t, int64(1), etcdJobInfo.DataQuarantined)
+
+		// The output contains the datum that succeeded
+		files, err := env.PachClient.ListFile(pi.Pipeline.Name, pi.OutputBranch, "/")
+		require.NoError(t, err)
+		require.Equal(t, 1, len(files))
+		require.Equal(t, "/good", files[0].File.Path)
+
+		// The quarantine branch contains a record of the datum that failed
+		records, err := ppsutil.ListQuarantinedDatums(env.PachClient, pi.Pipeline.Name)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(records))
+		for _, record := range records {
+			require.Equal(t, etcdJobInfo.Job.ID, record.Job.ID)
+			require.Equal(t, 1, len(record.Data))
+			require.Equal(t, "/bad", record.Data[0].Path)
+			require.True(t, strings.Contains(record.Logs, "oops"))
+			require.NotEqual(t, "", record.Error)
+			require.Equal(t, int64(0), record.Retries)
+			require.False(t, record.Released)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestJobReleasedQuarantinedDatum(t *testing.T) {
+	pi := defaultPipelineInfo()
+	pi.Transform.Cmd = []string{"bash", "-c", "if [ -e inputRepo/bad ]; then echo oops; exit 1; fi; cp inputRepo/* out"}
+	pi.QuarantineFailedDatums = true
+	err := withWorkerSpawnerPair(pi, func(env *testEnv) error {
+		ctx, etcdJobInfo := mockBasicJob(t, env, pi)
+		triggerJob(t, env, pi, []*inputFile{newInput("good", "foobar"), newInput("bad", "barfoo")})
+		ctx = withTimeout(ctx, 10*time.Second)
+		<-ctx.Done()
+		require.Equal(t, pps.JobState_JOB_SUCCESS, etcdJobInfo.State)
+		require.Equal(t, int64(1), etcdJobInfo.DataQuarantined)
+		firstJob := etcdJobInfo.Job
+
+		// Release the quarantined datum, as the real RestartDatum does
+		env.MockPachd.PPS.RestartDatum.Use(func(ctx context.Context, request *pps.RestartDatumRequest) (*types.Empty, error) {
+			require.True(t, request.Quarantined)
+			return &types.Empty{}, ppsutil.ReleaseQuarantinedDatums(env.PachClient, pi.Pipeline.Name, request.Job.ID, request.DataFilters)
+		})
+		require.NoError(t, env.PachClient.RestartQuarantinedDatums(firstJob.ID, []string{"/bad"}))
+		records, err := ppsutil.ListQuarantinedDatums(env.PachClient, pi.Pipeline.Name)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(records))
+		for _, record := range records {
+			require.True(t, record.Released)
+		}
+		// There is nothing left to release
+		require.YesError(t, env.PachClient.RestartQuarantinedDatums(firstJob.ID, nil))
+
+		// The next job reprocesses the released datum, which fails (and is
+		// quarantined) again
+		ctx, etcdJobInfo = mockBasicJob(t, env, pi)
+		triggerJob(t, env, pi, []*inputFile{newInput("other", "bazbaz")})
+		ctx = withTimeout(ctx, 10*time.Second)
+		<-ctx.Done()
+		require.Equal(t, pps.JobState_JOB_SUCCESS, etcdJobInfo.State)
+		require.Equal(t, int64(1), etcdJobInfo.DataQuarantined)
+
+		records, err = ppsutil.ListQuarantinedDatums(env.PachClient, pi.Pipeline.Name)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(records))
+		for _, record := range records {
+			require.Equal(t, etcdJobInfo.Job.ID, record.Job.ID)
+			require.Equal(t, "/bad", record.Data[0].Path)
+			require.Equal(t, int64(1), record.Retries)
+			require.False(t, record.Released)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestJobResourceStats(t *testing.T) {
+	pi := defaultPipelineInfo()
+	err := withWorkerSpawnerPair(pi, func(env *testEnv) error {
+		ctx, etcdJobInfo := mockBasicJob(t, env, pi)
+		triggerJob(t, env, pi, []*inputFile{newInput("a", "foobar"), newInput("b", "barfoo")})
+		ctx = withTimeout(ctx, 10*time.Second)
+		<-ctx.Done()
+		require.Equal(t, pps.JobState_JOB_SUCCESS, etcdJobInfo.State)
+
+		// The resources used by each datum's user code are aggregated into the
+		// job's stats
+		stats := etcdJobInfo.Stats
+		require.NotNil(t, stats)
+		require.NotNil(t, stats.CpuTime)
+		require.True(t, stats.PeakMemoryBytes > 0)
+		// Each datum's scratch space holds (at least) its 6-byte input and output
+		require.True(t, stats.ScratchBytes >= 2*12)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestJobTaskOptions(t *testing.T) {
+	taskFor := func(jobInfo *pps.JobInfo) *work.Task {
+		task := &work.Task{}
+		for _, opt := range jobTaskOptions(jobInfo) {
+			opt(task)
+		}
+		return task
+	}
+	small := taskFor(&pps.JobInfo{PipelineVersion: 2, DataTotal: smallJobDatums})
+	large := taskFor(&pps.JobInfo{PipelineVersion: 2, DataTotal: smallJobDatums + 1})
+	require.True(t, small.Priority > large.Priority)
+	require.Equal(t, small.Group, large.Group)
+	require.NotEqual(t, small.Group, taskFor(&pps.JobInfo{PipelineVersion: 1}).Group)
+	require.NotNil(t, large.ClaimTimeout)
+}
+
 func TestJobMultiDatum(t *testing.T) {
 	pi := defaultPipelineInfo()
 	err := withWorkerSpawnerPair(pi, func(env *testEnv) error {
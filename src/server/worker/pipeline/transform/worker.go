@@ -3,8 +3,10 @@ package transform
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path"
@@ -39,6 +41,12 @@ import (
 var (
 	errDatumRecovered = errors.New("the datum errored, and the error was handled successfully")
 	statsTagSuffix    = "_stats"
+	// outputMetadataFile is the file, in a datum's scratch directory, that
+	// $PACH_OUTPUT_METADATA points to
+	outputMetadataFile = ".metadata"
+	// quarantineLogSize is the amount of a quarantined datum's output that's
+	// kept in its record
+	quarantineLogSize = 64 * 1024
 )
 
 // TODO: would be nice to have these have a deterministic ID rather than based
@@ -48,6 +56,10 @@ func jobArtifactRecoveredDatums(jobID string, subtaskID string) string {
 	return path.Join(jobArtifactPrefix(jobID), fmt.Sprintf("recovered-%s", subtaskID))
 }
 
+func jobArtifactQuarantinedDatums(jobID string, subtaskID string) string {
+	return path.Join(jobArtifactPrefix(jobID), fmt.Sprintf("quarantined-%s", subtaskID))
+}
+
 func jobArtifactChunkStats(jobID string, subtaskID string) string {
 	return path.Join(jobArtifactPrefix(jobID), fmt.Sprintf("chunk-stats-%s", subtaskID))
 }
@@ -95,12 +107,22 @@ func mergeStats(x, y *DatumStats) error {
 		}
 		xps.DownloadBytes += yps.DownloadBytes
 		xps.UploadBytes += yps.UploadBytes
+		if xps.CpuTime, err = plusDuration(xps.CpuTime, yps.CpuTime); err != nil {
+			return err
+		}
+		if yps.PeakMemoryBytes > xps.PeakMemoryBytes {
+			xps.PeakMemoryBytes = yps.PeakMemoryBytes
+		}
+		xps.DiskReadBytes += yps.DiskReadBytes
+		xps.DiskWriteBytes += yps.DiskWriteBytes
+		xps.ScratchBytes += yps.ScratchBytes
 	}
 
 	x.DatumsProcessed += y.DatumsProcessed
 	x.DatumsSkipped += y.DatumsSkipped
 	x.DatumsFailed += y.DatumsFailed
 	x.DatumsRecovered += y.DatumsRecovered
+	x.DatumsQuarantined += y.DatumsQuarantined
 	if x.FailedDatumID == "" {
 		x.FailedDatumID = y.FailedDatumID
 	}
@@ -201,6 +223,26 @@ func uploadRecoveredDatums(driver driver.Driver, logger logs.TaggedLogger, recov
 	})
 }
 
+func uploadQuarantinedDatums(driver driver.Driver, logger logs.TaggedLogger, quarantinedDatums []*pps.QuarantinedDatum, object string) (retErr error) {
+	return logger.LogStep("uploading quarantined datums", func() error {
+		message := &QuarantinedDatums{Datums: quarantinedDatums}
+
+		writer, err := driver.PachClient().DirectObjWriter(object)
+		if err != nil {
+			return errors.EnsureStack(err)
+		}
+		defer func() {
+			if err := writer.Close(); err != nil && retErr == nil {
+				retErr = errors.EnsureStack(err)
+			}
+		}()
+
+		protoWriter := pbutil.NewWriter(writer)
+		_, err = protoWriter.Write(message)
+		return err
+	})
+}
+
 func uploadChunk(
 	driver driver.Driver,
 	logger logs.TaggedLogger,
@@ -278,6 +320,7 @@ func handleDatumTask(driver driver.Driver, logger logs.TaggedLogger, data *Datum
 		// statsMutex controls access to stats so that they can be safely merged
 		statsMutex := &sync.Mutex{}
 		recoveredDatums := []string{}
+		quarantinedDatums := []*pps.QuarantinedDatum{}
 		data.Stats = &DatumStats{
 			ProcessStats: &pps.ProcessStats{},
 		}
@@ -308,7 +351,7 @@ func handleDatumTask(driver driver.Driver, logger logs.TaggedLogger, data *Datum
 						logger = logger.WithJob(jobID).WithData(inputs)
 
 						// subStats is still valid even on an error, merge those in before proceeding
-						subStats, subRecovered, err := processDatum(driver, logger, index, inputs, data.OutputCommit, datumCache, statsCache, status)
+						subStats, subRecovered, subQuarantined, err := processDatum(driver, logger, index, inputs, data.OutputCommit, datumCache, statsCache, status)
 
 						statsMutex.Lock()
 						defer statsMutex.Unlock()
@@ -317,7 +360,9 @@ func handleDatumTask(driver driver.Driver, logger logs.TaggedLogger, data *Datum
 							return err
 						}
 						recoveredDatums = append(recoveredDatums, subRecovered...)
-						if len(subRecovered) == 0 {
+						if subQuarantined != nil {
+							quarantinedDatums = append(quarantinedDatums, subQuarantined)
+						} else if len(subRecovered) == 0 {
 							atomic.AddInt64(&dataProcessed, 1)
 						}
 						atomic.AddInt64(&dataRecovered, int64(len(recoveredDatums)))
@@ -336,6 +381,14 @@ func handleDatumTask(driver driver.Driver, logger logs.TaggedLogger, data *Datum
 			return err
 		}
 
+		if len(quarantinedDatums) > 0 {
+			quarantinedDatumsObject := jobArtifactQuarantinedDatums(logger.JobID(), subtaskID)
+			if err := uploadQuarantinedDatums(driver, logger, quarantinedDatums, quarantinedDatumsObject); err != nil {
+				return err
+			}
+			data.QuarantinedDatumsObject = quarantinedDatumsObject
+		}
+
 		if data.Stats.DatumsFailed == 0 && !driver.PipelineInfo().S3Out {
 			if len(recoveredDatums) > 0 {
 				recoveredDatumsObject := jobArtifactRecoveredDatums(logger.JobID(), subtaskID)
@@ -375,6 +428,30 @@ func handleDatumTask(driver driver.Driver, logger logs.TaggedLogger, data *Datum
 	})
 }
 
+// setOutputMetadata adds the metadata that user code wrote to the file at
+// $PACH_OUTPUT_METADATA (if any) to the output commit. Datums are processed
+// concurrently, so if several datums set the same key, the last one wins.
+func setOutputMetadata(driver driver.Driver, dir string, outputCommit *pfs.Commit) error {
+	if outputCommit == nil {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path.Join(dir, outputMetadataFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.EnsureStack(err)
+	}
+	var metadata map[string]string
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return errors.Wrapf(err, "could not parse %s as a JSON object of strings", client.OutputMetadataEnv)
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return driver.PachClient().SetCommitMetadata(outputCommit.Repo.Name, outputCommit.ID, metadata)
+}
+
 func processDatum(
 	driver driver.Driver,
 	logger logs.TaggedLogger,
@@ -384,8 +461,9 @@ func processDatum(
 	datumCache *hashtree.MergeCache,
 	datumStatsCache *hashtree.MergeCache,
 	status *Status,
-) (_ *DatumStats, _ []string, retErr error) {
+) (_ *DatumStats, _ []string, _ *pps.QuarantinedDatum, retErr error) {
 	recoveredDatums := []string{}
+	var quarantined *pps.QuarantinedDatum
 	stats := &DatumStats{}
 	tag := common.HashDatum(driver.PipelineInfo().Pipeline.Name, driver.PipelineInfo().Salt, inputs)
 	datumID := common.DatumID(inputs)
@@ -393,24 +471,31 @@ func processDatum(
 	if _, err := driver.PachClient().InspectTag(driver.PachClient().Ctx(), client.NewTag(tag)); err == nil {
 		buf := &bytes.Buffer{}
 		if err := driver.PachClient().GetTag(tag, buf); err != nil {
-			return stats, recoveredDatums, err
+			return stats, recoveredDatums, nil, err
 		}
 		if err := datumCache.Put(uuid.NewWithoutDashes(), buf); err != nil {
-			return stats, recoveredDatums, err
+			return stats, recoveredDatums, nil, err
 		}
 		if driver.PipelineInfo().EnableStats {
 			buf.Reset()
 			if err := driver.PachClient().GetTag(tag+statsTagSuffix, buf); err != nil {
 				// We are okay with not finding the stats hashtree. This allows users to
 				// enable stats on a pipeline with pre-existing jobs.
-				return stats, recoveredDatums, nil
+				return stats, recoveredDatums, nil, nil
 			}
 			if err := datumStatsCache.Put(uuid.NewWithoutDashes(), buf); err != nil {
-				return stats, recoveredDatums, err
+				return stats, recoveredDatums, nil, err
 			}
 		}
 		stats.DatumsSkipped++
-		return stats, recoveredDatums, nil
+		return stats, recoveredDatums, nil, nil
+	}
+
+	// Keep the output of user code, in case the datum is quarantined
+	var capture *logs.LogCapture
+	if driver.PipelineInfo().QuarantineFailedDatums {
+		capture = logs.NewLogCapture(quarantineLogSize)
+		logger = logs.WithCapture(logger, capture)
 	}
 
 	statsRoot := path.Join("/", datumID)
@@ -425,15 +510,15 @@ func processDatum(
 		// Write index in datum factory to stats tree
 		object, size, err := driver.PachClient().PutObject(strings.NewReader(fmt.Sprint(int(datumIndex))))
 		if err != nil {
-			return stats, recoveredDatums, err
+			return stats, recoveredDatums, nil, err
 		}
 		objectInfo, err := driver.PachClient().InspectObject(object.Hash)
 		if err != nil {
-			return stats, recoveredDatums, err
+			return stats, recoveredDatums, nil, err
 		}
 		h, err := pfs.DecodeHash(object.Hash)
 		if err != nil {
-			return stats, recoveredDatums, err
+			return stats, recoveredDatums, nil, err
 		}
 		statsTree.PutFile("index", h, size, objectInfo.BlockRef)
 		defer func() {
@@ -460,6 +545,7 @@ func processDatum(
 
 				return status.withDatum(inputs, cancel, func() error {
 					env := driver.UserCodeEnv(logger.JobID(), outputCommit, inputs)
+					env = append(env, fmt.Sprintf("%s=%s", client.OutputMetadataEnv, path.Join(dir, outputMetadataFile)))
 					if err := driver.RunUserCode(logger, env, processStats, driver.PipelineInfo().DatumTimeout); err != nil {
 						if driver.PipelineInfo().Transform.ErrCmd != nil && failures == driver.PipelineInfo().DatumTries-1 {
 							if err = driver.RunUserErrorHandlingCode(logger, env, processStats, driver.PipelineInfo().DatumTimeout); err != nil {
@@ -475,6 +561,10 @@ func processDatum(
 				return err
 			}
 
+			if err := setOutputMetadata(driver, dir, outputCommit); err != nil {
+				return err
+			}
+
 			if driver.PipelineInfo().S3Out {
 				return nil // S3Out pipelines do not store data in worker hashtrees
 			}
@@ -522,12 +612,30 @@ func processDatum(
 		recoveredDatums = []string{tag}
 		stats.DatumsRecovered++
 	} else if err != nil {
-		stats.FailedDatumID = datumID
-		stats.DatumsFailed++
+		if driver.PipelineInfo().QuarantineFailedDatums {
+			quarantined = &pps.QuarantinedDatum{
+				ID:          datumID,
+				Hash:        tag,
+				Job:         client.NewJob(logger.JobID()),
+				Error:       err.Error(),
+				Logs:        capture.String(),
+				Quarantined: types.TimestampNow(),
+			}
+			for _, input := range inputs {
+				quarantined.Data = append(quarantined.Data, &pps.InputFile{
+					Path: input.FileInfo.File.Path,
+					Hash: input.FileInfo.Hash,
+				})
+			}
+			stats.DatumsQuarantined++
+		} else {
+			stats.FailedDatumID = datumID
+			stats.DatumsFailed++
+		}
 	} else {
 		stats.DatumsProcessed++
 	}
-	return stats, recoveredDatums, nil
+	return stats, recoveredDatums, quarantined, nil
 }
 
 func writeStats(
@@ -20,6 +20,8 @@ var (
 	bucketFactor = 2.0
 	bucketCount  = 20 // Which makes the max bucket 2^20 seconds or ~12 days in size
 
+	byteBucketCount = 40 // Which makes the max bucket 2^40 bytes or 1TiB in size
+
 	// DatumCount is a counter tracking the number of datums processed by a pipeline
 	DatumCount = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -180,6 +182,78 @@ var (
 			"job",
 		},
 	)
+
+	// DatumCPUSecondsCount is a counter tracking the total CPU time consumed by user code in a pipeline
+	DatumCPUSecondsCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pachyderm",
+			Subsystem: "worker",
+			Name:      "datum_cpu_seconds_count",
+			Help:      "Cumulative number of CPU seconds (user + system) used by user code",
+		},
+		[]string{
+			"pipeline",
+			"job",
+		},
+	)
+
+	// DatumPeakMemory is a histogram tracking the peak resident memory of user code for datums processed by a pipeline
+	DatumPeakMemory = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "pachyderm",
+			Subsystem: "worker",
+			Name:      "datum_peak_memory",
+			Help:      "Peak resident set size of user code, in bytes",
+			Buckets:   prometheus.ExponentialBuckets(1.0, bucketFactor, byteBucketCount),
+		},
+		[]string{
+			"pipeline",
+			"job",
+		},
+	)
+
+	// DatumDiskReadBytesCount is a counter tracking the total bytes read from disk by user code in a pipeline
+	DatumDiskReadBytesCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pachyderm",
+			Subsystem: "worker",
+			Name:      "datum_disk_read_bytes_count",
+			Help:      "Cumulative number of bytes read from disk by user code",
+		},
+		[]string{
+			"pipeline",
+			"job",
+		},
+	)
+
+	// DatumDiskWriteBytesCount is a counter tracking the total bytes written to disk by user code in a pipeline
+	DatumDiskWriteBytesCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pachyderm",
+			Subsystem: "worker",
+			Name:      "datum_disk_write_bytes_count",
+			Help:      "Cumulative number of bytes written to disk by user code",
+		},
+		[]string{
+			"pipeline",
+			"job",
+		},
+	)
+
+	// DatumScratchSize is a histogram tracking the scratch space used by datums processed by a pipeline
+	DatumScratchSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "pachyderm",
+			Subsystem: "worker",
+			Name:      "datum_scratch_size",
+			Help:      "Size of a datum's scratch space (inputs and outputs), in bytes",
+			Buckets:   prometheus.ExponentialBuckets(1.0, bucketFactor, byteBucketCount),
+		},
+		[]string{
+			"pipeline",
+			"job",
+		},
+	)
 )
 
 // InitPrometheus sets up the default datum stats collectors for use by worker
@@ -197,6 +271,11 @@ func InitPrometheus() {
 		DatumDownloadBytesCount,
 		DatumUploadSize,
 		DatumUploadBytesCount,
+		DatumCPUSecondsCount,
+		DatumPeakMemory,
+		DatumDiskReadBytesCount,
+		DatumDiskWriteBytesCount,
+		DatumScratchSize,
 	}
 	for _, metric := range metrics {
 		if err := prometheus.Register(metric); err != nil {
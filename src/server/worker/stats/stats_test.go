@@ -156,6 +156,20 @@ func TestPrometheusStats(t *testing.T) {
 		datumCountQuery(t, query) // Just check query has a result
 	})
 
+	// Resource Counters
+	t.Run("DatumCPUSeconds", func(t *testing.T) {
+		query := fmt.Sprintf("sum(pachyderm_worker_datum_cpu_seconds_count{pipelineName=\"%v\"}) without (instance, exported_job)", pipeline)
+		datumCountQuery(t, query) // Just check query has a result
+	})
+	t.Run("DatumDiskReadBytes", func(t *testing.T) {
+		query := fmt.Sprintf("sum(pachyderm_worker_datum_disk_read_bytes_count{pipelineName=\"%v\"}) without (instance, exported_job)", pipeline)
+		datumCountQuery(t, query) // Just check query has a result
+	})
+	t.Run("DatumDiskWriteBytes", func(t *testing.T) {
+		query := fmt.Sprintf("sum(pachyderm_worker_datum_disk_write_bytes_count{pipelineName=\"%v\"}) without (instance, exported_job)", pipeline)
+		datumCountQuery(t, query) // Just check query has a result
+	})
+
 	// Test queries across all jobs
 	filter := "(instance,exported_job)"
 	// 'instance' is an auto recorded label w the IP of the pod ... this will
@@ -189,7 +203,7 @@ func TestPrometheusStats(t *testing.T) {
 	})
 
 	// Avg Datum Size Queries
-	for _, segment := range []string{"download", "upload"} {
+	for _, segment := range []string{"download", "upload", "scratch"} {
 		t.Run(fmt.Sprintf("AcrossJobsDatumSize=%v", segment), func(t *testing.T) {
 			sum := fmt.Sprintf("sum(pachyderm_worker_datum_%v_size_sum{pipelineName=\"%v\"}) without %v", segment, pipeline, filter)
 			count := fmt.Sprintf("sum(pachyderm_worker_datum_%v_size_count{pipelineName=\"%v\"}) without %v", segment, pipeline, filter)
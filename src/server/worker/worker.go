@@ -16,6 +16,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	"github.com/pachyderm/pachyderm/src/client/pps"
 	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/watch"
@@ -48,6 +49,7 @@ type Worker struct {
 func NewWorker(
 	pachClient *client.APIClient,
 	etcdClient *etcd.Client,
+	backend col.Backend,
 	etcdPrefix string,
 	pipelineInfo *pps.PipelineInfo,
 	workerName string,
@@ -66,6 +68,7 @@ func NewWorker(
 		pipelineInfo,
 		pachClient,
 		etcdClient,
+		backend,
 		etcdPrefix,
 		hashtreePath,
 		rootPath,
@@ -92,7 +95,7 @@ func NewWorker(
 		}
 		if pipelineInfo.Transform.Cmd == nil {
 			if len(image.Config.Entrypoint) == 0 {
-				ppsutil.FailPipeline(pachClient.Ctx(), etcdClient, driver.Pipelines(),
+				ppsutil.FailPipeline(pachClient.Ctx(), backend, driver.Pipelines(),
 					pipelineInfo.Pipeline.Name,
 					"nothing to run: no transform.cmd and no entrypoint")
 			}
@@ -107,7 +110,7 @@ func NewWorker(
 
 	worker.APIServer = server.NewAPIServer(driver, worker.status, workerName)
 
-	go worker.master(etcdClient, etcdPrefix)
+	go worker.master(etcdClient, backend, etcdPrefix)
 	go worker.worker()
 	return worker, nil
 }
@@ -159,7 +162,7 @@ func (w *Worker) worker() {
 	})
 }
 
-func (w *Worker) master(etcdClient *etcd.Client, etcdPrefix string) {
+func (w *Worker) master(etcdClient *etcd.Client, backend col.Backend, etcdPrefix string) {
 	pipelineInfo := w.driver.PipelineInfo()
 	logger := logs.NewMasterLogger(pipelineInfo)
 	lockPath := path.Join(etcdPrefix, masterLockPath, pipelineInfo.Pipeline.Name, pipelineInfo.Salt)
@@ -192,7 +195,7 @@ func (w *Worker) master(etcdClient *etcd.Client, etcdPrefix string) {
 			logger.Logf("failing %q due to auth rejection", pipelineInfo.Pipeline.Name)
 			return ppsutil.FailPipeline(
 				w.driver.PachClient().Ctx(),
-				etcdClient,
+				backend,
 				w.driver.Pipelines(),
 				pipelineInfo.Pipeline.Name,
 				"worker master could not access output repo to watch for new commits",